@@ -0,0 +1,60 @@
+// cmd/cli/audit.go
+package cli
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/hopkali04/health-sys/internal/audit"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/db"
+	"github.com/spf13/cobra"
+)
+
+func AuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Inspect the audit log",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Verify the audit log hash chain and report the first broken link",
+		Run: func(cmd *cobra.Command, args []string) {
+			// Load config
+			cfg, err := config.LoadConfig("config.yaml")
+			if err != nil {
+				log.Fatalf("Failed to load config: %v", err)
+			}
+
+			// Connect to database
+			dbConn, err := db.ConnectDB(cfg)
+			if err != nil {
+				log.Fatalf("Failed to connect to database: %v", err)
+			}
+
+			result, err := audit.Verify(dbConn)
+			if err != nil {
+				log.Fatalf("Failed to verify audit log: %v", err)
+			}
+
+			if result.Broken != nil {
+				fmt.Printf("Audit chain is BROKEN at sequence %d (entry %s): %s\n",
+					result.Broken.Sequence, result.Broken.ID, result.Broken.Reason)
+				fmt.Printf("%d entries verified before the break.\n", result.Checked)
+				os.Exit(1)
+			}
+
+			if result.Head == nil {
+				fmt.Println("Audit chain is empty.")
+				return
+			}
+
+			fmt.Printf("Audit chain verified: %d entries intact.\n", result.Checked)
+			fmt.Printf("Head: sequence %d, hash %s\n", result.Head.Sequence, result.Head.Hash)
+		},
+	})
+
+	return cmd
+}
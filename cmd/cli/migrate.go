@@ -4,38 +4,128 @@ package cli
 import (
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/hopkali04/health-sys/internal/config"
 	"github.com/hopkali04/health-sys/internal/db"
 	"github.com/spf13/cobra"
+	"gorm.io/gorm"
 )
 
 func MigrateCmd() *cobra.Command {
+	up := func(cmd *cobra.Command, args []string) {
+		dbConn := connectForMigration()
+
+		applied, err := db.MigrateUp(dbConn)
+		for _, migration := range applied {
+			fmt.Printf("applied   %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date.")
+			return
+		}
+		fmt.Println("Database migration completed successfully!")
+	}
+
 	cmd := &cobra.Command{
 		Use:   "migrate",
 		Short: "Run database migrations",
+		Long:  "Run database migrations. Without a subcommand, applies every pending migration like migrate up.",
+		Run:   up,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "up",
+		Short: "Apply every pending migration",
+		Args:  cobra.NoArgs,
+		Run:   up,
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "down N",
+		Short: "Revert the last N applied migrations",
+		Args:  cobra.ExactArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			// Load config
-			cfg, err := config.LoadConfig("config.yaml")
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				log.Fatalf("N must be a positive number, got %q", args[0])
+			}
+			dbConn := connectForMigration()
+
+			reverted, err := db.MigrateDown(dbConn, n)
+			for _, migration := range reverted {
+				fmt.Printf("reverted  %04d_%s\n", migration.Version, migration.Name)
+			}
 			if err != nil {
-				log.Fatalf("Failed to load config: %v", err)
+				log.Fatalf("Failed to revert migrations: %v", err)
+			}
+			if len(reverted) == 0 {
+				fmt.Println("No applied migrations to revert.")
 			}
+		},
+	})
 
-			// Connect to database
-			dbConn, err := db.ConnectDB(cfg)
+	cmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "List migrations and whether each is applied",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dbConn := connectForMigration()
+
+			statuses, err := db.MigrationStatuses(dbConn)
 			if err != nil {
-				log.Fatalf("Failed to connect to database: %v", err)
+				log.Fatalf("Failed to read migration status: %v", err)
 			}
 
-			// Run migrations
-			if err := db.AutoMigrate(dbConn); err != nil {
-				log.Fatalf("Failed to migrate database: %v", err)
+			pending := 0
+			for _, status := range statuses {
+				if status.AppliedAt == nil {
+					pending++
+					fmt.Printf("pending   %04d_%s\n", status.Version, status.Name)
+					continue
+				}
+				fmt.Printf("applied   %04d_%s  %s\n", status.Version, status.Name, status.AppliedAt.Format("2006-01-02 15:04:05"))
 			}
+			fmt.Printf("%d migrations, %d pending.\n", len(statuses), pending)
+		},
+	})
 
-			fmt.Println("Database migration completed successfully!")
+	cmd.AddCommand(&cobra.Command{
+		Use:   "baseline",
+		Short: "Adopt migrations on a database created by the old AutoMigrate",
+		Long: "Brings a database created before versioned migrations level with the baseline " +
+			"migration and records the baseline as applied without running it. Run migrate up afterwards.",
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dbConn := connectForMigration()
+
+			if err := db.Baseline(dbConn); err != nil {
+				log.Fatalf("Failed to baseline database: %v", err)
+			}
+			fmt.Printf("Baseline %04d recorded. Run migrate up to apply later migrations.\n", db.BaselineVersion)
 		},
-	}
+	})
 
 	return cmd
 }
 
+// connectForMigration loads the config and connects to the database, or
+// exits
+func connectForMigration() *gorm.DB {
+	// Load config
+	cfg, err := config.LoadConfig("config.yaml")
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Connect to database
+	dbConn, err := db.ConnectDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	return dbConn
+}
@@ -11,10 +11,14 @@ import (
 	"github.com/hopkali04/health-sys/internal/db"
 	"github.com/hopkali04/health-sys/internal/jobs"
 	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/services"
 	"github.com/hopkali04/health-sys/internal/routes"
 	"github.com/hopkali04/health-sys/internal/services/dashboard"
+	"github.com/hopkali04/health-sys/internal/services/scim"
+	"github.com/hopkali04/health-sys/internal/services/storage"
 	"github.com/hopkali04/health-sys/internal/services/token"
+	"github.com/hopkali04/health-sys/internal/services/upload"
 	"github.com/hopkali04/health-sys/internal/services/user"
 	"github.com/hopkali04/health-sys/internal/services/reports"
 	"github.com/hopkali04/health-sys/internal/utils"
@@ -40,21 +44,35 @@ func RunServer() {
 	// NotiRepo := notification.NewRepository(dbConn)
 	DashRepo := dashboard.NewRepository(dbConn)
 
-	NewIncidentHandler := services.NewIncidentService(dbConn)
+	fileStorage, err := storage.New(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize file storage: %v", err)
+	}
+
+	uploadService := upload.NewService(fileStorage, cfg.Uploads)
+
+	incidentWorkflow, err := services.NewIncidentWorkflow(cfg.IncidentWorkflow)
+	if err != nil {
+		log.Fatalf("Invalid incident workflow: %v", err)
+	}
+
+	NewIncidentHandler := services.NewIncidentService(dbConn, uploadService, incidentWorkflow)
 	// NewNotificationHandler := notification.NewService(NotiRepo)
 	NewDashboardHandler := dashboard.NewService(DashRepo)
-	correctiveActionSVCInitializer := services.NewCorrectiveActionService(dbConn)
+	correctiveActionSVCInitializer := services.NewCorrectiveActionService(dbConn, incidentWorkflow)
 
 	NewDepartmentHandler := services.NewDepartmentService(dbConn)
 	DepHandler := api.NewDepartmentHandler(NewDepartmentHandler)
 
-	AttachmentSVC := services.NewAttachmentService(dbConn)
+	AttachmentSVC := services.NewAttachmentService(dbConn, fileStorage, uploadService)
 
 	dashboardService := services.NewSafetyDashboardService(dbConn)
 	NewSafetyDashboardHandler := api.NewSafetyDashboardHandler(dashboardService)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
+		// Uploads are checked per file by the upload service; this caps a whole request
+		BodyLimit: cfg.Uploads.MaxRequestSizeMB << 20,
 		// Disable the default error handler to prevent default error pages
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			// Log the error
@@ -79,39 +97,62 @@ func RunServer() {
 		AllowMethods:     cfg.CORS.AllowedMethods,
 	}))
 	app.Use(middleware.LoggingMiddleware())
+	app.Use(middleware.AuditContext())
 
-	// Serve static files from the "uploads" directory
-	app.Static("/uploads", "./uploads")
-
-	// Middleware to restrict access to uploads
-	app.Use("/uploads", func(c *fiber.Ctx) error {
-		// Check if the user is authenticated
-		userID := c.Locals("userID")
-		if userID == nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
-		}
-
-		// Proceed to serve the file
-		return c.Next()
-	})
 	// app.Use(validation.CustomValidator())
 
 	// Start reminder job
 	emailService := services.NewEmailService(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, false)
+	emailService.SetFrom(cfg.SMTP.From)
 	notificationService, err := services.NewNotificationService(dbConn, emailService)
 	if err != nil {
 		log.Fatalf("Failed to initialize notification service: %v", err)
 	}
 
-	VerSvc := user.NewVerificationService(dbConn, emailService, token.NewTokenService(), cfg.Web.Domain)
-	userService := user.NewUserService(dbConn, VerSvc)
-	userHandler := api.NewUserHandler(userService, VerSvc)
+	jwtManager, err := token.NewJWTManager(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize JWT signing keys: %v", err)
+	}
+	middleware.SetJWTManager(jwtManager)
+
+	tokenService := token.NewTokenService()
+	VerSvc := user.NewVerificationService(dbConn, emailService, tokenService, cfg.Web.Domain)
+	sessionService := user.NewSessionService(dbConn, jwtManager, tokenService, cfg.Auth.RefreshTokenTTL)
+	middleware.SetSessionValidator(sessionService)
+	lockoutService := user.NewLockoutService(dbConn, emailService, cfg.Auth.Lockout)
+	userService := user.NewUserService(dbConn, VerSvc, lockoutService)
+	mfaService := user.NewMFAService(dbConn, jwtManager, lockoutService, cfg.Auth.Issuer, cfg.Auth.MFARequiredRoles)
+	ssoService, err := user.NewSSOService(dbConn, lockoutService, cfg.Auth.SSO)
+	if err != nil {
+		log.Fatalf("Failed to initialize single sign-on: %v", err)
+	}
+	userHandler := api.NewUserHandler(userService, VerSvc, sessionService, mfaService, ssoService)
+
+	roleService := services.NewRoleService(dbConn, cfg.Auth.PermissionCacheTTL)
+	middleware.UsePermissionStore(roleService.PermissionStore())
+	roleHandler := api.NewRoleHandler(roleService)
+
+	scimService := scim.NewService(dbConn, roleService, sessionService, cfg.SCIM)
+	scimHandler := api.NewSCIMHandler(scimService)
 
 	EmployeeSVC := services.NewEmployeeService(dbConn, emailService)
+
+	// Incident status changes notify the people involved
+	incidentWorkflow.OnTransition(notificationService.NotifyIncidentStatusChanged)
+	incidentWorkflow.OnTransition(func(change services.IncidentStatusChange) {
+		if change.To == models.IncidentStatusClosed {
+			EmployeeSVC.HandleClosingIncidentNotification(change.Incident)
+		}
+	})
 	EmpHandler := api.NewEmployeeHandler(EmployeeSVC)
 
-	NewInvestigationHandler := services.NewInvestigationService(dbConn)
+	NewInvestigationHandler := services.NewInvestigationService(dbConn, incidentWorkflow)
 	InvHandler := api.NewInvestigationHandler(NewInvestigationHandler, notificationService)
+	reviewService, err := services.NewInvestigationReviewService(dbConn, cfg.InvestigationReview, notificationService)
+	if err != nil {
+		log.Fatalf("Invalid investigation review: %v", err)
+	}
+	reviewHandler := api.NewInvestigationReviewHandler(reviewService)
 
 	reportH := api.NewReportHandler(services.NewReportService(dbConn))
 
@@ -119,16 +160,30 @@ func RunServer() {
 
 	notifySettings := api.NewNotificationSettingsHandler(services.NewNotificationSettingsService(dbConn))
 
-	vpc_svc := services.NewVPCService(dbConn, emailService)
+	vpc_svc := services.NewVPCService(dbConn, emailService, uploadService)
 	vpcHandler := api.NewVPCHandler(vpc_svc)
 
 	vpcReportHandler := api.NewVPCReportHandler(reports.NewVPCReportService(dbConn))
-	NewHazardHandler := api.NewHazardHandler(services.NewHazardService(dbConn))
+	NewHazardHandler := api.NewHazardHandler(services.NewHazardService(dbConn, fileStorage, uploadService))
 	
 	employeeService := services.NewTemporaryEmployeeService(dbConn)
 	tempEmplHandler := api.NewTemporaryEmployeeHandler(employeeService)
 
-	correctiveSvcHandler := api.NewCorrectiveActionHandler(correctiveActionSVCInitializer, notificationService)
+	auditHandler := api.NewAuditHandler(services.NewAuditService(dbConn))
+
+	fileHandler := api.NewFileHandler(services.NewFileService(dbConn, fileStorage, cfg.Storage))
+	if cfg.Storage.SigningKey == "" {
+		log.Println("storage.signing_key is not set: signed download links are disabled")
+	}
+
+	attachmentHandler := api.NewAttachmentHandler(AttachmentSVC)
+	evidenceHandler := api.NewEvidenceHandler(services.NewEvidenceService(dbConn, fileStorage, uploadService))
+	rcaHandler := api.NewRCAHandler(services.NewRCAService(dbConn))
+	memberHandler := api.NewInvestigationMemberHandler(services.NewInvestigationMemberService(dbConn, notificationService))
+	interviewHandler := api.NewInterviewHandler(services.NewInterviewService(dbConn, cfg.Interviews, notificationService, correctiveActionSVCInitializer))
+	interviewTemplateHandler := api.NewInterviewTemplateHandler(services.NewInterviewTemplateService(dbConn))
+
+	correctiveSvcHandler := api.NewCorrectiveActionHandler(correctiveActionSVCInitializer, notificationService, uploadService)
 
 	go jobs.StartReminderJob(notificationService, emailService)
 
@@ -136,6 +191,7 @@ func RunServer() {
 	api.SetupRoutes(app, userHandler, NewIncidentHandler, notificationService, NewDashboardHandler, AttachmentSVC, EmployeeSVC)
 	api.SetupEmployeeRoutes(app, EmpHandler)
 	api.SetupInvestigationRoutes(app, InvHandler)
+	api.SetupInvestigationReviewRoutes(app, reviewHandler)
 	api.SetupDepartmentRoutes(app, DepHandler)
 	api.SetupDashboardRoutes(app, NewSafetyDashboardHandler)
 	api.SetupNotificationRoutes(app, notificationHandler)
@@ -143,14 +199,27 @@ func RunServer() {
 	api.SetupNotificationSettingsRoutes(app, notifySettings)
 	api.SetupVpcReports(app, vpcReportHandler)
 	api.SetupTemporaryEmployeeRoutes(app, tempEmplHandler)
+	api.SetupAuditRoutes(app, auditHandler)
+	api.SetupRoleRoutes(app, roleHandler)
+	api.SetupSCIMRoutes(app, scimHandler, scimService.VerifyToken)
+	api.SetupFileRoutes(app, fileHandler)
+	api.SetupAttachmentRoutes(app, attachmentHandler)
+	api.SetupEvidenceRoutes(app, evidenceHandler)
+	api.SetupRCARoutes(app, rcaHandler)
+	api.SetupInvestigationMemberRoutes(app, memberHandler)
+	api.SetupInterViewRoutes(app, interviewHandler)
+	api.SetupInterviewTemplateRoutes(app, interviewTemplateHandler)
 
 	routes.SetupHazardRoutes(app, NewHazardHandler)
 	routes.SetupCorrectiveActionRoutes(app, correctiveSvcHandler)
 
 	api.SetupReportsRoutes(app, reportH)
-	app.Get("/panic", func(c *fiber.Ctx) error {
-		panic("This is a test panic!")
-	})
+
+	// Refuse to start while any route lacks an access policy
+	if err := api.ValidateRoutePolicies(app); err != nil {
+		log.Fatalf("Invalid route configuration: %v", err)
+	}
+
 	// Log startup
 	utils.LogInfo("Application started", map[string]interface{}{
 		"version": "1.0.0",
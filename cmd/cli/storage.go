@@ -0,0 +1,79 @@
+// cmd/cli/storage.go
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/db"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/spf13/cobra"
+)
+
+func StorageCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Maintain uploaded file storage",
+	}
+
+	var (
+		minAge time.Duration
+		remove bool
+	)
+	gc := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete stored files no attachment or evidence record refers to",
+		Run: func(cmd *cobra.Command, args []string) {
+			// Load config
+			cfg, err := config.LoadConfig("config.yaml")
+			if err != nil {
+				log.Fatalf("Failed to load config: %v", err)
+			}
+
+			// Connect to database
+			dbConn, err := db.ConnectDB(cfg)
+			if err != nil {
+				log.Fatalf("Failed to connect to database: %v", err)
+			}
+
+			fileStorage, err := storage.New(cfg.Storage)
+			if err != nil {
+				log.Fatalf("Failed to initialize file storage: %v", err)
+			}
+
+			result, err := services.CollectStorageGarbage(context.Background(), dbConn, fileStorage, services.StorageGCOptions{
+				MinAge: minAge,
+				Delete: remove,
+			})
+			if result == nil {
+				log.Fatalf("Failed to collect storage garbage: %v", err)
+			}
+
+			for _, entry := range result.Orphaned {
+				fmt.Printf("orphaned  %s (%d bytes, %s)\n", entry.Key, entry.Size, entry.ModTime.Format(time.RFC3339))
+			}
+			for _, key := range result.Missing {
+				fmt.Printf("missing   %s\n", key)
+			}
+
+			fmt.Printf("%d files checked, %d orphaned, %d missing.\n", result.Checked, len(result.Orphaned), len(result.Missing))
+			if err != nil {
+				log.Fatalf("Failed to collect storage garbage: %v", err)
+			}
+			if remove {
+				fmt.Printf("%d orphaned files deleted.\n", result.Deleted)
+			} else {
+				fmt.Println("Dry run: nothing was deleted. Run again with --delete to delete the orphaned files.")
+			}
+		},
+	}
+	gc.Flags().DurationVar(&minAge, "min-age", 24*time.Hour, "only delete files older than this, to spare uploads still in progress")
+	gc.Flags().BoolVar(&remove, "delete", false, "delete the orphaned files instead of only reporting them; refused while any referenced file is missing")
+	cmd.AddCommand(gc)
+
+	return cmd
+}
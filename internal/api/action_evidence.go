@@ -2,10 +2,7 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
-	"io"
 	"mime/multipart"
-	"os"
 	"path/filepath"
 	"strings"
 
@@ -13,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/storage"
 	"github.com/hopkali04/health-sys/internal/utils"
 )
 
@@ -302,34 +300,45 @@ func (h *CorrectiveActionHandler) CreateActionEvidenceWithAttachments(c *fiber.C
 
 	uuidUserID := emp.ID
 
+	// Evidence of the work is added by the assignee
+	if err := h.CorrectiveActionservice.CheckAssignee(c.Context(), actionID, emp.ID); err != nil {
+		return actionErrorResponse(c, err, "failed to add evidence")
+	}
+
+	// Files are staged until every evidence record is created
+	stage := h.Uploads.Stage()
+	evidence := make([]*models.ActionEvidence, 0, len(uploadedFiles))
 	for _, file := range uploadedFiles {
-		fileURL, err := saveFileToStorage(file, actionID)
+		saved, err := stage.Save(c.Context(), storage.Key("actions", actionID.String(), uuid.NewString()), file)
 		if err != nil {
+			stage.Rollback(c.Context())
 			utils.LogError("Failed to save file", map[string]interface{}{
 				"actionID": actionID,
 				"file":     file.Filename,
 				"error":    err.Error(),
 			})
+			if status, rejected := uploadRejectionStatus(err); rejected {
+				return c.Status(status).JSON(fiber.Map{"error": err.Error(), "file": file.Filename})
+			}
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save file", "details": err.Error()})
 		}
 
-		evidence := &models.ActionEvidence{
+		evidence = append(evidence, &models.ActionEvidence{
 			CorrectiveActionID: actionID,
 			FileType:           req.FileType,
-			FileName:           file.Filename,
-			FileURL:            fileURL,
+			FileName:           saved.Name,
+			FileURL:            saved.Key,
 			UploadedBy:         uuidUserID,
 			Description:        req.Description,
-		}
+		})
+	}
 
-		if err := h.CorrectiveActionservice.CreateActionEvidence(evidence); err != nil {
-			utils.LogError("Failed to create evidence record", map[string]interface{}{
-				"actionID": actionID,
-				"file":     file.Filename,
-				"error":    err.Error(),
-			})
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create evidence record", "details": err.Error()})
-		}
+	if err := h.CorrectiveActionservice.CreateStagedActionEvidence(c.Context(), stage, evidence); err != nil {
+		utils.LogError("Failed to create evidence records", map[string]interface{}{
+			"actionID": actionID,
+			"error":    err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create evidence record", "details": err.Error()})
 	}
 
 	utils.LogInfo("Successfully created evidence records", map[string]interface{}{
@@ -339,33 +348,6 @@ func (h *CorrectiveActionHandler) CreateActionEvidenceWithAttachments(c *fiber.C
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "evidence created successfully"})
 }
 
-func saveFileToStorage(file *multipart.FileHeader, actionID uuid.UUID) (string, error) {
-	filename := filepath.Base(file.Filename)
-	storagePath := fmt.Sprintf("uploads/actions/%s/%s", actionID, filename)
-
-	if err := os.MkdirAll(filepath.Dir(storagePath), 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	src, err := file.Open()
-	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
-	}
-	defer src.Close()
-
-	dst, err := os.Create(storagePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create file: %w", err)
-	}
-	defer dst.Close()
-
-	if _, err = io.Copy(dst, src); err != nil {
-		return "", fmt.Errorf("failed to save file: %w", err)
-	}
-
-	return storagePath, nil
-}
-
 func isAllowedFileTypes(filename string) bool {
 	allowedTypes := []string{".jpg", ".jpeg", ".png", ".pdf", ".mp4"}
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -23,211 +23,248 @@ func SetupRoutes(app *fiber.App, userSVC *UserHandler, incidentService *services
 	attachSVC *services.AttachmentService, empSVC *services.EmployeeService) {
 
 	incidentImpl := NewIncidentsHandler(incidentService, attachSVC, empSVC)
-	app.Get("/api/me", middleware.AuthMiddleware(), func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"user": fiber.Map{
-				"id":   c.Locals("userID"),
-				"role": c.Locals("role"),
-			},
-		})
-	})
-
-	app.Post("/api/auth/login", userSVC.LoginUser)
-	app.Post("/api/auth/logout", userSVC.LogoutUser)
-	app.Post("/api/auth/verify", userSVC.VerifyAccount)
-	app.Post("/api/auth/google/signup", userSVC.VerifyAccount)
-	app.Post("/api/auth/reset-password/request", userSVC.RequestPasswordReset)
-	app.Post("/api/auth/reset-password/complete", userSVC.CompletePasswordReset)
-
-	// app.Post("/auth/logout", func(c *fiber.Ctx) error {
-	// 	c.ClearCookie("auth-token")
-	// 	return c.JSON(fiber.Map{"message": "Logged out"})
-	// })
-
-	// User routes
-	app.Post("/api/auth/signup", userSVC.RegisterUserWithEmployeeAcc)
-	app.Post("/api/auth/signup/bulk", userSVC.BulkRegisterUsers)
-
-	app.Post("/api/auth/signup/employees", userSVC.RegisterUserWithEmployeeAcc)
-	app.Post("/api/auth/signup/employees/bulk", userSVC.BulkRegisterUsersWithEmployeeAcc)
-
-	app.Get("/api/users", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), userSVC.Getall)
-	app.Get("/api/users/:id/details", middleware.AuthMiddleware(), userSVC.GetUser)
-	app.Post("/api/users/:id/modify", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), userSVC.UpdateUserPassword)
-	app.Delete("/api/users/:id", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), userSVC.DeleteUser)
-
-	// User routes group
-	userRoutes := app.Group("/api/users")
-
-	// Apply auth middleware to all user routes
-	// userRoutes.Use(middleware.AuthMiddleware)
-
-	// Route for updating user role
-	userRoutes.Put("/:userId/role", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), userSVC.UpdateUserRole)
-
-	// Route for updating user information
-	userRoutes.Put("/:userId", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), userSVC.UpdateUser)
-
-	// Route for updating user status (active/inactive)
-	userRoutes.Put("/:userId/status", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), userSVC.UpdateUserStatus)
-
-	apiIncidents := app.Group("/api/v1")
-	// apiIncidents.Use(middleware.AuthMiddleware)
-
-	apiIncidents.Post("/incidents/with-attachments", middleware.AuthMiddleware(), middleware.PermissionMiddleware(middleware.PermissionCreateIncidents), incidentImpl.CreateIncidentWithAttachments)
-	apiIncidents.Post("/incidents", middleware.AuthMiddleware(), middleware.PermissionMiddleware(middleware.PermissionCreateIncidents), incidentImpl.CreateIncident)
-	apiIncidents.Get("/incidents", incidentImpl.ListIncidentsHandler)
-	apiIncidents.Get("/incidents/closed", incidentImpl.ListClosedIncidentsHandler)
-	apiIncidents.Post("/incidents/:id/status", incidentImpl.UpdateIncidentStatusHandler)
-	apiIncidents.Get("/incidents/:id/view", incidentImpl.GetIncidentHandler)
-	apiIncidents.Post("/incidents/:id/update", incidentImpl.UpdateIncidentHandler)
-	apiIncidents.Post("/incidents/:id/assign", incidentImpl.AssignIncidentToUserHandler)
-	apiIncidents.Get("/incidents/:id/summary", incidentImpl.GetIncidentSummary)
-	apiIncidents.Get("/incidents/employee/:id", incidentImpl.GetIncidentsByEmployeeID)
-	apiIncidents.Get("/incidents/employee/:employeeID/closed", incidentImpl.GetClosedIncidentsByEmployeeIDHandler)
-
-	apiIncidents.Post("/incidents/:id/close", middleware.AuthMiddleware(), middleware.PermissionMiddleware(middleware.PermissionManageIncidents), incidentImpl.CloseIncidentHandler)
-
-
-	// Admin-only route
-	app.Post("/api/users", middleware.AuthMiddleware(), middleware.RoleMiddleware(middleware.RoleAdmin),
-		userSVC.RegisterUser)
 
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/me", middleware.Authenticated(), h(func(c *fiber.Ctx) error {
+			return c.JSON(fiber.Map{
+				"user": fiber.Map{
+					"id":   c.Locals("userID"),
+					"role": c.Locals("role"),
+				},
+			})
+		})},
+
+		{fiber.MethodPost, "/api/auth/login", middleware.Public(), h(userSVC.LoginUser)},
+		{fiber.MethodPost, "/api/auth/logout", middleware.Public(), h(userSVC.LogoutUser)},
+		{fiber.MethodPost, "/api/auth/refresh", middleware.Public(), h(userSVC.RefreshToken)},
+
+		// Session management for the signed-in user
+		{fiber.MethodGet, "/api/auth/sessions", middleware.Authenticated(), h(userSVC.ListMySessions)},
+		{fiber.MethodDelete, "/api/auth/sessions/:sessionId", middleware.Authenticated(), h(userSVC.RevokeMySession)},
+		{fiber.MethodPost, "/api/auth/sessions/revoke-all", middleware.Authenticated(), h(userSVC.RevokeAllMySessions)},
+
+		// Two-factor authentication (TOTP). Enrollment also accepts the
+		// intermediate MFA token of a user who has not signed in yet.
+		{fiber.MethodPost, "/api/auth/mfa/verify", middleware.Public(), h(userSVC.VerifyMFA)},
+		{fiber.MethodPost, "/api/auth/mfa/enroll", middleware.Public(), h(middleware.OptionalAuthMiddleware(), userSVC.StartMFAEnrollment)},
+		{fiber.MethodPost, "/api/auth/mfa/enroll/confirm", middleware.Public(), h(middleware.OptionalAuthMiddleware(), userSVC.ConfirmMFAEnrollment)},
+		{fiber.MethodPost, "/api/auth/mfa/disable", middleware.Authenticated(), h(userSVC.DisableMFA)},
+		{fiber.MethodPost, "/api/auth/mfa/recovery-codes", middleware.Authenticated(), h(userSVC.RegenerateRecoveryCodes)},
+
+		{fiber.MethodPost, "/api/auth/verify", middleware.Public(), h(userSVC.VerifyAccount)},
+		{fiber.MethodPost, "/api/auth/reset-password/request", middleware.Public(), h(userSVC.RequestPasswordReset)},
+		{fiber.MethodPost, "/api/auth/reset-password/complete", middleware.Public(), h(userSVC.CompletePasswordReset)},
+
+		// Single sign-on through OpenID Connect providers. Google sign up is
+		// a Google sign-in that provisions the account on first use.
+		{fiber.MethodGet, "/api/auth/sso/providers", middleware.Public(), h(userSVC.ListSSOProviders)},
+		{fiber.MethodPost, "/api/auth/sso/:provider/authorize", middleware.Public(), h(userSVC.StartSSO)},
+		{fiber.MethodPost, "/api/auth/sso/:provider/callback", middleware.Public(), h(userSVC.CompleteSSO)},
+		{fiber.MethodPost, "/api/auth/google/signup", middleware.Public(), h(userSVC.StartGoogleSSO)},
+
+		// Accounts are created by user admins, since the request chooses the
+		// role of the new account
+		{fiber.MethodPost, "/api/auth/signup", middleware.Require(middleware.PermissionManageUsers), h(userSVC.RegisterUserWithEmployeeAcc)},
+		{fiber.MethodPost, "/api/auth/signup/employees", middleware.Require(middleware.PermissionManageUsers), h(userSVC.RegisterUserWithEmployeeAcc)},
+		{fiber.MethodPost, "/api/auth/signup/bulk", middleware.Require(middleware.PermissionManageUsers), h(userSVC.BulkRegisterUsers)},
+		{fiber.MethodPost, "/api/auth/signup/employees/bulk", middleware.Require(middleware.PermissionManageUsers), h(userSVC.BulkRegisterUsersWithEmployeeAcc)},
+
+		// User administration
+		{fiber.MethodPost, "/api/users", middleware.Require(middleware.PermissionManageUsers), h(userSVC.RegisterUser)},
+		{fiber.MethodGet, "/api/users", middleware.Require(middleware.PermissionManageUsers), h(userSVC.Getall)},
+		{fiber.MethodGet, "/api/users/:id/details", middleware.Authenticated(), h(userSVC.GetUser)},
+		{fiber.MethodPost, "/api/users/:id/modify", middleware.Require(middleware.PermissionManageUsers), h(userSVC.UpdateUserPassword)},
+		{fiber.MethodDelete, "/api/users/:id", middleware.Require(middleware.PermissionManageUsers), h(userSVC.DeleteUser)},
+		{fiber.MethodGet, "/api/users/:id/sessions", middleware.Require(middleware.PermissionManageUsers), h(userSVC.ListUserSessions)},
+		{fiber.MethodDelete, "/api/users/:id/sessions", middleware.Require(middleware.PermissionManageUsers), h(userSVC.RevokeUserSessions)},
+		{fiber.MethodDelete, "/api/users/:id/mfa", middleware.Require(middleware.PermissionManageUsers), h(userSVC.ResetUserMFA)},
+		{fiber.MethodPost, "/api/users/:id/unlock", middleware.Require(middleware.PermissionManageUsers), h(userSVC.UnlockUser)},
+		{fiber.MethodPut, "/api/users/:userId/role", middleware.Require(middleware.PermissionManageRoles), h(userSVC.UpdateUserRole)},
+		{fiber.MethodPut, "/api/users/:userId", middleware.Require(middleware.PermissionManageUsers), h(userSVC.UpdateUser)},
+		{fiber.MethodPut, "/api/users/:userId/status", middleware.Require(middleware.PermissionManageUsers), h(userSVC.UpdateUserStatus)},
+
+		// Incidents
+		{fiber.MethodPost, "/api/v1/incidents/with-attachments", middleware.Require(middleware.PermissionCreateIncidents), h(incidentImpl.CreateIncidentWithAttachments)},
+		{fiber.MethodPost, "/api/v1/incidents", middleware.Require(middleware.PermissionCreateIncidents), h(incidentImpl.CreateIncident)},
+		{fiber.MethodGet, "/api/v1/incidents", middleware.Require(middleware.PermissionReadIncidents), h(incidentImpl.ListIncidentsHandler)},
+		{fiber.MethodGet, "/api/v1/incidents/closed", middleware.Require(middleware.PermissionReadIncidents), h(incidentImpl.ListClosedIncidentsHandler)},
+		{fiber.MethodPost, "/api/v1/incidents/:id/status", middleware.Require(middleware.PermissionManageIncidents), h(incidentImpl.UpdateIncidentStatusHandler)},
+		{fiber.MethodGet, "/api/v1/incidents/:id/status-history", middleware.Require(middleware.PermissionReadIncidents), h(incidentImpl.GetStatusHistoryHandler)},
+		{fiber.MethodGet, "/api/v1/incidents/:id/view", middleware.Require(middleware.PermissionReadIncidents), h(incidentImpl.GetIncidentHandler)},
+		{fiber.MethodPost, "/api/v1/incidents/:id/update", middleware.Require(middleware.PermissionManageIncidents), h(incidentImpl.UpdateIncidentHandler)},
+		{fiber.MethodPost, "/api/v1/incidents/:id/assign", middleware.Require(middleware.PermissionAssignTasks), h(incidentImpl.AssignIncidentToUserHandler)},
+		{fiber.MethodGet, "/api/v1/incidents/:id/summary", middleware.Require(middleware.PermissionReadIncidents), h(incidentImpl.GetIncidentSummary)},
+		{fiber.MethodGet, "/api/v1/incidents/:id/summary/pdf", middleware.Require(middleware.PermissionReadIncidents), h(incidentImpl.GetIncidentSummaryPDF)},
+		{fiber.MethodGet, "/api/v1/incidents/employee/:id", middleware.Require(middleware.PermissionReadIncidents), h(incidentImpl.GetIncidentsByEmployeeID)},
+		{fiber.MethodGet, "/api/v1/incidents/employee/:employeeID/closed", middleware.Require(middleware.PermissionReadIncidents), h(incidentImpl.GetClosedIncidentsByEmployeeIDHandler)},
+		{fiber.MethodPost, "/api/v1/incidents/:id/close", middleware.Require(middleware.PermissionManageIncidents), h(incidentImpl.CloseIncidentHandler)},
+	})
 }
 
 func SetupEmployeeRoutes(app *fiber.App, employeeHandler *EmployeeHandler) {
-	apiEmp := app.Group("/api/v1")
-	apiEmp.Post("/employees", employeeHandler.CreateEmployee)
-	apiEmp.Get("/employees/search", employeeHandler.SearchEmployees)
-	apiEmp.Get("/employees/:id", employeeHandler.GetEmployee)
-	apiEmp.Get("/profile/employee", middleware.AuthMiddleware(), employeeHandler.GetEmployeeProfile)
-	apiEmp.Put("/employees/:id", middleware.AuthMiddleware(), employeeHandler.UpdateEmployee)
-	apiEmp.Post("/employees/profile/update", middleware.AuthMiddleware(), employeeHandler.UpdateUserProfile)
-	apiEmp.Delete("/employees/:id", employeeHandler.DeleteEmployee)
-	apiEmp.Get("/employees", employeeHandler.ListEmployees)
-	apiEmp.Get("/users/employees", middleware.AuthMiddleware(), middleware.RoleMiddleware("admin"), employeeHandler.ListEmployees)
-
+	RegisterRoutes(app, []Route{
+		{fiber.MethodPost, "/api/v1/employees", middleware.Require(middleware.PermissionManageEmployees), h(employeeHandler.CreateEmployee)},
+		{fiber.MethodGet, "/api/v1/employees/search", middleware.Require(middleware.PermissionReadEmployees), h(employeeHandler.SearchEmployees)},
+		{fiber.MethodGet, "/api/v1/employees/:id", middleware.Require(middleware.PermissionReadEmployees), h(employeeHandler.GetEmployee)},
+		{fiber.MethodGet, "/api/v1/profile/employee", middleware.Authenticated(), h(employeeHandler.GetEmployeeProfile)},
+		{fiber.MethodPut, "/api/v1/employees/:id", middleware.Require(middleware.PermissionManageEmployees), h(employeeHandler.UpdateEmployee)},
+		{fiber.MethodPost, "/api/v1/employees/profile/update", middleware.Authenticated(), h(employeeHandler.UpdateUserProfile)},
+		{fiber.MethodDelete, "/api/v1/employees/:id", middleware.Require(middleware.PermissionManageEmployees), h(employeeHandler.DeleteEmployee)},
+		{fiber.MethodGet, "/api/v1/employees", middleware.Require(middleware.PermissionReadEmployees), h(employeeHandler.ListEmployees)},
+		{fiber.MethodGet, "/api/v1/users/employees", middleware.Require(middleware.PermissionManageUsers), h(employeeHandler.ListEmployees)},
+	})
 }
-func SetupInvestigationRoutes(app *fiber.App, handler *InvestigationHandler) {
-
-	api := app.Group("/api/v1/investigations")
 
-	api.Get("/", handler.GetAll)
-	api.Get("/:id", handler.GetByID)
-	api.Get("/:id/employee", handler.GetAllByEmployeeID)
-	api.Get("/incident/:incidentId", handler.GetByIncidentID)
-	api.Post("/", handler.Create)
-	api.Put("/:id", handler.Update)
-	api.Delete("/:id", handler.Delete)
-	api.Post("/:id/close", handler.CloseInvestigation)
+func SetupInvestigationRoutes(app *fiber.App, handler *InvestigationHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/investigations", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetAll)},
+		{fiber.MethodGet, "/api/v1/investigations/:id", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetByID)},
+		{fiber.MethodGet, "/api/v1/investigations/:id/employee", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetAllByEmployeeID)},
+		{fiber.MethodGet, "/api/v1/investigations/incident/:incidentId", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetByIncidentID)},
+		{fiber.MethodPost, "/api/v1/investigations", middleware.Require(middleware.PermissionManageInvestigations), h(handler.Create)},
+		{fiber.MethodPut, "/api/v1/investigations/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.Update)},
+		{fiber.MethodDelete, "/api/v1/investigations/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.Delete)},
+	})
 }
+
 func SetupRoleRoutes(app *fiber.App, roleHandler *RoleHandler) {
-	app.Post("/employees/:id/assign-role", roleHandler.AssignRole)
+	RegisterRoutes(app, []Route{
+		{fiber.MethodPost, "/employees/:id/assign-role", middleware.Require(middleware.PermissionManageRoles), h(roleHandler.AssignRole)},
+
+		{fiber.MethodGet, "/api/v1/roles", middleware.Require(middleware.PermissionManageRoles), h(roleHandler.ListRoles)},
+		{fiber.MethodPost, "/api/v1/roles", middleware.Require(middleware.PermissionManageRoles), h(roleHandler.CreateRole)},
+		{fiber.MethodGet, "/api/v1/roles/:name", middleware.Require(middleware.PermissionManageRoles), h(roleHandler.GetRole)},
+		{fiber.MethodPut, "/api/v1/roles/:name", middleware.Require(middleware.PermissionManageRoles), h(roleHandler.UpdateRole)},
+		{fiber.MethodDelete, "/api/v1/roles/:name", middleware.Require(middleware.PermissionManageRoles), h(roleHandler.DeleteRole)},
+		{fiber.MethodGet, "/api/v1/permissions", middleware.Require(middleware.PermissionManageRoles), h(roleHandler.ListPermissions)},
+	})
+}
+
+// SetupSCIMRoutes mounts the SCIM 2.0 endpoint the HR directory provisions
+// accounts through. It authenticates with the configured SCIM tokens rather
+// than a user session.
+func SetupSCIMRoutes(app *fiber.App, handler *SCIMHandler, verifyToken func(token string) bool) {
+	policy := middleware.Token("scim", verifyToken)
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/scim/v2/ServiceProviderConfig", policy, h(handler.ServiceProviderConfig)},
+		{fiber.MethodGet, "/scim/v2/ResourceTypes", policy, h(handler.ResourceTypes)},
+
+		{fiber.MethodGet, "/scim/v2/Users", policy, h(handler.ListUsers)},
+		{fiber.MethodPost, "/scim/v2/Users", policy, h(handler.CreateUser)},
+		{fiber.MethodGet, "/scim/v2/Users/:id", policy, h(handler.GetUser)},
+		{fiber.MethodPut, "/scim/v2/Users/:id", policy, h(handler.ReplaceUser)},
+		{fiber.MethodPatch, "/scim/v2/Users/:id", policy, h(handler.PatchUser)},
+		{fiber.MethodDelete, "/scim/v2/Users/:id", policy, h(handler.DeleteUser)},
+
+		{fiber.MethodGet, "/scim/v2/Groups", policy, h(handler.ListGroups)},
+		{fiber.MethodPost, "/scim/v2/Groups", policy, h(handler.CreateGroup)},
+		{fiber.MethodGet, "/scim/v2/Groups/:id", policy, h(handler.GetGroup)},
+		{fiber.MethodPut, "/scim/v2/Groups/:id", policy, h(handler.ReplaceGroup)},
+		{fiber.MethodPatch, "/scim/v2/Groups/:id", policy, h(handler.PatchGroup)},
+		{fiber.MethodDelete, "/scim/v2/Groups/:id", policy, h(handler.DeleteGroup)},
+	})
 }
-func SetupDepartmentRoutes(app *fiber.App, handler *DepartmentHandler) {
-	api := app.Group("/api/v1/departments")
 
-	api.Post("/", handler.Create)
-	api.Post("/update", handler.Update)
-	api.Get("/", handler.GetAll)
+func SetupDepartmentRoutes(app *fiber.App, handler *DepartmentHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodPost, "/api/v1/departments", middleware.Require(middleware.PermissionManageEmployees), h(handler.Create)},
+		{fiber.MethodPost, "/api/v1/departments/update", middleware.Require(middleware.PermissionManageEmployees), h(handler.Update)},
+		{fiber.MethodGet, "/api/v1/departments", middleware.Authenticated(), h(handler.GetAll)},
+	})
 }
 
 // Setup routes for the dashboard
 func SetupDashboardRoutes(app *fiber.App, handler *SafetyDashboardHandler) {
-	api := app.Group("/api/v1")
-
-	// Employee dashboard routes
-	api.Get("/dashboard/employee/:employeeID", handler.GetEmployeeDashboard)
-
-	// Admin dashboard routes
-	api.Get("/dashboard/admin", handler.GetAdminDashboard)
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/dashboard/employee/:employeeID", middleware.Authenticated(), h(handler.GetEmployeeDashboard)},
+		{fiber.MethodGet, "/api/v1/dashboard/admin", middleware.Require(middleware.PermissionReadReports), h(handler.GetAdminDashboard)},
+	})
 }
 
 func SetupReportsRoutes(app *fiber.App, reportHandler *ReportHandler) {
-	// API middleware
-	api := app.Group("/api/v1/reports")
-
-	// api.Use(middleware.AuthMiddleware)
-
-	api.Post("/generate", reportHandler.GenerateReport)
-	api.Post("/download", reportHandler.DownloadReport)
+	RegisterRoutes(app, []Route{
+		{fiber.MethodPost, "/api/v1/reports/generate", middleware.Require(middleware.PermissionGenerateReports), h(reportHandler.GenerateReport)},
+		{fiber.MethodPost, "/api/v1/reports/download", middleware.Require(middleware.PermissionGenerateReports), h(reportHandler.DownloadReport)},
+	})
 }
-func SetupNotificationRoutes(app *fiber.App, handler *NotificationHandler) {
-	notifications := app.Group("/api/v1/notifications", middleware.AuthMiddleware())
 
-	// User notifications
-	notifications.Get("/user/:userId", handler.GetUserNotifications)
+func SetupNotificationRoutes(app *fiber.App, handler *NotificationHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/notifications/user/:userId", middleware.Authenticated(), h(handler.GetUserNotifications)},
+		// System notifications (admin/safety officer only)
+		{fiber.MethodGet, "/api/v1/notifications/system", middleware.Require(middleware.PermissionManageIncidents), h(handler.GetSystemNotifications)},
+		{fiber.MethodPut, "/api/v1/notifications/:id/read", middleware.Authenticated(), h(handler.MarkAsRead)},
+	})
+}
 
-	// System notifications (admin/safety officer only)
-	notifications.Get("/system", middleware.RoleMiddleware("admin", "safety_officer"),
-		handler.GetSystemNotifications)
+func SetupNotificationSettingsRoutes(app *fiber.App, handler *NotificationSettingsHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/notification-settings/:userId", middleware.Authenticated(), h(handler.GetSettings)},
+		{fiber.MethodPut, "/api/notification-settings/:userId", middleware.Authenticated(), h(handler.UpdateSettings)},
+	})
+}
 
-	// Mark as read
-	notifications.Put("/:id/read", handler.MarkAsRead)
+func SetupTemporaryEmployeeRoutes(app *fiber.App, employeeHandler *TemporaryEmployeeHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodPost, "/api/temporary-employees", middleware.Require(middleware.PermissionManageEmployees), h(employeeHandler.CreateEmployee)},
+		{fiber.MethodGet, "/api/temporary-employees", middleware.Require(middleware.PermissionReadEmployees), h(employeeHandler.SearchEmployees)},
+		{fiber.MethodGet, "/api/temporary-employees/search/with-temporarly", middleware.Require(middleware.PermissionReadEmployees), h(employeeHandler.SearchAllEmployees)},
+		{fiber.MethodGet, "/api/temporary-employees/get/all", middleware.Require(middleware.PermissionReadEmployees), h(employeeHandler.ListEmployees)},
+		{fiber.MethodGet, "/api/temporary-employees/:id", middleware.Require(middleware.PermissionReadEmployees), h(employeeHandler.GetEmployee)},
+		{fiber.MethodPut, "/api/temporary-employees/:id", middleware.Require(middleware.PermissionManageEmployees), h(employeeHandler.UpdateEmployee)},
+		{fiber.MethodDelete, "/api/temporary-employees/:id", middleware.Require(middleware.PermissionManageEmployees), h(employeeHandler.DeleteEmployee)},
+		{fiber.MethodPost, "/api/temporary-employees/:id/deactivate", middleware.Require(middleware.PermissionManageEmployees), h(employeeHandler.DeActivateEmployee)},
+		{fiber.MethodPost, "/api/temporary-employees/:id/activate", middleware.Require(middleware.PermissionManageEmployees), h(employeeHandler.ActivateEmployee)},
+	})
 }
 
-func SetupNotificationSettingsRoutes(app *fiber.App, handler *NotificationSettingsHandler) {
-	settings := app.Group("/api/notification-settings", middleware.AuthMiddleware())
+func SetupInterViewRoutes(app *fiber.App, handler *InterviewHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodPost, "/api/v1/interview/schedule", middleware.Require(middleware.PermissionManageInvestigations), h(handler.ScheduleInterviewHandler)},
+		{fiber.MethodPut, "/api/v1/interview/:id/status", middleware.Require(middleware.PermissionManageInvestigations), h(handler.UpdateInterviewStatus)},
+		{fiber.MethodPost, "/api/v1/interview/:id/reschedule", middleware.Require(middleware.PermissionManageInvestigations), h(handler.RescheduleInterview)},
+		{fiber.MethodPost, "/api/v1/interview/:id/cancel", middleware.Require(middleware.PermissionManageInvestigations), h(handler.CancelInterview)},
+		{fiber.MethodGet, "/api/v1/interview/:id", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetInterviewDetails)},
+		{fiber.MethodGet, "/api/v1/interview/:id/evidence", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetEvidenceDetails)},
+		{fiber.MethodPost, "/api/v1/interview/:id/template", middleware.Require(middleware.PermissionManageInvestigations), h(handler.ApplyTemplate)},
+		{fiber.MethodPut, "/api/v1/interview/:id/answers", middleware.Require(middleware.PermissionManageInvestigations), h(handler.RecordAnswers)},
+		// The interviewee acknowledges their own statement
+		{fiber.MethodPost, "/api/v1/interview/:id/acknowledge", middleware.Authenticated(), h(handler.Acknowledge)},
+	})
+}
 
-	settings.Get("/:userId", handler.GetSettings)
-	settings.Put("/:userId", handler.UpdateSettings)
+// SetupVpcRoutes registers the routes for VPC resources
+func SetupVpcRoutes(app *fiber.App, handler *VPCHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodPost, "/api/v1/vpcs", middleware.Require(middleware.PermissionCreateVPCs), h(handler.CreateVPC)},
+		{fiber.MethodPost, "/api/v1/vpcs/with-attachments", middleware.Require(middleware.PermissionCreateVPCs), h(handler.CreateVPCWithAttachments)},
+		{fiber.MethodPost, "/api/v1/vpcs/bulk", middleware.Require(middleware.PermissionManageVPCs), h(handler.CreateBulkVPCs)},
+		{fiber.MethodGet, "/api/v1/vpcs", middleware.Require(middleware.PermissionReadVPCs), h(handler.ListAllVPCs)},
+		{fiber.MethodGet, "/api/v1/vpcs/:id", middleware.Require(middleware.PermissionReadVPCs), h(handler.GetVPC)},
+		{fiber.MethodGet, "/api/v1/vpcs/number/:vpcNumber", middleware.Require(middleware.PermissionReadVPCs), h(handler.GetVPCByNumber)},
+		{fiber.MethodPut, "/api/v1/vpcs/:id", middleware.Require(middleware.PermissionManageVPCs), h(handler.UpdateVPC)},
+		{fiber.MethodDelete, "/api/v1/vpcs/:id", middleware.Require(middleware.PermissionManageVPCs), h(handler.DeleteVPC)},
+		{fiber.MethodGet, "/api/v1/vpcs/department/:department", middleware.Require(middleware.PermissionReadVPCs), h(handler.ListByDepartment)},
+		{fiber.MethodGet, "/api/v1/vpcs/type/:vpcType", middleware.Require(middleware.PermissionReadVPCs), h(handler.ListByVpcType)},
+	})
 }
 
-func SetupTemporaryEmployeeRoutes(app *fiber.App, employeeHandler *TemporaryEmployeeHandler) {
+func SetupVpcReports(app *fiber.App, handler *VPCReportHandler) {
+	RegisterRoutes(app, []Route{
+		// Single VPC reports
+		{fiber.MethodGet, "/api/v1/vpcs/reports/:id/preview", middleware.Require(middleware.PermissionReadReports), h(handler.GetVPCReportPreview)},
+		{fiber.MethodGet, "/api/v1/vpcs/reports/:id/pdf", middleware.Require(middleware.PermissionReadReports), h(handler.GetVPCReportPDF)},
+		{fiber.MethodGet, "/api/v1/vpcs/reports/:id/html", middleware.Require(middleware.PermissionReadReports), h(handler.GetVPCReportHTML)},
 
-	employeeRoutes := app.Group("/api/temporary-employees")
-	{
-		employeeRoutes.Post("/", employeeHandler.CreateEmployee)
-		employeeRoutes.Get("/", employeeHandler.SearchEmployees)
-		employeeRoutes.Get("/search/with-temporarly", employeeHandler.SearchAllEmployees)
-		employeeRoutes.Get("/get/all", employeeHandler.ListEmployees)
-		employeeRoutes.Get("/:id", employeeHandler.GetEmployee)
-		employeeRoutes.Put("/:id", employeeHandler.UpdateEmployee)
-		employeeRoutes.Delete("/:id", employeeHandler.DeleteEmployee)
-		employeeRoutes.Post("/:id/deactivate", employeeHandler.DeActivateEmployee)
-		employeeRoutes.Post("/:id/activate", employeeHandler.ActivateEmployee)
-	}
+		// Summary reports
+		{fiber.MethodGet, "/api/v1/vpc/reports/summary/preview", middleware.Require(middleware.PermissionReadReports), h(handler.GetSummaryReportPreview)},
+		{fiber.MethodGet, "/api/v1/vpc/reports/summary/download", middleware.Require(middleware.PermissionReadReports), h(handler.GetSummaryReportDownload)}, // For PDF/HTML full download
+	})
 }
-func SetupInterViewRoutes(app *fiber.App, handler *InterviewHandler) {
-	interview := app.Group("/api/v1/interview", middleware.AuthMiddleware())
-
-	// Route to schedule an interview
-	interview.Post("/schedule", handler.ScheduleInterviewHandler)
-
-	// Route to update the status of an interview
-	interview.Put("/:id/status", handler.UpdateInterviewStatus)
-
-	// Route to get details of a specific interview
-	interview.Get("/:id", handler.GetInterviewDetails)
-
-	// Route to get evidence details for a specific interview
-	interview.Get("/:id/evidence", handler.GetEvidenceDetails)
-}
-
-// RegisterRoutes registers the routes for VPC resources
-func SetupVpcRoutes(app *fiber.App, h *VPCHandler) {
-	vpcGroup := app.Group("/api/v1/vpcs")
-	vpcGroup.Post("/", middleware.AuthMiddleware(), h.CreateVPC)
-	vpcGroup.Post("/with-attachments", middleware.AuthMiddleware(), h.CreateVPCWithAttachments)
-	vpcGroup.Post("/bulk", middleware.AuthMiddleware(), h.CreateBulkVPCs)
-	vpcGroup.Get("/", middleware.AuthMiddleware(), h.ListAllVPCs)
-	vpcGroup.Get("/:id", middleware.AuthMiddleware(), h.GetVPC)
-	vpcGroup.Get("/number/:vpcNumber", middleware.AuthMiddleware(), h.GetVPCByNumber)
-	vpcGroup.Put("/:id", middleware.AuthMiddleware(), h.UpdateVPC)
-	vpcGroup.Delete("/:id", middleware.AuthMiddleware(), h.DeleteVPC)
-	vpcGroup.Get("/department/:department", middleware.AuthMiddleware(), h.ListByDepartment)
-	vpcGroup.Get("/type/:vpcType", middleware.AuthMiddleware(), h.ListByVpcType)
-}
-
-func SetupVpcReports(app *fiber.App, h *VPCReportHandler) {
-	// Single VPC Reports
-	vpcReportGroup := app.Group("/api/v1/vpcs/reports/:id") // Group by :id
-	vpcReportGroup.Get("/preview", h.GetVPCReportPreview)
-	vpcReportGroup.Get("/pdf", h.GetVPCReportPDF)
-	vpcReportGroup.Get("/html", h.GetVPCReportHTML)
-
-	// Summary Reports (New Group)
-	summaryReportGroup := app.Group("/api/v1/vpc/reports/summary")
-	summaryReportGroup.Get("/preview", h.GetSummaryReportPreview)
-	summaryReportGroup.Get("/download", h.GetSummaryReportDownload) // For PDF/HTML full download
+
+func SetupAuditRoutes(app *fiber.App, handler *AuditHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/audit", middleware.Require(middleware.PermissionReadAudit), h(handler.ListAuditLogs)},
+		{fiber.MethodGet, "/api/v1/audit/chain/head", middleware.Require(middleware.PermissionReadAudit), h(handler.GetChainHead)},
+	})
 }
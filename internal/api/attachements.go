@@ -1,8 +1,12 @@
 package api
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/services"
 	"github.com/hopkali04/health-sys/internal/utils"
 )
@@ -34,26 +38,108 @@ func (h *AttachmentHandler) ListAttachments(c *fiber.Ctx) error {
 		})
 	}
 
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
 	utils.LogDebug("Fetching attachments for incident", map[string]interface{}{
 		"incidentID": incidentID,
 	})
 
-	attachments, err := h.service.ListAttachments(incidentID)
+	attachments, err := h.service.ListVisibleAttachments(c.Context(), scope, incidentID)
 	if err != nil {
-		utils.LogError("Failed to fetch attachments", map[string]interface{}{
-			"incidentID": incidentID,
-			"error":      err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to list attachments",
-		})
+		return attachmentErrorResponse(c, err, "Failed to list attachments")
 	}
 
 	utils.LogInfo("Successfully retrieved attachments", map[string]interface{}{
 		"incidentID": incidentID,
 		"count":      len(attachments),
 	})
-	return c.JSON(attachments)
+	return c.JSON(models.ToAttachmentResponses(attachments))
+}
+
+// UploadAttachments adds the files in the "attachments" form field to an
+// existing incident
+func (h *AttachmentHandler) UploadAttachments(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to upload attachments", map[string]interface{}{
+		"path":       c.Path(),
+		"incidentID": c.Params("incidentID"),
+	})
+
+	incidentID, err := uuid.Parse(c.Params("incidentID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID"})
+	}
+
+	files, err := formFiles(c, "attachments")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "no files uploaded"})
+	}
+
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		utils.LogError("Failed to find employee for upload", map[string]interface{}{"error": err.Error()})
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can upload attachments"})
+	}
+
+	attachments, err := h.service.AddAttachments(c.Context(), scope, incidentID, files, employee.ID)
+	if err != nil {
+		return attachmentErrorResponse(c, err, "Failed to upload attachments")
+	}
+
+	utils.LogInfo("Successfully uploaded attachments", map[string]interface{}{
+		"incidentID": incidentID,
+		"count":      len(attachments),
+	})
+	return c.Status(fiber.StatusCreated).JSON(models.ToAttachmentResponses(attachments))
+}
+
+// ReplaceAttachment replaces the file of an attachment with the one in the
+// "file" form field
+func (h *AttachmentHandler) ReplaceAttachment(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to replace attachment", map[string]interface{}{
+		"path": c.Path(),
+		"id":   c.Params("id"),
+	})
+
+	incidentID, err := uuid.Parse(c.Params("incidentID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		utils.LogError("Failed to find employee for upload", map[string]interface{}{"error": err.Error()})
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can upload attachments"})
+	}
+
+	attachment, err := h.service.ReplaceAttachment(c.Context(), scope, incidentID, id, file, employee.ID)
+	if err != nil {
+		return attachmentErrorResponse(c, err, "Failed to replace attachment")
+	}
+
+	utils.LogInfo("Successfully replaced attachment", map[string]interface{}{
+		"attachmentID": id,
+	})
+	return c.JSON(attachment.ToResponse())
 }
 
 // DeleteAttachment deletes an attachment by ID
@@ -63,6 +149,10 @@ func (h *AttachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
 		"id":   c.Params("id"),
 	})
 
+	incidentID, err := uuid.Parse(c.Params("incidentID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID"})
+	}
 	id, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		utils.LogError("Invalid attachment ID format", map[string]interface{}{
@@ -74,18 +164,17 @@ func (h *AttachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
 		})
 	}
 
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
 	utils.LogDebug("Deleting attachment", map[string]interface{}{
 		"attachmentID": id,
 	})
 
-	if err := h.service.DeleteAttachment(id); err != nil {
-		utils.LogError("Failed to delete attachment", map[string]interface{}{
-			"attachmentID": id,
-			"error":        err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete attachment",
-		})
+	if err := h.service.DeleteAttachment(c.Context(), scope, incidentID, id); err != nil {
+		return attachmentErrorResponse(c, err, "Failed to delete attachment")
 	}
 
 	utils.LogInfo("Successfully deleted attachment", map[string]interface{}{
@@ -94,9 +183,33 @@ func (h *AttachmentHandler) DeleteAttachment(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// attachmentErrorResponse answers a failed attachment request. Records the
+// caller may not see are reported as not found.
+func attachmentErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrIncidentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Incident not found"})
+	case errors.Is(err, services.ErrHazardNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Hazard not found"})
+	case errors.Is(err, services.ErrInvestigationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Investigation not found"})
+	case errors.Is(err, services.ErrInvestigationLocked):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrAttachmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment not found"})
+	case errors.Is(err, services.ErrEvidenceNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Evidence not found"})
+	default:
+		return uploadErrorResponse(c, err, message)
+	}
+}
+
 // SetupAttachmentRoutes registers attachment routes
 func SetupAttachmentRoutes(app *fiber.App, handler *AttachmentHandler) {
-	attachments := app.Group("/api/v1/incidents/:incidentID/attachments")
-	attachments.Get("/", handler.ListAttachments)
-	attachments.Delete("/:id", handler.DeleteAttachment)
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/incidents/:incidentID/attachments", middleware.Require(middleware.PermissionReadIncidents), h(handler.ListAttachments)},
+		{fiber.MethodPost, "/api/v1/incidents/:incidentID/attachments", middleware.Require(middleware.PermissionCreateIncidents), h(handler.UploadAttachments)},
+		{fiber.MethodPut, "/api/v1/incidents/:incidentID/attachments/:id", middleware.Require(middleware.PermissionManageIncidents), h(handler.ReplaceAttachment)},
+		{fiber.MethodDelete, "/api/v1/incidents/:incidentID/attachments/:id", middleware.Require(middleware.PermissionManageIncidents), h(handler.DeleteAttachment)},
+	})
 }
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// ListAuditLogs returns the change history, filtered by table, record, user and date range
+func (h *AuditHandler) ListAuditLogs(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to list audit logs", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("pageSize", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 50
+	}
+
+	filter := schema.AuditLogFilter{TableName: c.Query("table")}
+
+	for param, target := range map[string]*uuid.UUID{"record_id": &filter.RecordID, "user_id": &filter.UserID} {
+		if value := c.Query(param); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid " + param + " UUID"})
+			}
+			*target = id
+		}
+	}
+
+	for param, target := range map[string]*time.Time{"start_date": &filter.From, "end_date": &filter.To} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid " + param + " (use RFC3339 format)"})
+			}
+			*target = parsed
+		}
+	}
+
+	logs, total, err := h.service.ListAuditLogs(c.Context(), filter, page, pageSize)
+	if err != nil {
+		utils.LogError("Failed to list audit logs", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve audit logs"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":     schema.ToAuditLogResponses(logs),
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetChainHead exposes the latest audit chain hash for external anchoring
+func (h *AuditHandler) GetChainHead(c *fiber.Ctx) error {
+	head, err := h.service.ChainHead(c.Context())
+	if err != nil {
+		utils.LogError("Failed to read audit chain head", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to read audit chain head"})
+	}
+	if head == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Audit chain is empty"})
+	}
+
+	return c.JSON(head)
+}
@@ -1,22 +1,27 @@
 package api
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/hopkali04/health-sys/internal/schema"
 	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/services/upload"
 	"github.com/hopkali04/health-sys/internal/utils"
 )
 
 type CorrectiveActionHandler struct {
 	CorrectiveActionservice *services.CorrectiveActionService
 	NotificationSVC         *services.NotificationService
+	Uploads                 *upload.Service
 }
 
-func NewCorrectiveActionHandler(service *services.CorrectiveActionService, notifySVC *services.NotificationService) *CorrectiveActionHandler {
+func NewCorrectiveActionHandler(service *services.CorrectiveActionService, notifySVC *services.NotificationService, uploads *upload.Service) *CorrectiveActionHandler {
 	return &CorrectiveActionHandler{
 		CorrectiveActionservice: service,
 		NotificationSVC:         notifySVC,
+		Uploads:                 uploads,
 	}
 }
 
@@ -360,6 +365,9 @@ func (h *CorrectiveActionHandler) UpdateCorrectiveAction(c *fiber.Ctx) error {
 	})
 
 	updatedAction, err := h.CorrectiveActionservice.Update(c.Context(), actionID, req)
+	if errors.Is(err, services.ErrActionStatusReserved) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		utils.LogError("Failed to update corrective action", map[string]interface{}{
 			"actionID": actionID,
@@ -451,12 +459,13 @@ func (h *CorrectiveActionHandler) LabelAsCompleted(c *fiber.Ctx) error {
 		"notes":    req.Notes,
 	})
 
-	if err := h.CorrectiveActionservice.LabelAsCompleted(actionID, req.Notes); err != nil {
-		utils.LogError("Failed to label corrective action as completed", map[string]interface{}{
-			"actionID": actionID,
-			"error":    err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	employee, err := currentEmployee(c, h.CorrectiveActionservice)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": services.ErrNotAssignee.Error()})
+	}
+
+	if err := h.CorrectiveActionservice.LabelAsCompleted(c.Context(), actionID, employee.ID, req.Notes); err != nil {
+		return actionErrorResponse(c, err, "Failed to label corrective action as completed")
 	}
 
 	utils.LogInfo("Successfully labeled corrective action as completed", map[string]interface{}{
@@ -526,26 +535,20 @@ func (h *CorrectiveActionHandler) RequestExtension(c *fiber.Ctx) error {
 		"requestedBy": userIDStr,
 	})
 
-	err = h.CorrectiveActionservice.RequestExtension(c.Context(), actionID, req)
+	emp, err := currentEmployee(c, h.CorrectiveActionservice)
 	if err != nil {
-		utils.LogError("Failed to request extension", map[string]interface{}{
-			"actionID": actionID,
-			"error":    err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   "Failed to request extension",
-			"details": err.Error(),
-		})
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": services.ErrNotAssignee.Error()})
+	}
+
+	err = h.CorrectiveActionservice.RequestExtension(c.Context(), actionID, emp.ID, req)
+	if err != nil {
+		return actionErrorResponse(c, err, "Failed to request extension")
 	}
 
 	// Notify appropriate parties about the extension request
 	action, _ := h.CorrectiveActionservice.InternalGetByID(c.Context(), actionID)
 	if action != nil {
-		// Get employee who requested the extension
-		emp, _ := h.CorrectiveActionservice.GetEmployeeByUserID(uuid.MustParse(userIDStr))
-		if emp != nil {
-			h.NotificationSVC.NotifyExtensionRequested(action, emp)
-		}
+		h.NotificationSVC.NotifyExtensionRequested(action, emp)
 	}
 
 	utils.LogInfo("Successfully requested extension for corrective action", map[string]interface{}{
@@ -555,3 +558,22 @@ func (h *CorrectiveActionHandler) RequestExtension(c *fiber.Ctx) error {
 		"message": "Extension request submitted successfully",
 	})
 }
+
+// actionErrorResponse answers the errors of the assignee's work on an
+// action
+func actionErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrActionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Corrective action not found"})
+	case errors.Is(err, services.ErrNotAssignee):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrActionNotOpen):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		utils.LogError(message, map[string]interface{}{
+			"path":  c.Path(),
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message, "details": err.Error()})
+	}
+}
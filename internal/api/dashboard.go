@@ -43,7 +43,12 @@ func (h *SafetyDashboardHandler) GetEmployeeDashboard(c *fiber.Ctx) error {
 		"timeRange":  timeRange,
 	})
 
-	dashboard, err := h.svc.GetEmployeeDashboard(employeeID, timeRange)
+	scope, err := incidentScope(c, h.svc)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	dashboard, err := h.svc.GetEmployeeDashboard(scope, employeeID, timeRange)
 	if err != nil {
 		utils.LogError("Failed to get employee dashboard", map[string]interface{}{
 			"employeeID": employeeID,
@@ -111,7 +116,12 @@ func (h *SafetyDashboardHandler) GetAdminDashboard(c *fiber.Ctx) error {
 		"filters": filters,
 	})
 
-	dashboard, err := h.svc.GetAdminDashboard(filters)
+	scope, err := incidentScope(c, h.svc)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	dashboard, err := h.svc.GetAdminDashboard(scope, filters)
 	if err != nil {
 		utils.LogError("Failed to get admin dashboard", map[string]interface{}{
 			"filters": filters,
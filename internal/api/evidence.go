@@ -0,0 +1,159 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"github.com/hopkali04/health-sys/internal/validation"
+)
+
+// EvidenceHandler handles the evidence collected during investigations
+type EvidenceHandler struct {
+	service *services.EvidenceService
+}
+
+func NewEvidenceHandler(service *services.EvidenceService) *EvidenceHandler {
+	return &EvidenceHandler{service: service}
+}
+
+// ListEvidence retrieves the evidence of an investigation
+func (h *EvidenceHandler) ListEvidence(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	evidence, err := h.service.ListEvidence(c.Context(), scope, investigationID)
+	if err != nil {
+		return attachmentErrorResponse(c, err, "Failed to list evidence")
+	}
+
+	response := make([]schema.InvestigationEvidenceResponse, len(evidence))
+	for i := range evidence {
+		response[i] = schema.ToInvestigationEvidenceResponse(&evidence[i])
+	}
+	return c.JSON(response)
+}
+
+// CreateEvidence records a piece of evidence from a multipart form. The
+// "file" field is optional.
+func (h *EvidenceHandler) CreateEvidence(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to add evidence", map[string]interface{}{
+		"path":            c.Path(),
+		"investigationID": c.Params("investigationID"),
+	})
+
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		utils.LogError("Failed to find employee for evidence", map[string]interface{}{"error": err.Error()})
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can record evidence"})
+	}
+
+	dto := schema.CreateEvidenceDTO{
+		InvestigationID: investigationID,
+		EvidenceType:    c.FormValue("evidenceType"),
+		Description:     c.FormValue("description"),
+		CollectedBy:     employee.ID,
+		StorageLocation: c.FormValue("storageLocation"),
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+
+	// The file is optional: physical items and testimony may have none
+	file, err := c.FormFile("file")
+	if err != nil {
+		file = nil
+	}
+
+	evidence, err := h.service.AddEvidence(c.Context(), scope, dto, file)
+	if err != nil {
+		return attachmentErrorResponse(c, err, "Failed to add evidence")
+	}
+
+	utils.LogInfo("Successfully added evidence", map[string]interface{}{
+		"investigationID": investigationID,
+		"evidenceID":      evidence.ID,
+	})
+	return c.Status(fiber.StatusCreated).JSON(schema.ToInvestigationEvidenceResponse(evidence))
+}
+
+// ReplaceEvidenceFile uploads the file in the "file" form field to a piece of
+// evidence, replacing the one it had
+func (h *EvidenceHandler) ReplaceEvidenceFile(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid evidence ID"})
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	evidence, err := h.service.ReplaceEvidenceFile(c.Context(), scope, investigationID, id, file)
+	if err != nil {
+		return attachmentErrorResponse(c, err, "Failed to replace evidence file")
+	}
+
+	utils.LogInfo("Successfully replaced evidence file", map[string]interface{}{"evidenceID": id})
+	return c.JSON(schema.ToInvestigationEvidenceResponse(evidence))
+}
+
+// DeleteEvidence deletes a piece of evidence and its file
+func (h *EvidenceHandler) DeleteEvidence(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid evidence ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	if err := h.service.DeleteEvidence(c.Context(), scope, investigationID, id); err != nil {
+		return attachmentErrorResponse(c, err, "Failed to delete evidence")
+	}
+
+	utils.LogInfo("Successfully deleted evidence", map[string]interface{}{"evidenceID": id})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SetupEvidenceRoutes registers investigation evidence routes
+func SetupEvidenceRoutes(app *fiber.App, handler *EvidenceHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/investigations/:investigationID/evidence", middleware.Require(middleware.PermissionReadInvestigations), h(handler.ListEvidence)},
+		{fiber.MethodPost, "/api/v1/investigations/:investigationID/evidence", middleware.Require(middleware.PermissionManageInvestigations), h(handler.CreateEvidence)},
+		{fiber.MethodPut, "/api/v1/investigations/:investigationID/evidence/:id/file", middleware.Require(middleware.PermissionManageInvestigations), h(handler.ReplaceEvidenceFile)},
+		{fiber.MethodDelete, "/api/v1/investigations/:investigationID/evidence/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.DeleteEvidence)},
+	})
+}
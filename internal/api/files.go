@@ -0,0 +1,237 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/services/upload"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// FileHandler serves attachments and evidence files. Stored files are never
+// served directly: every download checks that the caller can see the
+// incident, VPC or corrective action the file belongs to, or carries a
+// signed link issued to someone who could.
+type FileHandler struct {
+	service *services.FileService
+}
+
+func NewFileHandler(service *services.FileService) *FileHandler {
+	return &FileHandler{service: service}
+}
+
+// Download streams a file of the record named by the parent path parameter
+func (h *FileHandler) Download(kind services.FileKind, parentParam string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		file, err := h.findFile(c, kind, parentParam)
+		if err != nil {
+			return fileErrorResponse(c, err)
+		}
+		return h.sendFile(c, file)
+	}
+}
+
+// DownloadVariant streams the thumbnail or preview of a photo attachment
+func (h *FileHandler) DownloadVariant(kind services.FileKind, parentParam string, variant services.ImageVariant) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		file, err := h.findFile(c, kind, parentParam)
+		if err != nil {
+			return fileErrorResponse(c, err)
+		}
+		file, err = file.Variant(variant)
+		if err != nil {
+			return fileErrorResponse(c, err)
+		}
+		return h.sendFile(c, file)
+	}
+}
+
+// SignedURL issues a short-lived download link for a file, for embedding
+// in emails and PDFs
+func (h *FileHandler) SignedURL(kind services.FileKind, parentParam string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		file, err := h.findFile(c, kind, parentParam)
+		if err != nil {
+			return fileErrorResponse(c, err)
+		}
+
+		url, expiresAt, err := h.service.SignedURL(file)
+		if err != nil {
+			return fileErrorResponse(c, err)
+		}
+		return c.JSON(fiber.Map{
+			"url":       url,
+			"expiresAt": expiresAt,
+		})
+	}
+}
+
+// DownloadSigned streams the file a signed download link points to
+func (h *FileHandler) DownloadSigned(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fileErrorResponse(c, services.ErrFileNotFound)
+	}
+
+	file, err := h.service.FindSignedFile(c.Context(), services.FileKind(c.Params("kind")), id, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		return fileErrorResponse(c, err)
+	}
+	return h.sendFile(c, file)
+}
+
+func (h *FileHandler) findFile(c *fiber.Ctx, kind services.FileKind, parentParam string) (*services.StoredFile, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, services.ErrFileNotFound
+	}
+
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return nil, err
+	}
+	return h.service.FindFile(c.Context(), scope, kind, c.Params(parentParam), id)
+}
+
+// sendFile streams the file, or the byte range the client asked for
+func (h *FileHandler) sendFile(c *fiber.Ctx, file *services.StoredFile) error {
+	byteRange := parseByteRange(c.Get(fiber.HeaderRange))
+	object, err := h.service.Open(c.Context(), file, byteRange)
+	if err != nil {
+		return fileErrorResponse(c, err)
+	}
+
+	contentType := object.ContentType
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType("inline", map[string]string{"filename": file.Name}))
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	if !object.ModTime.IsZero() {
+		c.Set(fiber.HeaderLastModified, object.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	status := fiber.StatusOK
+	if byteRange != nil {
+		status = fiber.StatusPartialContent
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", object.Offset, object.Offset+object.Length-1, object.Size))
+	}
+	return c.Status(status).SendStream(object.Body, int(object.Length))
+}
+
+// parseByteRange reads a single-range Range header. Anything else, such as
+// several ranges, is ignored and the whole file is sent.
+func parseByteRange(header string) *storage.ByteRange {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return nil
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return nil
+	}
+
+	if first == "" {
+		// bytes=-500 asks for the last 500 bytes
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix <= 0 {
+			return nil
+		}
+		return &storage.ByteRange{Start: -suffix, End: -1}
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return nil
+	}
+	byteRange := &storage.ByteRange{Start: start, End: -1}
+	if last != "" {
+		end, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return nil
+		}
+		byteRange.End = end
+	}
+	return byteRange
+}
+
+func fileErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrFileNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "File not found"})
+	case errors.Is(err, services.ErrInvalidFileLink):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "This download link is invalid or has expired"})
+	case errors.Is(err, services.ErrSignedURLsDisabled):
+		return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{"error": "Signed download links are not enabled"})
+	case errors.Is(err, storage.ErrInvalidRange):
+		return c.Status(fiber.StatusRequestedRangeNotSatisfiable).JSON(fiber.Map{"error": "Requested range not satisfiable"})
+	default:
+		utils.LogError("Failed to serve file", map[string]interface{}{
+			"path":  c.Path(),
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to serve file"})
+	}
+}
+
+// uploadRejectionStatus returns the status for an upload that failed the
+// upload checks; other errors are server errors
+func uploadRejectionStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, upload.ErrFileType), errors.Is(err, upload.ErrContentMismatch):
+		return fiber.StatusUnsupportedMediaType, true
+	case errors.Is(err, upload.ErrFileTooLarge):
+		return fiber.StatusRequestEntityTooLarge, true
+	case errors.Is(err, upload.ErrEmptyFile):
+		return fiber.StatusBadRequest, true
+	case errors.Is(err, upload.ErrInfected):
+		return fiber.StatusUnprocessableEntity, true
+	default:
+		return 0, false
+	}
+}
+
+// SetupFileRoutes registers the file download routes. Each route requires
+// the permission that reads the parent record.
+func SetupFileRoutes(app *fiber.App, handler *FileHandler) {
+	readIncidents := middleware.Require(middleware.PermissionReadIncidents)
+	readVPCs := middleware.Require(middleware.PermissionReadVPCs)
+	readHazards := middleware.Require(middleware.PermissionReadHazards)
+	readInvestigations := middleware.Require(middleware.PermissionReadInvestigations)
+
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/incidents/:incidentID/attachments/:id/download", readIncidents, h(handler.Download(services.FileIncidentAttachment, "incidentID"))},
+		{fiber.MethodGet, "/api/v1/incidents/:incidentID/attachments/:id/signed-url", readIncidents, h(handler.SignedURL(services.FileIncidentAttachment, "incidentID"))},
+		{fiber.MethodGet, "/api/v1/incidents/:incidentID/attachments/:id/thumbnail", readIncidents, h(handler.DownloadVariant(services.FileIncidentAttachment, "incidentID", services.ImageThumbnail))},
+		{fiber.MethodGet, "/api/v1/incidents/:incidentID/attachments/:id/preview", readIncidents, h(handler.DownloadVariant(services.FileIncidentAttachment, "incidentID", services.ImagePreview))},
+		{fiber.MethodGet, "/api/v1/vpcs/:vpcID/attachments/:id/download", readVPCs, h(handler.Download(services.FileVPCAttachment, "vpcID"))},
+		{fiber.MethodGet, "/api/v1/vpcs/:vpcID/attachments/:id/signed-url", readVPCs, h(handler.SignedURL(services.FileVPCAttachment, "vpcID"))},
+		{fiber.MethodGet, "/api/v1/vpcs/:vpcID/attachments/:id/thumbnail", readVPCs, h(handler.DownloadVariant(services.FileVPCAttachment, "vpcID", services.ImageThumbnail))},
+		{fiber.MethodGet, "/api/v1/vpcs/:vpcID/attachments/:id/preview", readVPCs, h(handler.DownloadVariant(services.FileVPCAttachment, "vpcID", services.ImagePreview))},
+		{fiber.MethodGet, "/api/v1/hazards/:hazardID/attachments/:id/download", readHazards, h(handler.Download(services.FileHazardAttachment, "hazardID"))},
+		{fiber.MethodGet, "/api/v1/hazards/:hazardID/attachments/:id/signed-url", readHazards, h(handler.SignedURL(services.FileHazardAttachment, "hazardID"))},
+		{fiber.MethodGet, "/api/v1/hazards/:hazardID/attachments/:id/thumbnail", readHazards, h(handler.DownloadVariant(services.FileHazardAttachment, "hazardID", services.ImageThumbnail))},
+		{fiber.MethodGet, "/api/v1/hazards/:hazardID/attachments/:id/preview", readHazards, h(handler.DownloadVariant(services.FileHazardAttachment, "hazardID", services.ImagePreview))},
+		{fiber.MethodGet, "/api/v1/investigations/:investigationID/evidence/:id/download", readInvestigations, h(handler.Download(services.FileEvidence, "investigationID"))},
+		{fiber.MethodGet, "/api/v1/investigations/:investigationID/evidence/:id/signed-url", readInvestigations, h(handler.SignedURL(services.FileEvidence, "investigationID"))},
+		{fiber.MethodGet, "/api/v1/investigations/:investigationID/evidence/:id/thumbnail", readInvestigations, h(handler.DownloadVariant(services.FileEvidence, "investigationID", services.ImageThumbnail))},
+		{fiber.MethodGet, "/api/v1/investigations/:investigationID/evidence/:id/preview", readInvestigations, h(handler.DownloadVariant(services.FileEvidence, "investigationID", services.ImagePreview))},
+		{fiber.MethodGet, "/api/v1/actions/:actionID/evidence/:id/download", readIncidents, h(handler.Download(services.FileActionEvidence, "actionID"))},
+		{fiber.MethodGet, "/api/v1/actions/:actionID/evidence/:id/signed-url", readIncidents, h(handler.SignedURL(services.FileActionEvidence, "actionID"))},
+
+		// Signed links work without signing in; the signature is the credential
+		{fiber.MethodGet, "/api/v1/files/:kind/:id", middleware.Public(), h(handler.DownloadSigned)},
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// ListAttachments retrieves the photos and documents attached to a hazard
+func (h *HazardHandler) ListAttachments(c *fiber.Ctx) error {
+	hazardID, err := uuid.Parse(c.Params("hazardID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid hazard ID format"})
+	}
+
+	attachments, err := h.Service.ListAttachments(c.Context(), hazardID)
+	if err != nil {
+		return attachmentErrorResponse(c, err, "Failed to list attachments")
+	}
+	return c.JSON(models.ToHazardAttachmentResponses(attachments))
+}
+
+// UploadAttachments adds the files in the "attachments" form field to a
+// hazard
+func (h *HazardHandler) UploadAttachments(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to upload hazard attachments", map[string]interface{}{
+		"path":     c.Path(),
+		"hazardID": c.Params("hazardID"),
+	})
+
+	hazardID, err := uuid.Parse(c.Params("hazardID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid hazard ID format"})
+	}
+	files, err := formFiles(c, "attachments")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "no files uploaded"})
+	}
+	employee, err := currentEmployee(c, h.Service)
+	if err != nil {
+		utils.LogError("Failed to find employee for upload", map[string]interface{}{"error": err.Error()})
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can upload attachments"})
+	}
+
+	attachments, err := h.Service.AddAttachments(c.Context(), hazardID, files, employee.ID)
+	if err != nil {
+		return attachmentErrorResponse(c, err, "Failed to upload attachments")
+	}
+
+	utils.LogInfo("Successfully uploaded hazard attachments", map[string]interface{}{
+		"hazardID": hazardID,
+		"count":    len(attachments),
+	})
+	return c.Status(fiber.StatusCreated).JSON(models.ToHazardAttachmentResponses(attachments))
+}
+
+// ReplaceAttachment replaces the file of a hazard attachment with the one in
+// the "file" form field
+func (h *HazardHandler) ReplaceAttachment(c *fiber.Ctx) error {
+	hazardID, err := uuid.Parse(c.Params("hazardID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid hazard ID format"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+	employee, err := currentEmployee(c, h.Service)
+	if err != nil {
+		utils.LogError("Failed to find employee for upload", map[string]interface{}{"error": err.Error()})
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can upload attachments"})
+	}
+
+	attachment, err := h.Service.ReplaceAttachment(c.Context(), hazardID, id, file, employee.ID)
+	if err != nil {
+		return attachmentErrorResponse(c, err, "Failed to replace attachment")
+	}
+
+	utils.LogInfo("Successfully replaced hazard attachment", map[string]interface{}{"attachmentID": id})
+	return c.JSON(attachment.ToResponse())
+}
+
+// DeleteAttachment deletes a hazard attachment
+func (h *HazardHandler) DeleteAttachment(c *fiber.Ctx) error {
+	hazardID, err := uuid.Parse(c.Params("hazardID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid hazard ID format"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid attachment ID"})
+	}
+
+	if err := h.Service.DeleteAttachment(c.Context(), hazardID, id); err != nil {
+		return attachmentErrorResponse(c, err, "Failed to delete attachment")
+	}
+
+	utils.LogInfo("Successfully deleted hazard attachment", map[string]interface{}{"attachmentID": id})
+	return c.SendStatus(fiber.StatusNoContent)
+}
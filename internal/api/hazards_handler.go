@@ -40,7 +40,7 @@ func (h *HazardHandler) CreateHazard(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
 	}
 
-	hazard, err := h.Service.CreateHazard(req, userID)
+	hazard, err := h.Service.CreateHazard(c.Context(), req, userID)
 	if err != nil {
 		utils.LogError("Failed to create hazard", map[string]interface{}{"error": err.Error()})
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create hazard"})
@@ -119,7 +119,7 @@ func (h *HazardHandler) UpdateHazard(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	hazard, err := h.Service.UpdateHazard(id, req)
+	hazard, err := h.Service.UpdateHazard(c.Context(), id, req)
 	if err != nil {
 		utils.LogError("Failed to update hazard", map[string]interface{}{"id": id, "error": err.Error()})
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update hazard"})
@@ -140,7 +140,7 @@ func (h *HazardHandler) DeleteHazard(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid hazard ID format"})
 	}
 
-	if err := h.Service.DeleteHazard(id); err != nil {
+	if err := h.Service.DeleteHazard(c.Context(), id); err != nil {
 		utils.LogError("Failed to delete hazard", map[string]interface{}{"id": id, "error": err.Error()})
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete hazard"})
 	}
@@ -168,7 +168,7 @@ func (h *HazardHandler) AssignHazard(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	hazard, err := h.Service.AssignHazardToUser(id, req.UserID)
+	hazard, err := h.Service.AssignHazardToUser(c.Context(), id, req.UserID)
 	if err != nil {
 		utils.LogError("Failed to assign hazard", map[string]interface{}{"hazardID": id, "userID": req.UserID, "error": err.Error()})
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to assign hazard"})
@@ -44,13 +44,13 @@ func (h *IncidentsHandler) UpdateIncidentHandler(c *fiber.Ctx) error {
     }
 
     // Update the incident
-    incident, err := h.service.UpdateIncident(id, req)
+    incident, err := h.service.UpdateIncident(c.Context(), id, req, statusChanger(c, h.service))
     if err != nil {
         utils.LogError("Failed to update incident", map[string]interface{}{
             "incidentID": id,
             "error":      err.Error(),
         })
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return workflowErrorResponse(c, err, "Failed to update incident")
     }
 
     utils.LogInfo("Successfully updated incident", map[string]interface{}{
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// scopeResolver is implemented by the services that serve incident data
+type scopeResolver interface {
+	ResolveScope(ctx context.Context, userID uuid.UUID, role string) (services.IncidentScope, error)
+}
+
+// incidentScope resolves which incidents the signed-in user may see
+func incidentScope(c *fiber.Ctx, resolver scopeResolver) (services.IncidentScope, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return services.IncidentScope{}, err
+	}
+	role, _ := c.Locals("role").(string)
+
+	scope, err := resolver.ResolveScope(c.Context(), userID, role)
+	if err != nil {
+		utils.LogError("Failed to resolve incident scope", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+	}
+	return scope, err
+}
+
+func scopeErrorResponse(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to determine which incidents you can access"})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// statusChanger returns the employee ID status changes are recorded
+// against, or nil when the signed-in user has no employee record
+func statusChanger(c *fiber.Ctx, lookup employeeLookup) *uuid.UUID {
+	employee, err := currentEmployee(c, lookup)
+	if err != nil {
+		return nil
+	}
+	return &employee.ID
+}
+
+// workflowErrorResponse answers a failed status change. Changes the
+// workflow refuses are conflicts with the incident's current state.
+func workflowErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrIncidentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Incident not found"})
+	case errors.Is(err, services.ErrUnknownIncidentStatus):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrTransitionNotAllowed), errors.Is(err, services.ErrTransitionBlocked):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		utils.LogError(message, map[string]interface{}{
+			"path":  c.Path(),
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
+
+// GetStatusHistoryHandler lists the status changes of an incident
+func (h *IncidentsHandler) GetStatusHistoryHandler(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID"})
+	}
+
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	history, err := h.service.GetStatusHistory(c.Context(), scope, id)
+	if err != nil {
+		return workflowErrorResponse(c, err, "Failed to get status history")
+	}
+	return c.JSON(schema.ToIncidentStatusChangeResponses(history))
+}
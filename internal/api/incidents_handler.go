@@ -3,6 +3,8 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"mime/multipart"
 	"path/filepath"
 	"strconv"
@@ -14,6 +16,7 @@ import (
 	"github.com/hopkali04/health-sys/internal/schema"
 	"github.com/hopkali04/health-sys/internal/services"
 	"github.com/hopkali04/health-sys/internal/utils"
+	"gorm.io/gorm"
 )
 
 type IncidentsHandler struct {
@@ -60,8 +63,13 @@ func (h *IncidentsHandler) GetIncidentsByEmployeeID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to check user existence", "details": err.Error()})
 	}
 
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
 	// Fetch incidents by employee ID
-	incidents, err := h.service.GetByEmployeeID(employee.ID)
+	incidents, err := h.service.GetByEmployeeID(scope, employee.ID)
 	if err != nil {
 		utils.LogError("Failed to fetch incidents by employee ID", map[string]interface{}{
 			"employeeID": employee.ID,
@@ -115,7 +123,12 @@ func (h *IncidentsHandler) GetClosedIncidentsByEmployeeIDHandler(c *fiber.Ctx) e
 		pageSize = 10 // Minimum 10 items per page
 	}
 
-	incidents, total, err := h.service.GetClosedIncidentsByEmployeeID(employeeID, page, pageSize)
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	incidents, total, err := h.service.GetClosedIncidentsByEmployeeID(scope, employeeID, page, pageSize)
 	if err != nil {
 		utils.LogError("Failed to get closed incidents for employee", map[string]interface{}{
 			"employeeID": employeeID,
@@ -154,8 +167,16 @@ func (h *IncidentsHandler) GetIncidentSummary(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID format"})
 	}
 
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
 	// Generate summary
-	summary, err := h.service.GenerateIncidentSummary(id)
+	summary, err := h.service.GenerateIncidentSummary(scope, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Incident not found"})
+	}
 	if err != nil {
 		utils.LogError("Failed to generate incident summary", map[string]interface{}{
 			"incidentID": id,
@@ -181,6 +202,46 @@ func (h *IncidentsHandler) GetIncidentSummary(c *fiber.Ctx) error {
 	return c.JSON(summary)
 }
 
+// GetIncidentSummaryPDF downloads the incident summary as a PDF, with the
+// root cause analysis of its investigation
+func (h *IncidentsHandler) GetIncidentSummaryPDF(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID format"})
+	}
+
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	summary, err := h.service.GenerateIncidentSummary(scope, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Incident not found"})
+	}
+	if err != nil {
+		utils.LogError("Failed to generate incident summary", map[string]interface{}{
+			"incidentID": id,
+			"error":      err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate incident summary"})
+	}
+
+	buffer, err := services.IncidentSummaryPDF(summary)
+	if err != nil {
+		utils.LogError("Failed to render incident summary PDF", map[string]interface{}{
+			"incidentID": id,
+			"error":      err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate incident summary"})
+	}
+
+	fileName := fmt.Sprintf("incident_summary_%s.pdf", summary.Incident.ReferenceNumber)
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fileName))
+	return c.Send(buffer.Bytes())
+}
+
 // CreateIncident handles basic incident creation without attachments
 func (h *IncidentsHandler) CreateIncident(c *fiber.Ctx) error {
 	utils.LogInfo("Processing request to create an incident", map[string]interface{}{
@@ -229,7 +290,7 @@ func (h *IncidentsHandler) CreateIncident(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to check user existence", "details": err.Error()})
 	}
 
-	incident, err := h.service.CreateIncident(req, employee.ID)
+	incident, err := h.service.CreateIncident(c.Context(), req, employee.ID)
 	if err != nil {
 		utils.LogError("Failed to create incident", map[string]interface{}{
 			"userID": uuidUserID,
@@ -339,12 +400,15 @@ func (h *IncidentsHandler) CreateIncidentWithAttachments(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to check user existence", "details": err.Error()})
 	}
 
-	incident, err := h.service.CreateIncidentWithAttachment(req, uploadedFiles, employee.ID)
+	incident, err := h.service.CreateIncidentWithAttachment(c.Context(), req, uploadedFiles, employee.ID)
 	if err != nil {
 		utils.LogError("Failed to create incident with attachments", map[string]interface{}{
 			"userID": uuidUserID,
 			"error":  err.Error(),
 		})
+		if status, rejected := uploadRejectionStatus(err); rejected {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error(), "req": req, "incidentDataStr": incidentDataStr})
 	}
 
@@ -396,8 +460,13 @@ func (h *IncidentsHandler) ListIncidentsHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
 	// Call the service method
-	incidents, total, err := h.service.ListIncidents(page, pageSize, filters)
+	incidents, total, err := h.service.ListIncidents(scope, page, pageSize, filters)
 	if err != nil {
 		utils.LogError("Failed to list incidents", map[string]interface{}{
 			"page":     page,
@@ -461,8 +530,13 @@ func (h *IncidentsHandler) ListClosedIncidentsHandler(c *fiber.Ctx) error {
 		}
 	}
 
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
 	// Call the service method
-	incidents, total, err := h.service.ListClosedIncidents(page, pageSize, filters)
+	incidents, total, err := h.service.ListClosedIncidents(scope, page, pageSize, filters)
 	if err != nil {
 		utils.LogError("Failed to list closed incidents", map[string]interface{}{
 			"page":     page,
@@ -502,7 +576,12 @@ func (h *IncidentsHandler) GetIncidentHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID"})
 	}
 
-	incident, err := h.service.GetIncident(id)
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	incident, err := h.service.GetIncident(scope, id)
 	if err != nil {
 		utils.LogError("Failed to fetch incident", map[string]interface{}{
 			"incidentID": id,
@@ -545,15 +624,21 @@ func (h *IncidentsHandler) CloseIncidentHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid incident ID"})
 	}
 
-	incident, err := h.service.CloseIncident(id)
+	// The note is optional
+	var request struct {
+		Note string `json:"note"`
+	}
+	_ = c.BodyParser(&request)
+
+	// The reporter is notified by the workflow's close hook
+	incident, err := h.service.CloseIncident(c.Context(), id, statusChanger(c, h.service), request.Note)
 	if err != nil {
 		utils.LogError("Failed to close incident", map[string]interface{}{
 			"incidentID": id,
 			"error":      err.Error(),
 		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return workflowErrorResponse(c, err, "Failed to close incident")
 	}
-	go h.employeeSVC.HandleClosingIncidentNotification(incident)
 
 	utils.LogInfo("Successfully closed incident", map[string]interface{}{
 		"incidentID": id,
@@ -587,7 +672,7 @@ func (h *IncidentsHandler) AssignIncidentToUserHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	incident, err := h.service.AssignIncidentToUser(incidentID, request.UserID)
+	incident, err := h.service.AssignIncidentToUser(c.Context(), incidentID, request.UserID)
 	if err != nil {
 		utils.LogError("Failed to assign incident to user", map[string]interface{}{
 			"incidentID": incidentID,
@@ -661,8 +746,13 @@ func (h *IncidentsHandler) ListIncidentsHandlerFiltered(c *fiber.Ctx) error {
 		filters["end_date"] = parsedEnd
 	}
 
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
 	// Call service method
-	incidents, total, err := h.service.ListIncidents(page, pageSize, filters)
+	incidents, total, err := h.service.ListIncidents(scope, page, pageSize, filters)
 	if err != nil {
 		utils.LogError("Failed to list filtered incidents", map[string]interface{}{
 			"filters": filters,
@@ -697,6 +787,7 @@ func (h *IncidentsHandler) UpdateIncidentStatusHandler(c *fiber.Ctx) error {
 
 	var request struct {
 		Status string `json:"status"`
+		Note   string `json:"note"`
 	}
 	if err := c.BodyParser(&request); err != nil {
 		utils.LogError("Failed to parse request body", map[string]interface{}{
@@ -706,14 +797,14 @@ func (h *IncidentsHandler) UpdateIncidentStatusHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	incident, err := h.service.UpdateIncidentStatus(id, request.Status)
+	incident, err := h.service.UpdateIncidentStatus(c.Context(), id, request.Status, statusChanger(c, h.service), request.Note)
 	if err != nil {
 		utils.LogError("Failed to update incident status", map[string]interface{}{
 			"incidentID": id,
 			"status":     request.Status,
 			"error":      err.Error(),
 		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return workflowErrorResponse(c, err, "Failed to update incident status")
 	}
 
 	utils.LogInfo("Successfully updated incident status", map[string]interface{}{
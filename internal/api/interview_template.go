@@ -0,0 +1,140 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"github.com/hopkali04/health-sys/internal/validation"
+)
+
+// InterviewTemplateHandler handles the question templates of interviews
+type InterviewTemplateHandler struct {
+	service *services.InterviewTemplateService
+}
+
+func NewInterviewTemplateHandler(service *services.InterviewTemplateService) *InterviewTemplateHandler {
+	return &InterviewTemplateHandler{service: service}
+}
+
+// ListTemplates lists the active templates, filtered by ?incidentType.
+// ?all=true includes inactive templates.
+func (h *InterviewTemplateHandler) ListTemplates(c *fiber.Ctx) error {
+	templates, err := h.service.ListTemplates(c.Context(), c.Query("incidentType"), c.QueryBool("all"))
+	if err != nil {
+		return templateErrorResponse(c, err, "Failed to list interview templates")
+	}
+
+	response := make([]schema.InterviewTemplateResponse, len(templates))
+	for i := range templates {
+		response[i] = schema.ToInterviewTemplateResponse(&templates[i])
+	}
+	return c.JSON(response)
+}
+
+func (h *InterviewTemplateHandler) GetTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+
+	template, err := h.service.GetTemplate(c.Context(), id)
+	if err != nil {
+		return templateErrorResponse(c, err, "Failed to get interview template")
+	}
+	return c.JSON(schema.ToInterviewTemplateResponse(template))
+}
+
+func (h *InterviewTemplateHandler) CreateTemplate(c *fiber.Ctx) error {
+	var dto schema.InterviewTemplateDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can create interview templates"})
+	}
+
+	template, err := h.service.CreateTemplate(c.Context(), dto, employee.ID)
+	if err != nil {
+		return templateErrorResponse(c, err, "Failed to create interview template")
+	}
+
+	utils.LogInfo("Successfully created interview template", map[string]interface{}{
+		"templateID":   template.ID,
+		"incidentType": template.IncidentType,
+	})
+	return c.Status(fiber.StatusCreated).JSON(schema.ToInterviewTemplateResponse(template))
+}
+
+// ReplaceTemplate updates a template and replaces its questions
+func (h *InterviewTemplateHandler) ReplaceTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+	var dto schema.InterviewTemplateDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+
+	template, err := h.service.ReplaceTemplate(c.Context(), id, dto)
+	if err != nil {
+		return templateErrorResponse(c, err, "Failed to update interview template")
+	}
+
+	utils.LogInfo("Successfully updated interview template", map[string]interface{}{"templateID": id})
+	return c.JSON(schema.ToInterviewTemplateResponse(template))
+}
+
+func (h *InterviewTemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+
+	if err := h.service.DeleteTemplate(c.Context(), id); err != nil {
+		return templateErrorResponse(c, err, "Failed to delete interview template")
+	}
+
+	utils.LogInfo("Successfully deleted interview template", map[string]interface{}{"templateID": id})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func templateErrorResponse(c *fiber.Ctx, err error, message string) error {
+	if errors.Is(err, services.ErrInterviewTemplateNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Interview template not found"})
+	}
+	utils.LogError(message, map[string]interface{}{
+		"path":  c.Path(),
+		"error": err.Error(),
+	})
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+}
+
+// SetupInterviewTemplateRoutes registers interview question template routes
+func SetupInterviewTemplateRoutes(app *fiber.App, handler *InterviewTemplateHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/interview-templates", middleware.Require(middleware.PermissionReadInvestigations), h(handler.ListTemplates)},
+		{fiber.MethodGet, "/api/v1/interview-templates/:id", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetTemplate)},
+		{fiber.MethodPost, "/api/v1/interview-templates", middleware.Require(middleware.PermissionManageInvestigations), h(handler.CreateTemplate)},
+		{fiber.MethodPut, "/api/v1/interview-templates/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.ReplaceTemplate)},
+		{fiber.MethodDelete, "/api/v1/interview-templates/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.DeleteTemplate)},
+	})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -57,7 +58,12 @@ func (h *InvestigationHandler) GetAllByEmployeeID(c *fiber.Ctx) error {
 		"employeeID": emp.ID,
 	})
 
-	investigations, err := h.Service.GetAllByEmployeeID(emp.ID)
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	investigations, err := h.Service.GetAllByEmployeeID(scope, emp.ID)
 	if err != nil {
 		utils.LogError("Failed to fetch investigations", map[string]interface{}{
 			"employeeID": emp.ID,
@@ -94,7 +100,12 @@ func (h *InvestigationHandler) GetAll(c *fiber.Ctx) error {
 		"offset": offset,
 	})
 
-	investigations, err := h.Service.GetAll(status, limit, offset)
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	investigations, err := h.Service.GetAll(scope, status, limit, offset)
 	if err != nil {
 		utils.LogError("Failed to fetch investigations", map[string]interface{}{
 			"status": status,
@@ -133,7 +144,12 @@ func (h *InvestigationHandler) GetByIncidentID(c *fiber.Ctx) error {
 		"incidentID": incidentID,
 	})
 
-	investigation, err := h.Service.FullGetByIncidentID(c.Context(), incidentID)
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	investigation, err := h.Service.FullGetByIncidentID(c.Context(), scope, incidentID)
 	if err != nil {
 		utils.LogError("Failed to fetch investigation", map[string]interface{}{
 			"incidentID": incidentID,
@@ -168,7 +184,12 @@ func (h *InvestigationHandler) GetByID(c *fiber.Ctx) error {
 		"investigationID": id,
 	})
 
-	investigation, err := h.Service.FullGetByID(c.Context(), id)
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	investigation, err := h.Service.FullGetByID(c.Context(), scope, id)
 	if err != nil {
 		utils.LogError("Failed to fetch investigation", map[string]interface{}{
 			"investigationID": id,
@@ -214,7 +235,7 @@ func (h *InvestigationHandler) Create(c *fiber.Ctx) error {
 		"request": form,
 	})
 
-	investigation, err := h.Service.Create(&form)
+	investigation, err := h.Service.Create(c.Context(), &form)
 	if err != nil {
 		utils.LogError("Failed to create investigation", map[string]interface{}{
 			"request": form,
@@ -290,13 +311,16 @@ func (h *InvestigationHandler) Update(c *fiber.Ctx) error {
 		"request":         form,
 	})
 
-	investigation, err := h.Service.Update(id, &form)
+	investigation, err := h.Service.Update(c.Context(), id, &form)
 	if err != nil {
 		utils.LogError("Failed to update investigation", map[string]interface{}{
 			"investigationID": id,
 			"request":         form,
 			"error":           err.Error(),
 		})
+		if errors.Is(err, services.ErrInvestigationLocked) {
+			return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -328,7 +352,7 @@ func (h *InvestigationHandler) Delete(c *fiber.Ctx) error {
 		"investigationID": id,
 	})
 
-	if err := h.Service.Delete(id); err != nil {
+	if err := h.Service.Delete(c.Context(), id); err != nil {
 		utils.LogError("Failed to delete investigation", map[string]interface{}{
 			"investigationID": id,
 			"error":           err.Error(),
@@ -341,37 +365,3 @@ func (h *InvestigationHandler) Delete(c *fiber.Ctx) error {
 	})
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Investigation deleted successfully"})
 }
-
-// CloseInvestigation closes an investigation by ID
-func (h *InvestigationHandler) CloseInvestigation(c *fiber.Ctx) error {
-	utils.LogInfo("Processing request to close an investigation", map[string]interface{}{
-		"path": c.Path(),
-		"id":   c.Params("id"),
-	})
-
-	id, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		utils.LogError("Invalid investigation ID format", map[string]interface{}{
-			"investigationID": c.Params("id"),
-			"error":           err.Error(),
-		})
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
-	}
-
-	utils.LogDebug("Closing investigation", map[string]interface{}{
-		"investigationID": id,
-	})
-
-	if err := h.Service.CloseInvestigation(id); err != nil {
-		utils.LogError("Failed to close investigation", map[string]interface{}{
-			"investigationID": id,
-			"error":           err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-
-	utils.LogInfo("Successfully closed investigation", map[string]interface{}{
-		"investigationID": id,
-	})
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Investigation closed successfully"})
-}
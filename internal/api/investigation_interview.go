@@ -8,7 +8,7 @@ import (
 	"github.com/hopkali04/health-sys/internal/schema"
 	"github.com/hopkali04/health-sys/internal/services"
 	"github.com/hopkali04/health-sys/internal/utils"
-	"gorm.io/gorm"
+	"github.com/hopkali04/health-sys/internal/validation"
 )
 
 type InterviewHandler struct {
@@ -59,18 +59,15 @@ func (h *InterviewHandler) UpdateInterviewStatus(c *fiber.Ctx) error {
 		"notes":       request.Notes,
 	})
 
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
 	// Update the interview status using the service
-	interview, err := h.Service.UpdateInterviewStatus(interviewID, request.Status, request.Notes)
+	interview, err := h.Service.UpdateInterviewStatus(c.Context(), scope, interviewID, request.Status, request.Notes)
 	if err != nil {
-		utils.LogError("Failed to update interview status", map[string]interface{}{
-			"interviewID": interviewID,
-			"status":      request.Status,
-			"notes":       request.Notes,
-			"error":       err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return interviewErrorResponse(c, err, "Failed to update interview status")
 	}
 
 	utils.LogInfo("Successfully updated interview status", map[string]interface{}{
@@ -102,23 +99,14 @@ func (h *InterviewHandler) GetInterviewDetails(c *fiber.Ctx) error {
 		"interviewID": id,
 	})
 
-	interview, err := h.Service.GetInterviewDetails(c.Context(), id)
+	scope, err := incidentScope(c, h.Service)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.LogError("Interview not found", map[string]interface{}{
-				"interviewID": id,
-			})
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error": "Interview not found",
-			})
-		}
-		utils.LogError("Failed to fetch interview details", map[string]interface{}{
-			"interviewID": id,
-			"error":       err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return scopeErrorResponse(c)
+	}
+
+	interview, err := h.Service.GetInterviewDetails(c.Context(), scope, id)
+	if err != nil {
+		return interviewErrorResponse(c, err, "Failed to fetch interview details")
 	}
 
 	utils.LogInfo("Successfully retrieved interview details", map[string]interface{}{
@@ -149,23 +137,19 @@ func (h *InterviewHandler) GetEvidenceDetails(c *fiber.Ctx) error {
 		"evidenceID": id,
 	})
 
-	evidence, err := h.Service.GetEvidenceDetails(c.Context(), id)
+	scope, err := incidentScope(c, h.Service)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			utils.LogError("Evidence not found", map[string]interface{}{
-				"evidenceID": id,
-			})
+		return scopeErrorResponse(c)
+	}
+
+	evidence, err := h.Service.GetEvidenceDetails(c.Context(), scope, id)
+	if err != nil {
+		if errors.Is(err, services.ErrEvidenceNotFound) {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": "Evidence not found",
 			})
 		}
-		utils.LogError("Failed to fetch evidence details", map[string]interface{}{
-			"evidenceID": id,
-			"error":      err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return interviewErrorResponse(c, err, "Failed to fetch evidence details")
 	}
 
 	utils.LogInfo("Successfully retrieved evidence details", map[string]interface{}{
@@ -209,16 +193,19 @@ func (h *InterviewHandler) ScheduleInterviewHandler(c *fiber.Ctx) error {
 		"location":        dto.Location,
 	})
 
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.Service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can schedule interviews"})
+	}
+
 	// Call the service method to schedule the interview
-	interview, err := h.Service.ScheduleInterview(dto)
+	interview, err := h.Service.ScheduleInterview(c.Context(), scope, dto, employee.ID)
 	if err != nil {
-		utils.LogError("Failed to schedule interview", map[string]interface{}{
-			"request": dto,
-			"error":   err.Error(),
-		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to schedule interview",
-		})
+		return interviewErrorResponse(c, err, "Failed to schedule interview")
 	}
 
 	utils.LogInfo("Successfully scheduled interview", map[string]interface{}{
@@ -226,3 +213,220 @@ func (h *InterviewHandler) ScheduleInterviewHandler(c *fiber.Ctx) error {
 	})
 	return c.Status(fiber.StatusCreated).JSON(interview)
 }
+
+// RescheduleInterview moves an interview to another time and sends the
+// updated calendar invite
+func (h *InterviewHandler) RescheduleInterview(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid interview ID format"})
+	}
+	var dto schema.RescheduleInterviewDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.Service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can reschedule interviews"})
+	}
+
+	interview, err := h.Service.RescheduleInterview(c.Context(), scope, id, dto, employee.ID)
+	if err != nil {
+		return interviewErrorResponse(c, err, "Failed to reschedule interview")
+	}
+
+	utils.LogInfo("Successfully rescheduled interview", map[string]interface{}{
+		"interviewID":  id,
+		"scheduledFor": interview.ScheduledFor,
+	})
+	return c.JSON(schema.ToInvestigationInterviewResponse(interview))
+}
+
+// CancelInterview cancels an interview and withdraws its calendar invite
+func (h *InterviewHandler) CancelInterview(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid interview ID format"})
+	}
+	var dto schema.CancelInterviewDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.Service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can cancel interviews"})
+	}
+
+	interview, err := h.Service.CancelInterview(c.Context(), scope, id, dto.Reason, employee.ID)
+	if err != nil {
+		return interviewErrorResponse(c, err, "Failed to cancel interview")
+	}
+
+	utils.LogInfo("Successfully cancelled interview", map[string]interface{}{"interviewID": id})
+	return c.JSON(schema.ToInvestigationInterviewResponse(interview))
+}
+
+// ApplyTemplate sets the questions of an interview from a question template
+func (h *InterviewHandler) ApplyTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid interview ID format"})
+	}
+	var dto schema.ApplyInterviewTemplateDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	interview, err := h.Service.ApplyTemplate(c.Context(), scope, id, dto.TemplateID)
+	if err != nil {
+		return interviewErrorResponse(c, err, "Failed to apply interview template")
+	}
+
+	utils.LogInfo("Successfully applied interview template", map[string]interface{}{
+		"interviewID": id,
+		"templateID":  dto.TemplateID,
+	})
+	return c.JSON(schema.ToInvestigationInterviewResponse(interview))
+}
+
+// RecordAnswers records answers to the questions of an interview
+func (h *InterviewHandler) RecordAnswers(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid interview ID format"})
+	}
+	var dto schema.RecordInterviewAnswersDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.Service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can record interview answers"})
+	}
+
+	interview, err := h.Service.RecordAnswers(c.Context(), scope, id, dto, employee.ID)
+	if err != nil {
+		return interviewErrorResponse(c, err, "Failed to record interview answers")
+	}
+
+	utils.LogInfo("Successfully recorded interview answers", map[string]interface{}{
+		"interviewID": id,
+		"answers":     len(dto.Answers),
+	})
+	return c.JSON(schema.ToInvestigationInterviewResponse(interview))
+}
+
+// Acknowledge records the interviewee's acknowledgement of their statement
+func (h *InterviewHandler) Acknowledge(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid interview ID format"})
+	}
+	scope, err := incidentScope(c, h.Service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.Service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": services.ErrNotInterviewee.Error()})
+	}
+
+	interview, err := h.Service.Acknowledge(c.Context(), scope, id, employee)
+	if err != nil {
+		return interviewErrorResponse(c, err, "Failed to acknowledge statement")
+	}
+
+	utils.LogInfo("Interview statement acknowledged", map[string]interface{}{
+		"interviewID": id,
+		"evidenceID":  interview.EvidenceID,
+	})
+	return c.JSON(schema.ToInvestigationInterviewResponse(interview))
+}
+
+func interviewErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvestigationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Investigation not found"})
+	case errors.Is(err, services.ErrInterviewNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Interview not found"})
+	case errors.Is(err, services.ErrInterviewTemplateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Interview template not found"})
+	case errors.Is(err, services.ErrNotInterviewee):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrStatementAcknowledged):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTranscript), errors.Is(err, services.ErrInvalidSchedule):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInterviewConflict):
+		return interviewConflictResponse(c, err)
+	default:
+		utils.LogError(message, map[string]interface{}{
+			"path":  c.Path(),
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
+
+// interviewConflictResponse lists the booked interviews a schedule overlaps
+func interviewConflictResponse(c *fiber.Ctx, err error) error {
+	body := fiber.Map{"error": services.ErrInterviewConflict.Error()}
+	var conflictErr *services.InterviewConflictError
+	if errors.As(err, &conflictErr) {
+		conflicts := make([]schema.InterviewConflictResponse, len(conflictErr.Conflicts))
+		for i, conflict := range conflictErr.Conflicts {
+			conflicts[i] = schema.InterviewConflictResponse{
+				InterviewID:     conflict.Interview.ID.String(),
+				InvestigationID: conflict.Interview.InvestigationID.String(),
+				EmployeeID:      conflict.Employee.ID.String(),
+				EmployeeName:    conflict.Employee.FirstName + " " + conflict.Employee.LastName,
+				Role:            conflict.Role,
+				ScheduledFor:    conflict.Interview.ScheduledFor,
+				EndsAt:          conflict.Interview.EndsAt(),
+				Location:        conflict.Interview.Location,
+			}
+		}
+		body["conflicts"] = conflicts
+	}
+	return c.Status(fiber.StatusConflict).JSON(body)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"github.com/hopkali04/health-sys/internal/validation"
+)
+
+// InvestigationMemberHandler handles the teams of investigations
+type InvestigationMemberHandler struct {
+	service *services.InvestigationMemberService
+}
+
+func NewInvestigationMemberHandler(service *services.InvestigationMemberService) *InvestigationMemberHandler {
+	return &InvestigationMemberHandler{service: service}
+}
+
+// GetTeam retrieves the lead investigator and the members of an
+// investigation, including those who left the team
+func (h *InvestigationMemberHandler) GetTeam(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	team, err := h.service.GetTeam(c.Context(), scope, investigationID)
+	if err != nil {
+		return memberErrorResponse(c, err, "Failed to get investigation team")
+	}
+	return c.JSON(schema.ToInvestigationTeamResponse(team.Investigation, team.Members))
+}
+
+// AddMember puts an employee on an investigation team
+func (h *InvestigationMemberHandler) AddMember(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	var dto schema.InvestigationMemberDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can manage investigation teams"})
+	}
+
+	member, err := h.service.AddMember(c.Context(), scope, investigationID, dto, employee.ID)
+	if err != nil {
+		return memberErrorResponse(c, err, "Failed to add investigation member")
+	}
+
+	utils.LogInfo("Successfully added investigation member", map[string]interface{}{
+		"investigationID": investigationID,
+		"employeeID":      member.EmployeeID,
+		"role":            member.Role,
+	})
+	return c.Status(fiber.StatusCreated).JSON(schema.ToInvestigationMemberResponse(member))
+}
+
+// UpdateMember changes the role of a current member
+func (h *InvestigationMemberHandler) UpdateMember(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid member ID"})
+	}
+	var dto schema.UpdateInvestigationMemberDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	member, err := h.service.UpdateMemberRole(c.Context(), scope, investigationID, id, dto.Role)
+	if err != nil {
+		return memberErrorResponse(c, err, "Failed to update investigation member")
+	}
+
+	utils.LogInfo("Successfully updated investigation member", map[string]interface{}{
+		"memberID": id,
+		"role":     dto.Role,
+	})
+	return c.JSON(schema.ToInvestigationMemberResponse(member))
+}
+
+// RemoveMember takes a member off an investigation team. The record of
+// their membership is kept.
+func (h *InvestigationMemberHandler) RemoveMember(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid member ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	if err := h.service.RemoveMember(c.Context(), scope, investigationID, id); err != nil {
+		return memberErrorResponse(c, err, "Failed to remove investigation member")
+	}
+
+	utils.LogInfo("Successfully removed investigation member", map[string]interface{}{"memberID": id})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func memberErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvestigationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Investigation not found"})
+	case errors.Is(err, services.ErrInvestigationLocked):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrMemberNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Member not found"})
+	case errors.Is(err, services.ErrAlreadyMember):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidMember):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		utils.LogError(message, map[string]interface{}{
+			"path":  c.Path(),
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
+
+// SetupInvestigationMemberRoutes registers investigation team routes
+func SetupInvestigationMemberRoutes(app *fiber.App, handler *InvestigationMemberHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/investigations/:investigationID/members", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetTeam)},
+		{fiber.MethodPost, "/api/v1/investigations/:investigationID/members", middleware.Require(middleware.PermissionManageInvestigations), h(handler.AddMember)},
+		{fiber.MethodPut, "/api/v1/investigations/:investigationID/members/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.UpdateMember)},
+		{fiber.MethodDelete, "/api/v1/investigations/:investigationID/members/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.RemoveMember)},
+	})
+}
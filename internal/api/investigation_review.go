@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// InvestigationReviewHandler handles submitting investigations for review
+// and the reviewers' sign-off
+type InvestigationReviewHandler struct {
+	service *services.InvestigationReviewService
+}
+
+func NewInvestigationReviewHandler(service *services.InvestigationReviewService) *InvestigationReviewHandler {
+	return &InvestigationReviewHandler{service: service}
+}
+
+// GetReviews retrieves where an investigation stands in the review chain,
+// with every signed decision
+func (h *InvestigationReviewHandler) GetReviews(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	state, err := h.service.GetState(c.Context(), scope, id)
+	if err != nil {
+		return reviewErrorResponse(c, err, "Failed to get investigation reviews")
+	}
+	return c.JSON(toReviewStateResponse(state))
+}
+
+// Submit sends an investigation for review. Only its lead investigator may.
+func (h *InvestigationReviewHandler) Submit(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can submit investigations"})
+	}
+
+	investigation, err := h.service.Submit(c.Context(), scope, id, employee)
+	if err != nil {
+		return reviewErrorResponse(c, err, "Failed to submit investigation")
+	}
+
+	utils.LogInfo("Investigation submitted for review", map[string]interface{}{
+		"investigationID": id,
+		"round":           investigation.ReviewRound,
+	})
+	return h.respond(c, scope, id)
+}
+
+// Approve signs off the step awaiting review
+func (h *InvestigationReviewHandler) Approve(c *fiber.Ctx) error {
+	return h.decide(c, models.ReviewDecisionApproved)
+}
+
+// Reject rejects the investigation at the step awaiting review and reopens it
+func (h *InvestigationReviewHandler) Reject(c *fiber.Ctx) error {
+	return h.decide(c, models.ReviewDecisionRejected)
+}
+
+func (h *InvestigationReviewHandler) decide(c *fiber.Ctx, decision string) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	var dto schema.ReviewDecisionDTO
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&dto); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can review investigations"})
+	}
+
+	if decision == models.ReviewDecisionRejected {
+		_, err = h.service.Reject(c.Context(), scope, id, employee, dto.Comments)
+	} else {
+		_, err = h.service.Approve(c.Context(), scope, id, employee, dto.Comments)
+	}
+	if err != nil {
+		return reviewErrorResponse(c, err, "Failed to record review")
+	}
+
+	utils.LogInfo("Investigation review recorded", map[string]interface{}{
+		"investigationID": id,
+		"reviewerID":      employee.ID,
+		"decision":        decision,
+	})
+	return h.respond(c, scope, id)
+}
+
+// respond answers with the review state after a change
+func (h *InvestigationReviewHandler) respond(c *fiber.Ctx, scope services.IncidentScope, id uuid.UUID) error {
+	state, err := h.service.GetState(c.Context(), scope, id)
+	if err != nil {
+		return reviewErrorResponse(c, err, "Failed to get investigation reviews")
+	}
+	return c.JSON(toReviewStateResponse(state))
+}
+
+func reviewErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvestigationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Investigation not found"})
+	case errors.Is(err, services.ErrNotLeadInvestigator), errors.Is(err, services.ErrNotReviewer):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrReviewNotAllowed):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrCommentsRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		utils.LogError(message, map[string]interface{}{
+			"path":  c.Path(),
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
+
+func toReviewStepResponse(step config.ReviewStep) schema.ReviewStepResponse {
+	return schema.ReviewStepResponse{Name: step.Name, Role: step.Role, SameDepartment: step.SameDepartment}
+}
+
+func toReviewStateResponse(state *services.ReviewState) schema.InvestigationReviewStateResponse {
+	response := schema.InvestigationReviewStateResponse{
+		InvestigationID: state.Investigation.ID.String(),
+		Status:          state.Investigation.Status,
+		Round:           state.Investigation.ReviewRound,
+		SubmittedAt:     state.Investigation.SubmittedAt,
+		Steps:           make([]schema.ReviewStepResponse, len(state.Steps)),
+		Reviews:         make([]schema.InvestigationReviewResponse, len(state.Reviews)),
+	}
+	if state.CurrentStep != nil {
+		step := toReviewStepResponse(*state.CurrentStep)
+		response.CurrentStep = &step
+	}
+	for i, step := range state.Steps {
+		response.Steps[i] = toReviewStepResponse(step)
+	}
+	for i := range state.Reviews {
+		response.Reviews[i] = schema.ToInvestigationReviewResponse(&state.Reviews[i].InvestigationReview, state.Reviews[i].Valid)
+	}
+	return response
+}
+
+// SetupInvestigationReviewRoutes registers investigation review routes.
+// Who may submit and sign off is checked against the review chain.
+func SetupInvestigationReviewRoutes(app *fiber.App, handler *InvestigationReviewHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/investigations/:id/reviews", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetReviews)},
+		{fiber.MethodPost, "/api/v1/investigations/:id/submit", middleware.Require(middleware.PermissionReadInvestigations), h(handler.Submit)},
+		// Closing an investigation now means submitting it for sign-off
+		{fiber.MethodPost, "/api/v1/investigations/:id/close", middleware.Require(middleware.PermissionReadInvestigations), h(handler.Submit)},
+		{fiber.MethodPost, "/api/v1/investigations/:id/approve", middleware.Require(middleware.PermissionReadInvestigations), h(handler.Approve)},
+		{fiber.MethodPost, "/api/v1/investigations/:id/reject", middleware.Require(middleware.PermissionReadInvestigations), h(handler.Reject)},
+	})
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/user"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"github.com/hopkali04/health-sys/internal/validation"
+)
+
+// startMFAChallenge answers a correct password with an intermediate token
+// instead of a session when the user has, or must set up, a second factor
+func (app *UserHandler) startMFAChallenge(c *fiber.Ctx, u *models.User) error {
+	mfaToken, expiresAt, err := app.mfaService.IssueChallenge(u.ID)
+	if err != nil {
+		utils.LogError("Failed to issue MFA challenge", map[string]interface{}{
+			"userID": u.ID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
+	}
+
+	utils.LogInfo("Password accepted, second factor required", map[string]interface{}{
+		"userID":            u.ID,
+		"enrollmentPending": !u.MFAEnabled,
+	})
+	return c.JSON(fiber.Map{
+		"mfa_required":            true,
+		"mfa_enrollment_required": !u.MFAEnabled,
+		"mfaToken":                mfaToken,
+		"expiresAt":               expiresAt,
+	})
+}
+
+// VerifyMFA exchanges an intermediate MFA token plus a TOTP or recovery code for a session
+func (app *UserHandler) VerifyMFA(c *fiber.Ctx) error {
+	utils.LogInfo("Processing MFA verification request", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	var req schema.MFAVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validation.ParseAndValidate(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request data", "details": err.Error()})
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "A verification code or recovery code is required"})
+	}
+
+	userID, err := app.mfaService.ParseChallenge(req.MFAToken)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "MFA session expired, please log in again"})
+	}
+
+	if err := app.mfaService.VerifyCode(userID, req.Code, req.RecoveryCode, c.IP()); err != nil {
+		return app.mfaErrorResponse(c, userID, err)
+	}
+
+	return app.finishMFALogin(c, userID, nil)
+}
+
+// StartMFAEnrollment generates a TOTP secret and otpauth URI for the caller.
+// It accepts either a normal session or the intermediate token of a user
+// whose role forces enrollment before the first login.
+func (app *UserHandler) StartMFAEnrollment(c *fiber.Ctx) error {
+	utils.LogInfo("Processing MFA enrollment request", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	var req struct {
+		MFAToken string `json:"mfaToken"`
+	}
+	_ = c.BodyParser(&req)
+
+	userID, _, err := app.mfaSubject(c, req.MFAToken)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	enrollment, err := app.mfaService.BeginEnrollment(userID)
+	if err != nil {
+		return app.mfaErrorResponse(c, userID, err)
+	}
+
+	utils.LogInfo("Started MFA enrollment", map[string]interface{}{
+		"userID": userID,
+	})
+	return c.JSON(enrollment)
+}
+
+// ConfirmMFAEnrollment enables MFA with the first code from the authenticator
+// app and returns the one-time recovery codes
+func (app *UserHandler) ConfirmMFAEnrollment(c *fiber.Ctx) error {
+	utils.LogInfo("Processing MFA enrollment confirmation", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	var req schema.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validation.ParseAndValidate(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request data", "details": err.Error()})
+	}
+
+	userID, loginPending, err := app.mfaSubject(c, req.MFAToken)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	recoveryCodes, err := app.mfaService.ConfirmEnrollment(userID, req.Code)
+	if err != nil {
+		return app.mfaErrorResponse(c, userID, err)
+	}
+
+	utils.LogInfo("MFA enabled", map[string]interface{}{
+		"userID": userID,
+	})
+
+	// A user enrolling during login has now proven both factors
+	if loginPending {
+		return app.finishMFALogin(c, userID, fiber.Map{"recoveryCodes": recoveryCodes})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "Two-factor authentication enabled",
+		"recoveryCodes": recoveryCodes,
+	})
+}
+
+// DisableMFA lets a user switch MFA off, unless their role requires it
+func (app *UserHandler) DisableMFA(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to disable MFA", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req schema.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	role, _ := c.Locals("role").(string)
+	if err := app.mfaService.Disable(userID, role, req.Code, c.IP()); err != nil {
+		return app.mfaErrorResponse(c, userID, err)
+	}
+
+	utils.LogInfo("MFA disabled", map[string]interface{}{
+		"userID": userID,
+	})
+	return c.JSON(fiber.Map{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the caller's recovery codes
+func (app *UserHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to regenerate recovery codes", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var req schema.MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	recoveryCodes, err := app.mfaService.RegenerateRecoveryCodes(userID, req.Code, c.IP())
+	if err != nil {
+		return app.mfaErrorResponse(c, userID, err)
+	}
+
+	return c.JSON(fiber.Map{"recoveryCodes": recoveryCodes})
+}
+
+// ResetUserMFA lets an admin clear a user's second factor so they can enroll again
+func (app *UserHandler) ResetUserMFA(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to reset user MFA", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID format"})
+	}
+
+	if err := app.mfaService.Reset(userID); err != nil {
+		utils.LogError("Failed to reset user MFA", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Sessions opened with the old factor should not survive the reset
+	if err := app.sessionService.RevokeUserSessions(userID); err != nil {
+		utils.LogError("Failed to revoke sessions after MFA reset", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+	}
+
+	utils.LogInfo("Successfully reset user MFA", map[string]interface{}{
+		"userID":  userID,
+		"resetBy": c.Locals("userID"),
+	})
+	return c.JSON(fiber.Map{"message": "Two-factor authentication reset successfully"})
+}
+
+// mfaSubject resolves the user an MFA request is about, either from the
+// intermediate MFA token or from the authenticated session
+func (app *UserHandler) mfaSubject(c *fiber.Ctx, mfaToken string) (uuid.UUID, bool, error) {
+	if mfaToken != "" {
+		userID, err := app.mfaService.ParseChallenge(mfaToken)
+		return userID, true, err
+	}
+
+	userID, err := currentUserID(c)
+	return userID, false, err
+}
+
+func (app *UserHandler) finishMFALogin(c *fiber.Ctx, userID uuid.UUID, extra fiber.Map) error {
+	u, err := app.userService.GetUserByID(userID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+	}
+
+	role, err := app.userService.GetUserRole(userID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Employee Account Not Found, Contact System Support"})
+	}
+
+	return app.completeLogin(c, u, role, extra)
+}
+
+func (app *UserHandler) mfaErrorResponse(c *fiber.Ctx, userID uuid.UUID, err error) error {
+	utils.LogWarn("MFA request rejected", map[string]interface{}{
+		"userID": userID,
+		"error":  err.Error(),
+	})
+
+	switch {
+	case errors.Is(err, user.ErrInvalidMFACode):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid verification code"})
+	case errors.Is(err, user.ErrMFAAlreadyEnabled):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Two-factor authentication is already enabled"})
+	case errors.Is(err, user.ErrMFANotEnrolled):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Start enrollment before confirming a code"})
+	case errors.Is(err, user.ErrMFANotEnabled):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Two-factor authentication must be set up before signing in"})
+	case errors.Is(err, user.ErrAccountLocked):
+		return accountLockedResponse(c, err)
+	case errors.Is(err, user.ErrMFARequired):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Two-factor authentication is required for your role"})
+	default:
+		utils.LogError("MFA request failed", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process two-factor authentication"})
+	}
+}
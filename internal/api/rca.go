@@ -0,0 +1,269 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"github.com/hopkali04/health-sys/internal/validation"
+)
+
+// RCAHandler handles the root cause analysis of investigations: 5-Whys
+// chains and fishbone causes
+type RCAHandler struct {
+	service *services.RCAService
+}
+
+func NewRCAHandler(service *services.RCAService) *RCAHandler {
+	return &RCAHandler{service: service}
+}
+
+// GetAnalysis retrieves the 5-Whys chains and the fishbone diagram of an
+// investigation
+func (h *RCAHandler) GetAnalysis(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	analysis, err := h.service.GetAnalysis(c.Context(), scope, investigationID)
+	if err != nil {
+		return rcaErrorResponse(c, err, "Failed to get root cause analysis")
+	}
+	return c.JSON(schema.ToRootCauseAnalysisResponse(investigationID, analysis.FiveWhys, analysis.Causes))
+}
+
+// CreateFiveWhys adds a 5-Whys chain to an investigation
+func (h *RCAHandler) CreateFiveWhys(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	var dto schema.FiveWhysDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can record root cause analysis"})
+	}
+
+	chain, err := h.service.CreateFiveWhys(c.Context(), scope, investigationID, dto, employee.ID)
+	if err != nil {
+		return rcaErrorResponse(c, err, "Failed to create 5-whys chain")
+	}
+
+	utils.LogInfo("Successfully created 5-whys chain", map[string]interface{}{
+		"investigationID": investigationID,
+		"fiveWhysID":      chain.ID,
+	})
+	return c.Status(fiber.StatusCreated).JSON(schema.ToFiveWhysResponse(chain))
+}
+
+// ReplaceFiveWhys rewrites a 5-Whys chain
+func (h *RCAHandler) ReplaceFiveWhys(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 5-whys chain ID"})
+	}
+	var dto schema.FiveWhysDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can record root cause analysis"})
+	}
+
+	chain, err := h.service.ReplaceFiveWhys(c.Context(), scope, investigationID, id, dto, employee.ID)
+	if err != nil {
+		return rcaErrorResponse(c, err, "Failed to update 5-whys chain")
+	}
+
+	utils.LogInfo("Successfully updated 5-whys chain", map[string]interface{}{"fiveWhysID": id})
+	return c.JSON(schema.ToFiveWhysResponse(chain))
+}
+
+// DeleteFiveWhys deletes a 5-Whys chain
+func (h *RCAHandler) DeleteFiveWhys(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 5-whys chain ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	if err := h.service.DeleteFiveWhys(c.Context(), scope, investigationID, id); err != nil {
+		return rcaErrorResponse(c, err, "Failed to delete 5-whys chain")
+	}
+
+	utils.LogInfo("Successfully deleted 5-whys chain", map[string]interface{}{"fiveWhysID": id})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateCause adds a cause, or with a parent a sub-cause, to the fishbone
+// diagram of an investigation
+func (h *RCAHandler) CreateCause(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	var dto schema.RootCauseDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+	employee, err := currentEmployee(c, h.service)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only employees can record root cause analysis"})
+	}
+
+	cause, err := h.service.CreateCause(c.Context(), scope, investigationID, dto, employee.ID)
+	if err != nil {
+		return rcaErrorResponse(c, err, "Failed to create cause")
+	}
+
+	utils.LogInfo("Successfully created cause", map[string]interface{}{
+		"investigationID": investigationID,
+		"causeID":         cause.ID,
+	})
+	return c.Status(fiber.StatusCreated).JSON(schema.ToRootCauseResponse(cause))
+}
+
+// UpdateCause changes a fishbone cause and replaces its links
+func (h *RCAHandler) UpdateCause(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid cause ID"})
+	}
+	var dto schema.UpdateRootCauseDTO
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if errs, err := validation.ValidateStruct(dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "Validation failed",
+			"details": errs,
+		})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	cause, err := h.service.UpdateCause(c.Context(), scope, investigationID, id, dto)
+	if err != nil {
+		return rcaErrorResponse(c, err, "Failed to update cause")
+	}
+
+	utils.LogInfo("Successfully updated cause", map[string]interface{}{"causeID": id})
+	return c.JSON(schema.ToRootCauseResponse(cause))
+}
+
+// DeleteCause deletes a fishbone cause and its sub-causes
+func (h *RCAHandler) DeleteCause(c *fiber.Ctx) error {
+	investigationID, err := uuid.Parse(c.Params("investigationID"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid investigation ID"})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid cause ID"})
+	}
+	scope, err := incidentScope(c, h.service)
+	if err != nil {
+		return scopeErrorResponse(c)
+	}
+
+	if err := h.service.DeleteCause(c.Context(), scope, investigationID, id); err != nil {
+		return rcaErrorResponse(c, err, "Failed to delete cause")
+	}
+
+	utils.LogInfo("Successfully deleted cause", map[string]interface{}{"causeID": id})
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func rcaErrorResponse(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, services.ErrInvestigationNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Investigation not found"})
+	case errors.Is(err, services.ErrInvestigationLocked):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrFiveWhysNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "5-whys chain not found"})
+	case errors.Is(err, services.ErrRootCauseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Cause not found"})
+	case errors.Is(err, services.ErrInvalidRootCause):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		utils.LogError(message, map[string]interface{}{
+			"path":  c.Path(),
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+	}
+}
+
+// SetupRCARoutes registers root cause analysis routes
+func SetupRCARoutes(app *fiber.App, handler *RCAHandler) {
+	RegisterRoutes(app, []Route{
+		{fiber.MethodGet, "/api/v1/investigations/:investigationID/rca", middleware.Require(middleware.PermissionReadInvestigations), h(handler.GetAnalysis)},
+		{fiber.MethodPost, "/api/v1/investigations/:investigationID/rca/five-whys", middleware.Require(middleware.PermissionManageInvestigations), h(handler.CreateFiveWhys)},
+		{fiber.MethodPut, "/api/v1/investigations/:investigationID/rca/five-whys/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.ReplaceFiveWhys)},
+		{fiber.MethodDelete, "/api/v1/investigations/:investigationID/rca/five-whys/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.DeleteFiveWhys)},
+		{fiber.MethodPost, "/api/v1/investigations/:investigationID/rca/causes", middleware.Require(middleware.PermissionManageInvestigations), h(handler.CreateCause)},
+		{fiber.MethodPut, "/api/v1/investigations/:investigationID/rca/causes/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.UpdateCause)},
+		{fiber.MethodDelete, "/api/v1/investigations/:investigationID/rca/causes/:id", middleware.Require(middleware.PermissionManageInvestigations), h(handler.DeleteCause)},
+	})
+}
@@ -1,12 +1,15 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/schema"
 	"github.com/hopkali04/health-sys/internal/services"
 	"github.com/hopkali04/health-sys/internal/utils"
+	"github.com/hopkali04/health-sys/internal/validation"
 )
 
 type RoleHandler struct {
@@ -49,12 +52,10 @@ func (h *RoleHandler) AssignRole(c *fiber.Ctx) error {
 	})
 
 	if err := h.roleService.AssignRole(c.Context(), employeeID, request.Role); err != nil {
-		utils.LogError("Failed to assign role", map[string]interface{}{
-			"employeeID": employeeID,
-			"role":       request.Role,
-			"error":      err.Error(),
-		})
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		if errors.Is(err, services.ErrEmployeeNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Employee not found"})
+		}
+		return h.roleErrorResponse(c, request.Role, err)
 	}
 
 	utils.LogInfo("Successfully assigned role to employee", map[string]interface{}{
@@ -63,3 +64,124 @@ func (h *RoleHandler) AssignRole(c *fiber.Ctx) error {
 	})
 	return c.Status(http.StatusOK).JSON(fiber.Map{"message": "Role assigned successfully"})
 }
+
+// ListRoles returns every role with its permissions
+func (h *RoleHandler) ListRoles(c *fiber.Ctx) error {
+	roles, err := h.roleService.ListRoles(c.Context())
+	if err != nil {
+		utils.LogError("Failed to list roles", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch roles"})
+	}
+	return c.JSON(schema.ToRoleResponses(roles))
+}
+
+// GetRole returns a single role
+func (h *RoleHandler) GetRole(c *fiber.Ctx) error {
+	role, err := h.roleService.GetRole(c.Context(), c.Params("name"))
+	if err != nil {
+		return h.roleErrorResponse(c, c.Params("name"), err)
+	}
+	return c.JSON(schema.ToRoleResponse(*role))
+}
+
+// ListPermissions returns the permissions that can be granted to roles
+func (h *RoleHandler) ListPermissions(c *fiber.Ctx) error {
+	permissions, err := h.roleService.ListPermissions(c.Context())
+	if err != nil {
+		utils.LogError("Failed to list permissions", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch permissions"})
+	}
+	return c.JSON(schema.ToPermissionResponses(permissions))
+}
+
+// CreateRole adds a custom role
+func (h *RoleHandler) CreateRole(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to create role", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	var request schema.CreateRoleRequest
+	if err := validation.ParseAndValidate(c, &request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request data", "details": err.Error()})
+	}
+
+	role, err := h.roleService.CreateRole(c.Context(), request)
+	if err != nil {
+		return h.roleErrorResponse(c, request.Name, err)
+	}
+
+	utils.LogInfo("Successfully created role", map[string]interface{}{
+		"role": role.Name,
+	})
+	return c.Status(http.StatusCreated).JSON(schema.ToRoleResponse(*role))
+}
+
+// UpdateRole replaces a role's description and permissions
+func (h *RoleHandler) UpdateRole(c *fiber.Ctx) error {
+	name := c.Params("name")
+	utils.LogInfo("Processing request to update role", map[string]interface{}{
+		"path": c.Path(),
+		"role": name,
+	})
+
+	var request schema.UpdateRoleRequest
+	if err := validation.ParseAndValidate(c, &request); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request data", "details": err.Error()})
+	}
+
+	role, err := h.roleService.UpdateRole(c.Context(), name, request)
+	if err != nil {
+		return h.roleErrorResponse(c, name, err)
+	}
+
+	utils.LogInfo("Successfully updated role", map[string]interface{}{
+		"role":        role.Name,
+		"permissions": len(role.Permissions),
+	})
+	return c.JSON(schema.ToRoleResponse(*role))
+}
+
+// DeleteRole removes a custom role
+func (h *RoleHandler) DeleteRole(c *fiber.Ctx) error {
+	name := c.Params("name")
+	utils.LogInfo("Processing request to delete role", map[string]interface{}{
+		"path": c.Path(),
+		"role": name,
+	})
+
+	if err := h.roleService.DeleteRole(c.Context(), name); err != nil {
+		return h.roleErrorResponse(c, name, err)
+	}
+
+	utils.LogInfo("Successfully deleted role", map[string]interface{}{
+		"role": name,
+	})
+	return c.JSON(fiber.Map{"message": "Role deleted successfully"})
+}
+
+func (h *RoleHandler) roleErrorResponse(c *fiber.Ctx, role string, err error) error {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": "Role not found"})
+	case errors.Is(err, services.ErrRoleExists):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "A role with this name already exists"})
+	case errors.Is(err, services.ErrRoleInUse):
+		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": "The role is still assigned to employees"})
+	case errors.Is(err, services.ErrInvalidRoleName),
+		errors.Is(err, services.ErrUnknownPermission),
+		errors.Is(err, services.ErrAdminLockout):
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrSystemRole):
+		return c.Status(http.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+	default:
+		utils.LogError("Role request failed", map[string]interface{}{
+			"role":  role,
+			"error": err.Error(),
+		})
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to process role request"})
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hopkali04/health-sys/internal/middleware"
+)
+
+// Route is one entry of a route table. Every route names the policy that
+// guards it; the policy's middleware runs before the handlers.
+type Route struct {
+	Method   string
+	Path     string
+	Policy   middleware.Policy
+	Handlers []fiber.Handler
+}
+
+// routePolicies remembers the policy of every route registered through a route table
+var routePolicies = map[string]middleware.Policy{}
+
+// h lists a route's handlers
+func h(handlers ...fiber.Handler) []fiber.Handler {
+	return handlers
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// RegisterRoutes mounts a route table on the app
+func RegisterRoutes(app *fiber.App, routes []Route) {
+	for _, route := range routes {
+		routePolicies[routeKey(route.Method, route.Path)] = route.Policy
+
+		handlers := append(route.Policy.Handlers(), route.Handlers...)
+		app.Add(route.Method, route.Path, handlers...)
+	}
+}
+
+// ValidateRoutePolicies fails when a route was registered without a policy,
+// either outside a route table or with an undefined policy, or when a policy
+// names a permission that no role has. The server refuses to start then.
+func ValidateRoutePolicies(app *fiber.App) error {
+	var problems []string
+	seen := map[string]bool{}
+
+	for _, route := range app.GetRoutes(true) {
+		// Fiber adds a HEAD route for every GET route
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		key := routeKey(route.Method, route.Path)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		policy, ok := routePolicies[key]
+		switch {
+		case !ok:
+			problems = append(problems, key+": registered outside a route table")
+		case !policy.Defined():
+			problems = append(problems, key+": no policy")
+		case policy.Permission() != "" && !middleware.IsKnownPermission(policy.Permission()):
+			problems = append(problems, fmt.Sprintf("%s: permission %q is not granted to any role", key, policy.Permission()))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("routes without a valid access policy:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/scim"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+const scimContentType = "application/scim+json"
+
+// SCIMHandler serves the SCIM 2.0 provisioning endpoint under /scim/v2
+type SCIMHandler struct {
+	service *scim.Service
+}
+
+func NewSCIMHandler(service *scim.Service) *SCIMHandler {
+	return &SCIMHandler{service: service}
+}
+
+// ServiceProviderConfig describes the SCIM features this server supports
+func (h *SCIMHandler) ServiceProviderConfig(c *fiber.Ctx) error {
+	return scimJSON(c, fiber.StatusOK, fiber.Map{
+		"schemas":        []string{schema.SCIMServiceConfigSchema},
+		"patch":          fiber.Map{"supported": true},
+		"bulk":           fiber.Map{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         fiber.Map{"supported": true, "maxResults": 200},
+		"changePassword": fiber.Map{"supported": false},
+		"sort":           fiber.Map{"supported": false},
+		"etag":           fiber.Map{"supported": false},
+		"authenticationSchemes": []fiber.Map{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "A token configured under scim.tokens",
+			"primary":     true,
+		}},
+	})
+}
+
+// ResourceTypes lists the resources this server provisions
+func (h *SCIMHandler) ResourceTypes(c *fiber.Ctx) error {
+	return scimJSON(c, fiber.StatusOK, schema.SCIMListResponse{
+		Schemas:      []string{schema.SCIMListResponseSchema},
+		TotalResults: 2,
+		StartIndex:   1,
+		ItemsPerPage: 2,
+		Resources: []fiber.Map{
+			{
+				"schemas":          []string{schema.SCIMResourceTypeSchema},
+				"id":               "User",
+				"name":             "User",
+				"endpoint":         "/Users",
+				"schema":           schema.SCIMUserSchema,
+				"schemaExtensions": []fiber.Map{{"schema": schema.SCIMEnterpriseUserSchema, "required": false}},
+			},
+			{
+				"schemas":  []string{schema.SCIMResourceTypeSchema},
+				"id":       "Group",
+				"name":     "Group",
+				"endpoint": "/Groups",
+				"schema":   schema.SCIMGroupSchema,
+			},
+		},
+	})
+}
+
+// ListUsers handles GET /scim/v2/Users
+func (h *SCIMHandler) ListUsers(c *fiber.Ctx) error {
+	page := scimPage(c)
+	users, total, err := h.service.ListUsers(c.Context(), c.Query("filter"), page)
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return scimJSON(c, fiber.StatusOK, scimList(users, len(users), total, page))
+}
+
+// GetUser handles GET /scim/v2/Users/:id
+func (h *SCIMHandler) GetUser(c *fiber.Ctx) error {
+	user, err := h.service.GetUser(c.Context(), c.Params("id"))
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return scimJSON(c, fiber.StatusOK, user)
+}
+
+// CreateUser handles POST /scim/v2/Users
+func (h *SCIMHandler) CreateUser(c *fiber.Ctx) error {
+	var resource schema.SCIMUser
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return scimErrorResponse(c, scim.ErrInvalidSyntax)
+	}
+
+	user, err := h.service.CreateUser(c.Context(), resource)
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	if user.Meta != nil && user.Meta.Location != "" {
+		c.Set(fiber.HeaderLocation, user.Meta.Location)
+	}
+	return scimJSON(c, fiber.StatusCreated, user)
+}
+
+// ReplaceUser handles PUT /scim/v2/Users/:id
+func (h *SCIMHandler) ReplaceUser(c *fiber.Ctx) error {
+	var resource schema.SCIMUser
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return scimErrorResponse(c, scim.ErrInvalidSyntax)
+	}
+
+	user, err := h.service.ReplaceUser(c.Context(), c.Params("id"), resource)
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return scimJSON(c, fiber.StatusOK, user)
+}
+
+// PatchUser handles PATCH /scim/v2/Users/:id, including deactivation
+func (h *SCIMHandler) PatchUser(c *fiber.Ctx) error {
+	var request schema.SCIMPatchRequest
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		return scimErrorResponse(c, scim.ErrInvalidSyntax)
+	}
+
+	user, err := h.service.PatchUser(c.Context(), c.Params("id"), request)
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return scimJSON(c, fiber.StatusOK, user)
+}
+
+// DeleteUser handles DELETE /scim/v2/Users/:id
+func (h *SCIMHandler) DeleteUser(c *fiber.Ctx) error {
+	if err := h.service.DeleteUser(c.Context(), c.Params("id")); err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListGroups handles GET /scim/v2/Groups
+func (h *SCIMHandler) ListGroups(c *fiber.Ctx) error {
+	page := scimPage(c)
+	groups, total, err := h.service.ListGroups(c.Context(), c.Query("filter"), page, scimWantsMembers(c))
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return scimJSON(c, fiber.StatusOK, scimList(groups, len(groups), total, page))
+}
+
+// GetGroup handles GET /scim/v2/Groups/:id
+func (h *SCIMHandler) GetGroup(c *fiber.Ctx) error {
+	group, err := h.service.GetGroup(c.Context(), c.Params("id"), scimWantsMembers(c))
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return scimJSON(c, fiber.StatusOK, group)
+}
+
+// CreateGroup handles POST /scim/v2/Groups
+func (h *SCIMHandler) CreateGroup(c *fiber.Ctx) error {
+	var resource schema.SCIMGroup
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return scimErrorResponse(c, scim.ErrInvalidSyntax)
+	}
+
+	group, err := h.service.CreateGroup(c.Context(), resource)
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	if group.Meta != nil && group.Meta.Location != "" {
+		c.Set(fiber.HeaderLocation, group.Meta.Location)
+	}
+	return scimJSON(c, fiber.StatusCreated, group)
+}
+
+// ReplaceGroup handles PUT /scim/v2/Groups/:id
+func (h *SCIMHandler) ReplaceGroup(c *fiber.Ctx) error {
+	var resource schema.SCIMGroup
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return scimErrorResponse(c, scim.ErrInvalidSyntax)
+	}
+
+	group, err := h.service.ReplaceGroup(c.Context(), c.Params("id"), resource)
+	if err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return scimJSON(c, fiber.StatusOK, group)
+}
+
+// PatchGroup handles PATCH /scim/v2/Groups/:id
+func (h *SCIMHandler) PatchGroup(c *fiber.Ctx) error {
+	var request schema.SCIMPatchRequest
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		return scimErrorResponse(c, scim.ErrInvalidSyntax)
+	}
+
+	if err := h.service.PatchGroup(c.Context(), c.Params("id"), request); err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// DeleteGroup handles DELETE /scim/v2/Groups/:id
+func (h *SCIMHandler) DeleteGroup(c *fiber.Ctx) error {
+	if err := h.service.DeleteGroup(c.Context(), c.Params("id")); err != nil {
+		return scimErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func scimJSON(c *fiber.Ctx, status int, body interface{}) error {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, scimContentType)
+	return c.Status(status).Send(encoded)
+}
+
+func scimPage(c *fiber.Ctx) scim.Page {
+	page := scim.DefaultPage()
+	page.StartIndex = c.QueryInt("startIndex", page.StartIndex)
+	page.Count = c.QueryInt("count", page.Count)
+	return page
+}
+
+func scimList(resources interface{}, count int, total int64, page scim.Page) schema.SCIMListResponse {
+	startIndex := page.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	return schema.SCIMListResponse{
+		Schemas:      []string{schema.SCIMListResponseSchema},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: count,
+		Resources:    resources,
+	}
+}
+
+// scimWantsMembers honours excludedAttributes=members, which directories
+// send to avoid loading large groups
+func scimWantsMembers(c *fiber.Ctx) bool {
+	for _, attribute := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			return false
+		}
+	}
+	return true
+}
+
+// scimErrorResponse maps service errors onto SCIM error responses
+func scimErrorResponse(c *fiber.Ctx, err error) error {
+	status, scimType := fiber.StatusInternalServerError, ""
+	switch {
+	case errors.Is(err, scim.ErrNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, scim.ErrUniqueness):
+		status, scimType = fiber.StatusConflict, "uniqueness"
+	case errors.Is(err, scim.ErrInvalidFilter):
+		status, scimType = fiber.StatusBadRequest, "invalidFilter"
+	case errors.Is(err, scim.ErrInvalidValue):
+		status, scimType = fiber.StatusBadRequest, "invalidValue"
+	case errors.Is(err, scim.ErrInvalidSyntax):
+		status, scimType = fiber.StatusBadRequest, "invalidSyntax"
+	case errors.Is(err, scim.ErrMutability):
+		status, scimType = fiber.StatusBadRequest, "mutability"
+	}
+
+	detail := err.Error()
+	if status == fiber.StatusInternalServerError {
+		utils.LogError("SCIM request failed", map[string]interface{}{
+			"method": c.Method(),
+			"path":   c.Path(),
+			"error":  err.Error(),
+		})
+		detail = "Internal server error"
+	}
+
+	return scimJSON(c, status, schema.SCIMError{
+		Schemas:  []string{schema.SCIMErrorSchema},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	})
+}
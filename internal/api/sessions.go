@@ -0,0 +1,177 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/user"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// ListMySessions returns the authenticated user's active sessions
+func (app *UserHandler) ListMySessions(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to list own sessions", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	sessions, err := app.sessionService.ListActiveSessions(userID)
+	if err != nil {
+		utils.LogError("Failed to list sessions", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch sessions"})
+	}
+
+	currentSessionID, _ := c.Locals("sessionID").(string)
+	return c.JSON(toSessionResponses(sessions, currentSessionID))
+}
+
+// RevokeMySession signs out a single device belonging to the authenticated user
+func (app *UserHandler) RevokeMySession(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to revoke own session", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	sessionID, err := uuid.Parse(c.Params("sessionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid session ID format"})
+	}
+
+	if err := app.sessionService.RevokeUserSession(userID, sessionID); err != nil {
+		if errors.Is(err, user.ErrSessionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
+		}
+		utils.LogError("Failed to revoke session", map[string]interface{}{
+			"userID":    userID,
+			"sessionID": sessionID,
+			"error":     err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke session"})
+	}
+
+	if currentSessionID, _ := c.Locals("sessionID").(string); currentSessionID == sessionID.String() {
+		clearAuthCookies(c)
+	}
+
+	utils.LogInfo("Successfully revoked session", map[string]interface{}{
+		"userID":    userID,
+		"sessionID": sessionID,
+	})
+	return c.JSON(fiber.Map{"message": "Session revoked successfully"})
+}
+
+// RevokeAllMySessions signs the authenticated user out on every device, including this one
+func (app *UserHandler) RevokeAllMySessions(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to sign out everywhere", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	userID, err := currentUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	if err := app.sessionService.RevokeUserSessions(userID); err != nil {
+		utils.LogError("Failed to revoke all sessions", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke sessions"})
+	}
+
+	clearAuthCookies(c)
+
+	utils.LogInfo("Successfully signed user out everywhere", map[string]interface{}{
+		"userID": userID,
+	})
+	return c.JSON(fiber.Map{"message": "Signed out of all devices"})
+}
+
+// ListUserSessions lets an admin inspect the active sessions of any user
+func (app *UserHandler) ListUserSessions(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to list user sessions", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID format"})
+	}
+
+	sessions, err := app.sessionService.ListActiveSessions(userID)
+	if err != nil {
+		utils.LogError("Failed to list user sessions", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch sessions"})
+	}
+
+	return c.JSON(toSessionResponses(sessions, ""))
+}
+
+// RevokeUserSessions lets an admin sign a user out of every device
+func (app *UserHandler) RevokeUserSessions(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to revoke all sessions of a user", map[string]interface{}{
+		"path": c.Path(),
+	})
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID format"})
+	}
+
+	if err := app.sessionService.RevokeUserSessions(userID); err != nil {
+		utils.LogError("Failed to revoke user sessions", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke sessions"})
+	}
+
+	utils.LogInfo("Successfully revoked all sessions of user", map[string]interface{}{
+		"userID":    userID,
+		"revokedBy": c.Locals("userID"),
+	})
+	return c.JSON(fiber.Map{"message": "All sessions revoked successfully"})
+}
+
+// currentUserID reads the authenticated user's ID set by AuthMiddleware
+func currentUserID(c *fiber.Ctx) (uuid.UUID, error) {
+	userIDStr, ok := c.Locals("userID").(string)
+	if !ok {
+		return uuid.Nil, errors.New("user ID missing from context")
+	}
+	return uuid.Parse(userIDStr)
+}
+
+func toSessionResponses(sessions []models.UserSession, currentSessionID string) []schema.SessionResponse {
+	responses := make([]schema.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		userAgent, _ := session.DeviceInfo["user_agent"].(string)
+		responses = append(responses, schema.SessionResponse{
+			ID:         session.ID.String(),
+			UserAgent:  userAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID.String() == currentSessionID,
+		})
+	}
+	return responses
+}
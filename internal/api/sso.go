@@ -0,0 +1,161 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/user"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"github.com/hopkali04/health-sys/internal/validation"
+)
+
+// ssoStateCookie binds a sign-in to the browser that started it, so an
+// attacker cannot complete their own sign-in in someone else's browser
+const ssoStateCookie = "sso-state"
+
+// ListSSOProviders returns the identity providers users can sign in with
+func (app *UserHandler) ListSSOProviders(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"providers": app.ssoService.Providers()})
+}
+
+// StartSSO begins a sign-in at the provider named in the path
+func (app *UserHandler) StartSSO(c *fiber.Ctx) error {
+	return app.startSSO(c, c.Params("provider"))
+}
+
+// StartGoogleSSO begins a Google sign-in. Accounts that do not exist yet are
+// provisioned when the Google provider allows it.
+func (app *UserHandler) StartGoogleSSO(c *fiber.Ctx) error {
+	return app.startSSO(c, "google")
+}
+
+func (app *UserHandler) startSSO(c *fiber.Ctx, provider string) error {
+	utils.LogInfo("Processing SSO sign-in request", map[string]interface{}{
+		"path":     c.Path(),
+		"provider": provider,
+	})
+
+	login, err := app.ssoService.Begin(c.Context(), provider)
+	if err != nil {
+		if errors.Is(err, user.ErrUnknownSSOProvider) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Unknown sign-in provider"})
+		}
+		utils.LogError("Failed to start SSO sign-in", map[string]interface{}{
+			"provider": provider,
+			"error":    err.Error(),
+		})
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "The sign-in provider is unavailable"})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     ssoStateCookie,
+		Value:    login.State,
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Lax",
+		Expires:  login.ExpiresAt,
+		Path:     "/api/auth",
+	})
+
+	return c.JSON(schema.SSOAuthorizeResponse{
+		AuthorizationURL: login.AuthorizationURL,
+		State:            login.State,
+		ExpiresAt:        login.ExpiresAt,
+	})
+}
+
+// CompleteSSO exchanges the authorization code the provider returned for a
+// session, or for an MFA challenge when a second factor is required
+func (app *UserHandler) CompleteSSO(c *fiber.Ctx) error {
+	provider := c.Params("provider")
+	utils.LogInfo("Processing SSO callback", map[string]interface{}{
+		"path":     c.Path(),
+		"provider": provider,
+	})
+
+	var req schema.SSOCallbackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validation.ParseAndValidate(c, &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request data", "details": err.Error()})
+	}
+
+	cookieState := c.Cookies(ssoStateCookie)
+	clearSSOStateCookie(c)
+	if subtle.ConstantTimeCompare([]byte(cookieState), []byte(req.State)) != 1 {
+		utils.LogWarn("SSO callback state does not match the browser", map[string]interface{}{
+			"provider": provider,
+			"ip":       c.IP(),
+		})
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Sign-in expired, please try again"})
+	}
+
+	if err := app.userService.CheckLoginAllowed(c.IP()); err != nil {
+		return loginErrorResponse(c, err)
+	}
+
+	u, err := app.ssoService.Complete(c.Context(), provider, req.State, req.Code, c.IP())
+	if err != nil {
+		return app.ssoErrorResponse(c, provider, err)
+	}
+
+	role, err := app.userService.GetUserRole(u.ID)
+	if err != nil {
+		utils.LogError("Failed to fetch user role", map[string]interface{}{
+			"userID": u.ID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Employee Account Not Found, Contact System Support"})
+	}
+
+	if u.MFAEnabled || app.mfaService.RoleRequiresMFA(role) {
+		return app.startMFAChallenge(c, u)
+	}
+
+	return app.completeLogin(c, u, role, fiber.Map{"provider": provider})
+}
+
+func (app *UserHandler) ssoErrorResponse(c *fiber.Ctx, provider string, err error) error {
+	utils.LogError("SSO sign-in failed", map[string]interface{}{
+		"provider": provider,
+		"ip":       c.IP(),
+		"error":    err.Error(),
+	})
+
+	switch {
+	case errors.Is(err, user.ErrUnknownSSOProvider):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Unknown sign-in provider"})
+	case errors.Is(err, user.ErrInvalidSSOState):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Sign-in expired, please try again"})
+	case errors.Is(err, user.ErrSSOEmailNotVerified):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Your email address has not been verified by the sign-in provider"})
+	case errors.Is(err, user.ErrSSODomainNotAllowed):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Your email domain cannot sign in with this provider"})
+	case errors.Is(err, user.ErrSSONoAccount):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "No account exists for this email, contact your administrator"})
+	case errors.Is(err, user.ErrSSOIdentityConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "This account is linked to a different identity at the sign-in provider"})
+	case errors.Is(err, user.ErrAccountInactive):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Account is inactive"})
+	case errors.Is(err, user.ErrAccountLocked):
+		return accountLockedResponse(c, err)
+	default:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Sign-in failed"})
+	}
+}
+
+func clearSSOStateCookie(c *fiber.Ctx) {
+	c.Cookie(&fiber.Cookie{
+		Name:     ssoStateCookie,
+		Value:    "",
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Lax",
+		Expires:  time.Now().Add(-24 * time.Hour),
+		Path:     "/api/auth",
+	})
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"mime/multipart"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// employeeLookup is implemented by the services that record who uploaded a
+// file
+type employeeLookup interface {
+	GetEmployeeByUserID(userID uuid.UUID) (*models.Employee, error)
+}
+
+// currentEmployee finds the employee record of the signed-in user
+func currentEmployee(c *fiber.Ctx, lookup employeeLookup) (*models.Employee, error) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return nil, err
+	}
+	return lookup.GetEmployeeByUserID(userID)
+}
+
+// formFiles returns the files uploaded in a multipart form field
+func formFiles(c *fiber.Ctx, field string) ([]*multipart.FileHeader, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, err
+	}
+	files := form.File[field]
+	if len(files) == 0 {
+		return nil, errors.New("no files uploaded")
+	}
+	return files, nil
+}
+
+// uploadErrorResponse answers a failed upload: files that failed the upload
+// checks are the client's fault, anything else is a server error
+func uploadErrorResponse(c *fiber.Ctx, err error, message string) error {
+	if status, rejected := uploadRejectionStatus(err); rejected {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	utils.LogError(message, map[string]interface{}{
+		"path":  c.Path(),
+		"error": err.Error(),
+	})
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": message})
+}
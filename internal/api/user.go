@@ -1,11 +1,12 @@
 package api
 
 import (
+	"errors"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
 	"github.com/hopkali04/health-sys/internal/services/user"
 	"github.com/hopkali04/health-sys/internal/utils"
@@ -15,12 +16,18 @@ import (
 type UserHandler struct {
 	userService         *user.UserService
 	verificationService *user.VerificationService
+	sessionService      *user.SessionService
+	mfaService          *user.MFAService
+	ssoService          *user.SSOService
 }
 
-func NewUserHandler(userService *user.UserService, vc *user.VerificationService) *UserHandler {
+func NewUserHandler(userService *user.UserService, vc *user.VerificationService, ss *user.SessionService, mfa *user.MFAService, sso *user.SSOService) *UserHandler {
 	return &UserHandler{
 		userService:         userService,
 		verificationService: vc,
+		sessionService:      ss,
+		mfaService:          mfa,
+		ssoService:          sso,
 	}
 }
 
@@ -194,15 +201,7 @@ func (app *UserHandler) CompletePasswordReset(c *fiber.Ctx) error {
 		"token": req.Token,
 	})
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "auth-token",
-		Value:    "",
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Lax",
-		Expires:  time.Now().Add(-24 * time.Hour),
-		Path:     "/",
-	})
+	clearAuthCookies(c)
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Password has been reset successfully. Please log in with your new password."})
 }
@@ -263,7 +262,7 @@ func (app *UserHandler) RegisterUser(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Email Already In Use By Another User"})
 	}
 
-	if err := app.userService.CreateUser(&user); err != nil {
+	if err := app.userService.CreateUser(ctx.Context(), &user); err != nil {
 		utils.LogError("Failed to create user", map[string]interface{}{
 			"email": user.Email,
 			"error": err.Error(),
@@ -281,9 +280,9 @@ func (app *UserHandler) RegisterUserWithEmployeeAcc(ctx *fiber.Ctx) error {
 		"path": ctx.Path(),
 	})
 
-	var user schema.CreateUserWithEmployeeRequest
+	var request schema.CreateUserWithEmployeeRequest
 
-	err := ctx.BodyParser(&user)
+	err := ctx.BodyParser(&request)
 	if err != nil {
 		utils.LogError("Failed to parse request body", map[string]interface{}{
 			"error": err.Error(),
@@ -291,31 +290,34 @@ func (app *UserHandler) RegisterUserWithEmployeeAcc(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	emailExists, err := app.userService.CheckEmailExists(user.Email)
+	emailExists, err := app.userService.CheckEmailExists(request.Email)
 	if err != nil {
 		utils.LogError("Failed to check if email exists", map[string]interface{}{
-			"email": user.Email,
+			"email": request.Email,
 			"error": err.Error(),
 		})
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 	if emailExists {
 		utils.LogError("Email already in use", map[string]interface{}{
-			"email": user.Email,
+			"email": request.Email,
 		})
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Email Already In Use By Another User"})
 	}
 
-	if err := app.userService.CreateUserWithEmployee(&user); err != nil {
+	if err := app.userService.CreateUserWithEmployee(ctx.Context(), &request); err != nil {
+		if errors.Is(err, user.ErrInvalidRole) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		utils.LogError("Failed to create user with employee account", map[string]interface{}{
-			"email": user.Email,
+			"email": request.Email,
 			"error": err.Error(),
 		})
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	utils.LogInfo("Successfully registered user with employee account", map[string]interface{}{
-		"email": user.Email,
+		"email": request.Email,
 	})
 	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "User Created Successfully!"})
 }
@@ -359,7 +361,7 @@ func (app *UserHandler) BulkRegisterUsers(ctx *fiber.Ctx) error {
 		}
 	}
 
-	if err := app.userService.BulkCreateUsers(users); err != nil {
+	if err := app.userService.BulkCreateUsers(ctx.Context(), users); err != nil {
 		utils.LogError("Failed to bulk create users", map[string]interface{}{
 			"error": err.Error(),
 		})
@@ -403,7 +405,10 @@ func (app *UserHandler) BulkRegisterUsersWithEmployeeAcc(ctx *fiber.Ctx) error {
 		}
 	}
 
-	if err := app.userService.BulkCreateUsersWithEmployees(users); err != nil {
+	if err := app.userService.BulkCreateUsersWithEmployees(ctx.Context(), users); err != nil {
+		if errors.Is(err, user.ErrInvalidRole) {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		utils.LogError("Failed to bulk create users with employee accounts", map[string]interface{}{
 			"error": err.Error(),
 		})
@@ -431,6 +436,11 @@ func (app *UserHandler) LoginUser(c *fiber.Ctx) error {
 	if requestData.Email == "" && requestData.Phone == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Email or phone number is required"})
 	}
+	requestData.IPAddress = c.IP()
+
+	if err := app.userService.CheckLoginAllowed(requestData.IPAddress); err != nil {
+		return loginErrorResponse(c, err)
+	}
 
 	if requestData.Phone != "" {
 		user, err := app.userService.GetUserByPhone(requestData.Phone)
@@ -439,6 +449,7 @@ func (app *UserHandler) LoginUser(c *fiber.Ctx) error {
 				"phone": requestData.Phone,
 				"error": err.Error(),
 			})
+			app.userService.RecordUnknownAccountLogin(requestData.Phone, requestData.IPAddress)
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Phone Number Not Found"})
 
 		}
@@ -451,6 +462,7 @@ func (app *UserHandler) LoginUser(c *fiber.Ctx) error {
 				"email": requestData.Email,
 				"error": err.Error(),
 			})
+			app.userService.RecordUnknownAccountLogin(requestData.Email, requestData.IPAddress)
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid Email"})
 		}
 
@@ -483,7 +495,7 @@ func (app *UserHandler) LoginUser(c *fiber.Ctx) error {
 			"email": requestData.Email,
 			"error": err.Error(),
 		})
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+		return loginErrorResponse(c, err)
 	}
 
 	role, err := app.userService.GetUserRole(user.ID)
@@ -495,55 +507,132 @@ func (app *UserHandler) LoginUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Employee Account Not Found, Contact System Support"})
 	}
 
-	response := schema.UserResponse{
-		Email:             user.Email,
-		ID:                user.ID.String(),
-		LastLoginAt:       user.LastLoginAt,
-		PasswordChangedAt: user.PasswordChangedAt,
-		CreatedAt:         user.CreatedAt,
-		UpdatedAt:         user.UpdatedAt,
+	if user.MFAEnabled || app.mfaService.RoleRequiresMFA(role) {
+		return app.startMFAChallenge(c, user)
+	}
+
+	return app.completeLogin(c, user, role, nil)
+}
+
+// loginErrorResponse maps lockout errors to their own status codes so
+// clients can tell a lock apart from a wrong password
+func loginErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, user.ErrAccountLocked):
+		return accountLockedResponse(c, err)
+	case errors.Is(err, user.ErrTooManyAttempts):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "Too many failed sign-in attempts, please try again later"})
+	default:
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid credentials"})
+	}
+}
+
+func accountLockedResponse(c *fiber.Ctx, err error) error {
+	body := fiber.Map{"error": "Account is locked"}
+	var lockErr *user.AccountLockedError
+	if errors.As(err, &lockErr) && !lockErr.Until.IsZero() {
+		body["lockedUntil"] = lockErr.Until
 	}
+	return c.Status(fiber.StatusLocked).JSON(body)
+}
 
-	expirationTime := time.Now().Add(12 * time.Hour)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userID": response.ID,
-		"role":   role,
-		"exp":    expirationTime.Unix(),
+// UnlockUser lets an admin lift an account lock before it expires
+func (app *UserHandler) UnlockUser(c *fiber.Ctx) error {
+	utils.LogInfo("Processing request to unlock user", map[string]interface{}{
+		"path": c.Path(),
 	})
 
-	tokenString, err := token.SignedString([]byte("your-secret-key"))
+	userID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
-		utils.LogError("Failed to generate JWT token", map[string]interface{}{
-			"userID": response.ID,
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID format"})
+	}
+
+	if err := app.userService.UnlockUser(userID); err != nil {
+		utils.LogError("Failed to unlock user", map[string]interface{}{
+			"userID": userID,
 			"error":  err.Error(),
 		})
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "auth-token",
-		Value:    tokenString,
-		HTTPOnly: true,
-		Secure:   true,
-		SameSite: "Lax",
-		Expires:  expirationTime,
-		Path:     "/",
+	utils.LogInfo("Successfully unlocked user", map[string]interface{}{
+		"userID":     userID,
+		"unlockedBy": c.Locals("userID"),
 	})
+	return c.JSON(fiber.Map{"message": "Account unlocked successfully"})
+}
+
+// completeLogin opens a session for a fully authenticated user and responds
+// with its tokens. Any extra fields are merged into the response body.
+func (app *UserHandler) completeLogin(c *fiber.Ctx, user *models.User, role string, extra fiber.Map) error {
+	tokens, err := app.sessionService.CreateSession(user.ID, role, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		utils.LogError("Failed to create user session", map[string]interface{}{
+			"userID": user.ID,
+			"error":  err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
+	}
+
+	setAuthCookies(c, tokens)
 
 	utils.LogInfo("User logged in successfully", map[string]interface{}{
-		"userID": response.ID,
-		"email":  response.Email,
-		"role":   role,
+		"userID":    user.ID,
+		"email":     user.Email,
+		"role":      role,
+		"sessionID": tokens.SessionID,
 	})
-	return c.JSON(fiber.Map{
+
+	response := fiber.Map{
 		"user": fiber.Map{
-			"id":    response.ID,
-			"email": response.Email,
+			"id":    user.ID.String(),
+			"email": user.Email,
 			"role":  role,
 		},
-		"token":     tokenString,
-		"expiresAt": expirationTime,
+		"token":            tokens.AccessToken,
+		"expiresAt":        tokens.AccessTokenExpiresAt,
+		"refreshToken":     tokens.RefreshToken,
+		"refreshExpiresAt": tokens.RefreshTokenExpiresAt,
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+	return c.JSON(response)
+}
+
+// RefreshToken exchanges a refresh token for a new access token and rotates the refresh token
+func (app *UserHandler) RefreshToken(c *fiber.Ctx) error {
+	utils.LogInfo("Processing token refresh request", map[string]interface{}{
+		"path": c.Path(),
 	})
+
+	refreshToken := refreshTokenFromRequest(c)
+	if refreshToken == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Refresh token is required"})
+	}
+
+	tokens, err := app.sessionService.RefreshSession(refreshToken, c.IP())
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidRefreshToken) || errors.Is(err, user.ErrSessionExpired) {
+			utils.LogWarn("Rejected refresh token", map[string]interface{}{
+				"ip":    c.IP(),
+				"error": err.Error(),
+			})
+			clearAuthCookies(c)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Session expired, please log in again"})
+		}
+		utils.LogError("Failed to refresh session", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to refresh token"})
+	}
+
+	setAuthCookies(c, tokens)
+
+	utils.LogInfo("Successfully refreshed session", map[string]interface{}{
+		"sessionID": tokens.SessionID,
+	})
+	return c.JSON(tokens)
 }
 
 func (app *UserHandler) LogoutUser(c *fiber.Ctx) error {
@@ -551,6 +640,52 @@ func (app *UserHandler) LogoutUser(c *fiber.Ctx) error {
 		"path": c.Path(),
 	})
 
+	if refreshToken := refreshTokenFromRequest(c); refreshToken != "" {
+		if err := app.sessionService.RevokeSession(refreshToken); err != nil {
+			utils.LogError("Failed to revoke session on logout", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to log out"})
+		}
+	}
+
+	clearAuthCookies(c)
+
+	utils.LogInfo("User logged out successfully", map[string]interface{}{})
+	return c.JSON(fiber.Map{"message": "Logged out successfully"})
+}
+
+// refreshTokenFromRequest reads the refresh token from the request body, falling back to the cookie
+func refreshTokenFromRequest(c *fiber.Ctx) string {
+	var req schema.RefreshTokenRequest
+	if err := c.BodyParser(&req); err == nil && req.RefreshToken != "" {
+		return req.RefreshToken
+	}
+	return c.Cookies("refresh-token")
+}
+
+func setAuthCookies(c *fiber.Ctx, tokens *schema.AuthTokens) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "auth-token",
+		Value:    tokens.AccessToken,
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Lax",
+		Expires:  tokens.AccessTokenExpiresAt,
+		Path:     "/",
+	})
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh-token",
+		Value:    tokens.RefreshToken,
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Strict",
+		Expires:  tokens.RefreshTokenExpiresAt,
+		Path:     "/api/auth",
+	})
+}
+
+func clearAuthCookies(c *fiber.Ctx) {
 	c.Cookie(&fiber.Cookie{
 		Name:     "auth-token",
 		Value:    "",
@@ -560,9 +695,15 @@ func (app *UserHandler) LogoutUser(c *fiber.Ctx) error {
 		Expires:  time.Now().Add(-24 * time.Hour),
 		Path:     "/",
 	})
-
-	utils.LogInfo("User logged out successfully", map[string]interface{}{})
-	return c.JSON(fiber.Map{"message": "Logged out successfully"})
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh-token",
+		Value:    "",
+		HTTPOnly: true,
+		Secure:   true,
+		SameSite: "Strict",
+		Expires:  time.Now().Add(-24 * time.Hour),
+		Path:     "/api/auth",
+	})
 }
 
 func (app *UserHandler) GetUser(c *fiber.Ctx) error {
@@ -630,7 +771,7 @@ func (app *UserHandler) UpdateUserPassword(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Email Does not exist"})
 	}
 
-	err = app.userService.ChangePassword(UserID, user.ConfirmPassword)
+	err = app.userService.ChangePassword(ctx.Context(), UserID, user.ConfirmPassword)
 	if err != nil {
 		utils.LogError("Failed to change user password", map[string]interface{}{
 			"userID": UserID,
@@ -654,7 +795,7 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userID := uuid.MustParse(id)
 
-	err := h.userService.DeleteUser(userID)
+	err := h.userService.DeleteUser(c.Context(), userID)
 	if err != nil {
 		utils.LogError("Failed to delete user", map[string]interface{}{
 			"userID": userID,
@@ -705,7 +846,7 @@ func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
 			"role":   req.Role,
 			"error":  err.Error(),
 		})
-		if err.Error() == "invalid role: "+req.Role || err.Error() == "employee not found for user ID: "+userID.String() {
+		if errors.Is(err, user.ErrInvalidRole) || err.Error() == "employee not found for user ID: "+userID.String() {
 			statusCode = fiber.StatusBadRequest
 		}
 		return c.Status(statusCode).JSON(fiber.Map{"error": err.Error()})
@@ -72,7 +72,7 @@ func (h *VPCHandler) CreateVPC(c *fiber.Ctx) error {
 	req.CreatedBy = employee.ID // Set the creator ID in the request
 
 	vpc := req.ToModel()
-	err = h.service.Create(&vpc)
+	err = h.service.Create(c.Context(), &vpc)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(schema.NewErrorResponse("Failed to create VPC: " + err.Error()))
 	}
@@ -106,7 +106,7 @@ func (h *VPCHandler) CreateBulkVPCs(c *fiber.Ctx) error {
 	}
 
 	vpcs := req.ToModel()
-	err := h.service.CreateBulk(vpcs)
+	err := h.service.CreateBulk(c.Context(), vpcs)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(schema.NewErrorResponse("Failed to create VPCs: " + err.Error()))
 	}
@@ -233,12 +233,15 @@ func (h *VPCHandler) CreateVPCWithAttachments(c *fiber.Ctx) error {
 	}
 
 	// Call the service to create VPC and handle attachments
-	vpc, err := h.service.CreateVPCWithAttachments(req, uploadedFiles, creatorEmployeeID)
+	vpc, err := h.service.CreateVPCWithAttachments(c.Context(), req, uploadedFiles, creatorEmployeeID)
 	if err != nil {
 		utils.LogError("Service failed to create VPC with attachments", map[string]interface{}{
 			"creatorEmployeeID": creatorEmployeeID,
 			"error":             err.Error(),
 		})
+		if status, rejected := uploadRejectionStatus(err); rejected {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create VPC", "details": err.Error()})
 	}
 
@@ -342,7 +345,7 @@ func (h *VPCHandler) UpdateVPC(c *fiber.Ctx) error {
 	vpc := req.ToModel()
 	vpc.ID = id
 
-	err = h.service.Update(&vpc)
+	err = h.service.Update(c.Context(), &vpc)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(schema.NewErrorResponse("Failed to update VPC: " + err.Error()))
 	}
@@ -357,7 +360,7 @@ func (h *VPCHandler) DeleteVPC(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(schema.NewErrorResponse("VPC ID is required"))
 	}
 
-	err := h.service.Delete(id)
+	err := h.service.Delete(c.Context(), id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(schema.NewErrorResponse("Failed to delete VPC: " + err.Error()))
 	}
@@ -0,0 +1,344 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	ActionInsert = "INSERT"
+	ActionUpdate = "UPDATE"
+	ActionDelete = "DELETE"
+
+	beforeSnapshotKey = "audit:before"
+)
+
+// AuditedModels are the entities whose changes are written to the audit log
+var AuditedModels = []interface{}{
+	&models.Incident{},
+	&models.Investigation{},
+	&models.CorrectiveAction{},
+	&models.Hazard{},
+	&models.VPC{},
+	&models.Employee{},
+	&models.User{},
+	&models.Role{},
+}
+
+// redactedColumns never leave the database in an audit snapshot
+var redactedColumns = map[string]bool{
+	"password_hash":      true,
+	"mfa_secret":         true,
+	"verification_token": true,
+	"reset_token":        true,
+}
+
+// ignoredColumns change as a side effect of signing in. An update that only
+// touches these is bookkeeping, not an edit, and is not audited.
+var ignoredColumns = map[string]bool{
+	"updated_at":            true,
+	"last_login_at":         true,
+	"failed_login_attempts": true,
+	"mfa_last_used_counter": true,
+}
+
+// RegisterCallbacks hooks the audit log into every create, update and delete
+// of the audited models. The rows are written in the same transaction as the
+// change, so a change is never committed without its audit record.
+func RegisterCallbacks(db *gorm.DB) error {
+	tables := make(map[string]bool, len(AuditedModels))
+	for _, model := range AuditedModels {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return fmt.Errorf("failed to parse audited model %T: %w", model, err)
+		}
+		tables[stmt.Schema.Table] = true
+	}
+
+	r := &recorder{tables: tables}
+
+	if err := db.Callback().Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_create", r.afterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:begin_transaction").Before("gorm:update").
+		Register("audit:before_update", r.before); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_update", r.afterUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:begin_transaction").Before("gorm:delete").
+		Register("audit:before_delete", r.before); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_delete", r.afterDelete)
+}
+
+type recorder struct {
+	tables map[string]bool
+}
+
+func (r *recorder) audited(db *gorm.DB) bool {
+	return db.Error == nil && db.Statement.Schema != nil && r.tables[db.Statement.Schema.Table]
+}
+
+func (r *recorder) afterCreate(db *gorm.DB) {
+	if !r.audited(db) || db.RowsAffected == 0 {
+		return
+	}
+
+	ids := primaryKeys(db.Statement, db.Statement.ReflectValue)
+	if len(ids) == 0 {
+		return
+	}
+
+	rows, err := snapshot(db, []clause.Expression{clause.IN{Column: primaryColumn(db.Statement), Values: ids}})
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	logs := make([]models.AuditLog, 0, len(rows))
+	for _, row := range rows {
+		logs = append(logs, newLog(db, ActionInsert, row, nil, row))
+	}
+	writeLogs(db, logs)
+}
+
+// before captures the rows an update or delete is about to touch
+func (r *recorder) before(db *gorm.DB) {
+	if !r.audited(db) {
+		return
+	}
+
+	var conds []clause.Expression
+	if where, ok := db.Statement.Clauses["WHERE"].Expression.(clause.Where); ok {
+		conds = append(conds, where.Exprs...)
+	}
+
+	ids := primaryKeys(db.Statement, reflect.ValueOf(db.Statement.Model))
+	if len(ids) == 0 {
+		ids = primaryKeys(db.Statement, db.Statement.ReflectValue)
+	}
+	if len(ids) > 0 {
+		conds = append(conds, clause.IN{Column: primaryColumn(db.Statement), Values: ids})
+	}
+
+	// Without any condition GORM refuses the statement anyway
+	if len(conds) == 0 {
+		return
+	}
+
+	rows, err := snapshot(db, conds)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(beforeSnapshotKey, rows)
+}
+
+func (r *recorder) afterUpdate(db *gorm.DB) {
+	before, ok := r.beforeRows(db)
+	if !ok {
+		return
+	}
+
+	ids := make([]interface{}, 0, len(before))
+	for _, row := range before {
+		ids = append(ids, row[primaryColumn(db.Statement).Name])
+	}
+
+	after, err := snapshot(db, []clause.Expression{clause.IN{Column: primaryColumn(db.Statement), Values: ids}})
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	afterByID := make(map[string]models.JSONB, len(after))
+	for _, row := range after {
+		afterByID[fmt.Sprint(row[primaryColumn(db.Statement).Name])] = row
+	}
+
+	logs := make([]models.AuditLog, 0, len(before))
+	for _, old := range before {
+		current, ok := afterByID[fmt.Sprint(old[primaryColumn(db.Statement).Name])]
+		if !ok || !changed(old, current) {
+			continue
+		}
+		logs = append(logs, newLog(db, ActionUpdate, old, old, current))
+	}
+	writeLogs(db, logs)
+}
+
+func (r *recorder) afterDelete(db *gorm.DB) {
+	before, ok := r.beforeRows(db)
+	if !ok {
+		return
+	}
+
+	logs := make([]models.AuditLog, 0, len(before))
+	for _, old := range before {
+		logs = append(logs, newLog(db, ActionDelete, old, old, nil))
+	}
+	writeLogs(db, logs)
+}
+
+func (r *recorder) beforeRows(db *gorm.DB) ([]models.JSONB, bool) {
+	if !r.audited(db) || db.RowsAffected == 0 {
+		return nil, false
+	}
+	value, ok := db.InstanceGet(beforeSnapshotKey)
+	if !ok {
+		return nil, false
+	}
+	rows, ok := value.([]models.JSONB)
+	return rows, ok && len(rows) > 0
+}
+
+// snapshot reads the current state of the matching rows on the statement's
+// connection, so it sees the changes of the surrounding transaction
+func snapshot(db *gorm.DB, conds []clause.Expression) ([]models.JSONB, error) {
+	stmt := db.Statement
+	var raw []map[string]interface{}
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Unscoped().
+		Model(reflect.New(stmt.Schema.ModelType).Interface()).
+		Table(stmt.Table).
+		Clauses(clause.Where{Exprs: conds}).
+		Find(&raw).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s for the audit log: %w", stmt.Table, err)
+	}
+
+	rows := make([]models.JSONB, 0, len(raw))
+	for _, values := range raw {
+		rows = append(rows, normalize(stmt.Schema, values))
+	}
+	return rows, nil
+}
+
+// normalize turns a scanned row into JSON-friendly values
+func normalize(s *schema.Schema, values map[string]interface{}) models.JSONB {
+	row := make(models.JSONB, len(values))
+	for column, value := range values {
+		if redactedColumns[column] {
+			continue
+		}
+
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+
+		if field := s.LookUpField(column); field != nil && strings.EqualFold(field.TagSettings["TYPE"], "jsonb") {
+			if text, ok := value.(string); ok && text != "" {
+				var decoded interface{}
+				if err := json.Unmarshal([]byte(text), &decoded); err == nil {
+					value = decoded
+				}
+			}
+		}
+
+		row[column] = value
+	}
+	return row
+}
+
+// changed reports whether anything other than bookkeeping columns differs
+func changed(old, current models.JSONB) bool {
+	for column, value := range current {
+		if ignoredColumns[column] {
+			continue
+		}
+		if !reflect.DeepEqual(old[column], value) {
+			return true
+		}
+	}
+	return false
+}
+
+func newLog(db *gorm.DB, action string, row, oldData, newData models.JSONB) models.AuditLog {
+	entry := models.AuditLog{
+		ID:        uuid.New(),
+		TableName: db.Statement.Schema.Table,
+		Action:    action,
+		OldData:   oldData,
+		NewData:   newData,
+	}
+
+	if id, err := uuid.Parse(fmt.Sprint(row[primaryColumn(db.Statement).Name])); err == nil {
+		entry.RecordID = id
+	}
+
+	if actor, ok := ActorFromContext(db.Statement.Context); ok {
+		if actor.UserID != uuid.Nil {
+			userID := actor.UserID
+			entry.UserID = &userID
+		}
+		if actor.IPAddress != "" {
+			ip := actor.IPAddress
+			entry.IPAddress = &ip
+		}
+	}
+
+	return entry
+}
+
+func writeLogs(db *gorm.DB, logs []models.AuditLog) {
+	if len(logs) == 0 {
+		return
+	}
+	tx := db.Session(&gorm.Session{NewDB: true})
+	if err := appendToChain(tx, logs); err != nil {
+		db.AddError(err)
+		return
+	}
+	if err := tx.Create(&logs).Error; err != nil {
+		db.AddError(fmt.Errorf("failed to write audit log: %w", err))
+	}
+}
+
+func primaryColumn(stmt *gorm.Statement) clause.Column {
+	return clause.Column{Table: stmt.Table, Name: stmt.Schema.PrioritizedPrimaryField.DBName}
+}
+
+// primaryKeys collects the non-zero primary keys held by a struct, pointer or slice
+func primaryKeys(stmt *gorm.Statement, value reflect.Value) []interface{} {
+	field := stmt.Schema.PrioritizedPrimaryField
+	if field == nil || !value.IsValid() {
+		return nil
+	}
+
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	var ids []interface{}
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.Type() != stmt.Schema.ModelType {
+			return nil
+		}
+		if id, isZero := field.ValueOf(stmt.Context, value); !isZero {
+			ids = append(ids, id)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			ids = append(ids, primaryKeys(stmt, value.Index(i))...)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,316 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"gorm.io/gorm"
+)
+
+// chainLockKey is the Postgres advisory lock that serialises appends to the
+// chain, so concurrent transactions cannot both extend the same head
+const chainLockKey int64 = 0x5afe365a0d17
+
+const verifyBatchSize = 500
+
+// ChainHead is the newest link of the audit chain. Publishing it somewhere
+// outside the database anchors all history up to that point.
+type ChainHead struct {
+	Sequence  int64     `json:"sequence"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BrokenLink describes the first row that does not follow from its predecessor
+type BrokenLink struct {
+	Sequence int64
+	ID       uuid.UUID
+	Reason   string
+}
+
+// VerifyResult is the outcome of walking the chain
+type VerifyResult struct {
+	Checked int64
+	Head    *ChainHead
+	Broken  *BrokenLink
+}
+
+// chainRow is an audit row as stored. The snapshots are read as text so
+// they hash exactly as they did when the row was written.
+type chainRow struct {
+	ID        uuid.UUID
+	Sequence  int64
+	TableName string
+	RecordID  uuid.UUID
+	Action    string
+	OldData   *string
+	NewData   *string
+	UserID    *uuid.UUID
+	IPAddress *string
+	CreatedAt time.Time
+	PrevHash  string
+	Hash      string
+}
+
+// hashedContent fixes the field order of the hashed representation
+type hashedContent struct {
+	Sequence  int64           `json:"sequence"`
+	PrevHash  string          `json:"prevHash"`
+	ID        string          `json:"id"`
+	TableName string          `json:"tableName"`
+	RecordID  string          `json:"recordId"`
+	Action    string          `json:"action"`
+	OldData   json.RawMessage `json:"oldData"`
+	NewData   json.RawMessage `json:"newData"`
+	UserID    string          `json:"userId"`
+	IPAddress string          `json:"ipAddress"`
+	CreatedAt string          `json:"createdAt"`
+}
+
+// appendToChain links logs onto the current head. It must run inside the
+// transaction that inserts them, which holds the chain lock until it ends.
+func appendToChain(tx *gorm.DB, logs []models.AuditLog) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+		return fmt.Errorf("failed to lock the audit chain: %w", err)
+	}
+
+	head, err := currentHead(tx)
+	if err != nil {
+		return err
+	}
+
+	var sequence int64
+	var prevHash string
+	if head != nil {
+		sequence, prevHash = head.Sequence, head.Hash
+	}
+
+	// Postgres stores microseconds, so hash the timestamp it will hand back
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	for i := range logs {
+		entry := &logs[i]
+		sequence++
+		entry.Sequence = sequence
+		entry.PrevHash = prevHash
+		entry.CreatedAt = now
+		if entry.IPAddress != nil {
+			if ip := net.ParseIP(*entry.IPAddress); ip != nil {
+				normalized := ip.String()
+				entry.IPAddress = &normalized
+			}
+		}
+
+		oldData, err := snapshotJSON(entry.OldData)
+		if err != nil {
+			return err
+		}
+		newData, err := snapshotJSON(entry.NewData)
+		if err != nil {
+			return err
+		}
+
+		entry.Hash, err = hashRow(chainRow{
+			ID:        entry.ID,
+			Sequence:  entry.Sequence,
+			TableName: entry.TableName,
+			RecordID:  entry.RecordID,
+			Action:    entry.Action,
+			OldData:   oldData,
+			NewData:   newData,
+			UserID:    entry.UserID,
+			IPAddress: entry.IPAddress,
+			CreatedAt: entry.CreatedAt,
+			PrevHash:  entry.PrevHash,
+		})
+		if err != nil {
+			return err
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+// Head returns the newest link of the chain, or nil while it is empty
+func Head(db *gorm.DB) (*ChainHead, error) {
+	return currentHead(db)
+}
+
+// Verify walks the chain from the first row and stops at the first row whose
+// sequence, previous hash or content hash does not check out
+func Verify(db *gorm.DB) (*VerifyResult, error) {
+	result := &VerifyResult{}
+	var lastSequence int64
+	var lastHash string
+
+	for {
+		var rows []chainRow
+		err := db.Table("audit_logs").
+			Select("id, sequence, table_name, record_id, action, old_data::text AS old_data, new_data::text AS new_data, "+
+				"user_id, host(ip_address) AS ip_address, created_at, prev_hash, hash").
+			Where("sequence > ?", lastSequence).
+			Order("sequence ASC").
+			Limit(verifyBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the audit chain: %w", err)
+		}
+
+		for _, row := range rows {
+			if broken := checkLink(row, lastSequence, lastHash); broken != nil {
+				result.Broken = broken
+				return result, nil
+			}
+			result.Checked++
+			lastSequence, lastHash = row.Sequence, row.Hash
+			result.Head = &ChainHead{Sequence: row.Sequence, Hash: row.Hash, CreatedAt: row.CreatedAt}
+		}
+
+		if len(rows) < verifyBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func checkLink(row chainRow, lastSequence int64, lastHash string) *BrokenLink {
+	link := &BrokenLink{Sequence: row.Sequence, ID: row.ID}
+
+	switch {
+	case row.Sequence != lastSequence+1:
+		link.Reason = fmt.Sprintf("expected sequence %d, found %d", lastSequence+1, row.Sequence)
+	case row.PrevHash != lastHash:
+		link.Reason = "previous hash does not match the preceding row"
+	default:
+		hash, err := hashRow(row)
+		if err != nil {
+			link.Reason = err.Error()
+		} else if hash != row.Hash {
+			link.Reason = "content does not match its hash"
+		} else {
+			return nil
+		}
+	}
+	return link
+}
+
+func currentHead(db *gorm.DB) (*ChainHead, error) {
+	var heads []ChainHead
+	err := db.Table("audit_logs").
+		Select("sequence, hash, created_at").
+		Where("sequence > 0").
+		Order("sequence DESC").
+		Limit(1).
+		Scan(&heads).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the audit chain head: %w", err)
+	}
+	if len(heads) == 0 {
+		return nil, nil
+	}
+	return &heads[0], nil
+}
+
+func hashRow(row chainRow) (string, error) {
+	oldData, err := canonicalJSON(row.OldData)
+	if err != nil {
+		return "", err
+	}
+	newData, err := canonicalJSON(row.NewData)
+	if err != nil {
+		return "", err
+	}
+
+	content := hashedContent{
+		Sequence:  row.Sequence,
+		PrevHash:  row.PrevHash,
+		ID:        row.ID.String(),
+		TableName: row.TableName,
+		RecordID:  row.RecordID.String(),
+		Action:    row.Action,
+		OldData:   oldData,
+		NewData:   newData,
+		CreatedAt: row.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if row.UserID != nil {
+		content.UserID = row.UserID.String()
+	}
+	if row.IPAddress != nil {
+		content.IPAddress = *row.IPAddress
+	}
+
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit row: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func snapshotJSON(data models.JSONB) (*string, error) {
+	if data == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	text := string(encoded)
+	return &text, nil
+}
+
+// canonicalJSON re-encodes a snapshot so that the JSON written by Go and the
+// JSON Postgres returns from a jsonb column produce the same bytes: object
+// keys sorted, no whitespace and numbers in plain decimal notation
+func canonicalJSON(text *string) (json.RawMessage, error) {
+	if text == nil {
+		return json.RawMessage("null"), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(*text)))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, errors.New("snapshot is not valid JSON")
+	}
+
+	encoded, err := json.Marshal(canonicalNumbers(value))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	return encoded, nil
+}
+
+// canonicalNumbers rewrites numbers so that 1e-7 (Go) and 0.0000001 (jsonb) agree
+func canonicalNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = canonicalNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = canonicalNumbers(item)
+		}
+	case json.Number:
+		r, ok := new(big.Rat).SetString(v.String())
+		if !ok {
+			return v
+		}
+		if r.IsInt() {
+			return json.Number(r.Num().String())
+		}
+		text := strings.TrimRight(r.FloatString(64), "0")
+		return json.Number(strings.TrimSuffix(text, "."))
+	}
+	return value
+}
@@ -0,0 +1,35 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Actor identifies who made a change and from where
+type Actor struct {
+	UserID    uuid.UUID
+	IPAddress string
+}
+
+type actorKey struct{}
+
+// ActorKey is the key the actor is stored under. Fiber handlers can store it
+// with c.Locals(audit.ActorKey, actor); because fasthttp's request context
+// resolves Value lookups against its locals, passing c.Context() to a service
+// is then enough for the audit callbacks to find it.
+var ActorKey = actorKey{}
+
+// WithActor returns a copy of ctx that carries the acting user
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, ActorKey, actor)
+}
+
+// ActorFromContext returns the acting user stored in ctx, if any
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	if ctx == nil {
+		return Actor{}, false
+	}
+	actor, ok := ctx.Value(ActorKey).(Actor)
+	return actor, ok
+}
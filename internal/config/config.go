@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -27,6 +28,9 @@ type Config struct {
 		Username string `yaml:"username"`
 		Password string `yaml:"password"`
 		UseTLS   bool   `yaml:"use_tls"`
+		// From is the mailbox mail is sent from. It organizes the calendar
+		// invites of interviews, so replies to them go there.
+		From string `yaml:"from"`
 	} `yaml:"smtp"`
 	Sentry struct {
 		DSN string `yaml:"dsn"`
@@ -40,6 +44,233 @@ type Config struct {
 		AllowedHeaders   string `yaml:"allowed_headers"`
 		AllowedMethods   string `yaml:"allowed_methods"`
 	} `yaml:"cors"`
+	Auth struct {
+		Issuer          string        `yaml:"issuer"`
+		ActiveKeyID     string        `yaml:"active_key_id"`
+		Keys            []SigningKey  `yaml:"keys"`
+		AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+		RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+		// MFARequiredRoles lists the roles that must enroll in TOTP before they can sign in
+		MFARequiredRoles []string      `yaml:"mfa_required_roles"`
+		Lockout          LockoutPolicy `yaml:"lockout"`
+		// PermissionCacheTTL bounds how long a server keeps role permissions
+		// cached when another instance changed them
+		PermissionCacheTTL time.Duration `yaml:"permission_cache_ttl"`
+		SSO                SSO           `yaml:"sso"`
+	} `yaml:"auth"`
+	SCIM                SCIM                `yaml:"scim"`
+	Storage             Storage             `yaml:"storage"`
+	Uploads             Uploads             `yaml:"uploads"`
+	ReferenceNumbers    ReferenceNumbers    `yaml:"reference_numbers"`
+	IncidentWorkflow    IncidentWorkflow    `yaml:"incident_workflow"`
+	InvestigationReview InvestigationReview `yaml:"investigation_review"`
+	Interviews          Interviews          `yaml:"interviews"`
+}
+
+// Interviews sets how investigation interviews are booked
+type Interviews struct {
+	// DefaultDuration is how long an interview is booked for when no
+	// duration is given
+	DefaultDuration time.Duration `yaml:"default_duration"`
+}
+
+// IncidentWorkflow lists the status changes allowed on incidents. Leaving
+// Transitions empty keeps the built-in workflow: new, investigating,
+// action_required, resolved, closed, and reopening.
+type IncidentWorkflow struct {
+	Transitions []WorkflowTransition `yaml:"transitions"`
+}
+
+// WorkflowTransition allows an incident in any of the From statuses to
+// move to To once every guard passes. Guards are named:
+// investigation_completed, actions_completed, actions_verified and
+// note_required.
+type WorkflowTransition struct {
+	Name   string   `yaml:"name"`
+	From   []string `yaml:"from"`
+	To     string   `yaml:"to"`
+	Guards []string `yaml:"guards"`
+}
+
+// InvestigationReview is the chain of reviewers who sign off an
+// investigation once the lead investigator submits it. Steps are approved
+// in order; a rejection at any step reopens the investigation.
+type InvestigationReview struct {
+	Steps []ReviewStep `yaml:"steps"`
+	// SigningKey signs approval records so that later changes to them show.
+	// It is required.
+	SigningKey string `yaml:"signing_key"`
+}
+
+// ReviewStep is one sign-off of the review chain
+type ReviewStep struct {
+	Name string `yaml:"name"`
+	// Role is the role an employee needs to sign off this step
+	Role string `yaml:"role"`
+	// SameDepartment limits reviewers to the department of the employee
+	// who reported the incident
+	SameDepartment bool `yaml:"same_department"`
+}
+
+// ReferenceNumbers sets how new incidents, hazards and VPCs are numbered,
+// e.g. INC-2026-00042, or INC-LLW-2026-00042 with a site code
+type ReferenceNumbers struct {
+	// Site is this site's short code, put in every number when set
+	Site string `yaml:"site"`
+	// Prefixes by kind: incident, hazard and vpc
+	Prefixes map[string]string `yaml:"prefixes"`
+	// Reset is "yearly" to put the year in the number and restart the
+	// count every year, or "never"
+	Reset string `yaml:"reset"`
+	// Digits is the width the count is zero-padded to
+	Digits int `yaml:"digits"`
+}
+
+// Uploads limits what users may upload
+type Uploads struct {
+	// MaxRequestSizeMB caps a whole upload request, all files included
+	MaxRequestSizeMB int `yaml:"max_request_size_mb"`
+	// MaxSizeMB caps a single file by kind: image, document or video
+	MaxSizeMB map[string]int64 `yaml:"max_size_mb"`
+	ClamAV    ClamAV           `yaml:"clamav"`
+	Images    Images           `yaml:"images"`
+}
+
+// Images controls how uploaded photos are processed
+type Images struct {
+	// EXIF is "strip" to remove camera metadata, GPS position included, from
+	// stored photos, or "keep" to store them as uploaded. The capture time
+	// and position are recorded either way.
+	EXIF string `yaml:"exif"`
+	// ThumbnailSize and PreviewSize are the longest side, in pixels, of the
+	// resized copies made of photo attachments
+	ThumbnailSize int `yaml:"thumbnail_size"`
+	PreviewSize   int `yaml:"preview_size"`
+}
+
+// ClamAV scans uploads with a clamd daemon. Scanning is off while Address
+// is empty.
+type ClamAV struct {
+	// Address is clamd's host:port, or the path of its unix socket
+	Address string        `yaml:"address"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Storage selects where uploaded files are kept. Every API replica must
+// use the same backend, so deployments with more than one replica need
+// the s3 driver.
+type Storage struct {
+	// Driver is "local" or "s3"
+	Driver string       `yaml:"driver"`
+	Local  LocalStorage `yaml:"local"`
+	S3     S3Storage    `yaml:"s3"`
+	// SigningKey signs the short-lived download links embedded in emails
+	// and PDFs. Signed links are disabled while it is empty.
+	SigningKey string `yaml:"signing_key"`
+	// SignedURLTTL is how long a signed download link stays valid
+	SignedURLTTL time.Duration `yaml:"signed_url_ttl"`
+	// PublicURL is the public base URL of this API, used in signed links
+	PublicURL string `yaml:"public_url"`
+}
+
+// LocalStorage keeps files in a directory on the server's disk
+type LocalStorage struct {
+	Dir string `yaml:"dir"`
+}
+
+// S3Storage keeps files in a bucket of an S3-compatible object store such
+// as AWS S3 or MinIO
+type S3Storage struct {
+	// Endpoint is the store's URL, e.g. https://s3.eu-west-1.amazonaws.com
+	// or http://localhost:9000 for MinIO
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	// Prefix is prepended to every object key
+	Prefix string `yaml:"prefix"`
+	// UsePathStyle addresses the bucket in the path rather than the host
+	// name, which MinIO requires
+	UsePathStyle bool `yaml:"use_path_style"`
+}
+
+// SCIM configures the SCIM 2.0 endpoint the HR directory provisions accounts through
+type SCIM struct {
+	// Tokens are the bearer tokens the directory authenticates with. The
+	// endpoint refuses every request while none is configured.
+	Tokens []string `yaml:"tokens"`
+	// BaseURL is the public URL of /scim/v2, used in resource locations
+	BaseURL string `yaml:"base_url"`
+	// DefaultRole, DefaultDepartment and DefaultPosition fill in employee
+	// fields the directory does not send
+	DefaultRole       string `yaml:"default_role"`
+	DefaultDepartment string `yaml:"default_department"`
+	DefaultPosition   string `yaml:"default_position"`
+}
+
+// SSO configures sign-in through OpenID Connect identity providers
+type SSO struct {
+	// StateTTL bounds how long a user may take to sign in at the provider
+	StateTTL  time.Duration  `yaml:"state_ttl"`
+	Providers []OIDCProvider `yaml:"providers"`
+}
+
+// OIDCProvider is an OpenID Connect identity provider. Name selects which
+// account column the provider's subject is linked through: "google" or
+// "microsoft".
+type OIDCProvider struct {
+	Name string `yaml:"name"`
+	// Issuer is the provider's issuer URL; its discovery document is read
+	// from /.well-known/openid-configuration below it
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
+	// AllowedDomains restricts sign-in to these email domains when set
+	AllowedDomains []string `yaml:"allowed_domains"`
+	// TrustEmail accepts the email claim without email_verified, for
+	// single-tenant providers such as Microsoft Entra whose directory
+	// controls every address
+	TrustEmail bool             `yaml:"trust_email"`
+	Provision  OIDCProvisioning `yaml:"provision"`
+}
+
+// OIDCProvisioning controls creating accounts for first-time SSO users
+type OIDCProvisioning struct {
+	Enabled           bool   `yaml:"enabled"`
+	DefaultRole       string `yaml:"default_role"`
+	DefaultDepartment string `yaml:"default_department"`
+	DefaultPosition   string `yaml:"default_position"`
+	// DepartmentClaim and JobTitleClaim name the ID token claims that carry
+	// the employee's department and job title
+	DepartmentClaim string `yaml:"department_claim"`
+	JobTitleClaim   string `yaml:"job_title_claim"`
+}
+
+// LockoutPolicy controls how failed sign-in attempts lock accounts
+type LockoutPolicy struct {
+	// Threshold is the number of consecutive failures that locks an account
+	Threshold int `yaml:"threshold"`
+	// Duration is how long the first lock lasts
+	Duration time.Duration `yaml:"duration"`
+	// BackoffMultiplier grows the lock duration for every repeated lock
+	BackoffMultiplier float64 `yaml:"backoff_multiplier"`
+	// MaxDuration caps the lock duration
+	MaxDuration time.Duration `yaml:"max_duration"`
+	// IPThreshold is the number of failures from one IP address within
+	// IPWindow after which that address is refused outright
+	IPThreshold int           `yaml:"ip_threshold"`
+	IPWindow    time.Duration `yaml:"ip_window"`
+}
+
+// SigningKey is a JWT signing secret identified by its key ID (kid).
+// Several keys may be configured at once so that a new key can be
+// introduced before the old one is retired.
+type SigningKey struct {
+	ID     string `yaml:"kid"`
+	Secret string `yaml:"secret"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -54,5 +285,140 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("error decoding path: %w", err)
 	}
 
+	applyDefaults(config)
+
 	return config, nil
 }
+
+// applyDefaults fills in optional settings that were left empty in the config file
+func applyDefaults(config *Config) {
+	if config.Auth.Issuer == "" {
+		config.Auth.Issuer = "safety365"
+	}
+	if config.Auth.AccessTokenTTL == 0 {
+		config.Auth.AccessTokenTTL = 15 * time.Minute
+	}
+	if config.Auth.RefreshTokenTTL == 0 {
+		config.Auth.RefreshTokenTTL = 30 * 24 * time.Hour
+	}
+	if config.Auth.Lockout.Threshold == 0 {
+		config.Auth.Lockout.Threshold = 5
+	}
+	if config.Auth.Lockout.Duration == 0 {
+		config.Auth.Lockout.Duration = 15 * time.Minute
+	}
+	if config.Auth.Lockout.BackoffMultiplier < 1 {
+		config.Auth.Lockout.BackoffMultiplier = 2
+	}
+	if config.Auth.Lockout.MaxDuration == 0 {
+		config.Auth.Lockout.MaxDuration = 24 * time.Hour
+	}
+	if config.Auth.Lockout.IPThreshold == 0 {
+		config.Auth.Lockout.IPThreshold = 20
+	}
+	if config.Auth.Lockout.IPWindow == 0 {
+		config.Auth.Lockout.IPWindow = 15 * time.Minute
+	}
+	if config.Auth.PermissionCacheTTL == 0 {
+		config.Auth.PermissionCacheTTL = time.Minute
+	}
+	if config.Auth.SSO.StateTTL == 0 {
+		config.Auth.SSO.StateTTL = 10 * time.Minute
+	}
+	for i := range config.Auth.SSO.Providers {
+		provider := &config.Auth.SSO.Providers[i]
+		if len(provider.Scopes) == 0 {
+			provider.Scopes = []string{"openid", "email", "profile"}
+		}
+		if provider.Provision.DefaultRole == "" {
+			provider.Provision.DefaultRole = "employee"
+		}
+		if provider.Provision.DefaultDepartment == "" {
+			provider.Provision.DefaultDepartment = "Unassigned"
+		}
+		if provider.Provision.DefaultPosition == "" {
+			provider.Provision.DefaultPosition = "Employee"
+		}
+	}
+	if config.SCIM.DefaultRole == "" {
+		config.SCIM.DefaultRole = "employee"
+	}
+	if config.SCIM.DefaultDepartment == "" {
+		config.SCIM.DefaultDepartment = "Unassigned"
+	}
+	if config.SCIM.DefaultPosition == "" {
+		config.SCIM.DefaultPosition = "Employee"
+	}
+	if config.Storage.Driver == "" {
+		config.Storage.Driver = "local"
+	}
+	if config.Storage.Local.Dir == "" {
+		config.Storage.Local.Dir = "uploads"
+	}
+	if config.Storage.SignedURLTTL == 0 {
+		config.Storage.SignedURLTTL = 15 * time.Minute
+	}
+	if config.Storage.S3.Region == "" {
+		config.Storage.S3.Region = "us-east-1"
+	}
+	if config.Uploads.MaxRequestSizeMB == 0 {
+		config.Uploads.MaxRequestSizeMB = 100
+	}
+	defaultUploadSizes := map[string]int64{"image": 10, "document": 20, "video": 50}
+	if config.Uploads.MaxSizeMB == nil {
+		config.Uploads.MaxSizeMB = map[string]int64{}
+	}
+	for kind, size := range defaultUploadSizes {
+		if config.Uploads.MaxSizeMB[kind] == 0 {
+			config.Uploads.MaxSizeMB[kind] = size
+		}
+	}
+	if config.Uploads.ClamAV.Timeout == 0 {
+		config.Uploads.ClamAV.Timeout = 30 * time.Second
+	}
+	if config.Uploads.Images.EXIF == "" {
+		config.Uploads.Images.EXIF = "strip"
+	}
+	if config.Uploads.Images.ThumbnailSize == 0 {
+		config.Uploads.Images.ThumbnailSize = 320
+	}
+	if config.Uploads.Images.PreviewSize == 0 {
+		config.Uploads.Images.PreviewSize = 1280
+	}
+	defaultPrefixes := map[string]string{"incident": "INC", "hazard": "HAZ", "vpc": "VPC"}
+	if config.ReferenceNumbers.Prefixes == nil {
+		config.ReferenceNumbers.Prefixes = map[string]string{}
+	}
+	for kind, prefix := range defaultPrefixes {
+		if config.ReferenceNumbers.Prefixes[kind] == "" {
+			config.ReferenceNumbers.Prefixes[kind] = prefix
+		}
+	}
+	if config.ReferenceNumbers.Reset == "" {
+		config.ReferenceNumbers.Reset = "yearly"
+	}
+	if config.ReferenceNumbers.Digits == 0 {
+		config.ReferenceNumbers.Digits = 5
+	}
+	if len(config.IncidentWorkflow.Transitions) == 0 {
+		config.IncidentWorkflow.Transitions = []WorkflowTransition{
+			{Name: "start_investigation", From: []string{"new"}, To: "investigating"},
+			{Name: "require_action", From: []string{"new", "investigating", "resolved"}, To: "action_required"},
+			{Name: "resolve", From: []string{"investigating", "action_required"}, To: "resolved", Guards: []string{"actions_completed"}},
+			{Name: "close", From: []string{"resolved"}, To: "closed", Guards: []string{"investigation_completed", "actions_verified"}},
+			{Name: "reopen", From: []string{"resolved", "closed"}, To: "investigating", Guards: []string{"note_required"}},
+		}
+	}
+	if len(config.InvestigationReview.Steps) == 0 {
+		config.InvestigationReview.Steps = []ReviewStep{
+			{Name: "department_manager", Role: "manager", SameDepartment: true},
+			{Name: "safety_officer", Role: "safety_officer"},
+		}
+	}
+	if config.Interviews.DefaultDuration == 0 {
+		config.Interviews.DefaultDuration = time.Hour
+	}
+	if config.Auth.MFARequiredRoles == nil {
+		config.Auth.MFARequiredRoles = []string{"admin", "safety_officer"}
+	}
+}
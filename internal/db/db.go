@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"github.com/hopkali04/health-sys/internal/audit"
 	"github.com/hopkali04/health-sys/internal/config"
 	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/services/sequence"
 )
 
 func EnableUUIDExtension(db *gorm.DB) error {
@@ -28,17 +30,52 @@ func ConnectDB(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := audit.RegisterCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register audit callbacks: %w", err)
+	}
+
+	referenceNumbers, err := sequence.NewGenerator(cfg.ReferenceNumbers)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference number config: %w", err)
+	}
+	models.UseReferenceNumbers(referenceNumbers)
+
 	return db, nil
 }
 
-func AutoMigrate(db *gorm.DB) error {
+// autoMigrate is the schema tool used before versioned migrations. Only
+// Baseline still runs it, to bring an old database level with the baseline.
+func autoMigrate(db *gorm.DB) error {
+	// Roles go first: employees reference them by name
+	if err := db.AutoMigrate(&models.Permission{}, &models.Role{}); err != nil {
+		return fmt.Errorf("failed to auto-migrate roles: %w", err)
+	}
+	if err := SeedRoles(db); err != nil {
+		return err
+	}
+	if db.Migrator().HasConstraint(&models.Employee{}, employeeRoleCheck) {
+		if err := db.Migrator().DropConstraint(&models.Employee{}, employeeRoleCheck); err != nil {
+			return fmt.Errorf("failed to drop the employee role check: %w", err)
+		}
+	}
+
+	// Reports numbered twice before the counters existed keep their first
+	// number; the later ones get a suffix so the number can be unique
+	if err := dedupeIncidentReferenceNumbers(db); err != nil {
+		return err
+	}
+
 	// List all models here
 	err := db.AutoMigrate(
 		&models.AuditLog{},
+		&models.ReferenceCounter{},
 		&models.User{},
 		&models.Department{},
 		&models.Employee{},
 		&models.UserSession{},
+		&models.MFARecoveryCode{},
+		&models.LoginAttempt{},
+		&models.SSOLoginState{},
 		&models.Incident{},
 		&models.IncidentAttachment{},
 		&models.Investigation{},
@@ -52,10 +89,30 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.VPC{},
 		&models.VPCAttachment{},
 		&models.Hazard{},
+		&models.HazardAttachment{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to auto-migrate database: %w", err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// dedupeIncidentReferenceNumbers renames duplicate incident numbers to
+// <number>-1, <number>-2 and so on, oldest first
+func dedupeIncidentReferenceNumbers(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Incident{}) {
+		return nil
+	}
+	err := db.Exec(`
+		UPDATE incidents SET reference_number = incidents.reference_number || '-' || duplicates.n
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY reference_number ORDER BY created_at, id) - 1 AS n
+			FROM incidents
+		) duplicates
+		WHERE incidents.id = duplicates.id AND duplicates.n > 0`).Error
+	if err != nil {
+		return fmt.Errorf("failed to rename duplicate incident numbers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the Postgres advisory lock held while a migration
+// runs, so two deployments migrating at once cannot apply one twice
+const migrationLockKey int64 = 0x5afe365d6a7e
+
+// BaselineVersion is the migration that recreates the schema AutoMigrate
+// built before versioned migrations were introduced
+const BaselineVersion = 1
+
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change, read from the pair of files
+// <version>_<name>.up.sql and <version>_<name>.down.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// schemaMigration records an applied migration
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrations returns the migrations embedded in the binary, oldest first
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named <version>_<name>.<up|down>.sql", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// MigrateUp applies every pending migration in order, each in its own
+// transaction, then seeds the built-in roles. It returns the migrations it
+// applied.
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			continue
+		}
+		migration := status.Migration
+		err := runMigration(db, migration.Version, func(tx *gorm.DB, done bool) error {
+			if done {
+				return nil
+			}
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+
+	if err := SeedRoles(db); err != nil {
+		return applied, err
+	}
+	return applied, nil
+}
+
+// MigrateDown reverts the last n applied migrations, newest first. It
+// returns the migrations it reverted.
+func MigrateDown(db *gorm.DB, n int) ([]Migration, error) {
+	if n < 1 {
+		return nil, errors.New("the number of migrations to revert must be at least 1")
+	}
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(statuses) - 1; i >= 0 && len(reverted) < n; i-- {
+		if statuses[i].AppliedAt == nil {
+			continue
+		}
+		migration := statuses[i].Migration
+		err := runMigration(db, migration.Version, func(tx *gorm.DB, done bool) error {
+			if !done {
+				return nil
+			}
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// MigrationStatuses lists every embedded migration and when it was
+// applied. Versions recorded in the database that this binary does not
+// know are an error: the database is newer than the code.
+func MigrationStatuses(db *gorm.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var records []schemaMigration
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	appliedAt := make(map[int64]time.Time, len(records))
+	for _, record := range records {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+
+	statuses := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		statuses[i].Migration = migration
+		if at, ok := appliedAt[migration.Version]; ok {
+			at := at
+			statuses[i].AppliedAt = &at
+			delete(appliedAt, migration.Version)
+		}
+	}
+	if len(appliedAt) > 0 {
+		unknown := make([]int64, 0, len(appliedAt))
+		for version := range appliedAt {
+			unknown = append(unknown, version)
+		}
+		sort.Slice(unknown, func(i, j int) bool { return unknown[i] < unknown[j] })
+		return nil, fmt.Errorf("migrations %v are applied but unknown to this version of safety365", unknown)
+	}
+	return statuses, nil
+}
+
+// Baseline adopts versioned migrations on a database built by AutoMigrate.
+// The models are migrated one last time to close any gap to the baseline,
+// and the baseline is then recorded as applied without running it. Later
+// migrations are left for MigrateUp; they use IF NOT EXISTS so that they
+// also apply over what AutoMigrate may already have added.
+func Baseline(db *gorm.DB) error {
+	var count int64
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	if err := db.Model(&schemaMigration{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	if count > 0 {
+		return errors.New("the database already has migrations recorded; use migrate up")
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	if err := autoMigrate(db); err != nil {
+		return err
+	}
+
+	return runMigration(db, BaselineVersion, func(tx *gorm.DB, done bool) error {
+		for _, migration := range migrations {
+			if migration.Version > BaselineVersion {
+				break
+			}
+			record := schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+			if err := tx.Create(&record).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// runMigration runs fn in a transaction holding the migration lock. done
+// reports whether version is recorded as applied, read after the lock is
+// taken so a concurrent run is seen.
+func runMigration(db *gorm.DB, version int64, fn func(tx *gorm.DB, done bool) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+			return fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		var count int64
+		if err := tx.Model(&schemaMigration{}).Where("version = ?", version).Count(&count).Error; err != nil {
+			return err
+		}
+		return fn(tx, count > 0)
+	})
+}
@@ -0,0 +1,28 @@
+-- Drops every table of the baseline. All data is lost.
+
+DROP TABLE IF EXISTS "role_permissions";
+DROP TABLE IF EXISTS "hazard_attachments";
+DROP TABLE IF EXISTS "hazards";
+DROP TABLE IF EXISTS "vpc_attachments";
+DROP TABLE IF EXISTS "vpcs";
+DROP TABLE IF EXISTS "temporary_employees";
+DROP TABLE IF EXISTS "action_evidences";
+DROP TABLE IF EXISTS "investigation_evidences";
+DROP TABLE IF EXISTS "investigation_interviews";
+DROP TABLE IF EXISTS "notifications";
+DROP TABLE IF EXISTS "action_updates";
+DROP TABLE IF EXISTS "corrective_actions";
+DROP TABLE IF EXISTS "investigations";
+DROP TABLE IF EXISTS "incident_attachments";
+DROP TABLE IF EXISTS "incidents";
+DROP TABLE IF EXISTS "sso_login_states";
+DROP TABLE IF EXISTS "login_attempts";
+DROP TABLE IF EXISTS "mfa_recovery_codes";
+DROP TABLE IF EXISTS "user_sessions";
+DROP TABLE IF EXISTS "employees";
+DROP TABLE IF EXISTS "departments";
+DROP TABLE IF EXISTS "users";
+DROP TABLE IF EXISTS "reference_counters";
+DROP TABLE IF EXISTS "audit_logs";
+DROP TABLE IF EXISTS "roles";
+DROP TABLE IF EXISTS "permissions";
@@ -0,0 +1,478 @@
+-- Baseline: the schema as the GORM models described it when versioned
+-- migrations were introduced. Databases created by the old AutoMigrate are
+-- brought to this state and marked as migrated by `migrate baseline`.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE "permissions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(100) NOT NULL,
+    "description" varchar(255),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_permissions_name" ON "permissions" ("name");
+
+CREATE TABLE "roles" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(50) NOT NULL,
+    "description" varchar(255),
+    "is_system" boolean DEFAULT false,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_roles_name" ON "roles" ("name");
+
+CREATE TABLE "audit_logs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "sequence" bigint DEFAULT 0,
+    "table_name" varchar(50) NOT NULL,
+    "record_id" uuid NOT NULL,
+    "action" varchar(10) NOT NULL,
+    "old_data" jsonb,
+    "new_data" jsonb,
+    "user_id" uuid,
+    "ip_address" inet,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "prev_hash" varchar(64),
+    "hash" varchar(64),
+    PRIMARY KEY ("id"),
+    CONSTRAINT "chk_audit_logs_action" CHECK (action IN ('INSERT', 'UPDATE', 'DELETE'))
+);
+CREATE INDEX "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+CREATE INDEX "idx_audit_logs_user_id" ON "audit_logs" ("user_id");
+CREATE INDEX "idx_audit_logs_record" ON "audit_logs" ("table_name","record_id");
+CREATE INDEX "idx_audit_logs_sequence" ON "audit_logs" ("sequence");
+
+CREATE TABLE "reference_counters" (
+    "name" varchar(100),
+    "value" bigint NOT NULL DEFAULT 0,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("name")
+);
+
+CREATE TABLE "users" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "email" varchar(255) NOT NULL,
+    "password_hash" varchar(255),
+    "google_id" varchar(255),
+    "microsoft_id" varchar(255),
+    "external_id" varchar(255),
+    "mfa_enabled" boolean DEFAULT false,
+    "mfa_secret" varchar(255),
+    "mfa_last_used_counter" bigint DEFAULT 0,
+    "failed_login_attempts" bigint DEFAULT 0,
+    "last_login_at" timestamptz,
+    "password_changed_at" timestamptz,
+    "account_locked" boolean DEFAULT false,
+    "account_locked_until" timestamptz,
+    "lockout_count" bigint DEFAULT 0,
+    "is_active" boolean DEFAULT true,
+    "is_verified" boolean DEFAULT false,
+    "verification_token" varchar(255),
+    "verification_expires" timestamptz,
+    "reset_token" varchar(255),
+    "reset_token_expires" timestamptz,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email"),
+    CONSTRAINT "uni_users_google_id" UNIQUE ("google_id"),
+    CONSTRAINT "uni_users_microsoft_id" UNIQUE ("microsoft_id"),
+    CONSTRAINT "uni_users_external_id" UNIQUE ("external_id")
+);
+
+CREATE TABLE "departments" (
+    "id" bigserial,
+    "name" text NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE "employees" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid,
+    "employee_number" varchar(50) NOT NULL,
+    "first_name" varchar(100) NOT NULL,
+    "last_name" varchar(100) NOT NULL,
+    "department" varchar(100) NOT NULL,
+    "position" varchar(100) NOT NULL,
+    "role" varchar(50) NOT NULL,
+    "reporting_manager_id" uuid,
+    "start_date" timestamptz NOT NULL,
+    "end_date" timestamptz,
+    "emergency_contact" jsonb,
+    "contact_number" varchar(20),
+    "office_location" varchar(100),
+    "is_safety_officer" boolean DEFAULT false,
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_employees_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_employees_reporting_manager" FOREIGN KEY ("reporting_manager_id") REFERENCES "employees"("id"),
+    CONSTRAINT "fk_employees_role_definition" FOREIGN KEY ("role") REFERENCES "roles"("name") ON DELETE RESTRICT ON UPDATE CASCADE,
+    CONSTRAINT "uni_employees_user_id" UNIQUE ("user_id"),
+    CONSTRAINT "uni_employees_employee_number" UNIQUE ("employee_number")
+);
+CREATE INDEX "idx_employees_role" ON "employees" ("role");
+
+CREATE TABLE "user_sessions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "refresh_token" varchar(255) NOT NULL,
+    "device_info" jsonb,
+    "ip_address" inet,
+    "expires_at" timestamptz NOT NULL,
+    "last_seen_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_sessions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_user_sessions_user_id" ON "user_sessions" ("user_id");
+CREATE INDEX "idx_user_sessions_revoked_at" ON "user_sessions" ("revoked_at");
+CREATE UNIQUE INDEX "idx_user_sessions_refresh_token" ON "user_sessions" ("refresh_token");
+
+CREATE TABLE "mfa_recovery_codes" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "code_hash" varchar(64) NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_mfa_recovery_codes_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+CREATE INDEX "idx_mfa_recovery_codes_user_id" ON "mfa_recovery_codes" ("user_id");
+
+CREATE TABLE "login_attempts" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid,
+    "identifier" varchar(255),
+    "ip_address" inet,
+    "success" boolean DEFAULT false,
+    "reason" varchar(50),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_login_attempts_ip_created" ON "login_attempts" ("ip_address","created_at");
+CREATE INDEX "idx_login_attempts_user_id" ON "login_attempts" ("user_id");
+
+CREATE TABLE "sso_login_states" (
+    "state" varchar(64),
+    "provider" varchar(50) NOT NULL,
+    "nonce" varchar(100) NOT NULL,
+    "code_verifier" varchar(128) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("state")
+);
+CREATE INDEX "idx_sso_login_states_expires_at" ON "sso_login_states" ("expires_at");
+
+CREATE TABLE "incidents" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "reference_number" varchar(50) NOT NULL,
+    "user_incident_id" text,
+    "type" varchar(50) NOT NULL,
+    "injury_type" varchar(50),
+    "severity_level" varchar(20) NOT NULL,
+    "status" varchar(30) NOT NULL DEFAULT 'new',
+    "title" varchar(255) NOT NULL,
+    "description" text NOT NULL,
+    "location" varchar(255) NOT NULL,
+    "full_location" varchar(255),
+    "late_reason" text,
+    "occurred_at" timestamptz NOT NULL,
+    "reported_by" uuid NOT NULL,
+    "user_reported" varchar(255),
+    "assigned_to" uuid,
+    "immediate_actions_taken" text,
+    "witnesses" jsonb,
+    "environmental_conditions" jsonb,
+    "equipment_involved" jsonb,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "closed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_incidents_reporter" FOREIGN KEY ("reported_by") REFERENCES "employees"("id"),
+    CONSTRAINT "fk_incidents_assignee" FOREIGN KEY ("assigned_to") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_incidents_severity_level" CHECK (severity_level IN ('low', 'medium', 'high', 'critical')),
+    CONSTRAINT "chk_incidents_status" CHECK (status IN ('new', 'investigating', 'action_required', 'resolved', 'closed')),
+    CONSTRAINT "chk_incidents_type" CHECK (type IN ('injury', 'near_miss', 'property_damage', 'environmental', 'security'))
+);
+CREATE UNIQUE INDEX "idx_incidents_reference_number" ON "incidents" ("reference_number");
+
+CREATE TABLE "incident_attachments" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "incident_id" uuid NOT NULL,
+    "file_name" varchar(255) NOT NULL,
+    "file_type" varchar(100) NOT NULL,
+    "file_size" bigint NOT NULL,
+    "storage_path" varchar(512) NOT NULL,
+    "uploaded_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "thumbnail_path" varchar(512),
+    "preview_path" varchar(512),
+    "image_width" bigint,
+    "image_height" bigint,
+    "captured_at" timestamptz,
+    "latitude" decimal,
+    "longitude" decimal,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_incident_attachments_incident" FOREIGN KEY ("incident_id") REFERENCES "incidents"("id"),
+    CONSTRAINT "fk_incident_attachments_uploader" FOREIGN KEY ("uploaded_by") REFERENCES "employees"("id")
+);
+
+CREATE TABLE "investigations" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "incident_id" uuid NOT NULL,
+    "lead_investigator_id" uuid NOT NULL,
+    "description" text,
+    "root_cause" text,
+    "contributing_factors" jsonb,
+    "investigation_methods" jsonb,
+    "findings" text,
+    "recommendations" text,
+    "started_at" timestamptz NOT NULL,
+    "completed_at" timestamptz,
+    "status" varchar(30) NOT NULL DEFAULT 'in_progress',
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investigations_incident" FOREIGN KEY ("incident_id") REFERENCES "incidents"("id"),
+    CONSTRAINT "fk_investigations_lead_investigator" FOREIGN KEY ("lead_investigator_id") REFERENCES "employees"("id"),
+    CONSTRAINT "uni_investigations_incident_id" UNIQUE ("incident_id"),
+    CONSTRAINT "chk_investigations_status" CHECK (status IN ('in_progress', 'pending_review', 'completed', 'reopened'))
+);
+
+CREATE TABLE "corrective_actions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "incident_id" uuid NOT NULL,
+    "description" text NOT NULL,
+    "action_type" varchar(50) NOT NULL,
+    "priority" varchar(20) NOT NULL,
+    "status" varchar(30) NOT NULL DEFAULT 'pending',
+    "assigned_to" uuid NOT NULL,
+    "assigned_by" uuid NOT NULL,
+    "due_date" timestamptz NOT NULL,
+    "completed_at" timestamptz,
+    "completion_notes" text,
+    "verification_required" boolean DEFAULT false,
+    "verified_by" uuid,
+    "verified_at" timestamptz,
+    "previous_due_date" timestamptz,
+    "extension_reason" text,
+    "extension_requested_at" timestamptz,
+    "extension_requested_by" varchar(255),
+    "extension_requested_by_id" uuid,
+    "extension_status" varchar(50),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_corrective_actions_assignee" FOREIGN KEY ("assigned_to") REFERENCES "employees"("id"),
+    CONSTRAINT "fk_corrective_actions_assigner" FOREIGN KEY ("assigned_by") REFERENCES "employees"("id"),
+    CONSTRAINT "fk_corrective_actions_verifier" FOREIGN KEY ("verified_by") REFERENCES "employees"("id"),
+    CONSTRAINT "fk_corrective_actions_incident" FOREIGN KEY ("incident_id") REFERENCES "incidents"("id"),
+    CONSTRAINT "chk_corrective_actions_priority" CHECK (priority IN ('low', 'medium', 'high', 'critical')),
+    CONSTRAINT "chk_corrective_actions_status" CHECK (status IN ('pending', 'in_progress', 'completed', 'verified', 'overdue'))
+);
+
+CREATE TABLE "action_updates" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "action_id" uuid NOT NULL,
+    "update_text" text NOT NULL,
+    "status_change" varchar(30),
+    "updated_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_action_updates_corrective_action" FOREIGN KEY ("action_id") REFERENCES "corrective_actions"("id"),
+    CONSTRAINT "fk_action_updates_updater" FOREIGN KEY ("updated_by") REFERENCES "employees"("id")
+);
+
+CREATE TABLE "notifications" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "title" varchar(255) NOT NULL,
+    "message" text NOT NULL,
+    "reference_id" uuid,
+    "reference_type" varchar(50),
+    "read_at" timestamptz,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_notifications_user" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE "investigation_interviews" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "investigation_id" uuid NOT NULL,
+    "interviewee_id" uuid NOT NULL,
+    "scheduled_for" timestamptz NOT NULL,
+    "status" varchar(30) NOT NULL DEFAULT 'scheduled',
+    "notes" text,
+    "location" varchar(255),
+    "completed_at" timestamptz,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investigation_interviews_investigation" FOREIGN KEY ("investigation_id") REFERENCES "investigations"("id"),
+    CONSTRAINT "fk_investigation_interviews_interviewee" FOREIGN KEY ("interviewee_id") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_investigation_interviews_status" CHECK (status IN ('scheduled', 'completed', 'cancelled', 'rescheduled'))
+);
+
+CREATE TABLE "investigation_evidences" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "investigation_id" uuid NOT NULL,
+    "evidence_type" varchar(50) NOT NULL,
+    "description" text NOT NULL,
+    "file_url" varchar(512),
+    "file_name" varchar(255),
+    "file_type" varchar(100),
+    "file_size" bigint DEFAULT 0,
+    "storage_path" varchar(512),
+    "collected_at" timestamptz NOT NULL,
+    "collected_by" uuid NOT NULL,
+    "storage_location" varchar(512),
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "thumbnail_path" varchar(512),
+    "preview_path" varchar(512),
+    "image_width" bigint,
+    "image_height" bigint,
+    "captured_at" timestamptz,
+    "latitude" decimal,
+    "longitude" decimal,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investigation_evidences_investigation" FOREIGN KEY ("investigation_id") REFERENCES "investigations"("id"),
+    CONSTRAINT "fk_investigation_evidences_collector" FOREIGN KEY ("collected_by") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_investigation_evidences_evidence_type" CHECK (evidence_type IN ('document', 'photo', 'video', 'physical_item', 'testimony'))
+);
+
+CREATE TABLE "action_evidences" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "corrective_action_id" uuid NOT NULL,
+    "file_type" varchar(50) NOT NULL,
+    "file_name" varchar(255) NOT NULL,
+    "file_url" varchar(512) NOT NULL,
+    "uploaded_by" uuid NOT NULL,
+    "uploaded_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "description" text,
+    "feedback" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_action_evidences_corrective_action" FOREIGN KEY ("corrective_action_id") REFERENCES "corrective_actions"("id"),
+    CONSTRAINT "fk_action_evidences_uploader" FOREIGN KEY ("uploaded_by") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_action_evidences_file_type" CHECK (file_type IN ('document', 'photo', 'video'))
+);
+
+CREATE TABLE "temporary_employees" (
+    "id" bigserial,
+    "first_name" varchar(100) NOT NULL,
+    "last_name" varchar(100) NOT NULL,
+    "department" text,
+    "position" varchar(100),
+    "contact_number" varchar(20),
+    "office_location" varchar(100),
+    "is_active" boolean DEFAULT true,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE "vpcs" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "vpc_number" varchar(50) NOT NULL,
+    "reported_by" varchar(50) NOT NULL,
+    "reported_date" timestamptz NOT NULL,
+    "department" varchar(50) NOT NULL,
+    "description" text NOT NULL,
+    "vpc_type" varchar(50) NOT NULL,
+    "action_taken" text NOT NULL,
+    "incident_relates_to" varchar(50) NOT NULL,
+    "created_by" uuid,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_vpcs_creator" FOREIGN KEY ("created_by") REFERENCES "employees"("id")
+);
+CREATE UNIQUE INDEX "idx_vpcs_vpc_number" ON "vpcs" ("vpc_number");
+
+CREATE TABLE "vpc_attachments" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "vpc_id" uuid NOT NULL,
+    "file_name" varchar(255) NOT NULL,
+    "file_type" varchar(100) NOT NULL,
+    "file_size" bigint NOT NULL,
+    "storage_path" varchar(512) NOT NULL,
+    "uploaded_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "thumbnail_path" varchar(512),
+    "preview_path" varchar(512),
+    "image_width" bigint,
+    "image_height" bigint,
+    "captured_at" timestamptz,
+    "latitude" decimal,
+    "longitude" decimal,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_vpc_attachments_uploader" FOREIGN KEY ("uploaded_by") REFERENCES "employees"("id"),
+    CONSTRAINT "fk_vpcs_attachments" FOREIGN KEY ("vpc_id") REFERENCES "vpcs"("id") ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE TABLE "hazards" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "reference_number" varchar(50) NOT NULL,
+    "type" varchar(50) NOT NULL,
+    "risk_level" varchar(20) NOT NULL,
+    "status" varchar(30) NOT NULL DEFAULT 'new',
+    "title" varchar(255) NOT NULL,
+    "description" text NOT NULL,
+    "location" varchar(255) NOT NULL,
+    "full_location" varchar(255),
+    "recommended_action" text,
+    "reported_by" uuid NOT NULL,
+    "user_reported" varchar(255),
+    "assigned_to" uuid,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "closed_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_hazards_reporter" FOREIGN KEY ("reported_by") REFERENCES "employees"("id"),
+    CONSTRAINT "fk_hazards_assignee" FOREIGN KEY ("assigned_to") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_hazards_status" CHECK (status IN ('new', 'assessing', 'action_required', 'resolved', 'closed')),
+    CONSTRAINT "chk_hazards_type" CHECK (type IN ('unsafe_act', 'unsafe_condition', 'environmental')),
+    CONSTRAINT "chk_hazards_risk_level" CHECK (risk_level IN ('low', 'medium', 'high', 'extreme'))
+);
+CREATE UNIQUE INDEX "idx_hazards_reference_number" ON "hazards" ("reference_number");
+
+CREATE TABLE "hazard_attachments" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "hazard_id" uuid NOT NULL,
+    "file_name" varchar(255) NOT NULL,
+    "file_type" varchar(100) NOT NULL,
+    "file_size" bigint NOT NULL,
+    "storage_path" varchar(512) NOT NULL,
+    "uploaded_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "thumbnail_path" varchar(512),
+    "preview_path" varchar(512),
+    "image_width" bigint,
+    "image_height" bigint,
+    "captured_at" timestamptz,
+    "latitude" decimal,
+    "longitude" decimal,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_hazard_attachments_hazard" FOREIGN KEY ("hazard_id") REFERENCES "hazards"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_hazard_attachments_uploader" FOREIGN KEY ("uploaded_by") REFERENCES "employees"("id")
+);
+CREATE INDEX "idx_hazard_attachments_hazard_id" ON "hazard_attachments" ("hazard_id");
+
+CREATE TABLE "role_permissions" (
+    "role_id" uuid DEFAULT uuid_generate_v4(),
+    "permission_id" uuid DEFAULT uuid_generate_v4(),
+    PRIMARY KEY ("role_id","permission_id"),
+    CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles"("id"),
+    CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions"("id")
+);
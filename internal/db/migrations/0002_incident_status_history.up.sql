@@ -0,0 +1,16 @@
+-- Every incident status change made through the incident workflow.
+
+CREATE TABLE IF NOT EXISTS "incident_status_history" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "incident_id" uuid NOT NULL,
+    "transition" varchar(50) NOT NULL,
+    "from_status" varchar(30) NOT NULL,
+    "to_status" varchar(30) NOT NULL,
+    "changed_by" uuid,
+    "note" text,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_incident_status_history_incident" FOREIGN KEY ("incident_id") REFERENCES "incidents"("id"),
+    CONSTRAINT "fk_incident_status_history_changer" FOREIGN KEY ("changed_by") REFERENCES "employees"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_incident_status_history_incident_id" ON "incident_status_history" ("incident_id");
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "root_cause_actions";
+DROP TABLE IF EXISTS "root_cause_evidence";
+DROP TABLE IF EXISTS "root_causes";
+DROP TABLE IF EXISTS "five_whys";
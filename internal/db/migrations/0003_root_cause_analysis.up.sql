@@ -0,0 +1,57 @@
+-- Structured root cause analysis: 5-Whys chains and fishbone causes, each
+-- cause linked to the evidence behind it and the corrective actions that
+-- address it.
+
+CREATE TABLE IF NOT EXISTS "five_whys" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "investigation_id" uuid NOT NULL,
+    "problem" text NOT NULL,
+    "position" bigint NOT NULL DEFAULT 0,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_five_whys_investigation" FOREIGN KEY ("investigation_id") REFERENCES "investigations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_five_whys_creator" FOREIGN KEY ("created_by") REFERENCES "employees"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_five_whys_investigation_id" ON "five_whys" ("investigation_id");
+
+CREATE TABLE IF NOT EXISTS "root_causes" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "investigation_id" uuid NOT NULL,
+    "five_whys_id" uuid,
+    "parent_id" uuid,
+    "category" varchar(20),
+    "description" text NOT NULL,
+    "position" bigint NOT NULL DEFAULT 0,
+    "is_root_cause" boolean NOT NULL DEFAULT false,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_five_whys_whys" FOREIGN KEY ("five_whys_id") REFERENCES "five_whys"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_root_causes_investigation" FOREIGN KEY ("investigation_id") REFERENCES "investigations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_root_causes_creator" FOREIGN KEY ("created_by") REFERENCES "employees"("id"),
+    CONSTRAINT "fk_root_causes_sub_causes" FOREIGN KEY ("parent_id") REFERENCES "root_causes"("id") ON DELETE CASCADE,
+    CONSTRAINT "chk_root_causes_category" CHECK (category IN ('', 'people', 'method', 'machine', 'material', 'environment', 'measurement'))
+);
+CREATE INDEX IF NOT EXISTS "idx_root_causes_investigation_id" ON "root_causes" ("investigation_id");
+CREATE INDEX IF NOT EXISTS "idx_root_causes_five_whys_id" ON "root_causes" ("five_whys_id");
+CREATE INDEX IF NOT EXISTS "idx_root_causes_parent_id" ON "root_causes" ("parent_id");
+
+-- Links go with either end, so deleting evidence or an action unlinks it
+CREATE TABLE IF NOT EXISTS "root_cause_evidence" (
+    "root_cause_id" uuid,
+    "investigation_evidence_id" uuid,
+    PRIMARY KEY ("root_cause_id","investigation_evidence_id"),
+    CONSTRAINT "fk_root_cause_evidence_root_cause" FOREIGN KEY ("root_cause_id") REFERENCES "root_causes"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_root_cause_evidence_investigation_evidence" FOREIGN KEY ("investigation_evidence_id") REFERENCES "investigation_evidences"("id") ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "root_cause_actions" (
+    "root_cause_id" uuid,
+    "corrective_action_id" uuid,
+    PRIMARY KEY ("root_cause_id","corrective_action_id"),
+    CONSTRAINT "fk_root_cause_actions_root_cause" FOREIGN KEY ("root_cause_id") REFERENCES "root_causes"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_root_cause_actions_corrective_action" FOREIGN KEY ("corrective_action_id") REFERENCES "corrective_actions"("id") ON DELETE CASCADE
+);
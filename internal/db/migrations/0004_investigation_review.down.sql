@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "investigation_reviews";
+
+ALTER TABLE "investigations" DROP COLUMN IF EXISTS "submitted_at";
+ALTER TABLE "investigations" DROP COLUMN IF EXISTS "review_step";
+ALTER TABLE "investigations" DROP COLUMN IF EXISTS "review_round";
@@ -0,0 +1,24 @@
+-- Review chain of investigations: the submission round and step awaiting
+-- sign-off, and the signed decision of every reviewer.
+
+ALTER TABLE "investigations" ADD COLUMN IF NOT EXISTS "review_round" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "investigations" ADD COLUMN IF NOT EXISTS "review_step" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "investigations" ADD COLUMN IF NOT EXISTS "submitted_at" timestamptz;
+
+CREATE TABLE IF NOT EXISTS "investigation_reviews" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "investigation_id" uuid NOT NULL,
+    "round" bigint NOT NULL,
+    "step" bigint NOT NULL,
+    "step_name" varchar(50) NOT NULL,
+    "reviewer_id" uuid NOT NULL,
+    "decision" varchar(20) NOT NULL,
+    "comments" text,
+    "signed_at" timestamptz NOT NULL,
+    "signature" varchar(64) NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investigation_reviews_investigation" FOREIGN KEY ("investigation_id") REFERENCES "investigations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_investigation_reviews_reviewer" FOREIGN KEY ("reviewer_id") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_investigation_reviews_decision" CHECK (decision IN ('approved', 'rejected'))
+);
+CREATE INDEX IF NOT EXISTS "idx_investigation_reviews_investigation_id" ON "investigation_reviews" ("investigation_id");
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "investigation_members";
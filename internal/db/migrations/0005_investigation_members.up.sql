@@ -0,0 +1,24 @@
+-- Investigation teams: the members besides the lead investigator, with
+-- their role and when they joined and left.
+
+CREATE TABLE IF NOT EXISTS "investigation_members" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "investigation_id" uuid NOT NULL,
+    "employee_id" uuid NOT NULL,
+    "role" varchar(30) NOT NULL,
+    "joined_at" timestamptz NOT NULL,
+    "left_at" timestamptz,
+    "added_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investigation_members_investigation" FOREIGN KEY ("investigation_id") REFERENCES "investigations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_investigation_members_employee" FOREIGN KEY ("employee_id") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_investigation_members_role" CHECK (role IN ('investigator', 'subject_expert', 'union_representative', 'observer'))
+);
+CREATE INDEX IF NOT EXISTS "idx_investigation_members_investigation_id" ON "investigation_members" ("investigation_id");
+CREATE INDEX IF NOT EXISTS "idx_investigation_members_employee_id" ON "investigation_members" ("employee_id");
+CREATE INDEX IF NOT EXISTS "idx_investigation_members_left_at" ON "investigation_members" ("left_at");
+
+-- An employee is on a team at most once at a time
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_investigation_members_current" ON "investigation_members" ("investigation_id", "employee_id") WHERE "left_at" IS NULL;
@@ -0,0 +1,10 @@
+ALTER TABLE "investigation_interviews" DROP CONSTRAINT IF EXISTS "fk_investigation_interviews_evidence";
+ALTER TABLE "investigation_interviews" DROP CONSTRAINT IF EXISTS "fk_investigation_interviews_template";
+ALTER TABLE "investigation_interviews" DROP COLUMN IF EXISTS "evidence_id";
+ALTER TABLE "investigation_interviews" DROP COLUMN IF EXISTS "statement_hash";
+ALTER TABLE "investigation_interviews" DROP COLUMN IF EXISTS "acknowledged_at";
+ALTER TABLE "investigation_interviews" DROP COLUMN IF EXISTS "template_id";
+
+DROP TABLE IF EXISTS "interview_answers";
+DROP TABLE IF EXISTS "interview_questions";
+DROP TABLE IF EXISTS "interview_templates";
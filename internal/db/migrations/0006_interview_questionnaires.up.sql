@@ -0,0 +1,59 @@
+-- Interview questionnaires: question templates per incident type, the
+-- questions asked in each interview with their answers, the interviewee's
+-- acknowledgement of the statement and the testimony evidence recorded
+-- from it.
+
+CREATE TABLE IF NOT EXISTS "interview_templates" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" varchar(100) NOT NULL,
+    "incident_type" varchar(50) NOT NULL,
+    "description" text,
+    "is_active" boolean NOT NULL DEFAULT true,
+    "created_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_interview_templates_creator" FOREIGN KEY ("created_by") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_interview_templates_incident_type" CHECK (incident_type IN ('injury', 'near_miss', 'property_damage', 'environmental', 'security'))
+);
+CREATE INDEX IF NOT EXISTS "idx_interview_templates_incident_type" ON "interview_templates" ("incident_type");
+
+CREATE TABLE IF NOT EXISTS "interview_questions" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "template_id" uuid NOT NULL,
+    "position" bigint NOT NULL DEFAULT 0,
+    "text" text NOT NULL,
+    "guidance" text,
+    "required" boolean NOT NULL DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_interview_templates_questions" FOREIGN KEY ("template_id") REFERENCES "interview_templates"("id") ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_interview_questions_template_id" ON "interview_questions" ("template_id");
+
+CREATE TABLE IF NOT EXISTS "interview_answers" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "interview_id" uuid NOT NULL,
+    "question_id" uuid,
+    "position" bigint NOT NULL DEFAULT 0,
+    "question" text NOT NULL,
+    "required" boolean NOT NULL DEFAULT false,
+    "answer" text,
+    "recorded_by" uuid,
+    "recorded_at" timestamptz,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    "updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_interview_answers_interview" FOREIGN KEY ("interview_id") REFERENCES "investigation_interviews"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_interview_answers_template_question" FOREIGN KEY ("question_id") REFERENCES "interview_questions"("id") ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS "idx_interview_answers_interview_id" ON "interview_answers" ("interview_id");
+
+ALTER TABLE "investigation_interviews" ADD COLUMN IF NOT EXISTS "template_id" uuid;
+ALTER TABLE "investigation_interviews" ADD COLUMN IF NOT EXISTS "acknowledged_at" timestamptz;
+ALTER TABLE "investigation_interviews" ADD COLUMN IF NOT EXISTS "statement_hash" varchar(64);
+ALTER TABLE "investigation_interviews" ADD COLUMN IF NOT EXISTS "evidence_id" uuid;
+
+ALTER TABLE "investigation_interviews" DROP CONSTRAINT IF EXISTS "fk_investigation_interviews_template";
+ALTER TABLE "investigation_interviews" ADD CONSTRAINT "fk_investigation_interviews_template" FOREIGN KEY ("template_id") REFERENCES "interview_templates"("id") ON DELETE SET NULL;
+ALTER TABLE "investigation_interviews" DROP CONSTRAINT IF EXISTS "fk_investigation_interviews_evidence";
+ALTER TABLE "investigation_interviews" ADD CONSTRAINT "fk_investigation_interviews_evidence" FOREIGN KEY ("evidence_id") REFERENCES "investigation_evidences"("id") ON DELETE SET NULL;
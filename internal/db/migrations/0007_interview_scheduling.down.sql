@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "interview_schedule_changes";
+
+DROP INDEX IF EXISTS "idx_investigation_interviews_interviewee_scheduled";
+ALTER TABLE "investigation_interviews" DROP COLUMN IF EXISTS "sequence";
+ALTER TABLE "investigation_interviews" DROP COLUMN IF EXISTS "duration_minutes";
@@ -0,0 +1,34 @@
+-- Interview scheduling: how long interviews are booked for, the sequence
+-- of their calendar invites and the history of when and where they were
+-- scheduled.
+
+ALTER TABLE "investigation_interviews" ADD COLUMN IF NOT EXISTS "duration_minutes" bigint NOT NULL DEFAULT 60;
+ALTER TABLE "investigation_interviews" ADD COLUMN IF NOT EXISTS "sequence" bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_investigation_interviews_interviewee_scheduled" ON "investigation_interviews" ("interviewee_id", "scheduled_for");
+
+CREATE TABLE IF NOT EXISTS "interview_schedule_changes" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "interview_id" uuid NOT NULL,
+    "action" varchar(30) NOT NULL,
+    "previous_scheduled_for" timestamptz,
+    "previous_location" varchar(255),
+    "scheduled_for" timestamptz NOT NULL,
+    "duration_minutes" bigint NOT NULL,
+    "location" varchar(255),
+    "reason" text,
+    "changed_by" uuid NOT NULL,
+    "created_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_investigation_interviews_schedule_changes" FOREIGN KEY ("interview_id") REFERENCES "investigation_interviews"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_interview_schedule_changes_changer" FOREIGN KEY ("changed_by") REFERENCES "employees"("id"),
+    CONSTRAINT "chk_interview_schedule_changes_action" CHECK (action IN ('scheduled', 'rescheduled', 'cancelled'))
+);
+CREATE INDEX IF NOT EXISTS "idx_interview_schedule_changes_interview_id" ON "interview_schedule_changes" ("interview_id");
+
+-- Interviews scheduled before the history was kept start it with their
+-- current schedule, recorded against the lead investigator
+INSERT INTO "interview_schedule_changes" ("interview_id", "action", "scheduled_for", "duration_minutes", "location", "changed_by", "created_at")
+SELECT i."id", 'scheduled', i."scheduled_for", i."duration_minutes", i."location", inv."lead_investigator_id", i."created_at"
+FROM "investigation_interviews" i
+JOIN "investigations" inv ON inv."id" = i."investigation_id"
+WHERE NOT EXISTS (SELECT 1 FROM "interview_schedule_changes" c WHERE c."interview_id" = i."id");
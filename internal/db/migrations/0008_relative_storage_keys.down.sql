@@ -0,0 +1,6 @@
+-- Put the local upload directory back in front of the keys of the files
+-- the code before pluggable storage wrote.
+
+UPDATE "incident_attachments" SET "storage_path" = 'uploads/' || "storage_path" WHERE "storage_path" LIKE 'incidents/%';
+UPDATE "vpc_attachments" SET "storage_path" = 'uploads/' || "storage_path" WHERE "storage_path" LIKE 'vpcs/%';
+UPDATE "action_evidences" SET "file_url" = 'uploads/' || "file_url" WHERE "file_url" LIKE 'actions/%';
@@ -0,0 +1,11 @@
+-- Storage keys are relative to the storage root. Files uploaded before
+-- pluggable storage were recorded with the local upload directory in front,
+-- e.g. uploads/incidents/<id>/<name>; strip it so those files resolve to
+-- the same place under the local driver, and to the same key once copied
+-- into an S3 bucket.
+
+UPDATE "incident_attachments" SET "storage_path" = regexp_replace("storage_path", '^(\./)?uploads/', '') WHERE "storage_path" ~ '^(\./)?uploads/';
+UPDATE "vpc_attachments" SET "storage_path" = regexp_replace("storage_path", '^(\./)?uploads/', '') WHERE "storage_path" ~ '^(\./)?uploads/';
+UPDATE "hazard_attachments" SET "storage_path" = regexp_replace("storage_path", '^(\./)?uploads/', '') WHERE "storage_path" ~ '^(\./)?uploads/';
+UPDATE "investigation_evidences" SET "storage_path" = regexp_replace("storage_path", '^(\./)?uploads/', '') WHERE "storage_path" ~ '^(\./)?uploads/';
+UPDATE "action_evidences" SET "file_url" = regexp_replace("file_url", '^(\./)?uploads/', '') WHERE "file_url" ~ '^(\./)?uploads/';
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/models"
+	"gorm.io/gorm"
+)
+
+// employeeRoleCheck is the check constraint that limited employees to the
+// four built-in roles before roles moved into their own table
+const employeeRoleCheck = "chk_employees_role"
+
+// SeedRoles creates the built-in roles and permissions from
+// middleware.RolePermissions. Roles that already exist are left alone so
+// edits made at runtime survive a restart; a permission that is new to the
+// database is granted to the built-in roles that have it by default.
+func SeedRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissions := map[string]*models.Permission{}
+		added := map[string]bool{}
+
+		for _, name := range defaultPermissionNames() {
+			permission := models.Permission{Name: name}
+			created, err := findOrCreate(tx, &permission, name)
+			if err != nil {
+				return fmt.Errorf("failed to seed permission %s: %w", name, err)
+			}
+			permissions[name] = &permission
+			added[name] = created
+		}
+
+		for roleName, defaults := range middleware.RolePermissions {
+			role := models.Role{Name: roleName, IsSystem: true}
+			created, err := findOrCreate(tx, &role, roleName)
+			if err != nil {
+				return fmt.Errorf("failed to seed role %s: %w", roleName, err)
+			}
+
+			var grants []*models.Permission
+			for _, name := range defaults {
+				if created || added[name] {
+					grants = append(grants, permissions[name])
+				}
+			}
+			if len(grants) == 0 {
+				continue
+			}
+			if err := tx.Model(&role).Association("Permissions").Append(grants); err != nil {
+				return fmt.Errorf("failed to seed permissions of role %s: %w", roleName, err)
+			}
+		}
+		return nil
+	})
+}
+
+// findOrCreate loads the row with the given name into value, or inserts value
+// when there is none, and reports whether it inserted
+func findOrCreate(tx *gorm.DB, value interface{}, name string) (bool, error) {
+	result := tx.Where("name = ?", name).Limit(1).Find(value)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return false, nil
+	}
+	return true, tx.Create(value).Error
+}
+
+func defaultPermissionNames() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, permissions := range middleware.RolePermissions {
+		for _, name := range permissions {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/audit"
+)
+
+// AuditContext records the caller's IP address for the audit log on every
+// request. AuthMiddleware adds the user once the request is authenticated.
+func AuditContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		setAuditActor(c, uuid.Nil)
+		return c.Next()
+	}
+}
+
+// setAuditActor makes the actor available both through c.Context(), which
+// services receive as their context, and through c.UserContext()
+func setAuditActor(c *fiber.Ctx, userID uuid.UUID) {
+	actor := audit.Actor{UserID: userID, IPAddress: c.IP()}
+	c.Locals(audit.ActorKey, actor)
+	c.SetUserContext(audit.WithActor(c.UserContext(), actor))
+}
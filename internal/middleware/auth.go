@@ -4,41 +4,97 @@ import (
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/services/token"
 )
 
+// SessionValidator reports whether the session an access token was issued for is still active
+type SessionValidator interface {
+	ValidateSession(sessionID string) error
+}
+
+var (
+	jwtManager       *token.JWTManager
+	sessionValidator SessionValidator
+)
+
+// SetJWTManager configures the token verifier used by AuthMiddleware.
+// It must be called once during server startup before any request is served.
+func SetJWTManager(manager *token.JWTManager) {
+	jwtManager = manager
+}
+
+// SetSessionValidator makes AuthMiddleware reject tokens whose session has been revoked
+func SetSessionValidator(validator SessionValidator) {
+	sessionValidator = validator
+}
+
 func AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		var tokenString string
-		authHeader := c.Get("Authorization")
-
-		if authHeader != "" {
-			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
-		} else {
-			// Fallback to cookie
-			tokenString = c.Cookies("auth-token")
-		}
-
+		tokenString := accessTokenFromRequest(c)
 		if tokenString == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Unauthorized: Missing token",
 			})
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte("your-secret-key"), nil
+		return authenticate(c, tokenString)
+	}
+}
+
+// OptionalAuthMiddleware authenticates the request when it carries a token
+// and lets anonymous requests through. Handlers behind it must cope with a
+// missing userID, e.g. the MFA enrollment endpoints that also accept the
+// intermediate MFA token during login.
+func OptionalAuthMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString := accessTokenFromRequest(c)
+		if tokenString == "" {
+			return c.Next()
+		}
+
+		return authenticate(c, tokenString)
+	}
+}
+
+func accessTokenFromRequest(c *fiber.Ctx) string {
+	authHeader := c.Get("Authorization")
+	if authHeader != "" {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	// Fallback to cookie
+	return c.Cookies("auth-token")
+}
+
+func authenticate(c *fiber.Ctx, tokenString string) error {
+	if jwtManager == nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Internal Server Error: Authentication is not configured",
 		})
+	}
+
+	claims, err := jwtManager.ParseAccessToken(tokenString)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized: Invalid token",
+		})
+	}
 
-		if err != nil || !token.Valid {
+	if sessionValidator != nil {
+		if err := sessionValidator.ValidateSession(claims.SessionID); err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Unauthorized: Invalid token",
+				"error": "Unauthorized: Session has been revoked or has expired",
 			})
 		}
+	}
 
-		claims := token.Claims.(jwt.MapClaims)
-		c.Locals("userID", claims["userID"])
-		c.Locals("role", claims["role"])
+	c.Locals("userID", claims.UserID)
+	c.Locals("role", claims.Role)
+	c.Locals("sessionID", claims.SessionID)
 
-		return c.Next()
-	}
+	// Changes made while serving this request are attributed to this user
+	userID, _ := uuid.Parse(claims.UserID)
+	setAuditActor(c, userID)
+
+	return c.Next()
 }
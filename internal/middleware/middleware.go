@@ -1,21 +1,41 @@
 package middleware
 
 const (
-	RoleAdmin        = "admin"
+	RoleAdmin         = "admin"
 	RoleSafetyOfficer = "safety_officer"
-	RoleManager      = "manager"
-	RoleEmployee     = "employee"
+	RoleManager       = "manager"
+	RoleEmployee      = "employee"
 )
 
 // Permission constants
 const (
-	PermissionReadIncidents      = "read:incidents"
-	PermissionCreateIncidents    = "create:incidents"
-	PermissionManageIncidents    = "manage:incidents"
-	PermissionAssignTasks        = "assign:tasks"
-	PermissionGenerateReports    = "generate:reports"
-	PermissionManageUsers        = "manage:users"
-	PermissionManageRoles        = "manage:roles"
+	PermissionReadIncidents   = "read:incidents"
+	PermissionCreateIncidents = "create:incidents"
+	PermissionManageIncidents = "manage:incidents"
+	PermissionAssignTasks     = "assign:tasks"
+	PermissionGenerateReports = "generate:reports"
+	PermissionManageUsers     = "manage:users"
+	PermissionManageRoles     = "manage:roles"
+
+	PermissionReadInvestigations   = "read:investigations"
+	PermissionManageInvestigations = "manage:investigations"
+	PermissionReadHazards          = "read:hazards"
+	PermissionCreateHazards        = "create:hazards"
+	PermissionManageHazards        = "manage:hazards"
+	PermissionReadVPCs             = "read:vpcs"
+	PermissionCreateVPCs           = "create:vpcs"
+	PermissionManageVPCs           = "manage:vpcs"
+	PermissionReadReports          = "read:reports"
+	PermissionReadEmployees        = "read:employees"
+	PermissionManageEmployees      = "manage:employees"
+	PermissionReadAudit            = "read:audit"
+
+	// Record-level access to incidents. Without either of these a role only
+	// sees the incidents its holder reported or is assigned to.
+	PermissionReadTeamIncidents = "read:incidents:team"
+	PermissionReadAllIncidents  = "read:incidents:all"
+	// PermissionReadMedical reveals medical details such as the injury type
+	PermissionReadMedical = "read:medical"
 )
 
 // RolePermissions defines what permissions each role has
@@ -28,6 +48,20 @@ var RolePermissions = map[string][]string{
 		PermissionGenerateReports,
 		PermissionManageUsers,
 		PermissionManageRoles,
+		PermissionReadInvestigations,
+		PermissionManageInvestigations,
+		PermissionReadHazards,
+		PermissionCreateHazards,
+		PermissionManageHazards,
+		PermissionReadVPCs,
+		PermissionCreateVPCs,
+		PermissionManageVPCs,
+		PermissionReadReports,
+		PermissionReadEmployees,
+		PermissionManageEmployees,
+		PermissionReadAudit,
+		PermissionReadAllIncidents,
+		PermissionReadMedical,
 	},
 	RoleSafetyOfficer: {
 		PermissionReadIncidents,
@@ -35,15 +69,43 @@ var RolePermissions = map[string][]string{
 		PermissionManageIncidents,
 		PermissionAssignTasks,
 		PermissionGenerateReports,
+		PermissionReadInvestigations,
+		PermissionManageInvestigations,
+		PermissionReadHazards,
+		PermissionCreateHazards,
+		PermissionManageHazards,
+		PermissionReadVPCs,
+		PermissionCreateVPCs,
+		PermissionManageVPCs,
+		PermissionReadReports,
+		PermissionReadEmployees,
+		PermissionReadAudit,
+		PermissionReadAllIncidents,
+		PermissionReadMedical,
 	},
 	RoleManager: {
 		PermissionReadIncidents,
 		PermissionCreateIncidents,
 		PermissionAssignTasks,
 		PermissionGenerateReports,
+		PermissionReadInvestigations,
+		PermissionManageInvestigations,
+		PermissionReadHazards,
+		PermissionCreateHazards,
+		PermissionReadVPCs,
+		PermissionCreateVPCs,
+		PermissionReadReports,
+		PermissionReadEmployees,
+		PermissionReadTeamIncidents,
 	},
 	RoleEmployee: {
 		PermissionReadIncidents,
 		PermissionCreateIncidents,
+		PermissionReadInvestigations,
+		PermissionReadHazards,
+		PermissionCreateHazards,
+		PermissionReadVPCs,
+		PermissionCreateVPCs,
+		PermissionReadEmployees,
 	},
-}
\ No newline at end of file
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// PermissionSource loads the permissions of every role from storage
+type PermissionSource func(ctx context.Context) (map[string][]string, error)
+
+// PermissionStore caches the role permissions read from the database. The
+// role service invalidates it after every change; the TTL bounds how long
+// other server instances keep serving a stale copy.
+type PermissionStore struct {
+	source PermissionSource
+	ttl    time.Duration
+
+	mu       sync.RWMutex
+	roles    map[string]map[string]bool
+	loadedAt time.Time
+}
+
+func NewPermissionStore(source PermissionSource, ttl time.Duration) *PermissionStore {
+	return &PermissionStore{source: source, ttl: ttl}
+}
+
+// permissionStore backs HasPermission once the server has set it up. Until
+// then the compiled-in RolePermissions apply.
+var permissionStore *PermissionStore
+
+// UsePermissionStore makes HasPermission and PermissionMiddleware read from store
+func UsePermissionStore(store *PermissionStore) {
+	permissionStore = store
+}
+
+// HasPermission reports whether the role is granted the permission
+func (s *PermissionStore) HasPermission(role, permission string) bool {
+	return s.current()[role][permission]
+}
+
+// Invalidate drops the cached permissions so the next check reloads them
+func (s *PermissionStore) Invalidate() {
+	s.mu.Lock()
+	s.roles = nil
+	s.mu.Unlock()
+}
+
+func (s *PermissionStore) current() map[string]map[string]bool {
+	s.mu.RLock()
+	roles, loadedAt := s.roles, s.loadedAt
+	s.mu.RUnlock()
+
+	if roles != nil && time.Since(loadedAt) < s.ttl {
+		return roles
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may have reloaded while we waited for the lock
+	if s.roles != nil && time.Since(s.loadedAt) < s.ttl {
+		return s.roles
+	}
+
+	loaded, err := s.source(context.Background())
+	if err != nil {
+		utils.LogError("Failed to load role permissions", map[string]interface{}{
+			"error": err.Error(),
+		})
+		// Keep serving the last known permissions rather than locking everyone out
+		if s.roles != nil {
+			return s.roles
+		}
+		return permissionSets(RolePermissions)
+	}
+
+	s.roles = permissionSets(loaded)
+	s.loadedAt = time.Now()
+	return s.roles
+}
+
+func permissionSets(rolePermissions map[string][]string) map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(rolePermissions))
+	for role, permissions := range rolePermissions {
+		set := make(map[string]bool, len(permissions))
+		for _, permission := range permissions {
+			set[permission] = true
+		}
+		sets[role] = set
+	}
+	return sets
+}
@@ -9,16 +9,22 @@ import (
 	"github.com/google/uuid"
 )
 
+// AuditLog records a before/after snapshot of every change to an audited entity.
+// Rows form a hash chain: Hash covers the row's content and PrevHash, so
+// editing or removing any row breaks every link after it.
 type AuditLog struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	TableName string    `gorm:"size:50;not null"`
-	RecordID  uuid.UUID `gorm:"type:uuid;not null"`
-	Action    string    `gorm:"size:10;not null;check:action IN ('INSERT', 'UPDATE', 'DELETE')"`
-	OldData   JSONB     `gorm:"type:jsonb"`
-	NewData   JSONB     `gorm:"type:jsonb"`
-	UserID    uuid.UUID `gorm:"type:uuid"`
-	IPAddress string    `gorm:"type:inet"`
-	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Sequence  int64      `gorm:"default:0;index"`
+	TableName string     `gorm:"size:50;not null;index:idx_audit_logs_record"`
+	RecordID  uuid.UUID  `gorm:"type:uuid;not null;index:idx_audit_logs_record"`
+	Action    string     `gorm:"size:10;not null;check:action IN ('INSERT', 'UPDATE', 'DELETE')"`
+	OldData   JSONB      `gorm:"type:jsonb"`
+	NewData   JSONB      `gorm:"type:jsonb"`
+	UserID    *uuid.UUID `gorm:"type:uuid;index"`
+	IPAddress *string    `gorm:"type:inet"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP;index"`
+	PrevHash  string     `gorm:"size:64"`
+	Hash      string     `gorm:"size:64"`
 }
 
 // JSONB is a custom type for handling JSONB fields in GORM.
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HazardAttachment is a photo or document attached to a hazard report
+type HazardAttachment struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HazardID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	FileName    string    `gorm:"size:255;not null"`
+	FileType    string    `gorm:"size:100;not null"`
+	FileSize    int       `gorm:"not null"`
+	StoragePath string    `gorm:"size:512;not null"`
+	UploadedBy  uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+
+	ImageMetadata `gorm:"embedded"`
+
+	// Relationships
+	Hazard   Hazard   `gorm:"foreignKey:HazardID;constraint:OnDelete:CASCADE;"`
+	Uploader Employee `gorm:"foreignKey:UploadedBy"`
+}
+
+// HazardAttachmentResponse represents the API response structure
+type HazardAttachmentResponse struct {
+	ID          uuid.UUID `json:"id"`
+	FileName    string    `json:"fileName"`
+	FileType    string    `json:"fileType"`
+	FileSize    int       `json:"fileSize"`
+	DownloadURL string    `json:"downloadUrl"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Uploader    string    `json:"uploader,omitempty"`
+
+	ImageMetadataResponse
+}
+
+// ToResponse converts HazardAttachment to HazardAttachmentResponse
+func (ha *HazardAttachment) ToResponse() HazardAttachmentResponse {
+	attachmentURL := fmt.Sprintf("/api/v1/hazards/%s/attachments/%s", ha.HazardID, ha.ID)
+	uploader := ""
+	if ha.Uploader.ID != uuid.Nil {
+		uploader = ha.Uploader.FirstName + " " + ha.Uploader.LastName
+	}
+	return HazardAttachmentResponse{
+		ID:          ha.ID,
+		FileName:    ha.FileName,
+		FileType:    ha.FileType,
+		FileSize:    ha.FileSize,
+		DownloadURL: attachmentURL + "/download",
+		CreatedAt:   ha.CreatedAt,
+		Uploader:    uploader,
+
+		ImageMetadataResponse: ha.ImageMetadata.ToResponse(attachmentURL),
+	}
+}
+
+func ToHazardAttachmentResponses(attachments []HazardAttachment) []HazardAttachmentResponse {
+	responses := make([]HazardAttachmentResponse, len(attachments))
+	for i, attachment := range attachments {
+		responses[i] = attachment.ToResponse()
+	}
+	return responses
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// ImageMetadata is what is recorded about a photo attachment: its resized
+// copies, its size once upright, and where and when it was taken according
+// to the camera. The fields are empty for other files.
+type ImageMetadata struct {
+	ThumbnailPath string `gorm:"size:512"`
+	PreviewPath   string `gorm:"size:512"`
+	ImageWidth    int
+	ImageHeight   int
+	CapturedAt    *time.Time
+	Latitude      *float64
+	Longitude     *float64
+}
+
+// ImageMetadataResponse represents the photo fields of an attachment
+// response
+type ImageMetadataResponse struct {
+	ThumbnailURL string       `json:"thumbnailUrl,omitempty"`
+	PreviewURL   string       `json:"previewUrl,omitempty"`
+	Width        int          `json:"width,omitempty"`
+	Height       int          `json:"height,omitempty"`
+	CapturedAt   *time.Time   `json:"capturedAt,omitempty"`
+	Location     *GeoLocation `json:"location,omitempty"`
+}
+
+// GeoLocation is a position in decimal degrees
+type GeoLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ToResponse converts the metadata of the attachment served under
+// attachmentURL, such as /api/v1/incidents/{id}/attachments/{id}
+func (im ImageMetadata) ToResponse(attachmentURL string) ImageMetadataResponse {
+	response := ImageMetadataResponse{
+		Width:      im.ImageWidth,
+		Height:     im.ImageHeight,
+		CapturedAt: im.CapturedAt,
+	}
+	if im.ThumbnailPath != "" {
+		response.ThumbnailURL = attachmentURL + "/thumbnail"
+	}
+	if im.PreviewPath != "" {
+		response.PreviewURL = attachmentURL + "/preview"
+	}
+	if im.Latitude != nil && im.Longitude != nil {
+		response.Location = &GeoLocation{Latitude: *im.Latitude, Longitude: *im.Longitude}
+	}
+	return response
+}
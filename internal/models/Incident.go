@@ -2,17 +2,34 @@ package models
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Incident statuses. Which changes between them are allowed is decided by
+// the incident workflow.
+const (
+	IncidentStatusNew            = "new"
+	IncidentStatusInvestigating  = "investigating"
+	IncidentStatusActionRequired = "action_required"
+	IncidentStatusResolved       = "resolved"
+	IncidentStatusClosed         = "closed"
+)
+
+// IncidentStatuses lists every incident status, in workflow order
+var IncidentStatuses = []string{
+	IncidentStatusNew,
+	IncidentStatusInvestigating,
+	IncidentStatusActionRequired,
+	IncidentStatusResolved,
+	IncidentStatusClosed,
+}
+
 type Incident struct {
 	ID                      uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	ReferenceNumber         string     `gorm:"size:50;not null;"`
+	ReferenceNumber         string     `gorm:"size:50;not null;uniqueIndex"`
 	UserIncidentID          string     `gorm:"type:text;"`
 	Type                    string     `gorm:"size:50;not null;check:type IN ('injury', 'near_miss', 'property_damage', 'environmental', 'security')"`
 	InjuryType              string     `gorm:"size:50"`
@@ -46,7 +63,7 @@ type IncidentSummary struct{}
 func (incident *Incident) BeforeCreate(tx *gorm.DB) error {
 	// Generate incident number if not provided
 	if incident.ReferenceNumber == "" {
-		incidentNumber, err := generateNextIncidentNumber(tx)
+		incidentNumber, err := nextReferenceNumber(tx, ReferenceIncident)
 		if err != nil {
 			return fmt.Errorf("failed to generate incident number: %w", err)
 		}
@@ -56,34 +73,6 @@ func (incident *Incident) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// generateNextIncidentNumber generates the next sequential incident number
-func generateNextIncidentNumber(tx *gorm.DB) (string, error) {
-	var lastIncident Incident
-
-	// Find the incident with the highest number
-	err := tx.Where("reference_number LIKE 'INC%'").
-		Order("LENGTH(reference_number) DESC, reference_number DESC").
-		First(&lastIncident).Error
-
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return "", fmt.Errorf("failed to query last incident: %w", err)
-	}
-
-	nextNumber := 1
-
-	if err != gorm.ErrRecordNotFound {
-		// Extract the numeric part from incident number
-		numberStr := strings.TrimPrefix(lastIncident.ReferenceNumber, "INC")
-		// Remove leading zeros and convert to int
-		if num, parseErr := strconv.Atoi(numberStr); parseErr == nil {
-			nextNumber = num + 1
-		}
-	}
-
-	// Format with leading zeros (INC00001, INC00002, etc.)
-	return fmt.Sprintf("INC%05d", nextNumber), nil
-}
-
 // TableName specifies the table name for GORM
 func (Incident) TableName() string {
 	return "incidents"
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,8 @@ type IncidentAttachment struct {
 	UploadedBy  uuid.UUID `gorm:"type:uuid;not null"`
 	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 
+	ImageMetadata `gorm:"embedded"`
+
 	// Relationships
 	Incident Incident `gorm:"foreignKey:IncidentID"`
 	Uploader Employee `gorm:"foreignKey:UploadedBy"`
@@ -31,6 +34,8 @@ type IncidentAttachmentResponse struct {
 	FileSize    int       `json:"fileSize"`
 	CreatedAt   time.Time `json:"createdAt"`
 	Uploader    string    `json:"uploader"`
+
+	ImageMetadataResponse
 }
 
 // ToResponse converts IncidentAttachment to IncidentAttachmentResponse
@@ -43,6 +48,9 @@ func (ia *IncidentAttachment) ToResponse() IncidentAttachmentResponse {
 		FileSize:    ia.FileSize,
 		CreatedAt:   ia.CreatedAt,
 		Uploader:    ia.Uploader.FirstName + " " + ia.Uploader.LastName,
+
+		ImageMetadataResponse: ia.ImageMetadata.ToResponse(
+			fmt.Sprintf("/api/v1/incidents/%s/attachments/%s", ia.IncidentID, ia.ID)),
 	}
 }
 
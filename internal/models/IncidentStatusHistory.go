@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IncidentStatusHistory records one status change of an incident and the
+// workflow transition that made it
+type IncidentStatusHistory struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	IncidentID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Transition string    `gorm:"size:50;not null"`
+	FromStatus string    `gorm:"size:30;not null"`
+	ToStatus   string    `gorm:"size:30;not null"`
+	// ChangedBy is the employee who made the change, or nil when the
+	// system made it, e.g. on creating a corrective action
+	ChangedBy *uuid.UUID `gorm:"type:uuid"`
+	Note      string     `gorm:"type:text"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Incident Incident  `gorm:"foreignKey:IncidentID"`
+	Changer  *Employee `gorm:"foreignKey:ChangedBy"`
+}
+
+// TableName specifies the table name for GORM
+func (IncidentStatusHistory) TableName() string {
+	return "incident_status_history"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Changes to the schedule of an interview
+const (
+	ScheduleActionScheduled   = "scheduled"
+	ScheduleActionRescheduled = "rescheduled"
+	ScheduleActionCancelled   = "cancelled"
+)
+
+// InterviewScheduleChange records an interview being scheduled, rescheduled
+// or cancelled, so the times it was booked for are kept when it moves
+type InterviewScheduleChange struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	InterviewID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Action      string    `gorm:"size:30;not null;check:action IN ('scheduled', 'rescheduled', 'cancelled')"`
+	// PreviousScheduledFor and PreviousLocation are empty when the
+	// interview is first scheduled
+	PreviousScheduledFor *time.Time
+	PreviousLocation     string    `gorm:"size:255"`
+	ScheduledFor         time.Time `gorm:"not null"`
+	DurationMinutes      int       `gorm:"not null"`
+	Location             string    `gorm:"size:255"`
+	Reason               string    `gorm:"type:text"`
+	ChangedBy            uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt            time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Interview InvestigationInterview `gorm:"foreignKey:InterviewID;constraint:OnDelete:CASCADE;"`
+	Changer   Employee               `gorm:"foreignKey:ChangedBy"`
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InterviewTemplate is a reusable questionnaire for the interviews of an
+// incident type
+type InterviewTemplate struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Name         string    `gorm:"size:100;not null"`
+	IncidentType string    `gorm:"size:50;not null;index;check:incident_type IN ('injury', 'near_miss', 'property_damage', 'environmental', 'security')"`
+	Description  string    `gorm:"type:text"`
+	IsActive     bool      `gorm:"not null;default:true"`
+	CreatedBy    uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Creator   Employee            `gorm:"foreignKey:CreatedBy"`
+	Questions []InterviewQuestion `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE;"`
+}
+
+// InterviewQuestion is a question of a template, asked in Position order
+type InterviewQuestion struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	TemplateID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Position   int       `gorm:"not null;default:0"`
+	Text       string    `gorm:"type:text;not null"`
+	// Guidance helps the interviewer ask the question and is not part of
+	// the transcript
+	Guidance string `gorm:"type:text"`
+	Required bool   `gorm:"not null;default:false"`
+}
+
+// InterviewAnswer is a question asked in an interview and the answer given.
+// The question is copied from the template when it is applied, so editing
+// the template later does not change transcripts; follow-up questions have
+// no template question.
+type InterviewAnswer struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	InterviewID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	QuestionID  *uuid.UUID `gorm:"type:uuid"`
+	Position    int        `gorm:"not null;default:0"`
+	Question    string     `gorm:"type:text;not null"`
+	Required    bool       `gorm:"not null;default:false"`
+	Answer      string     `gorm:"type:text"`
+	RecordedBy  *uuid.UUID `gorm:"type:uuid"`
+	RecordedAt  *time.Time
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Interview        InvestigationInterview `gorm:"foreignKey:InterviewID;constraint:OnDelete:CASCADE;"`
+	TemplateQuestion *InterviewQuestion     `gorm:"foreignKey:QuestionID;constraint:OnDelete:SET NULL;"`
+}
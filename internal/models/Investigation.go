@@ -6,6 +6,22 @@ import (
 	"github.com/google/uuid"
 )
 
+// Investigation statuses. An investigation goes to pending_review when its
+// lead submits it, to completed once every reviewer approved it, and to
+// reopened when a reviewer rejects it.
+const (
+	InvestigationStatusInProgress    = "in_progress"
+	InvestigationStatusPendingReview = "pending_review"
+	InvestigationStatusCompleted     = "completed"
+	InvestigationStatusReopened      = "reopened"
+)
+
+// Locked reports whether the investigation is under review or signed off,
+// so its findings may no longer change
+func (i *Investigation) Locked() bool {
+	return i.Status == InvestigationStatusPendingReview || i.Status == InvestigationStatusCompleted
+}
+
 type Investigation struct {
 	ID                   uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
 	IncidentID           uuid.UUID `gorm:"type:uuid;not null;unique"`
@@ -18,9 +34,14 @@ type Investigation struct {
 	Recommendations      string    `gorm:"type:text"`
 	StartedAt            time.Time `gorm:"not null"`
 	CompletedAt          *time.Time
-	Status               string    `gorm:"size:30;not null;default:'in_progress';check:status IN ('in_progress', 'pending_review', 'completed', 'reopened')"`
-	CreatedAt            time.Time `gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt            time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	Status               string `gorm:"size:30;not null;default:'in_progress';check:status IN ('in_progress', 'pending_review', 'completed', 'reopened')"`
+	// ReviewRound counts the submissions for review, and ReviewStep is the
+	// step of the review chain awaiting sign-off in the current round
+	ReviewRound int `gorm:"not null;default:0"`
+	ReviewStep  int `gorm:"not null;default:0"`
+	SubmittedAt *time.Time
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 
 	// Relationships
 	Incident         Incident `gorm:"foreignKey:IncidentID"`
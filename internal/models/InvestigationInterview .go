@@ -1,6 +1,10 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,16 +16,87 @@ type InvestigationInterview struct {
 	InvestigationID uuid.UUID `gorm:"type:uuid;not null"`
 	IntervieweeID   uuid.UUID `gorm:"type:uuid;not null"`
 	ScheduledFor    time.Time `gorm:"not null"`
-	Status          string    `gorm:"size:30;not null;default:'scheduled';check:status IN ('scheduled', 'completed', 'cancelled', 'rescheduled')"`
-	Notes           string    `gorm:"type:text"`
-	Location        string    `gorm:"size:255"`
+	// DurationMinutes is how long the interview is booked for; interviews
+	// of the same people may not overlap
+	DurationMinutes int    `gorm:"not null;default:60"`
+	Status          string `gorm:"size:30;not null;default:'scheduled';check:status IN ('scheduled', 'completed', 'cancelled', 'rescheduled')"`
+	Notes           string `gorm:"type:text"`
+	Location        string `gorm:"size:255"`
 	CompletedAt     time.Time
-	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	// TemplateID is the questionnaire the questions were taken from
+	TemplateID *uuid.UUID `gorm:"type:uuid"`
+	// AcknowledgedAt is when the interviewee acknowledged the statement;
+	// StatementHash is the hash of the transcript they acknowledged
+	AcknowledgedAt *time.Time
+	StatementHash  string `gorm:"size:64"`
+	// EvidenceID is the testimony evidence recorded from the interview
+	EvidenceID *uuid.UUID `gorm:"type:uuid"`
+	// Sequence counts the changes to the calendar invite, which is
+	// rescheduled or cancelled by sending it again with a higher sequence
+	Sequence  int       `gorm:"not null;default:0"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 
 	// Relationships
-	Investigation Investigation `gorm:"foreignKey:InvestigationID"`
-	Interviewee   Employee      `gorm:"foreignKey:IntervieweeID"`
+	Investigation Investigation          `gorm:"foreignKey:InvestigationID"`
+	Interviewee   Employee               `gorm:"foreignKey:IntervieweeID"`
+	Template      *InterviewTemplate     `gorm:"foreignKey:TemplateID;constraint:OnDelete:SET NULL;"`
+	Evidence      *InvestigationEvidence `gorm:"foreignKey:EvidenceID;constraint:OnDelete:SET NULL;"`
+	Answers       []InterviewAnswer      `gorm:"foreignKey:InterviewID"`
+	// ScheduleChanges is the history of when and where the interview was
+	// scheduled
+	ScheduleChanges []InterviewScheduleChange `gorm:"foreignKey:InterviewID"`
+}
+
+// Interview statuses that hold a place in the calendar
+var InterviewBookedStatuses = []string{"scheduled", "rescheduled"}
+
+// EndsAt is when the interview is booked until
+func (i *InvestigationInterview) EndsAt() time.Time {
+	return i.ScheduledFor.Add(time.Duration(i.DurationMinutes) * time.Minute)
+}
+
+// IsBooked reports whether the interview still holds its place in the
+// calendar
+func (i *InvestigationInterview) IsBooked() bool {
+	for _, status := range InterviewBookedStatuses {
+		if i.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// CalendarUID identifies the interview in calendars. It stays the same when
+// the interview is rescheduled or cancelled so the invite is updated.
+func (i *InvestigationInterview) CalendarUID() string {
+	return fmt.Sprintf("interview-%s@health-sys", i.ID)
+}
+
+// Transcript writes out the questions asked in an interview and the
+// answers given, in order. Answers must be loaded.
+func (i *InvestigationInterview) Transcript() string {
+	var b strings.Builder
+	for n, answer := range i.Answers {
+		if n > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "Q%d. %s\nA: %s", n+1, answer.Question, answer.Answer)
+	}
+	return b.String()
+}
+
+// TranscriptHash is the SHA-256 of the transcript, recorded as the
+// StatementHash when the interviewee acknowledges it
+func (i *InvestigationInterview) TranscriptHash() string {
+	sum := sha256.Sum256([]byte(i.Transcript()))
+	return hex.EncodeToString(sum[:])
+}
+
+// StatementIntact reports whether the transcript is still the statement the
+// interviewee acknowledged
+func (i *InvestigationInterview) StatementIntact() bool {
+	return i.AcknowledgedAt != nil && i.StatementHash == i.TranscriptHash()
 }
 
 // Evidence tracking for investigations
@@ -30,13 +105,21 @@ type InvestigationEvidence struct {
 	InvestigationID uuid.UUID `gorm:"type:uuid;not null"`
 	EvidenceType    string    `gorm:"size:50;not null;check:evidence_type IN ('document', 'photo', 'video', 'physical_item', 'testimony')"`
 	Description     string    `gorm:"type:text;not null"`
+	// FileURL is a link recorded before evidence files were uploaded; new
+	// evidence keeps its file in StoragePath instead
 	FileURL         string    `gorm:"size:512"`
+	FileName        string    `gorm:"size:255"`
+	FileType        string    `gorm:"size:100"`
+	FileSize        int       `gorm:"default:0"`
+	StoragePath     string    `gorm:"size:512"`
 	CollectedAt     time.Time `gorm:"not null"`
 	CollectedBy     uuid.UUID `gorm:"type:uuid;not null"`
 	StorageLocation string    `gorm:"size:512"`
 	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 	UpdatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 
+	ImageMetadata `gorm:"embedded"`
+
 	// Relationships
 	Investigation Investigation `gorm:"foreignKey:InvestigationID"`
 	Collector     Employee      `gorm:"foreignKey:CollectedBy"`
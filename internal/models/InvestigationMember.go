@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles on an investigation team. The lead investigator is not stored as a
+// member; MemberRoleLead names their role where roles are listed.
+const (
+	MemberRoleLead                = "lead_investigator"
+	MemberRoleInvestigator        = "investigator"
+	MemberRoleSubjectExpert       = "subject_expert"
+	MemberRoleUnionRepresentative = "union_representative"
+	MemberRoleObserver            = "observer"
+)
+
+// MemberRoles are the roles a member can be given
+var MemberRoles = []string{
+	MemberRoleInvestigator,
+	MemberRoleSubjectExpert,
+	MemberRoleUnionRepresentative,
+	MemberRoleObserver,
+}
+
+// InvestigationMember is an employee's place on an investigation team.
+// Leaving the team sets LeftAt rather than deleting the record, so the team
+// history is kept; an employee rejoining gets a new record.
+type InvestigationMember struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	InvestigationID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	EmployeeID      uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Role            string     `gorm:"size:30;not null;check:role IN ('investigator', 'subject_expert', 'union_representative', 'observer')"`
+	JoinedAt        time.Time  `gorm:"not null"`
+	LeftAt          *time.Time `gorm:"index"`
+	AddedBy         uuid.UUID  `gorm:"type:uuid;not null"`
+	CreatedAt       time.Time  `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time  `gorm:"default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Investigation Investigation `gorm:"foreignKey:InvestigationID;constraint:OnDelete:CASCADE;"`
+	Employee      Employee      `gorm:"foreignKey:EmployeeID"`
+}
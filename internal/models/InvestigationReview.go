@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review decisions
+const (
+	ReviewDecisionApproved = "approved"
+	ReviewDecisionRejected = "rejected"
+)
+
+// InvestigationReview is a reviewer's signed decision on one step of the
+// review chain. Records are never changed: each one's signature covers its
+// content and the signature of the record before it, so an edited or
+// removed record breaks the chain.
+type InvestigationReview struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	InvestigationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Round           int       `gorm:"not null"`
+	Step            int       `gorm:"not null"`
+	StepName        string    `gorm:"size:50;not null"`
+	ReviewerID      uuid.UUID `gorm:"type:uuid;not null"`
+	Decision        string    `gorm:"size:20;not null;check:decision IN ('approved', 'rejected')"`
+	Comments        string    `gorm:"type:text"`
+	SignedAt        time.Time `gorm:"not null"`
+	Signature       string    `gorm:"size:64;not null"`
+
+	// Relationships
+	Investigation Investigation `gorm:"foreignKey:InvestigationID;constraint:OnDelete:CASCADE;"`
+	Reviewer      Employee      `gorm:"foreignKey:ReviewerID"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginAttempt records every sign-in attempt so failures can be tracked per IP address
+type LoginAttempt struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID     *uuid.UUID `gorm:"type:uuid;index"`
+	Identifier string     `gorm:"size:255"`
+	IPAddress  string     `gorm:"type:inet;index:idx_login_attempts_ip_created"`
+	Success    bool       `gorm:"default:false"`
+	Reason     string     `gorm:"size:50"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP;index:idx_login_attempts_ip_created"`
+}
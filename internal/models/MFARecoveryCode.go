@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFARecoveryCode is a single-use code that lets a user sign in without their authenticator app
+type MFARecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	CodeHash  string    `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID"`
+}
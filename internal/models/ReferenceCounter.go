@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kinds of record that get a reference number
+const (
+	ReferenceIncident = "incident"
+	ReferenceHazard   = "hazard"
+	ReferenceVPC      = "vpc"
+)
+
+// ReferenceCounter holds the last number handed out for one reference
+// number prefix, such as INC-2026. Its row is locked while a number is
+// taken, so concurrent reports never get the same one.
+type ReferenceCounter struct {
+	Name      string    `gorm:"size:100;primaryKey"`
+	Value     int64     `gorm:"not null;default:0"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// ReferenceNumberGenerator hands out the reference numbers of new records
+type ReferenceNumberGenerator interface {
+	// Next takes the next number for a kind of record in the transaction
+	// tx; the number is only used up if tx commits
+	Next(tx *gorm.DB, kind string) (string, error)
+}
+
+var referenceNumbers ReferenceNumberGenerator
+
+// UseReferenceNumbers sets the generator the create hooks number new
+// incidents, hazards and VPCs with
+func UseReferenceNumbers(generator ReferenceNumberGenerator) {
+	referenceNumbers = generator
+}
+
+func nextReferenceNumber(tx *gorm.DB, kind string) (string, error) {
+	if referenceNumbers == nil {
+		return "", errors.New("reference numbers are not configured")
+	}
+	return referenceNumbers.Next(tx, kind)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role is a named set of permissions. Employees refer to a role by its name.
+// System roles are seeded from middleware.RolePermissions and cannot be deleted.
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Name        string       `gorm:"size:50;not null;uniqueIndex"`
+	Description string       `gorm:"size:255"`
+	IsSystem    bool         `gorm:"default:false"`
+	Permissions []Permission `gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time    `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time    `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// Permission is a single capability checked by the route policies
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Name        string    `gorm:"size:100;not null;uniqueIndex"`
+	Description string    `gorm:"size:255"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ishikawa (fishbone) cause categories
+const (
+	CauseCategoryPeople      = "people"
+	CauseCategoryMethod      = "method"
+	CauseCategoryMachine     = "machine"
+	CauseCategoryMaterial    = "material"
+	CauseCategoryEnvironment = "environment"
+	CauseCategoryMeasurement = "measurement"
+)
+
+// CauseCategories lists the fishbone categories in diagram order
+var CauseCategories = []string{
+	CauseCategoryPeople,
+	CauseCategoryMethod,
+	CauseCategoryMachine,
+	CauseCategoryMaterial,
+	CauseCategoryEnvironment,
+	CauseCategoryMeasurement,
+}
+
+// FiveWhys is a 5-Whys chain of an investigation: a problem statement and
+// the answers found by asking "why?" in turn, deepest last
+type FiveWhys struct {
+	ID              uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	InvestigationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Problem         string    `gorm:"type:text;not null"`
+	Position        int       `gorm:"not null;default:0"`
+	CreatedBy       uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Investigation Investigation `gorm:"foreignKey:InvestigationID;constraint:OnDelete:CASCADE;"`
+	Creator       Employee      `gorm:"foreignKey:CreatedBy"`
+	Whys          []RootCause   `gorm:"foreignKey:FiveWhysID;constraint:OnDelete:CASCADE;"`
+}
+
+// TableName specifies the table name for GORM
+func (FiveWhys) TableName() string {
+	return "five_whys"
+}
+
+// RootCause is a cause found by root cause analysis: an answer in a 5-Whys
+// chain, or a cause on the fishbone diagram. Fishbone causes sit under a
+// category and may have sub-causes; answers in a chain may be given one so
+// that they count in the cause reports too.
+type RootCause struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	InvestigationID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	FiveWhysID      *uuid.UUID `gorm:"type:uuid;index"`
+	ParentID        *uuid.UUID `gorm:"type:uuid;index"`
+	Category        string     `gorm:"size:20;check:category IN ('', 'people', 'method', 'machine', 'material', 'environment', 'measurement')"`
+	Description     string     `gorm:"type:text;not null"`
+	Position        int        `gorm:"not null;default:0"`
+	// IsRootCause marks the causes the investigation concluded are root
+	// causes, as opposed to contributing ones
+	IsRootCause bool      `gorm:"not null;default:false"`
+	CreatedBy   uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Investigation     Investigation           `gorm:"foreignKey:InvestigationID;constraint:OnDelete:CASCADE;"`
+	Creator           Employee                `gorm:"foreignKey:CreatedBy"`
+	SubCauses         []RootCause             `gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE;"`
+	Evidence          []InvestigationEvidence `gorm:"many2many:root_cause_evidence"`
+	CorrectiveActions []CorrectiveAction      `gorm:"many2many:root_cause_actions"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// SSOLoginState is an authorization request sent to an identity provider.
+// It is consumed by the callback that completes the sign-in.
+type SSOLoginState struct {
+	// State holds the SHA-256 hash of the state parameter, never the value itself
+	State        string    `gorm:"size:64;primaryKey"`
+	Provider     string    `gorm:"size:50;not null"`
+	Nonce        string    `gorm:"size:100;not null"`
+	CodeVerifier string    `gorm:"size:128;not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -12,13 +12,16 @@ type User struct {
 	PasswordHash        string    `gorm:"size:255"`
 	GoogleID            *string   `gorm:"size:255;unique"`
 	MicrosoftID         *string   `gorm:"size:255;unique"`
+	ExternalID          *string   `gorm:"size:255;unique"` // ID in the HR directory (SCIM)
 	MFAEnabled          bool      `gorm:"default:false"`
 	MFASecret           string    `gorm:"size:255"`
+	MFALastUsedCounter  int64     `gorm:"default:0"`
 	FailedLoginAttempts int       `gorm:"default:0"`
 	LastLoginAt         time.Time
 	PasswordChangedAt   time.Time
 	AccountLocked       bool `gorm:"default:false"`
 	AccountLockedUntil  time.Time
+	LockoutCount        int    `gorm:"default:0"`
 	IsActive            bool   `gorm:"default:true"`
 	IsVerified          bool   `gorm:"default:false"`
 	VerificationToken   string `gorm:"size:255"`
@@ -2,8 +2,6 @@ package models
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,7 +31,7 @@ type VPC struct {
 func (vpc *VPC) BeforeCreate(tx *gorm.DB) error {
 	// Generate VPC number if not provided
 	if vpc.VpcNumber == "" {
-		vpcNumber, err := generateNextVPCNumber(tx)
+		vpcNumber, err := nextReferenceNumber(tx, ReferenceVPC)
 		if err != nil {
 			return fmt.Errorf("failed to generate VPC number: %w", err)
 		}
@@ -43,34 +41,6 @@ func (vpc *VPC) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// generateNextVPCNumber generates the next sequential VPC number
-func generateNextVPCNumber(tx *gorm.DB) (string, error) {
-	var lastVPC VPC
-
-	// Find the VPC with the highest number
-	err := tx.Where("vpc_number LIKE 'VPC%'").
-		Order("LENGTH(vpc_number) DESC, vpc_number DESC").
-		First(&lastVPC).Error
-
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return "", fmt.Errorf("failed to query last VPC: %w", err)
-	}
-
-	nextNumber := 1
-
-	if err != gorm.ErrRecordNotFound {
-		// Extract the numeric part from VPC number
-		numberStr := strings.TrimPrefix(lastVPC.VpcNumber, "VPC")
-		// Remove leading zeros and convert to int
-		if num, parseErr := strconv.Atoi(numberStr); parseErr == nil {
-			nextNumber = num + 1
-		}
-	}
-
-	// Format with leading zeros (VPC00001, VPC00002, etc.)
-	return fmt.Sprintf("VPC%05d", nextNumber), nil
-}
-
 // TableName specifies the table name for GORM
 func (VPC) TableName() string {
 	return "vpcs"
@@ -17,6 +17,8 @@ type VPCAttachment struct {
 	UploadedBy  uuid.UUID `gorm:"type:uuid;not null"` // Foreign key to Employee.ID
 	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 
+	ImageMetadata `gorm:"embedded"`
+
 	VPC      VPC      `gorm:"foreignKey:VPCID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 	Uploader Employee `gorm:"foreignKey:UploadedBy;references:ID"` // Assumes Employee model has 'ID' as PK
 }
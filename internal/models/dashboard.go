@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type DashboardFilters struct {
@@ -18,6 +20,20 @@ type DashboardResponse struct {
 	Incidents         []Incident         `json:"incidents"`
 	Metrics           IncidentMetrics    `json:"metrics"`
 	CorrectiveActions []CorrectiveAction `json:"correctiveActions"`
+	Investigations    []MyInvestigation  `json:"investigations"`
+}
+
+// MyInvestigation is an investigation an employee takes part in, as its
+// lead investigator or as a team member
+type MyInvestigation struct {
+	InvestigationID uuid.UUID `json:"investigationId"`
+	IncidentID      uuid.UUID `json:"incidentId"`
+	ReferenceNumber string    `json:"referenceNumber"`
+	IncidentTitle   string    `json:"incidentTitle"`
+	Status          string    `json:"status"`
+	StartedAt       time.Time `json:"startedAt"`
+	// Role is lead_investigator or the member role
+	Role string `json:"role"`
 }
 
 // AdminDashboardResponse represents the admin dashboard data
@@ -14,7 +14,7 @@ type Employee struct {
 	LastName           string     `gorm:"size:100;not null"`
 	Department         string     `gorm:"size:100;not null"`
 	Position           string     `gorm:"size:100;not null"`
-	Role               string     `gorm:"size:50;not null;check:role IN ('admin', 'safety_officer', 'manager', 'employee')"`
+	Role               string     `gorm:"size:50;not null;index"`
 	ReportingManagerID *uuid.UUID `gorm:"type:uuid"`
 	StartDate          time.Time  `gorm:"not null"`
 	EndDate            time.Time
@@ -30,6 +30,7 @@ type Employee struct {
 	// Relationships
 	User             User      `gorm:"foreignKey:UserID"`
 	ReportingManager *Employee `gorm:"foreignKey:ReportingManagerID"`
+	RoleDefinition   *Role     `gorm:"foreignKey:Role;references:Name;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 }
 
 type TemporaryEmployee struct {
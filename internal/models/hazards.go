@@ -2,8 +2,6 @@ package models
 
 import (
 	"fmt"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -36,10 +34,10 @@ type Hazard struct {
 }
 
 // BeforeCreate is a GORM hook that runs before a new hazard record is created.
-// It generates a sequential reference number (e.g., HAZ00001).
+// It assigns the next reference number (e.g., HAZ-2026-00001).
 func (hazard *Hazard) BeforeCreate(tx *gorm.DB) error {
 	if hazard.ReferenceNumber == "" {
-		hazardNumber, err := generateNextHazardNumber(tx)
+		hazardNumber, err := nextReferenceNumber(tx, ReferenceHazard)
 		if err != nil {
 			return fmt.Errorf("failed to generate hazard number: %w", err)
 		}
@@ -48,32 +46,6 @@ func (hazard *Hazard) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// generateNextHazardNumber creates the next sequential hazard number.
-func generateNextHazardNumber(tx *gorm.DB) (string, error) {
-	var lastHazard Hazard
-
-	// Find the hazard with the highest reference number starting with "HAZ"
-	err := tx.Where("reference_number LIKE 'HAZ%'").
-		Order("LENGTH(reference_number) DESC, reference_number DESC").
-		First(&lastHazard).Error
-
-	if err != nil && err != gorm.ErrRecordNotFound {
-		return "", fmt.Errorf("failed to query last hazard: %w", err)
-	}
-
-	nextNumber := 1
-	if err != gorm.ErrRecordNotFound {
-		// Extract the numeric part from the reference number
-		numberStr := strings.TrimPrefix(lastHazard.ReferenceNumber, "HAZ")
-		if num, parseErr := strconv.Atoi(numberStr); parseErr == nil {
-			nextNumber = num + 1
-		}
-	}
-
-	// Format the number with leading zeros (e.g., HAZ00001)
-	return fmt.Sprintf("HAZ%05d", nextNumber), nil
-}
-
 // TableName specifies the table name for the Hazard model in the database.
 func (Hazard) TableName() string {
 	return "hazards"
@@ -7,14 +7,23 @@ import (
 )
 
 type UserSession struct {
-	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	UserID       uuid.UUID `gorm:"type:uuid;not null"`
-	RefreshToken string    `gorm:"size:255;not null"`
+	ID     uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
+	// RefreshToken holds the SHA-256 hash of the current refresh token, never the token itself
+	RefreshToken string    `gorm:"size:255;not null;uniqueIndex"`
 	DeviceInfo   JSONB     `gorm:"type:jsonb"`
 	IPAddress    string    `gorm:"type:inet"`
 	ExpiresAt    time.Time `gorm:"not null"`
-	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+	LastSeenAt   time.Time
+	RevokedAt    *time.Time `gorm:"index"`
+	CreatedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP"`
 
 	// Relationships
 	User User `gorm:"foreignKey:UserID"`
 }
+
+// IsActive reports whether the session can still be used to refresh tokens
+func (s *UserSession) IsActive() bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(time.Now())
+}
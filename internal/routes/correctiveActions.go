@@ -7,23 +7,34 @@ import (
 )
 
 func SetupCorrectiveActionRoutes(app *fiber.App, correctiveActionHandler *api.CorrectiveActionHandler) {
-
-	apiGroup := app.Group("/api/v1")
-
-	apiGroup.Post("/actions/:id/evidence", middleware.AuthMiddleware(), correctiveActionHandler.CreateActionEvidenceWithAttachments)
-
-	apiGroup.Get("/incidents/:incidentID/actions", correctiveActionHandler.GetCorrectiveActionsByIncidentID)
-	apiGroup.Get("/incidents/:id/user", correctiveActionHandler.GetCorrectiveActionsByEmployeeID)
-	apiGroup.Post("/actions", correctiveActionHandler.CreateCorrectiveAction)
-
-	apiGroup.Get("/actions/:id", correctiveActionHandler.GetCorrectiveActionByID)
-	apiGroup.Put("/actions/:id", middleware.AuthMiddleware(), correctiveActionHandler.UpdateCorrectiveAction)
-	apiGroup.Post("/actions/:id/admin", middleware.AuthMiddleware(), correctiveActionHandler.AdminCompleteActionAndVerify)
-	apiGroup.Delete("/actions/:id", correctiveActionHandler.DeleteCorrectiveAction)
-
-	apiGroup.Post("/actions/:id/complete", middleware.AuthMiddleware(), correctiveActionHandler.LabelAsCompleted)
-	apiGroup.Post("/actions/:id/verify", middleware.AuthMiddleware(), correctiveActionHandler.VerifyCompletion)
-
-	apiGroup.Post("/actions/:id/extension", middleware.AuthMiddleware(), correctiveActionHandler.RequestExtension)
-
+	api.RegisterRoutes(app, []api.Route{
+		// The assignee adds evidence, completes the action and asks for more
+		// time; the service checks the caller is the assignee
+		{Method: fiber.MethodPost, Path: "/api/v1/actions/:id/evidence", Policy: middleware.Authenticated(),
+			Handlers: []fiber.Handler{correctiveActionHandler.CreateActionEvidenceWithAttachments}},
+
+		{Method: fiber.MethodGet, Path: "/api/v1/incidents/:incidentID/actions", Policy: middleware.Require(middleware.PermissionReadIncidents),
+			Handlers: []fiber.Handler{correctiveActionHandler.GetCorrectiveActionsByIncidentID}},
+		{Method: fiber.MethodGet, Path: "/api/v1/incidents/:id/user", Policy: middleware.Require(middleware.PermissionReadIncidents),
+			Handlers: []fiber.Handler{correctiveActionHandler.GetCorrectiveActionsByEmployeeID}},
+		{Method: fiber.MethodPost, Path: "/api/v1/actions", Policy: middleware.Require(middleware.PermissionAssignTasks),
+			Handlers: []fiber.Handler{correctiveActionHandler.CreateCorrectiveAction}},
+
+		{Method: fiber.MethodGet, Path: "/api/v1/actions/:id", Policy: middleware.Require(middleware.PermissionReadIncidents),
+			Handlers: []fiber.Handler{correctiveActionHandler.GetCorrectiveActionByID}},
+		{Method: fiber.MethodPut, Path: "/api/v1/actions/:id", Policy: middleware.Require(middleware.PermissionAssignTasks),
+			Handlers: []fiber.Handler{correctiveActionHandler.UpdateCorrectiveAction}},
+		{Method: fiber.MethodPost, Path: "/api/v1/actions/:id/admin", Policy: middleware.Require(middleware.PermissionManageIncidents),
+			Handlers: []fiber.Handler{correctiveActionHandler.AdminCompleteActionAndVerify}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/actions/:id", Policy: middleware.Require(middleware.PermissionManageIncidents),
+			Handlers: []fiber.Handler{correctiveActionHandler.DeleteCorrectiveAction}},
+
+		{Method: fiber.MethodPost, Path: "/api/v1/actions/:id/complete", Policy: middleware.Authenticated(),
+			Handlers: []fiber.Handler{correctiveActionHandler.LabelAsCompleted}},
+		{Method: fiber.MethodPost, Path: "/api/v1/actions/:id/verify", Policy: middleware.Require(middleware.PermissionManageIncidents),
+			Handlers: []fiber.Handler{correctiveActionHandler.VerifyCompletion}},
+
+		{Method: fiber.MethodPost, Path: "/api/v1/actions/:id/extension", Policy: middleware.Authenticated(),
+			Handlers: []fiber.Handler{correctiveActionHandler.RequestExtension}},
+	})
 }
@@ -7,11 +7,28 @@ import (
 )
 
 func SetupHazardRoutes(app *fiber.App, h *api.HazardHandler) {
-	hazardGroup := app.Group("/api/v1/hazards")
-	hazardGroup.Post("/", middleware.AuthMiddleware(), h.CreateHazard)
-	hazardGroup.Get("/", middleware.AuthMiddleware(), h.ListHazards)
-	hazardGroup.Get("/:id", middleware.AuthMiddleware(), h.GetHazard)
-	hazardGroup.Put("/:id", middleware.AuthMiddleware(), h.UpdateHazard)
-	hazardGroup.Delete("/:id", middleware.AuthMiddleware(), h.DeleteHazard)
-	hazardGroup.Post("/:id/assign", middleware.AuthMiddleware(), h.AssignHazard)
+	api.RegisterRoutes(app, []api.Route{
+		{Method: fiber.MethodPost, Path: "/api/v1/hazards", Policy: middleware.Require(middleware.PermissionCreateHazards),
+			Handlers: []fiber.Handler{h.CreateHazard}},
+		{Method: fiber.MethodGet, Path: "/api/v1/hazards", Policy: middleware.Require(middleware.PermissionReadHazards),
+			Handlers: []fiber.Handler{h.ListHazards}},
+		{Method: fiber.MethodGet, Path: "/api/v1/hazards/:id", Policy: middleware.Require(middleware.PermissionReadHazards),
+			Handlers: []fiber.Handler{h.GetHazard}},
+		{Method: fiber.MethodPut, Path: "/api/v1/hazards/:id", Policy: middleware.Require(middleware.PermissionManageHazards),
+			Handlers: []fiber.Handler{h.UpdateHazard}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/hazards/:id", Policy: middleware.Require(middleware.PermissionManageHazards),
+			Handlers: []fiber.Handler{h.DeleteHazard}},
+		{Method: fiber.MethodPost, Path: "/api/v1/hazards/:id/assign", Policy: middleware.Require(middleware.PermissionManageHazards),
+			Handlers: []fiber.Handler{h.AssignHazard}},
+
+		// Photos and documents, added when the hazard is reported or later
+		{Method: fiber.MethodGet, Path: "/api/v1/hazards/:hazardID/attachments", Policy: middleware.Require(middleware.PermissionReadHazards),
+			Handlers: []fiber.Handler{h.ListAttachments}},
+		{Method: fiber.MethodPost, Path: "/api/v1/hazards/:hazardID/attachments", Policy: middleware.Require(middleware.PermissionCreateHazards),
+			Handlers: []fiber.Handler{h.UploadAttachments}},
+		{Method: fiber.MethodPut, Path: "/api/v1/hazards/:hazardID/attachments/:id", Policy: middleware.Require(middleware.PermissionManageHazards),
+			Handlers: []fiber.Handler{h.ReplaceAttachment}},
+		{Method: fiber.MethodDelete, Path: "/api/v1/hazards/:hazardID/attachments/:id", Policy: middleware.Require(middleware.PermissionManageHazards),
+			Handlers: []fiber.Handler{h.DeleteAttachment}},
+	})
 }
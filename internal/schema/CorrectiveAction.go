@@ -25,7 +25,7 @@ type CreateCorrectiveActionRequest struct {
 	Description          string `json:"description" validate:"required"`
 	ActionType           string `json:"actionType" validate:"required,max=50"`
 	Priority             string `json:"priority" validate:"required,oneof=low medium high critical"`
-	Status               string `json:"status" validate:"omitempty,oneof=pending in_progress completed verified overdue"`
+	Status               string `json:"status" validate:"omitempty,oneof=pending in_progress overdue"`
 	AssignedTo           string `json:"assignedTo" validate:"required,uuid4"`
 	AssignedBy           string `json:"assignedBy" validate:"required,uuid4"`
 	DueDate              string `json:"dueDate" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
@@ -81,7 +81,7 @@ func ToIncidentResponse(i models.Incident) IncidentResponse {
 	if i.ClosedAt != nil && !i.ClosedAt.IsZero() {
 		closedAt = i.ClosedAt
 	}
-	if i.Type == "injury" {
+	if i.Type == "injury" && i.InjuryType != "" {
 		i.Type = fmt.Sprintf("%s %s", i.Type, i.InjuryType)
 	}
 
@@ -119,3 +119,33 @@ func ToIncidentResponses(incidents []models.Incident) []IncidentResponse {
 	}
 	return responses
 }
+
+// IncidentStatusChangeResponse is one entry of an incident's status history
+type IncidentStatusChangeResponse struct {
+	Transition string    `json:"transition"`
+	FromStatus string    `json:"fromStatus"`
+	ToStatus   string    `json:"toStatus"`
+	ChangedBy  *string   `json:"changedBy,omitempty"`
+	Note       string    `json:"note,omitempty"`
+	ChangedAt  time.Time `json:"changedAt"`
+}
+
+func ToIncidentStatusChangeResponses(history []models.IncidentStatusHistory) []IncidentStatusChangeResponse {
+	responses := make([]IncidentStatusChangeResponse, len(history))
+	for i, entry := range history {
+		var changedBy *string
+		if entry.Changer != nil {
+			name := fmt.Sprintf("%s %s", entry.Changer.FirstName, entry.Changer.LastName)
+			changedBy = &name
+		}
+		responses[i] = IncidentStatusChangeResponse{
+			Transition: entry.Transition,
+			FromStatus: entry.FromStatus,
+			ToStatus:   entry.ToStatus,
+			ChangedBy:  changedBy,
+			Note:       entry.Note,
+			ChangedAt:  entry.CreatedAt,
+		}
+	}
+	return responses
+}
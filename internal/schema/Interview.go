@@ -12,8 +12,23 @@ type CreateInterviewDTO struct {
 	InvestigationID uuid.UUID `json:"investigationId" validate:"required"`
 	IntervieweeID   uuid.UUID `json:"intervieweeId" validate:"required"`
 	ScheduledFor    time.Time `json:"scheduledFor" validate:"required,future"`
-	Location        string    `json:"location" validate:"required"`
-	Notes           string    `json:"notes"`
+	// DurationMinutes defaults to the configured interview length
+	DurationMinutes int    `json:"durationMinutes" validate:"omitempty,min=5,max=480"`
+	Location        string `json:"location" validate:"required"`
+	Notes           string `json:"notes"`
+}
+
+// RescheduleInterviewDTO moves an interview. The duration and location are
+// kept when they are not given.
+type RescheduleInterviewDTO struct {
+	ScheduledFor    time.Time `json:"scheduledFor" validate:"required"`
+	DurationMinutes int       `json:"durationMinutes" validate:"omitempty,min=5,max=480"`
+	Location        string    `json:"location"`
+	Reason          string    `json:"reason" validate:"required"`
+}
+
+type CancelInterviewDTO struct {
+	Reason string `json:"reason" validate:"required"`
 }
 
 type UpdateInterviewDTO struct {
@@ -29,7 +44,6 @@ type CreateEvidenceDTO struct {
 	InvestigationID uuid.UUID `json:"investigationId" validate:"required"`
 	EvidenceType    string    `json:"evidenceType" validate:"required,oneof=document photo video physical_item testimony"`
 	Description     string    `json:"description" validate:"required"`
-	FileURL         string    `json:"fileUrl"`
 	CollectedBy     uuid.UUID `json:"collectedBy" validate:"required"`
 	StorageLocation string    `json:"storageLocation"`
 }
@@ -37,7 +51,6 @@ type CreateEvidenceDTO struct {
 type UpdateEvidenceDTO struct {
 	Description     *string `json:"description"`
 	StorageLocation *string `json:"storageLocation"`
-	FileURL         *string `json:"fileUrl"`
 }
 
 // Corrective Action Evidence DTOs
@@ -1 +1,156 @@
-package schema
\ No newline at end of file
+package schema
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+)
+
+// Interview template DTOs
+type InterviewQuestionDTO struct {
+	Text     string `json:"text" validate:"required"`
+	Guidance string `json:"guidance"`
+	Required bool   `json:"required"`
+}
+
+// InterviewTemplateDTO creates a template, or replaces one with its
+// questions in the order given
+type InterviewTemplateDTO struct {
+	Name         string                 `json:"name" validate:"required,max=100"`
+	IncidentType string                 `json:"incidentType" validate:"required,oneof=injury near_miss property_damage environmental security"`
+	Description  string                 `json:"description"`
+	IsActive     *bool                  `json:"isActive"`
+	Questions    []InterviewQuestionDTO `json:"questions" validate:"required,min=1,dive"`
+}
+
+// ApplyInterviewTemplateDTO sets the questions of an interview from a template
+type ApplyInterviewTemplateDTO struct {
+	TemplateID uuid.UUID `json:"templateId" validate:"required"`
+}
+
+// InterviewAnswerDTO answers a question of the interview given by ID, or
+// adds a follow-up question with its answer when ID is empty
+type InterviewAnswerDTO struct {
+	ID       *uuid.UUID `json:"id"`
+	Question string     `json:"question"`
+	Answer   string     `json:"answer"`
+}
+
+type RecordInterviewAnswersDTO struct {
+	Answers []InterviewAnswerDTO `json:"answers" validate:"required,min=1,dive"`
+}
+
+type InterviewQuestionResponse struct {
+	ID       string `json:"id"`
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+	Guidance string `json:"guidance,omitempty"`
+	Required bool   `json:"required"`
+}
+
+type InterviewTemplateResponse struct {
+	ID           string                      `json:"id"`
+	Name         string                      `json:"name"`
+	IncidentType string                      `json:"incidentType"`
+	Description  string                      `json:"description,omitempty"`
+	IsActive     bool                        `json:"isActive"`
+	CreatedBy    string                      `json:"createdBy"`
+	Questions    []InterviewQuestionResponse `json:"questions"`
+	CreatedAt    time.Time                   `json:"createdAt"`
+	UpdatedAt    time.Time                   `json:"updatedAt"`
+}
+
+type InterviewAnswerResponse struct {
+	ID         string     `json:"id"`
+	QuestionID *uuid.UUID `json:"questionId,omitempty"`
+	Position   int        `json:"position"`
+	Question   string     `json:"question"`
+	Required   bool       `json:"required"`
+	Answer     string     `json:"answer"`
+	RecordedBy *uuid.UUID `json:"recordedBy,omitempty"`
+	RecordedAt *time.Time `json:"recordedAt,omitempty"`
+}
+
+type InterviewScheduleChangeResponse struct {
+	ID                   string     `json:"id"`
+	Action               string     `json:"action"`
+	PreviousScheduledFor *time.Time `json:"previousScheduledFor,omitempty"`
+	PreviousLocation     string     `json:"previousLocation,omitempty"`
+	ScheduledFor         time.Time  `json:"scheduledFor"`
+	DurationMinutes      int        `json:"durationMinutes"`
+	Location             string     `json:"location,omitempty"`
+	Reason               string     `json:"reason,omitempty"`
+	ChangedBy            string     `json:"changedBy"`
+	ChangedByName        string     `json:"changedByName,omitempty"`
+	CreatedAt            time.Time  `json:"createdAt"`
+}
+
+// InterviewConflictResponse is a booked interview that overlaps the time
+// asked for, and the person it is booked for
+type InterviewConflictResponse struct {
+	InterviewID     string    `json:"interviewId"`
+	InvestigationID string    `json:"investigationId"`
+	EmployeeID      string    `json:"employeeId"`
+	EmployeeName    string    `json:"employeeName,omitempty"`
+	Role            string    `json:"role"`
+	ScheduledFor    time.Time `json:"scheduledFor"`
+	EndsAt          time.Time `json:"endsAt"`
+	Location        string    `json:"location,omitempty"`
+}
+
+func ToInterviewTemplateResponse(template *models.InterviewTemplate) InterviewTemplateResponse {
+	response := InterviewTemplateResponse{
+		ID:           template.ID.String(),
+		Name:         template.Name,
+		IncidentType: template.IncidentType,
+		Description:  template.Description,
+		IsActive:     template.IsActive,
+		CreatedBy:    template.CreatedBy.String(),
+		Questions:    make([]InterviewQuestionResponse, len(template.Questions)),
+		CreatedAt:    template.CreatedAt,
+		UpdatedAt:    template.UpdatedAt,
+	}
+	for i, question := range template.Questions {
+		response.Questions[i] = InterviewQuestionResponse{
+			ID:       question.ID.String(),
+			Position: question.Position,
+			Text:     question.Text,
+			Guidance: question.Guidance,
+			Required: question.Required,
+		}
+	}
+	return response
+}
+
+func ToInterviewAnswerResponse(answer *models.InterviewAnswer) InterviewAnswerResponse {
+	return InterviewAnswerResponse{
+		ID:         answer.ID.String(),
+		QuestionID: answer.QuestionID,
+		Position:   answer.Position,
+		Question:   answer.Question,
+		Required:   answer.Required,
+		Answer:     answer.Answer,
+		RecordedBy: answer.RecordedBy,
+		RecordedAt: answer.RecordedAt,
+	}
+}
+
+func ToInterviewScheduleChangeResponse(change *models.InterviewScheduleChange) InterviewScheduleChangeResponse {
+	response := InterviewScheduleChangeResponse{
+		ID:                   change.ID.String(),
+		Action:               change.Action,
+		PreviousScheduledFor: change.PreviousScheduledFor,
+		PreviousLocation:     change.PreviousLocation,
+		ScheduledFor:         change.ScheduledFor,
+		DurationMinutes:      change.DurationMinutes,
+		Location:             change.Location,
+		Reason:               change.Reason,
+		ChangedBy:            change.ChangedBy.String(),
+		CreatedAt:            change.CreatedAt,
+	}
+	if change.Changer.ID != uuid.Nil {
+		response.ChangedByName = change.Changer.FirstName + " " + change.Changer.LastName
+	}
+	return response
+}
@@ -47,12 +47,17 @@ type InvestigationEvidenceResponse struct {
 	EvidenceType    string    `json:"evidenceType"`
 	Description     string    `json:"description"`
 	FileURL         string    `json:"fileURL,omitempty"`
+	FileName        string    `json:"fileName,omitempty"`
+	FileType        string    `json:"fileType,omitempty"`
+	FileSize        int       `json:"fileSize,omitempty"`
 	CollectedAt     time.Time `json:"collectedAt"`
 	CollectedBy     string    `json:"collectedBy"`
 	CollectorName   string    `json:"collectorName,omitempty"`
 	StorageLocation string    `json:"storageLocation,omitempty"`
 	CreatedAt       time.Time `json:"createdAt"`
 	UpdatedAt       time.Time `json:"updatedAt"`
+
+	models.ImageMetadataResponse
 }
 
 type InvestigationInterviewResponse struct {
@@ -61,12 +66,24 @@ type InvestigationInterviewResponse struct {
 	IntervieweeID   string     `json:"intervieweeId"`
 	IntervieweeName string     `json:"intervieweeName,omitempty"`
 	ScheduledFor    time.Time  `json:"scheduledFor"`
+	DurationMinutes int        `json:"durationMinutes"`
+	EndsAt          time.Time  `json:"endsAt"`
 	Status          string     `json:"status"`
 	Notes           string     `json:"notes,omitempty"`
 	Location        string     `json:"location,omitempty"`
 	CompletedAt     *time.Time `json:"completedAt,omitempty"`
 	CreatedAt       time.Time  `json:"createdAt"`
 	UpdatedAt       time.Time  `json:"updatedAt"`
+	TemplateID      *uuid.UUID `json:"templateId,omitempty"`
+	EvidenceID      *uuid.UUID `json:"evidenceId,omitempty"`
+	// Answers is the transcript, when it was loaded
+	Answers        []InterviewAnswerResponse `json:"answers,omitempty"`
+	AcknowledgedAt *time.Time                `json:"acknowledgedAt,omitempty"`
+	// StatementIntact is true when the interviewee acknowledged the
+	// transcript as it stands
+	StatementIntact bool `json:"statementIntact"`
+	// ScheduleChanges is the scheduling history, when it was loaded
+	ScheduleChanges []InterviewScheduleChangeResponse `json:"scheduleChanges,omitempty"`
 }
 
 type InvestigationResponse struct {
@@ -196,18 +213,30 @@ func ToInvestigationEvidenceResponse(evidence *models.InvestigationEvidence) Inv
 		collectorName = fmt.Sprintf("%s %s", evidence.Collector.FirstName, evidence.Collector.LastName)
 	}
 
+	// Uploaded files are served through the download endpoint
+	evidenceURL := fmt.Sprintf("/api/v1/investigations/%s/evidence/%s", evidence.InvestigationID, evidence.ID)
+	fileURL := evidence.FileURL
+	if evidence.StoragePath != "" {
+		fileURL = evidenceURL + "/download"
+	}
+
 	return InvestigationEvidenceResponse{
 		ID:              evidence.ID.String(),
 		InvestigationID: evidence.InvestigationID.String(),
 		EvidenceType:    evidence.EvidenceType,
 		Description:     evidence.Description,
-		FileURL:         evidence.FileURL,
+		FileURL:         fileURL,
+		FileName:        evidence.FileName,
+		FileType:        evidence.FileType,
+		FileSize:        evidence.FileSize,
 		CollectedAt:     evidence.CollectedAt,
 		CollectedBy:     evidence.CollectedBy.String(),
 		CollectorName:   collectorName,
 		StorageLocation: evidence.StorageLocation,
 		CreatedAt:       evidence.CreatedAt,
 		UpdatedAt:       evidence.UpdatedAt,
+
+		ImageMetadataResponse: evidence.ImageMetadata.ToResponse(evidenceURL),
 	}
 }
 
@@ -222,17 +251,155 @@ func ToInvestigationInterviewResponse(interview *models.InvestigationInterview)
 		completedAt = &interview.CompletedAt
 	}
 
-	return InvestigationInterviewResponse{
+	response := InvestigationInterviewResponse{
 		ID:              interview.ID.String(),
 		InvestigationID: interview.InvestigationID.String(),
 		IntervieweeID:   interview.IntervieweeID.String(),
 		IntervieweeName: intervieweeName,
 		ScheduledFor:    interview.ScheduledFor,
+		DurationMinutes: interview.DurationMinutes,
+		EndsAt:          interview.EndsAt(),
 		Status:          interview.Status,
 		Notes:           interview.Notes,
 		Location:        interview.Location,
 		CompletedAt:     completedAt,
 		CreatedAt:       interview.CreatedAt,
 		UpdatedAt:       interview.UpdatedAt,
+		TemplateID:      interview.TemplateID,
+		EvidenceID:      interview.EvidenceID,
+		AcknowledgedAt:  interview.AcknowledgedAt,
+	}
+	if len(interview.Answers) > 0 {
+		response.Answers = make([]InterviewAnswerResponse, len(interview.Answers))
+		for i := range interview.Answers {
+			response.Answers[i] = ToInterviewAnswerResponse(&interview.Answers[i])
+		}
+	}
+	response.StatementIntact = interview.StatementIntact()
+	if len(interview.ScheduleChanges) > 0 {
+		response.ScheduleChanges = make([]InterviewScheduleChangeResponse, len(interview.ScheduleChanges))
+		for i := range interview.ScheduleChanges {
+			response.ScheduleChanges[i] = ToInterviewScheduleChangeResponse(&interview.ScheduleChanges[i])
+		}
+	}
+	return response
+}
+
+// ReviewDecisionDTO is a reviewer's decision on the step awaiting review.
+// Comments are required to reject.
+type ReviewDecisionDTO struct {
+	Comments string `json:"comments"`
+}
+
+type ReviewStepResponse struct {
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	SameDepartment bool   `json:"sameDepartment"`
+}
+
+type InvestigationReviewResponse struct {
+	ID           string    `json:"id"`
+	Round        int       `json:"round"`
+	Step         int       `json:"step"`
+	StepName     string    `json:"stepName"`
+	ReviewerID   string    `json:"reviewerId"`
+	ReviewerName string    `json:"reviewerName,omitempty"`
+	Decision     string    `json:"decision"`
+	Comments     string    `json:"comments,omitempty"`
+	SignedAt     time.Time `json:"signedAt"`
+	Signature    string    `json:"signature"`
+	// SignatureValid is false when the record, or one before it, was
+	// changed after it was signed
+	SignatureValid bool `json:"signatureValid"`
+}
+
+type InvestigationReviewStateResponse struct {
+	InvestigationID string                        `json:"investigationId"`
+	Status          string                        `json:"status"`
+	Round           int                           `json:"round"`
+	SubmittedAt     *time.Time                    `json:"submittedAt,omitempty"`
+	CurrentStep     *ReviewStepResponse           `json:"currentStep,omitempty"`
+	Steps           []ReviewStepResponse          `json:"steps"`
+	Reviews         []InvestigationReviewResponse `json:"reviews"`
+}
+
+func ToInvestigationReviewResponse(review *models.InvestigationReview, valid bool) InvestigationReviewResponse {
+	reviewerName := ""
+	if review.Reviewer.ID != uuid.Nil {
+		reviewerName = fmt.Sprintf("%s %s", review.Reviewer.FirstName, review.Reviewer.LastName)
+	}
+	return InvestigationReviewResponse{
+		ID:             review.ID.String(),
+		Round:          review.Round,
+		Step:           review.Step,
+		StepName:       review.StepName,
+		ReviewerID:     review.ReviewerID.String(),
+		ReviewerName:   reviewerName,
+		Decision:       review.Decision,
+		Comments:       review.Comments,
+		SignedAt:       review.SignedAt,
+		Signature:      review.Signature,
+		SignatureValid: valid,
+	}
+}
+
+// InvestigationMemberDTO puts an employee on an investigation team
+type InvestigationMemberDTO struct {
+	EmployeeID uuid.UUID `json:"employeeId" validate:"required"`
+	Role       string    `json:"role" validate:"required,oneof=investigator subject_expert union_representative observer"`
+}
+
+// UpdateInvestigationMemberDTO changes the role of a member
+type UpdateInvestigationMemberDTO struct {
+	Role string `json:"role" validate:"required,oneof=investigator subject_expert union_representative observer"`
+}
+
+type InvestigationMemberResponse struct {
+	ID           string     `json:"id"`
+	EmployeeID   string     `json:"employeeId"`
+	EmployeeName string     `json:"employeeName,omitempty"`
+	Role         string     `json:"role"`
+	JoinedAt     time.Time  `json:"joinedAt"`
+	LeftAt       *time.Time `json:"leftAt,omitempty"`
+	AddedBy      string     `json:"addedBy"`
+}
+
+type InvestigationTeamResponse struct {
+	InvestigationID      string                        `json:"investigationId"`
+	LeadInvestigatorID   string                        `json:"leadInvestigatorId"`
+	LeadInvestigatorName string                        `json:"leadInvestigatorName,omitempty"`
+	Members              []InvestigationMemberResponse `json:"members"`
+}
+
+func ToInvestigationMemberResponse(member *models.InvestigationMember) InvestigationMemberResponse {
+	employeeName := ""
+	if member.Employee.ID != uuid.Nil {
+		employeeName = fmt.Sprintf("%s %s", member.Employee.FirstName, member.Employee.LastName)
+	}
+	return InvestigationMemberResponse{
+		ID:           member.ID.String(),
+		EmployeeID:   member.EmployeeID.String(),
+		EmployeeName: employeeName,
+		Role:         member.Role,
+		JoinedAt:     member.JoinedAt,
+		LeftAt:       member.LeftAt,
+		AddedBy:      member.AddedBy.String(),
+	}
+}
+
+func ToInvestigationTeamResponse(investigation *models.Investigation, members []models.InvestigationMember) InvestigationTeamResponse {
+	leadName := ""
+	if investigation.LeadInvestigator.ID != uuid.Nil {
+		leadName = fmt.Sprintf("%s %s", investigation.LeadInvestigator.FirstName, investigation.LeadInvestigator.LastName)
+	}
+	response := InvestigationTeamResponse{
+		InvestigationID:      investigation.ID.String(),
+		LeadInvestigatorID:   investigation.LeadInvestigatorID.String(),
+		LeadInvestigatorName: leadName,
+		Members:              make([]InvestigationMemberResponse, len(members)),
+	}
+	for i := range members {
+		response.Members[i] = ToInvestigationMemberResponse(&members[i])
 	}
+	return response
 }
@@ -0,0 +1,148 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+)
+
+// FiveWhysDTO is a 5-Whys chain: the problem and the answers to each "why?"
+// in turn, deepest last
+type FiveWhysDTO struct {
+	Problem string         `json:"problem" validate:"required"`
+	Whys    []RootCauseDTO `json:"whys" validate:"required,min=1,max=10,dive"`
+}
+
+// RootCauseDTO is a fishbone cause or an answer in a 5-Whys chain. ParentID
+// makes a fishbone cause a sub-cause; it is ignored in 5-Whys chains.
+type RootCauseDTO struct {
+	Description         string      `json:"description" validate:"required"`
+	Category            string      `json:"category" validate:"omitempty,oneof=people method machine material environment measurement"`
+	ParentID            *uuid.UUID  `json:"parentId"`
+	IsRootCause         bool        `json:"isRootCause"`
+	EvidenceIDs         []uuid.UUID `json:"evidenceIds"`
+	CorrectiveActionIDs []uuid.UUID `json:"correctiveActionIds"`
+}
+
+// UpdateRootCauseDTO replaces a fishbone cause. The links given replace the
+// ones it had.
+type UpdateRootCauseDTO struct {
+	Description         string      `json:"description" validate:"required"`
+	Category            string      `json:"category" validate:"omitempty,oneof=people method machine material environment measurement"`
+	IsRootCause         bool        `json:"isRootCause"`
+	EvidenceIDs         []uuid.UUID `json:"evidenceIds"`
+	CorrectiveActionIDs []uuid.UUID `json:"correctiveActionIds"`
+}
+
+type LinkedEvidenceResponse struct {
+	ID           string `json:"id"`
+	EvidenceType string `json:"evidenceType"`
+	Description  string `json:"description"`
+}
+
+type LinkedActionResponse struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+}
+
+type RootCauseResponse struct {
+	ID                string                   `json:"id"`
+	Category          string                   `json:"category,omitempty"`
+	Description       string                   `json:"description"`
+	IsRootCause       bool                     `json:"isRootCause"`
+	Evidence          []LinkedEvidenceResponse `json:"evidence"`
+	CorrectiveActions []LinkedActionResponse   `json:"correctiveActions"`
+	SubCauses         []RootCauseResponse      `json:"subCauses,omitempty"`
+	CreatedAt         time.Time                `json:"createdAt"`
+	UpdatedAt         time.Time                `json:"updatedAt"`
+}
+
+type FiveWhysResponse struct {
+	ID        string              `json:"id"`
+	Problem   string              `json:"problem"`
+	Whys      []RootCauseResponse `json:"whys"`
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+}
+
+// FishboneCategoryResponse is one bone of the fishbone diagram
+type FishboneCategoryResponse struct {
+	Category string              `json:"category"`
+	Causes   []RootCauseResponse `json:"causes"`
+}
+
+type RootCauseAnalysisResponse struct {
+	InvestigationID string                     `json:"investigationId"`
+	FiveWhys        []FiveWhysResponse         `json:"fiveWhys"`
+	Fishbone        []FishboneCategoryResponse `json:"fishbone"`
+}
+
+func ToRootCauseResponse(cause *models.RootCause) RootCauseResponse {
+	response := RootCauseResponse{
+		ID:                cause.ID.String(),
+		Category:          cause.Category,
+		Description:       cause.Description,
+		IsRootCause:       cause.IsRootCause,
+		Evidence:          make([]LinkedEvidenceResponse, len(cause.Evidence)),
+		CorrectiveActions: make([]LinkedActionResponse, len(cause.CorrectiveActions)),
+		CreatedAt:         cause.CreatedAt,
+		UpdatedAt:         cause.UpdatedAt,
+	}
+	for i, evidence := range cause.Evidence {
+		response.Evidence[i] = LinkedEvidenceResponse{
+			ID:           evidence.ID.String(),
+			EvidenceType: evidence.EvidenceType,
+			Description:  evidence.Description,
+		}
+	}
+	for i, action := range cause.CorrectiveActions {
+		response.CorrectiveActions[i] = LinkedActionResponse{
+			ID:          action.ID.String(),
+			Description: action.Description,
+			Status:      action.Status,
+		}
+	}
+	for i := range cause.SubCauses {
+		response.SubCauses = append(response.SubCauses, ToRootCauseResponse(&cause.SubCauses[i]))
+	}
+	return response
+}
+
+func ToFiveWhysResponse(chain *models.FiveWhys) FiveWhysResponse {
+	response := FiveWhysResponse{
+		ID:        chain.ID.String(),
+		Problem:   chain.Problem,
+		Whys:      make([]RootCauseResponse, len(chain.Whys)),
+		CreatedAt: chain.CreatedAt,
+		UpdatedAt: chain.UpdatedAt,
+	}
+	for i := range chain.Whys {
+		response.Whys[i] = ToRootCauseResponse(&chain.Whys[i])
+	}
+	return response
+}
+
+// ToRootCauseAnalysisResponse lists every fishbone category, empty or not,
+// so the diagram always has all its bones
+func ToRootCauseAnalysisResponse(investigationID uuid.UUID, chains []models.FiveWhys, causes []models.RootCause) RootCauseAnalysisResponse {
+	response := RootCauseAnalysisResponse{
+		InvestigationID: investigationID.String(),
+		FiveWhys:        make([]FiveWhysResponse, len(chains)),
+		Fishbone:        make([]FishboneCategoryResponse, len(models.CauseCategories)),
+	}
+	for i := range chains {
+		response.FiveWhys[i] = ToFiveWhysResponse(&chains[i])
+	}
+	for i, category := range models.CauseCategories {
+		bone := FishboneCategoryResponse{Category: category, Causes: []RootCauseResponse{}}
+		for j := range causes {
+			if causes[j].Category == category {
+				bone.Causes = append(bone.Causes, ToRootCauseResponse(&causes[j]))
+			}
+		}
+		response.Fishbone[i] = bone
+	}
+	return response
+}
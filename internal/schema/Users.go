@@ -22,6 +22,7 @@ type UserLoginRequest struct {
 	Email       string `json:"email" validate:"omitempty,email"`
 	Phone       string `json:"phone" validate:"omitempty"`
 	UserID      string
+	IPAddress   string `json:"-"`
 	Password    string `json:"password" validate:"required,min=8"`
 	GoogleID    string `json:"google_id,omitempty"`
 	MicrosoftID string `json:"microsoft_id,omitempty"`
@@ -58,7 +59,7 @@ type CreateUserWithEmployeeRequest struct {
 	LastName           string     `json:"lastname" validate:"required"`
 	Department         string     `json:"department" validate:"required"`
 	Position           string     `json:"position" validate:"required"`
-	Role               string     `json:"role" validate:"required,oneof=admin safety_officer manager employee"`
+	Role               string     `json:"role" validate:"required"`
 	ReportingManagerID *uuid.UUID `json:"reporting_manager_id"`
 	StartDate          time.Time  `json:"start_date" validate:"required"`
 	EndDate            time.Time  `json:"end_date"`
@@ -96,3 +97,61 @@ type UserUpdateData struct {
 type UpdateUserStatusRequest struct {
 	IsActive bool `json:"status" binding:"required"`
 }
+
+// RefreshTokenRequest carries a refresh token when it is not sent as a cookie
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// AuthTokens is the access/refresh token pair issued for a user session
+type AuthTokens struct {
+	SessionID             string    `json:"sessionId"`
+	AccessToken           string    `json:"token"`
+	AccessTokenExpiresAt  time.Time `json:"expiresAt"`
+	RefreshToken          string    `json:"refreshToken"`
+	RefreshTokenExpiresAt time.Time `json:"refreshExpiresAt"`
+}
+
+// SessionResponse describes an active login session for the session management UI
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+// MFAEnrollmentResponse carries the TOTP secret for the authenticator app
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// MFACodeRequest carries a TOTP code. MFAToken is only needed when the
+// caller is still in the middle of logging in.
+type MFACodeRequest struct {
+	MFAToken string `json:"mfaToken,omitempty"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// MFAVerifyRequest completes a login with either a TOTP code or a recovery code
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfaToken" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// SSOAuthorizeResponse sends the browser to the identity provider
+type SSOAuthorizeResponse struct {
+	AuthorizationURL string    `json:"authorizationUrl"`
+	State            string    `json:"state"`
+	ExpiresAt        time.Time `json:"expiresAt"`
+}
+
+// SSOCallbackRequest carries the parameters the identity provider redirected back with
+type SSOCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/hopkali04/health-sys/internal/models"
@@ -17,6 +18,8 @@ type VPCAttachmentResponse struct {
 	FileSize    int       `json:"fileSize"`
 	CreatedAt   time.Time `json:"createdAt"`
 	Uploader    string    `json:"uploader,omitempty"` // "FirstName LastName"
+
+	models.ImageMetadataResponse
 }
 
 func ToVPCResponse(va *models.VPCAttachment) VPCAttachmentResponse {
@@ -37,6 +40,9 @@ func ToVPCResponse(va *models.VPCAttachment) VPCAttachmentResponse {
 		FileSize:    va.FileSize,
 		CreatedAt:   va.CreatedAt,
 		Uploader:    uploaderName,
+
+		ImageMetadataResponse: va.ImageMetadata.ToResponse(
+			fmt.Sprintf("/api/v1/vpcs/%s/attachments/%s", va.VPCID, va.ID)),
 	}
 }
 
@@ -0,0 +1,54 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+)
+
+// AuditLogFilter narrows an audit log query. Zero values are ignored.
+type AuditLogFilter struct {
+	TableName string
+	RecordID  uuid.UUID
+	UserID    uuid.UUID
+	From      time.Time
+	To        time.Time
+}
+
+type AuditLogResponse struct {
+	ID        uuid.UUID    `json:"id"`
+	Sequence  int64        `json:"sequence"`
+	TableName string       `json:"tableName"`
+	RecordID  uuid.UUID    `json:"recordId"`
+	Action    string       `json:"action"`
+	OldData   models.JSONB `json:"oldData,omitempty"`
+	NewData   models.JSONB `json:"newData,omitempty"`
+	UserID    *uuid.UUID   `json:"userId,omitempty"`
+	IPAddress *string      `json:"ipAddress,omitempty"`
+	CreatedAt time.Time    `json:"createdAt"`
+	PrevHash  string       `json:"prevHash"`
+	Hash      string       `json:"hash"`
+}
+
+// ToAuditLogResponses maps audit log rows to their API representation
+func ToAuditLogResponses(logs []models.AuditLog) []AuditLogResponse {
+	responses := make([]AuditLogResponse, len(logs))
+	for i, log := range logs {
+		responses[i] = AuditLogResponse{
+			ID:        log.ID,
+			Sequence:  log.Sequence,
+			TableName: log.TableName,
+			RecordID:  log.RecordID,
+			Action:    log.Action,
+			OldData:   log.OldData,
+			NewData:   log.NewData,
+			UserID:    log.UserID,
+			IPAddress: log.IPAddress,
+			CreatedAt: log.CreatedAt,
+			PrevHash:  log.PrevHash,
+			Hash:      log.Hash,
+		}
+	}
+	return responses
+}
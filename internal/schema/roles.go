@@ -0,0 +1,73 @@
+package schema
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+)
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleRequest replaces a role's description and permissions. Roles
+// cannot be renamed because employees and issued tokens refer to the name.
+type UpdateRoleRequest struct {
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsSystem    bool      `json:"isSystem"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type PermissionResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+}
+
+func ToRoleResponse(role models.Role) RoleResponse {
+	permissions := make([]string, len(role.Permissions))
+	for i, permission := range role.Permissions {
+		permissions[i] = permission.Name
+	}
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		IsSystem:    role.IsSystem,
+		Permissions: permissions,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+}
+
+func ToRoleResponses(roles []models.Role) []RoleResponse {
+	responses := make([]RoleResponse, len(roles))
+	for i, role := range roles {
+		responses[i] = ToRoleResponse(role)
+	}
+	return responses
+}
+
+func ToPermissionResponses(permissions []models.Permission) []PermissionResponse {
+	responses := make([]PermissionResponse, len(permissions))
+	for i, permission := range permissions {
+		responses[i] = PermissionResponse{
+			ID:          permission.ID,
+			Name:        permission.Name,
+			Description: permission.Description,
+		}
+	}
+	return responses
+}
@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"time"
+)
+
+// SCIM 2.0 schema URNs (RFC 7643, RFC 7644)
+const (
+	SCIMUserSchema           = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMEnterpriseUserSchema = "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"
+	SCIMGroupSchema          = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMListResponseSchema   = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMPatchOpSchema        = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMErrorSchema          = "urn:ietf:params:scim:api:messages:2.0:Error"
+	SCIMServiceConfigSchema  = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMResourceTypeSchema   = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+)
+
+// SCIMMeta describes a SCIM resource
+type SCIMMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// SCIMName is the name of a SCIM user
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMMultiValue is an entry of a multi-valued attribute such as emails
+type SCIMMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMManager references the manager of a SCIM user by their user ID
+type SCIMManager struct {
+	Value       string `json:"value,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+}
+
+// SCIMEnterpriseUser is the enterprise extension of a SCIM user
+type SCIMEnterpriseUser struct {
+	EmployeeNumber string       `json:"employeeNumber,omitempty"`
+	Department     string       `json:"department,omitempty"`
+	Manager        *SCIMManager `json:"manager,omitempty"`
+}
+
+// SCIMUser is a user as exchanged with the directory. Groups are read-only
+// and list the user's role.
+type SCIMUser struct {
+	Schemas      []string            `json:"schemas"`
+	ID           string              `json:"id,omitempty"`
+	ExternalID   string              `json:"externalId,omitempty"`
+	UserName     string              `json:"userName"`
+	Name         *SCIMName           `json:"name,omitempty"`
+	DisplayName  string              `json:"displayName,omitempty"`
+	Title        string              `json:"title,omitempty"`
+	Active       *bool               `json:"active,omitempty"`
+	Emails       []SCIMMultiValue    `json:"emails,omitempty"`
+	PhoneNumbers []SCIMMultiValue    `json:"phoneNumbers,omitempty"`
+	Addresses    []SCIMAddress       `json:"addresses,omitempty"`
+	Groups       []SCIMMultiValue    `json:"groups,omitempty"`
+	Enterprise   *SCIMEnterpriseUser `json:"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User,omitempty"`
+	Meta         *SCIMMeta           `json:"meta,omitempty"`
+}
+
+// SCIMAddress is a postal address; the work address's locality is the office location
+type SCIMAddress struct {
+	Type      string `json:"type,omitempty"`
+	Formatted string `json:"formatted,omitempty"`
+	Locality  string `json:"locality,omitempty"`
+	Primary   bool   `json:"primary,omitempty"`
+}
+
+// SCIMGroup is a role as exchanged with the directory
+type SCIMGroup struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id,omitempty"`
+	DisplayName string           `json:"displayName"`
+	Members     []SCIMMultiValue `json:"members,omitempty"`
+	Meta        *SCIMMeta        `json:"meta,omitempty"`
+}
+
+// SCIMListResponse is a page of query results
+type SCIMListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int64       `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// SCIMPatchOperation is one operation of a PATCH request. Value is kept raw
+// because its shape depends on the path.
+type SCIMPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// SCIMPatchRequest is the body of a PATCH request
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMError is the body of a SCIM error response
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
@@ -2,70 +2,171 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
 	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInterviewNotFound = errors.New("interview not found")
 )
 
 type InterviewService struct {
-	db *gorm.DB
-    notificationService NotificationService
-    correctiveActionService CorrectiveActionService
+	scopeResolver
+	db                      *gorm.DB
+	notificationService     *NotificationService
+	correctiveActionService *CorrectiveActionService
+	// defaultDuration is how long interviews are booked for when no
+	// duration is given
+	defaultDuration time.Duration
+}
+
+func NewInterviewService(db *gorm.DB, cfg config.Interviews, notifications *NotificationService, actions *CorrectiveActionService) *InterviewService {
+	return &InterviewService{
+		scopeResolver:           scopeResolver{db: db},
+		db:                      db,
+		notificationService:     notifications,
+		correctiveActionService: actions,
+		defaultDuration:         cfg.DefaultDuration,
+	}
 }
-func (s *InterviewService) UpdateInterviewStatus(interviewID uuid.UUID, status string, notes string) (*models.InvestigationInterview, error) {
-	interview := &models.InvestigationInterview{}
-	
-	if err := s.db.First(interview, interviewID).Error; err != nil {
+
+// UpdateInterviewStatus changes the status of an interview. Completing it
+// records the interview as testimony evidence.
+func (s *InterviewService) UpdateInterviewStatus(ctx context.Context, scope IncidentScope, interviewID uuid.UUID, status string, notes string) (*models.InvestigationInterview, error) {
+	interview, err := s.scopedInterview(ctx, scope, interviewID)
+	if err != nil {
 		return nil, err
 	}
-	
-	interview.Status = status
-	
-	// If notes are provided, update them
-	if notes != "" {
-		interview.Notes = notes
+	// Rescheduling and cancelling keep the schedule history and update the
+	// calendar invite, so they have their own operations
+	if status == "rescheduled" || status == "cancelled" {
+		return nil, fmt.Errorf("%w: reschedule or cancel the interview instead of setting its status", ErrInvalidSchedule)
 	}
-	
-	// If status is completed, set CompletedAt timestamp
-	if status == "completed" {
-		interview.CompletedAt = time.Now()
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		interview, err := lockInterview(tx, interviewID)
+		if err != nil {
+			return err
+		}
+		if interview.Status == "cancelled" {
+			return fmt.Errorf("%w: the interview is cancelled", ErrInvalidSchedule)
+		}
+		// The acknowledged statement is testimony of the completed interview
+		if interview.AcknowledgedAt != nil {
+			return ErrStatementAcknowledged
+		}
+
+		interview.Status = status
+
+		// If notes are provided, update them
+		if notes != "" {
+			interview.Notes = notes
+		}
+
+		// If status is completed, set CompletedAt timestamp
+		if status == "completed" {
+			interview.CompletedAt = time.Now()
+		}
+
+		if err := tx.Omit(clause.Associations).Save(interview).Error; err != nil {
+			return err
+		}
+		if status == "completed" {
+			return recordTestimony(tx, interview)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	
-	if err := s.db.Save(interview).Error; err != nil {
+
+	interview, err = s.scopedInterview(ctx, scope, interviewID)
+	if err != nil {
 		return nil, err
 	}
-	
+
 	// Send notification about status change
 	if err := s.notificationService.NotifyInterviewStatusChanged(interview, interview.Status); err != nil {
 		log.Printf("Failed to send interview status notification: %v", err)
 	}
-	
+
 	return interview, nil
- }
-// Add these methods to the InvestigationService
-func (s *InterviewService) ScheduleInterview(dto schema.CreateInterviewDTO) (*models.InvestigationInterview, error) {
+}
+
+// ScheduleInterview books an interview unless it overlaps another interview
+// of the interviewee or the lead investigator, and sends them the calendar
+// invite
+func (s *InterviewService) ScheduleInterview(ctx context.Context, scope IncidentScope, dto schema.CreateInterviewDTO, scheduledBy uuid.UUID) (*models.InvestigationInterview, error) {
+	if _, err := scopedInvestigation(ctx, s.db, scope, dto.InvestigationID); err != nil {
+		return nil, err
+	}
+	if !dto.ScheduledFor.After(time.Now()) {
+		return nil, fmt.Errorf("%w: the interview must be scheduled in the future", ErrInvalidSchedule)
+	}
+
+	duration := dto.DurationMinutes
+	if duration == 0 {
+		duration = int(s.defaultDuration / time.Minute)
+	}
 	interview := &models.InvestigationInterview{
 		InvestigationID: dto.InvestigationID,
 		IntervieweeID:   dto.IntervieweeID,
 		ScheduledFor:    dto.ScheduledFor,
+		DurationMinutes: duration,
 		Location:        dto.Location,
 		Status:          "scheduled",
+		Notes:           dto.Notes,
 	}
 
-	if err := s.db.Create(interview).Error; err != nil {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := s.checkConflicts(tx, uuid.Nil, booking{
+			InvestigationID: dto.InvestigationID,
+			IntervieweeID:   dto.IntervieweeID,
+			ScheduledFor:    dto.ScheduledFor,
+			DurationMinutes: duration,
+		})
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(interview).Error; err != nil {
+			return err
+		}
+		change := &models.InterviewScheduleChange{
+			InterviewID:     interview.ID,
+			Action:          models.ScheduleActionScheduled,
+			ScheduledFor:    interview.ScheduledFor,
+			DurationMinutes: interview.DurationMinutes,
+			Location:        interview.Location,
+			ChangedBy:       scheduledBy,
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return fmt.Errorf("failed to record schedule change: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Send notification to interviewee
-	if err := s.notificationService.NotifyInterviewScheduled(interview); err != nil {
-		// Log error but don't fail the operation
-		log.Printf("Failed to send interview notification: %v", err)
-	}
+	// Notify the interviewee and send the invite; failures are logged but
+	// do not fail the operation
+	s.notificationService.inBackground(func() {
+		if err := s.notificationService.NotifyInterviewScheduled(interview); err != nil {
+			log.Printf("Failed to send interview notification: %v", err)
+		}
+		if err := s.notificationService.SendInterviewInvite(interview, ""); err != nil {
+			log.Printf("Failed to send interview calendar invite: %v", err)
+		}
+	})
 
 	return interview, nil
 }
@@ -75,7 +176,6 @@ func (s *InterviewService) AddEvidence(dto schema.CreateEvidenceDTO) (*models.In
 		InvestigationID: dto.InvestigationID,
 		EvidenceType:    dto.EvidenceType,
 		Description:     dto.Description,
-		FileURL:         dto.FileURL,
 		CollectedAt:     time.Now(),
 		CollectedBy:     dto.CollectedBy,
 		StorageLocation: dto.StorageLocation,
@@ -161,32 +261,95 @@ func (s *InterviewService) MonitorDeadlines() error {
 	return nil
 }
 
-// Fetch interview details with evidence
-func (s *InterviewService) GetInterviewDetails(ctx context.Context, interviewID uuid.UUID) (*schema.InvestigationInterviewResponse, error) {
+// Fetch interview details with the transcript. The interviewee may read
+// them to acknowledge their statement.
+func (s *InterviewService) GetInterviewDetails(ctx context.Context, scope IncidentScope, interviewID uuid.UUID) (*schema.InvestigationInterviewResponse, error) {
+	interview, err := s.findInterview(ctx, scope, interviewID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	response := schema.ToInvestigationInterviewResponse(interview)
+	return &response, nil
+}
+
+// scopedInterview finds an interview of an investigation in the scope, with
+// its interviewee and transcript
+func (s *InterviewService) scopedInterview(ctx context.Context, scope IncidentScope, interviewID uuid.UUID) (*models.InvestigationInterview, error) {
+	return s.findInterview(ctx, scope, interviewID, false)
+}
+
+// findInterview finds an interview with its interviewee and transcript.
+// Interviews of investigations outside the scope are reported as not
+// found, except to the interviewee when allowInterviewee is set.
+func (s *InterviewService) findInterview(ctx context.Context, scope IncidentScope, interviewID uuid.UUID, allowInterviewee bool) (*models.InvestigationInterview, error) {
 	var interview models.InvestigationInterview
 	err := s.db.WithContext(ctx).
 		Preload("Interviewee").
-		Preload("Investigation").
+		Preload("Answers", orderByPosition).
+		Preload("ScheduleChanges", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("ScheduleChanges.Changer").
 		First(&interview, "id = ?", interviewID).Error
-
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInterviewNotFound
+	}
 	if err != nil {
-		return nil, fmt.Errorf("interview not found: %w", err)
+		return nil, fmt.Errorf("failed to find interview: %w", err)
 	}
 
-	response := schema.ToInvestigationInterviewResponse(&interview)
-	return &response, nil
+	if scope.All || (allowInterviewee && scope.EmployeeID != uuid.Nil && interview.IntervieweeID == scope.EmployeeID) {
+		return &interview, nil
+	}
+	if _, err := scopedInvestigation(ctx, s.db, scope, interview.InvestigationID); err != nil {
+		if errors.Is(err, ErrInvestigationNotFound) {
+			return nil, ErrInterviewNotFound
+		}
+		return nil, err
+	}
+	return &interview, nil
+}
+
+// lockInterview locks an interview for the rest of the transaction and
+// loads it with its interviewee and transcript, so that checks made on it
+// hold until the change is committed
+func lockInterview(tx *gorm.DB, interviewID uuid.UUID) (*models.InvestigationInterview, error) {
+	var interview models.InvestigationInterview
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&interview, "id = ?", interviewID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInterviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock interview: %w", err)
+	}
+	if err := tx.First(&interview.Interviewee, "id = ?", interview.IntervieweeID).Error; err != nil {
+		return nil, fmt.Errorf("failed to find interviewee: %w", err)
+	}
+	err = orderByPosition(tx.Where("interview_id = ?", interviewID)).Find(&interview.Answers).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interview answers: %w", err)
+	}
+	return &interview, nil
 }
-// Fetch evidence details
-func (s *InterviewService) GetEvidenceDetails(ctx context.Context, evidenceID uuid.UUID) (*schema.InvestigationEvidenceResponse, error) {
+
+// Fetch evidence details of an investigation in the scope
+func (s *InterviewService) GetEvidenceDetails(ctx context.Context, scope IncidentScope, evidenceID uuid.UUID) (*schema.InvestigationEvidenceResponse, error) {
 	var evidence models.InvestigationEvidence
 	err := s.db.WithContext(ctx).
 		Preload("Collector").
 		Preload("Investigation").
 		First(&evidence, "id = ?", evidenceID).Error
-
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEvidenceNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("evidence not found: %w", err)
 	}
+	if _, err := scopedInvestigation(ctx, s.db, scope, evidence.InvestigationID); err != nil {
+		if errors.Is(err, ErrInvestigationNotFound) {
+			return nil, ErrEvidenceNotFound
+		}
+		return nil, err
+	}
 
 	response := schema.ToInvestigationEvidenceResponse(&evidence)
 	return &response, nil
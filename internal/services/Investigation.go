@@ -14,19 +14,22 @@ import (
 )
 
 type InvestigationService struct {
-	DB *gorm.DB
+	scopeResolver
+	DB       *gorm.DB
+	workflow *IncidentWorkflow
 }
 
-func NewInvestigationService(db *gorm.DB) *InvestigationService {
-	return &InvestigationService{DB: db}
+func NewInvestigationService(db *gorm.DB, workflow *IncidentWorkflow) *InvestigationService {
+	return &InvestigationService{scopeResolver: scopeResolver{db: db}, DB: db, workflow: workflow}
 }
 
 // List all investigations with interviews and evidence
-func (s *InvestigationService) FullGetAll(ctx context.Context, limit, offset int) ([]schema.InvestigationResponse, error) {
+func (s *InvestigationService) FullGetAll(ctx context.Context, scope IncidentScope, limit, offset int) ([]schema.InvestigationResponse, error) {
 	var investigations []models.Investigation
 	err := s.DB.WithContext(ctx).
 		Preload("Incident").
 		Preload("LeadInvestigator").
+		Scopes(scope.Investigations).
 		Limit(limit).
 		Offset(offset).
 		Find(&investigations).Error
@@ -38,6 +41,7 @@ func (s *InvestigationService) FullGetAll(ctx context.Context, limit, offset int
 	// Convert to response format
 	responses := make([]schema.InvestigationResponse, len(investigations))
 	for i, investigation := range investigations {
+		scope.Redact(&investigation.Incident)
 		responses[i] = *schema.ConvertToInvestigationResponse(&investigation)
 
 		// Fetch interviews for each investigation
@@ -71,11 +75,11 @@ func (s *InvestigationService) FullGetAll(ctx context.Context, limit, offset int
 
 	return responses, nil
 }
-func (s *InvestigationService) GetAll(status string, limit, offset int) ([]models.Investigation, error) {
+func (s *InvestigationService) GetAll(scope IncidentScope, status string, limit, offset int) ([]models.Investigation, error) {
 	var investigations []models.Investigation
 
 	// Start building the query
-	query := s.DB.Model(&models.Investigation{})
+	query := s.DB.Model(&models.Investigation{}).Scopes(scope.Investigations)
 
 	// Apply filters if provided
 	if status != "" {
@@ -100,36 +104,12 @@ func (s *InvestigationService) GetAll(status string, limit, offset int) ([]model
 
 	return investigations, nil
 }
-func (s *InvestigationService) CloseInvestigation(id uuid.UUID) error {
-	// Fetch the investigation
-	var investigation models.Investigation
-	if err := s.DB.First(&investigation, "id = ?", id).Error; err != nil {
-		return fmt.Errorf("investigation not found: %w", err)
-	}
-
-	// Check if the investigation is already closed
-	if investigation.Status == "completed" {
-		return fmt.Errorf("investigation is already closed")
-	}
-
-	// Update the investigation status and set the completion time
-	now := time.Now()
-	investigation.Status = "completed"
-	investigation.CompletedAt = &now
-
-	// Save the updated investigation
-	if err := s.DB.Save(&investigation).Error; err != nil {
-		return fmt.Errorf("failed to close investigation: %w", err)
-	}
-
-	return nil
-}
-
-func (s *InvestigationService) FullGetByIncidentID(ctx context.Context, incidentID uuid.UUID) (*schema.InvestigationResponse, error) {
+func (s *InvestigationService) FullGetByIncidentID(ctx context.Context, scope IncidentScope, incidentID uuid.UUID) (*schema.InvestigationResponse, error) {
 	var investigation models.Investigation
 	err := s.DB.WithContext(ctx).
 		Preload("Incident").
 		Preload("LeadInvestigator").
+		Scopes(scope.Investigations).
 		Where("incident_id = ?", incidentID).
 		First(&investigation).Error
 
@@ -138,24 +118,32 @@ func (s *InvestigationService) FullGetByIncidentID(ctx context.Context, incident
 	}
 
 	// Use GetByID to get full details including interviews and evidence
-	return s.FullGetByID(ctx, investigation.ID)
+	return s.FullGetByID(ctx, scope, investigation.ID)
 }
-func (s *InvestigationService) GetByIncidentID(incidentID uuid.UUID) (*models.Investigation, error) {
+func (s *InvestigationService) GetByIncidentID(scope IncidentScope, incidentID uuid.UUID) (*models.Investigation, error) {
 	investigation := &models.Investigation{}
-	if err := s.DB.Where("incident_id = ?", incidentID).First(investigation).Error; err != nil {
+	if err := s.DB.Scopes(scope.Investigations).Where("incident_id = ?", incidentID).First(investigation).Error; err != nil {
 		return nil, err
 	}
 	return investigation, nil
 }
-func (s *InvestigationService) GetAllByEmployeeID(employeeID uuid.UUID) ([]models.Investigation, error) {
+func (s *InvestigationService) GetAllByEmployeeID(scope IncidentScope, employeeID uuid.UUID) ([]models.Investigation, error) {
     var investigations []models.Investigation
     
-    result := s.DB.Preload("Incident").Preload("LeadInvestigator").Where("lead_investigator_id = ?", employeeID).Find(&investigations)
+    // Investigations the employee leads or is a current team member of
+    result := s.DB.Preload("Incident").Preload("LeadInvestigator").
+        Scopes(scope.Investigations).
+        Where("(investigations.lead_investigator_id = @employee OR investigations.id IN ("+memberQuery+"))",
+            map[string]interface{}{"employee": employeeID}).
+        Find(&investigations)
     
     if result.Error != nil {
         return nil, result.Error
     }
     
+    for i := range investigations {
+        scope.Redact(&investigations[i].Incident)
+    }
     return investigations, nil
 }
 func (s *InvestigationService) IsIncidentOpen(incidentID uuid.UUID) (bool, error) {
@@ -166,9 +154,9 @@ func (s *InvestigationService) IsIncidentOpen(incidentID uuid.UUID) (bool, error
     return incident.Status != "closed", nil
 }
 // Create a new investigation
-func (s *InvestigationService) Create(form *schema.InvestigationForm) (*models.Investigation, error) {
+func (s *InvestigationService) Create(ctx context.Context, form *schema.InvestigationForm) (*models.Investigation, error) {
 	// Start a transaction
-	tx := s.DB.Begin()
+	tx := s.DB.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -188,10 +176,9 @@ func (s *InvestigationService) Create(form *schema.InvestigationForm) (*models.I
 		return nil, err
 	}
 
-	// Update the incident status to 'investigating'
-	if err := tx.Model(&models.Incident{}).
-		Where("id = ?", form.IncidentID).
-		Update("status", "investigating").Error; err != nil {
+	// Move the incident to 'investigating' where the workflow allows it
+	change, err := s.workflow.Advance(tx, form.IncidentID, models.IncidentStatusInvestigating, nil)
+	if err != nil {
 		tx.Rollback() // Rollback the transaction in case of error
 		return nil, err
 	}
@@ -200,28 +187,34 @@ func (s *InvestigationService) Create(form *schema.InvestigationForm) (*models.I
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
+	s.workflow.Notify(change)
 
 	return &investigation, nil
 }
 
 // Get an investigation by ID
-func (s *InvestigationService) GetByID(id uuid.UUID) (*models.Investigation, error) {
+func (s *InvestigationService) GetByID(scope IncidentScope, id uuid.UUID) (*models.Investigation, error) {
 	investigation := &models.Investigation{}
-	if err := s.DB.Preload("Incident").Preload("LeadInvestigator").First(investigation, id).Error; err != nil {
+	if err := s.DB.Preload("Incident").Preload("LeadInvestigator").
+		Scopes(scope.Investigations).
+		First(investigation, "investigations.id = ?", id).Error; err != nil {
 		return nil, err
 	}
+	scope.Redact(&investigation.Incident)
 	return investigation, nil
 }
-func (s *InvestigationService) FullGetByID(ctx context.Context, id uuid.UUID) (*schema.InvestigationResponse, error) {
+func (s *InvestigationService) FullGetByID(ctx context.Context, scope IncidentScope, id uuid.UUID) (*schema.InvestigationResponse, error) {
 	var investigation models.Investigation
 	err := s.DB.WithContext(ctx).
 		Preload("Incident").
 		Preload("LeadInvestigator").
-		First(&investigation, "id = ?", id).Error
+		Scopes(scope.Investigations).
+		First(&investigation, "investigations.id = ?", id).Error
 
 	if err != nil {
 		return nil, fmt.Errorf("investigation not found: %w", err)
 	}
+	scope.Redact(&investigation.Incident)
 
 	// Convert to response
 	response := schema.ConvertToInvestigationResponse(&investigation)
@@ -283,16 +276,21 @@ func (r *InvestigationService) GetEmployeeByUserID(userID uuid.UUID) (*models.Em
 }
 
 // Update an investigation
-func (s *InvestigationService) Update(id uuid.UUID, form *schema.FlexibleInvestigationForm) (*models.Investigation, error) {
+func (s *InvestigationService) Update(ctx context.Context, id uuid.UUID, form *schema.FlexibleInvestigationForm) (*models.Investigation, error) {
 	// Retrieve existing investigation
 	var investigation models.Investigation
-	if err := s.DB.First(&investigation, "id = ?", id).Error; err != nil {
+	if err := s.DB.WithContext(ctx).First(&investigation, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("investigation not found")
 		}
 		return nil, err
 	}
 
+	// Signed-off findings must not change under their reviewers
+	if investigation.Locked() {
+		return nil, ErrInvestigationLocked
+	}
+
 	// Convert the original form to the flexible form
 	flexForm := &schema.FlexibleInvestigationForm{}
 	jsonData, err := json.Marshal(form)
@@ -350,30 +348,26 @@ func (s *InvestigationService) Update(id uuid.UUID, form *schema.FlexibleInvesti
 		investigation.CompletedAt = flexForm.CompletedAt
 	}
 
-	if flexForm.Status != nil {
-		investigation.Status = *flexForm.Status
-	}
-
+	// The status is changed by the review chain, not by editing
 	investigation.UpdatedAt = time.Now()
-	investigation.Status = "pending_review"
 
-	if err := s.DB.Save(&investigation).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Save(&investigation).Error; err != nil {
 		return nil, err
 	}
 	return &investigation, nil
 }
 
 // Delete an investigation
-func (s *InvestigationService) Delete(id uuid.UUID) error {
+func (s *InvestigationService) Delete(ctx context.Context, id uuid.UUID) error {
 	var investigation models.Investigation
-	if err := s.DB.First(&investigation, "id = ?", id).Error; err != nil {
+	if err := s.DB.WithContext(ctx).First(&investigation, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("investigation not found")
 		}
 		return err
 	}
 
-	if err := s.DB.Delete(&investigation).Error; err != nil {
+	if err := s.DB.WithContext(ctx).Delete(&investigation).Error; err != nil {
 		return err
 	}
 	return nil
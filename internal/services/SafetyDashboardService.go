@@ -13,11 +13,12 @@ import (
 )
 
 type SafetyDashboardService struct {
+	scopeResolver
 	db *gorm.DB
 }
 
 func NewSafetyDashboardService(db *gorm.DB) *SafetyDashboardService {
-	return &SafetyDashboardService{db: db}
+	return &SafetyDashboardService{scopeResolver: scopeResolver{db: db}, db: db}
 }
 func (r *SafetyDashboardService) GetEmployeeByUserID(userID uuid.UUID) (*models.Employee, error) {
 	var employee models.Employee
@@ -32,8 +33,9 @@ func (r *SafetyDashboardService) GetEmployeeByUserID(userID uuid.UUID) (*models.
 	return &employee, nil
 }
 
-// GetEmployeeDashboard returns incidents and metrics relevant to a specific employee
-func (s *SafetyDashboardService) GetEmployeeDashboard(userID uuid.UUID, timeRange string) (*models.DashboardResponse, error) {
+// GetEmployeeDashboard returns incidents and metrics relevant to a specific
+// employee, limited to what the viewer's scope allows
+func (s *SafetyDashboardService) GetEmployeeDashboard(scope IncidentScope, userID uuid.UUID, timeRange string) (*models.DashboardResponse, error) {
 	var employee models.Employee
 	if err := s.db.First(&employee, "user_id = ?", userID).Error; err != nil {
 		return nil, errors.New("employee not found")
@@ -47,16 +49,28 @@ func (s *SafetyDashboardService) GetEmployeeDashboard(userID uuid.UUID, timeRang
 	// Get incidents reported by or assigned to the employee
 	var incidents []models.Incident
 	query := s.db.Where("(reported_by = ? OR assigned_to = ?) AND occurred_at >= ?",
-		employeeID, employeeID, timeFilter)
+		employeeID, employeeID, timeFilter).
+		Scopes(scope.Incidents)
 
 	if err := query.Find(&incidents).Error; err != nil {
 		return nil, err
 	}
+	scope.RedactAll(incidents)
 
 	// Get corrective actions assigned to the employee
 	var actions []models.CorrectiveAction
-	if err := s.db.Where("assigned_to = ? AND due_date >= ?",
-		employeeID, timeFilter).Find(&actions).Error; err != nil {
+	actionQuery := s.db.Where("assigned_to = ? AND due_date >= ?", employeeID, timeFilter)
+	if !scope.All {
+		actionQuery = actionQuery.Where("incident_id IN (?)", scope.incidentIDs(s.db))
+	}
+	if err := actionQuery.Find(&actions).Error; err != nil {
+		return nil, err
+	}
+
+	// Investigations the employee leads or is on the team of, whatever
+	// the time range
+	investigations, err := myInvestigations(s.db, scope, employeeID)
+	if err != nil {
 		return nil, err
 	}
 
@@ -66,14 +80,23 @@ func (s *SafetyDashboardService) GetEmployeeDashboard(userID uuid.UUID, timeRang
 		Incidents:         incidents,
 		Metrics:           metrics,
 		CorrectiveActions: actions,
+		Investigations:    investigations,
 	}, nil
 }
 
-// GetAdminDashboard returns system-wide safety metrics and incidents
-func (s *SafetyDashboardService) GetAdminDashboard(filters models.DashboardFilters) (*models.AdminDashboardResponse, error) {
+// GetAdminDashboard returns safety metrics and incidents across the scope:
+// system-wide for safety officers, the team for managers
+func (s *SafetyDashboardService) GetAdminDashboard(scope IncidentScope, filters models.DashboardFilters) (*models.AdminDashboardResponse, error) {
 	timeFilter := s.getTimeFilter(filters.TimeRange)
 
-	query := s.db.Model(&models.Incident{}).Where("occurred_at >= ?", timeFilter)
+	// Raw queries below restrict themselves to the scope with this condition
+	scopeCondition, scopeArgs := "", []interface{}{}
+	if !scope.All {
+		scopeCondition = "AND i.id IN (?)"
+		scopeArgs = append(scopeArgs, scope.incidentIDs(s.db))
+	}
+
+	query := s.db.Model(&models.Incident{}).Where("occurred_at >= ?", timeFilter).Scopes(scope.Incidents)
 
 	// Apply optional filters
 	if filters.DepartmentName != "" {
@@ -93,6 +116,7 @@ func (s *SafetyDashboardService) GetAdminDashboard(filters models.DashboardFilte
 	if err := query.Find(&incidents).Error; err != nil {
 		return nil, err
 	}
+	scope.RedactAll(incidents)
 
 	// Calculate department-wise metrics
 	var departmentMetrics []models.DepartmentMetrics
@@ -105,8 +129,8 @@ func (s *SafetyDashboardService) GetAdminDashboard(filters models.DashboardFilte
 			SUM(CASE WHEN i.severity_level = 'critical' THEN 1 ELSE 0 END) as critical_incidents
 		FROM incidents i
 		JOIN employees e ON i.reported_by = e.id
-		WHERE i.occurred_at >= ?
-		GROUP BY e.department`, timeFilter).
+		WHERE i.occurred_at >= ? `+scopeCondition+`
+		GROUP BY e.department`, append([]interface{}{timeFilter}, scopeArgs...)...).
 		Scan(&departmentMetrics).Error; err != nil {
 		return nil, err
 	}
@@ -127,12 +151,12 @@ func (s *SafetyDashboardService) GetAdminDashboard(filters models.DashboardFilte
         END) as average_severity,
         MAX(occurred_at) as last_reported_at,
         STRING_AGG(DISTINCT e.department, ',') as affected_departments
-    FROM incidents
-    JOIN employees e ON incidents.reported_by = e.id
-    WHERE incidents.occurred_at >= ?
+    FROM incidents i
+    JOIN employees e ON i.reported_by = e.id
+    WHERE i.occurred_at >= ? `+scopeCondition+`
     GROUP BY type
     ORDER BY frequency DESC
-    LIMIT 5`, timeFilter).
+    LIMIT 5`, append([]interface{}{timeFilter}, scopeArgs...)...).
 		Scan(&topHazards).Error; err != nil {
 		return nil, err
 	}
@@ -145,7 +169,7 @@ func (s *SafetyDashboardService) GetAdminDashboard(filters models.DashboardFilte
 	}
 
 	// Get trend analysis
-	trendAnalysis, err := s.calculateTrendAnalysis(filters.TimeRange)
+	trendAnalysis, err := s.calculateTrendAnalysis(scope, filters.TimeRange)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +184,7 @@ func (s *SafetyDashboardService) GetAdminDashboard(filters models.DashboardFilte
 }
 
 // calculateTrendAnalysis calculates incident trends over time
-func (s *SafetyDashboardService) calculateTrendAnalysis(timeRange string) (models.TrendAnalysis, error) {
+func (s *SafetyDashboardService) calculateTrendAnalysis(scope IncidentScope, timeRange string) (models.TrendAnalysis, error) {
 	// Determine time period based on the filter
 	timePeriod := "monthly" // Default
 	if timeRange == "week" {
@@ -244,14 +268,14 @@ func (s *SafetyDashboardService) calculateTrendAnalysis(timeRange string) (model
 		var totalSeverity int64
 
 		// Get total incidents
-		if err := s.db.Model(&models.Incident{}).
+		if err := s.db.Model(&models.Incident{}).Scopes(scope.Incidents).
 			Where("occurred_at >= ? AND occurred_at < ?", startInterval, endInterval).
 			Count(&incidentCount).Error; err != nil {
 			return models.TrendAnalysis{}, err
 		}
 
 		// Get resolved incidents
-		if err := s.db.Model(&models.Incident{}).
+		if err := s.db.Model(&models.Incident{}).Scopes(scope.Incidents).
 			Where("occurred_at >= ? AND occurred_at < ? AND status IN ('resolved', 'closed')",
 				startInterval, endInterval).
 			Count(&resolvedCount).Error; err != nil {
@@ -259,13 +283,13 @@ func (s *SafetyDashboardService) calculateTrendAnalysis(timeRange string) (model
 		}
 
 		// Get critical incidents
-		if err := s.db.Model(&models.Incident{}).
+		if err := s.db.Model(&models.Incident{}).Scopes(scope.Incidents).
 			Where("occurred_at >= ? AND occurred_at < ?", startInterval, endInterval).
 			Count(&totalSeverity).Error; err != nil {
 			return models.TrendAnalysis{}, err
 		}
 
-		if err := s.db.Model(&models.Incident{}).
+		if err := s.db.Model(&models.Incident{}).Scopes(scope.Incidents).
 			Where("occurred_at >= ? AND occurred_at < ? AND severity_level = 'critical'",
 				startInterval, endInterval).
 			Count(&criticalCount).Error; err != nil {
@@ -279,16 +303,14 @@ func (s *SafetyDashboardService) calculateTrendAnalysis(timeRange string) (model
 			var result struct {
 				WeightedSeverity float64
 			}
-			if err := s.db.Raw(`
-                SELECT 
-                    SUM(CASE 
+			if err := s.db.Model(&models.Incident{}).Scopes(scope.Incidents).
+				Select(`SUM(CASE 
                         WHEN severity_level = 'critical' THEN 3
                         WHEN severity_level = 'major' THEN 2
                         ELSE 1
-                    END) / COUNT(*) as weighted_severity
-                FROM incidents
-                WHERE occurred_at >= ? AND occurred_at < ?
-            `, startInterval, endInterval).Scan(&result).Error; err != nil {
+                    END) / COUNT(*) as weighted_severity`).
+				Where("occurred_at >= ? AND occurred_at < ?", startInterval, endInterval).
+				Scan(&result).Error; err != nil {
 				return models.TrendAnalysis{}, err
 			}
 			severityValue = result.WeightedSeverity
@@ -1,20 +1,34 @@
 package services
 
 import (
-	"os"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
 
 	"github.com/google/uuid"
 	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/services/upload"
+	"github.com/hopkali04/health-sys/internal/utils"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrIncidentNotFound   = errors.New("incident not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
 // Add CRUD operations for IncidentAttachment
 type AttachmentService struct {
-	db *gorm.DB
+	scopeResolver
+	db      *gorm.DB
+	storage storage.Storage
+	uploads *upload.Service
 }
 
-func NewAttachmentService(db *gorm.DB) *AttachmentService {
-	return &AttachmentService{db: db}
+func NewAttachmentService(db *gorm.DB, store storage.Storage, uploads *upload.Service) *AttachmentService {
+	return &AttachmentService{scopeResolver: scopeResolver{db: db}, db: db, storage: store, uploads: uploads}
 }
 
 // CreateAttachment creates a new attachment
@@ -41,31 +55,191 @@ func (s *AttachmentService) ListAttachments(incidentID uuid.UUID) ([]models.Inci
 	return attachments, err
 }
 
-// DeleteAttachment deletes an attachment
-func (s *AttachmentService) DeleteAttachment(id uuid.UUID) error {
+// ListVisibleAttachments lists the attachments of an incident in the scope
+func (s *AttachmentService) ListVisibleAttachments(ctx context.Context, scope IncidentScope, incidentID uuid.UUID) ([]models.IncidentAttachment, error) {
+	if err := s.checkIncident(ctx, scope, incidentID); err != nil {
+		return nil, err
+	}
+	return s.ListAttachments(incidentID)
+}
+
+// AddAttachments uploads files to an existing incident. Each upload gets its
+// own folder, so files with the same name do not overwrite each other.
+func (s *AttachmentService) AddAttachments(ctx context.Context, scope IncidentScope, incidentID uuid.UUID, files []*multipart.FileHeader, uploadedBy uuid.UUID) ([]models.IncidentAttachment, error) {
+	if err := s.checkIncident(ctx, scope, incidentID); err != nil {
+		return nil, err
+	}
+
+	attachments := make([]models.IncidentAttachment, 0, len(files))
+	err := withUploads(ctx, s.db, s.uploads, func(tx *gorm.DB, stage *upload.Stage) error {
+		for _, file := range files {
+			stored, err := stage.SaveAttachment(ctx, storage.Key("incidents", incidentID.String(), uuid.NewString()), file)
+			if err != nil {
+				return err
+			}
+			attachments = append(attachments, models.IncidentAttachment{
+				IncidentID:    incidentID,
+				FileName:      stored.Name,
+				FileType:      stored.ContentType,
+				FileSize:      int(stored.Size),
+				StoragePath:   stored.Key,
+				UploadedBy:    uploadedBy,
+				ImageMetadata: imageMetadata(stored),
+			})
+		}
+		if err := tx.Create(&attachments).Error; err != nil {
+			return fmt.Errorf("failed to create attachment records: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// ReplaceAttachment swaps the file of an attachment for a new upload. The
+// old file is deleted once the record points at the new one.
+func (s *AttachmentService) ReplaceAttachment(ctx context.Context, scope IncidentScope, incidentID, id uuid.UUID, file *multipart.FileHeader, uploadedBy uuid.UUID) (*models.IncidentAttachment, error) {
+	attachment, err := s.findAttachment(ctx, scope, incidentID, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := *attachment
+
+	err = withUploads(ctx, s.db, s.uploads, func(tx *gorm.DB, stage *upload.Stage) error {
+		stored, err := stage.SaveAttachment(ctx, storage.Key("incidents", incidentID.String(), uuid.NewString()), file)
+		if err != nil {
+			return err
+		}
+		updates := fileColumns(stored)
+		updates["uploaded_by"] = uploadedBy
+		if err := tx.Model(attachment).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update attachment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleteStoredFiles(ctx, s.storage, previous.StoragePath, previous.ThumbnailPath, previous.PreviewPath)
+	return s.GetAttachment(id)
+}
+
+// DeleteAttachment deletes an attachment of an incident in the scope
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, scope IncidentScope, incidentID, id uuid.UUID) error {
 	// First get the attachment to get the file path
-	attachment, err := s.GetAttachment(id)
+	attachment, err := s.findAttachment(ctx, scope, incidentID, id)
 	if err != nil {
 		return err
 	}
 
-	// Start a transaction
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		return tx.Error
+	if err := s.db.WithContext(ctx).Delete(&models.IncidentAttachment{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
 	}
 
-	// Delete from database
-	if err := tx.Delete(&models.IncidentAttachment{}, "id = ?", id).Error; err != nil {
-		tx.Rollback()
-		return err
+	// Delete the actual file and its resized copies once nothing refers to them
+	deleteStoredFiles(ctx, s.storage, attachment.StoragePath, attachment.ThumbnailPath, attachment.PreviewPath)
+	return nil
+}
+
+// checkIncident reports incidents outside the scope as not found
+func (s *AttachmentService) checkIncident(ctx context.Context, scope IncidentScope, incidentID uuid.UUID) error {
+	var count int64
+	err := s.db.WithContext(ctx).Model(&models.Incident{}).
+		Scopes(scope.Incidents).
+		Where("incidents.id = ?", incidentID).
+		Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("failed to find incident: %w", err)
 	}
+	if count == 0 {
+		return ErrIncidentNotFound
+	}
+	return nil
+}
 
-	// Delete the actual file
-	if err := os.Remove(attachment.StoragePath); err != nil {
-		tx.Rollback()
-		return err
+func (s *AttachmentService) findAttachment(ctx context.Context, scope IncidentScope, incidentID, id uuid.UUID) (*models.IncidentAttachment, error) {
+	if err := s.checkIncident(ctx, scope, incidentID); err != nil {
+		return nil, err
 	}
+	var attachment models.IncidentAttachment
+	err := s.db.WithContext(ctx).First(&attachment, "id = ? AND incident_id = ?", id, incidentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find attachment: %w", err)
+	}
+	return &attachment, nil
+}
 
-	return tx.Commit().Error
+// withUploads runs fn in a database transaction with a stage for the files
+// it uploads. See commitUploads.
+func withUploads(ctx context.Context, db *gorm.DB, uploads *upload.Service, fn func(tx *gorm.DB, stage *upload.Stage) error) error {
+	stage := uploads.Stage()
+	return commitUploads(ctx, db, stage, func(tx *gorm.DB) error {
+		return fn(tx, stage)
+	})
+}
+
+// commitUploads runs fn in a database transaction and moves the staged
+// files into place just before it commits. If anything fails, the records
+// are rolled back and the files deleted, so neither outlives the other.
+func commitUploads(ctx context.Context, db *gorm.DB, stage *upload.Stage, fn func(tx *gorm.DB) error) error {
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return stage.Commit(ctx)
+	})
+	if err != nil {
+		stage.Rollback(ctx)
+	}
+	return err
+}
+
+// deleteStoredFiles deletes files that are no longer referenced. Failures
+// are logged; a file left behind is only wasted space.
+func deleteStoredFiles(ctx context.Context, store storage.Storage, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			utils.LogError("Failed to delete stored file", map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// fileColumns are the columns of an attachment record that describe its
+// stored file
+func fileColumns(file *upload.File) map[string]interface{} {
+	metadata := imageMetadata(file)
+	return map[string]interface{}{
+		"file_name":      file.Name,
+		"file_type":      file.ContentType,
+		"file_size":      int(file.Size),
+		"storage_path":   file.Key,
+		"thumbnail_path": metadata.ThumbnailPath,
+		"preview_path":   metadata.PreviewPath,
+		"image_width":    metadata.ImageWidth,
+		"image_height":   metadata.ImageHeight,
+		"captured_at":    metadata.CapturedAt,
+		"latitude":       metadata.Latitude,
+		"longitude":      metadata.Longitude,
+	}
+}
+
+// employeeByUserID finds the employee record of a user account
+func employeeByUserID(db *gorm.DB, userID uuid.UUID) (*models.Employee, error) {
+	var employee models.Employee
+	if err := db.Where("user_id = ?", userID).First(&employee).Error; err != nil {
+		return nil, fmt.Errorf("failed to find employee: %w", err)
+	}
+	return &employee, nil
 }
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/audit"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"gorm.io/gorm"
+)
+
+type AuditService struct {
+	db *gorm.DB
+}
+
+func NewAuditService(db *gorm.DB) *AuditService {
+	return &AuditService{db: db}
+}
+
+// ListAuditLogs returns audit log entries matching the filter, newest first
+func (s *AuditService) ListAuditLogs(ctx context.Context, filter schema.AuditLogFilter, page, pageSize int) ([]models.AuditLog, int64, error) {
+	var logs []models.AuditLog
+	var total int64
+
+	query := s.db.WithContext(ctx).Model(&models.AuditLog{})
+	if filter.TableName != "" {
+		query = query.Where("table_name = ?", filter.TableName)
+	}
+	if filter.RecordID != uuid.Nil {
+		query = query.Where("record_id = ?", filter.RecordID)
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	return logs, total, nil
+}
+
+// ChainHead returns the newest link of the audit hash chain so it can be
+// recorded outside the database
+func (s *AuditService) ChainHead(ctx context.Context) (*audit.ChainHead, error) {
+	return audit.Head(s.db.WithContext(ctx))
+}
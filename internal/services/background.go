@@ -0,0 +1,32 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// runInBackground runs a task in its own goroutine. A panic in the task is
+// logged instead of taking the server down.
+func runInBackground(task string, run func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				utils.LogError(task+" panicked", map[string]interface{}{
+					"panic": fmt.Sprint(r),
+				})
+			}
+		}()
+		run()
+	}()
+}
+
+// inBackground sends notifications in the background, so a slow mail server
+// or a failure to notify never holds up or fails the change notified. It
+// does nothing when there is no notification service.
+func (s *NotificationService) inBackground(send func()) {
+	if s == nil {
+		return
+	}
+	runInBackground("Notification", send)
+}
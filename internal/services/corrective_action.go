@@ -10,15 +10,32 @@ import (
 	"github.com/google/uuid"
 	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/upload"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrActionNotFound = errors.New("corrective action not found")
+	// ErrNotAssignee means only the employee the action is assigned to may
+	// do what was asked
+	ErrNotAssignee = errors.New("only the assignee can work on the corrective action")
+	// ErrActionNotOpen means the action is completed or verified
+	ErrActionNotOpen = errors.New("the corrective action is not open")
+	// ErrActionStatusReserved means the status is only reached by completing
+	// or verifying the action, which records who did it and when
+	ErrActionStatusReserved = errors.New("completed and verified are set by completing and verifying the corrective action")
+)
+
+// openActionStatuses are the statuses an assignee works on an action in
+var openActionStatuses = []string{"pending", "in_progress"}
+
 type CorrectiveActionService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	workflow *IncidentWorkflow
 }
 
-func NewCorrectiveActionService(db *gorm.DB) *CorrectiveActionService {
-	return &CorrectiveActionService{db: db}
+func NewCorrectiveActionService(db *gorm.DB, workflow *IncidentWorkflow) *CorrectiveActionService {
+	return &CorrectiveActionService{db: db, workflow: workflow}
 }
 
 func (s *CorrectiveActionService) InternalGetByID(ctx context.Context, id uuid.UUID) (*models.CorrectiveAction, error) {
@@ -186,11 +203,9 @@ func (s *CorrectiveActionService) Create(ctx context.Context, req schema.CreateC
 		return nil, fmt.Errorf("database error: %w", err)
 	}
 
-	// Update the incident status to 'action_required'
-	if err := tx.Model(&models.Incident{}).
-		Where("id = ?", incidentID).
-		Update("status", "action_required").
-		Error; err != nil {
+	// Move the incident to 'action_required' where the workflow allows it
+	change, err := s.workflow.Advance(tx, incidentID, models.IncidentStatusActionRequired, &empID)
+	if err != nil {
 		tx.Rollback()
 		return nil, fmt.Errorf("failed to update incident status: %w", err)
 	}
@@ -199,6 +214,7 @@ func (s *CorrectiveActionService) Create(ctx context.Context, req schema.CreateC
 	if err := tx.Commit().Error; err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	s.workflow.Notify(change)
 
 	return correctiveAction, nil
 }
@@ -264,19 +280,10 @@ func (s *CorrectiveActionService) AdminCompleteActionAndVerify(ctx context.Conte
 		return fmt.Errorf("failed to complete corrective action: %w", err)
 	}
 
-	// Fetch the associated incident
-	var incident models.Incident
-	if err := tx.First(&incident, "id = ?", action.IncidentID).Error; err != nil {
-		tx.Rollback() // Rollback in case of an error
-		return fmt.Errorf("failed to find incident: %w", err)
-	}
-
-	// Update the incident status
-	incident.Status = "resolved"
-
-	// incident.ClosedAt = time.Now()
-
-	if err := tx.Save(&incident).Error; err != nil {
+	// Resolve the incident once the workflow allows it, i.e. when this was
+	// its last open action
+	change, err := s.workflow.Advance(tx, action.IncidentID, models.IncidentStatusResolved, &verifierID)
+	if err != nil {
 		tx.Rollback() // Rollback in case of an error
 		return fmt.Errorf("failed to update incident: %w", err)
 	}
@@ -285,6 +292,7 @@ func (s *CorrectiveActionService) AdminCompleteActionAndVerify(ctx context.Conte
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	s.workflow.Notify(change)
 
 	return nil
 }
@@ -320,7 +328,10 @@ func (s *CorrectiveActionService) Update(ctx context.Context, id uuid.UUID, req
 		action.Priority = req.Priority
 	}
 
-	if req.Status != "" {
+	if req.Status != "" && req.Status != action.Status {
+		if req.Status == "completed" || req.Status == "verified" {
+			return nil, fmt.Errorf("%w: use the complete and verify endpoints", ErrActionStatusReserved)
+		}
 		action.Status = req.Status
 	}
 
@@ -397,6 +408,24 @@ func (s *CorrectiveActionService) CreateActionEvidence(evidence *models.ActionEv
 
 	return nil
 }
+
+// CreateStagedActionEvidence creates evidence records for files staged in
+// stage. The files are moved into place only if the records are created,
+// and deleted otherwise.
+func (s *CorrectiveActionService) CreateStagedActionEvidence(ctx context.Context, stage *upload.Stage, evidence []*models.ActionEvidence) error {
+	return commitUploads(ctx, s.db, stage, func(tx *gorm.DB) error {
+		for _, e := range evidence {
+			if e.CorrectiveActionID == uuid.Nil || e.FileType == "" || e.FileName == "" || e.FileURL == "" || e.UploadedBy == uuid.Nil {
+				return fmt.Errorf("missing required fields")
+			}
+			if err := tx.Create(e).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (s *CorrectiveActionService) GetActionEvidenceByID(id uuid.UUID) (*models.ActionEvidence, error) {
 	var evidence models.ActionEvidence
 
@@ -466,19 +495,10 @@ func (s *CorrectiveActionService) VerifyCompletion(ctx context.Context, actionID
 		return fmt.Errorf("failed to verify corrective action: %w", err)
 	}
 
-	// Fetch the associated incident
-	var incident models.Incident
-	if err := tx.First(&incident, "id = ?", action.IncidentID).Error; err != nil {
-		tx.Rollback() // Rollback in case of an error
-		return fmt.Errorf("failed to find incident: %w", err)
-	}
-
-	// Update the incident status
-	incident.Status = "resolved"
-	now = time.Now()
-	incident.ClosedAt = &now
-
-	if err := tx.Save(&incident).Error; err != nil {
+	// Resolve the incident once the workflow allows it, i.e. when this was
+	// its last open action
+	change, err := s.workflow.Advance(tx, action.IncidentID, models.IncidentStatusResolved, &verifierID)
+	if err != nil {
 		tx.Rollback() // Rollback in case of an error
 		return fmt.Errorf("failed to update incident: %w", err)
 	}
@@ -487,46 +507,72 @@ func (s *CorrectiveActionService) VerifyCompletion(ctx context.Context, actionID
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	s.workflow.Notify(change)
 
 	return nil
 }
 
-func (s *CorrectiveActionService) LabelAsCompleted(id uuid.UUID, notes string) error {
-	// Fetch the investigation
-	var action models.CorrectiveAction
-	if err := s.db.First(&action, "id = ?", id).Error; err != nil {
-		return fmt.Errorf("action not found: %w", err)
-	}
+// CheckAssignee returns ErrNotAssignee unless the action is assigned to
+// the employee
+func (s *CorrectiveActionService) CheckAssignee(ctx context.Context, id uuid.UUID, employeeID uuid.UUID) error {
+	_, err := assignedAction(s.db.WithContext(ctx), id, employeeID)
+	return err
+}
 
-	// Check if the investigation is already closed
-	if action.Status == "completed" {
-		return fmt.Errorf("action is already closed")
+// LabelAsCompleted lets the assignee complete a pending or in-progress
+// action
+func (s *CorrectiveActionService) LabelAsCompleted(ctx context.Context, id uuid.UUID, employeeID uuid.UUID, notes string) error {
+	db := s.db.WithContext(ctx)
+	if _, err := assignedAction(db, id, employeeID); err != nil {
+		return err
 	}
 
-	// Update the action status and set the completion time
+	// The status is checked in the update so that a verified action cannot
+	// be completed again
 	now := time.Now()
-	action.Status = "completed"
-	action.CompletedAt = &now
-	action.CompletionNotes = notes
-
-	// Save the updated action
-	if err := s.db.Save(&action).Error; err != nil {
-		return fmt.Errorf("failed to close action: %w", err)
+	result := db.Model(&models.CorrectiveAction{}).
+		Where("id = ? AND status IN ?", id, openActionStatuses).
+		Updates(map[string]interface{}{
+			"status":           "completed",
+			"completed_at":     now,
+			"completion_notes": notes,
+			"updated_at":       now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to close action: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrActionNotOpen
 	}
-
 	return nil
 }
 
+// assignedAction finds an action assigned to the employee
+func assignedAction(db *gorm.DB, id uuid.UUID, employeeID uuid.UUID) (*models.CorrectiveAction, error) {
+	var action models.CorrectiveAction
+	err := db.First(&action, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrActionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find action: %w", err)
+	}
+	if action.AssignedTo != employeeID {
+		return nil, ErrNotAssignee
+	}
+	return &action, nil
+}
+
 // RequestExtension processes a request to extend the due date of a corrective action
-func (s *CorrectiveActionService) RequestExtension(ctx context.Context, actionID uuid.UUID, req schema.ExtensionRequest) error {
-	action, err := s.InternalGetByID(ctx, actionID)
+func (s *CorrectiveActionService) RequestExtension(ctx context.Context, actionID uuid.UUID, employeeID uuid.UUID, req schema.ExtensionRequest) error {
+	action, err := assignedAction(s.db.WithContext(ctx), actionID, employeeID)
 	if err != nil {
 		return err
 	}
 
 	// Verify action is still open
 	if action.Status == "completed" || action.Status == "verified" {
-		return fmt.Errorf("cannot request extension for action that is already %s", action.Status)
+		return fmt.Errorf("%w: cannot request extension for action that is already %s", ErrActionNotOpen, action.Status)
 	}
 
 	// Parse and validate new due date
@@ -548,6 +594,7 @@ func (s *CorrectiveActionService) RequestExtension(ctx context.Context, actionID
 	action.ExtensionReason = req.Reason
 	action.ExtensionRequestedAt = &now
 	action.ExtensionRequestedBy = &req.RequestedBy
+	action.ExtensionRequestedByID = &employeeID
 	action.ExtensionStatus = "pending"
 
 	if err := s.db.WithContext(ctx).Save(&action).Error; err != nil {
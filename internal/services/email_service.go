@@ -3,11 +3,14 @@ package services
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
 	"html/template"
 	"log"
+	"mime/multipart"
 	"net"
 	"net/smtp"
+	"net/textproto"
 	"os"
 	"strings"
 	"time"
@@ -23,6 +26,9 @@ type EmailService struct {
 	smtpPassword string
 	useTLS       bool
 	timeout      time.Duration
+	// from is the mailbox mail is sent from and the organizer of calendar
+	// invites; mail is sent from the SMTP username when it is not set
+	from string
 }
 
 type EmailTemplate struct {
@@ -60,6 +66,11 @@ func (s *EmailService) SetTimeout(timeout time.Duration) {
 	s.timeout = timeout
 }
 
+// SetFrom sets the mailbox mail is sent from
+func (s *EmailService) SetFrom(address string) {
+	s.from = address
+}
+
 // TestConnection tests the SMTP connection without sending an email
 func (s *EmailService) TestConnection() error {
 	log.Println("Testing Connection!")
@@ -169,6 +180,17 @@ func (s *EmailService) testDirectTLS(addr string, tlsConfig *tls.Config) error {
 
 // SendEmail sends an email to a list of recipients
 func (s *EmailService) SendEmail(to []string, subject string, body template.HTML) error {
+	return s.sendEmail(to, subject, body, nil)
+}
+
+// calendarAttachment is a calendar invite sent with an email
+type calendarAttachment struct {
+	Method  string
+	Content []byte
+}
+
+// sendEmail sends an HTML email, with a calendar invite when one is given
+func (s *EmailService) sendEmail(to []string, subject string, body template.HTML, invite *calendarAttachment) error {
 	if len(to) == 0 {
 		return fmt.Errorf("recipient list cannot be empty")
 	}
@@ -187,13 +209,27 @@ func (s *EmailService) SendEmail(to []string, subject string, body template.HTML
 
 	// Create email content
 	headers := make([]string, 0)
-	headers = append(headers, fmt.Sprintf("From: %s", s.smtpUsername))
+	from := s.from
+	if from == "" {
+		from = s.smtpUsername
+	}
+	headers = append(headers, fmt.Sprintf("From: %s", from))
 	headers = append(headers, fmt.Sprintf("To: %s", strings.Join(to, ", ")))
 	headers = append(headers, fmt.Sprintf("Subject: %s", subject))
 	headers = append(headers, "MIME-Version: 1.0")
-	headers = append(headers, "Content-Type: text/html; charset=UTF-8")
 
-	emailContent := strings.Join(headers, "\r\n") + "\r\n\r\n" + htmlContent
+	var emailContent string
+	if invite == nil {
+		headers = append(headers, "Content-Type: text/html; charset=UTF-8")
+		emailContent = strings.Join(headers, "\r\n") + "\r\n\r\n" + htmlContent
+	} else {
+		contentType, content, err := calendarEmailBody(htmlContent, invite)
+		if err != nil {
+			return fmt.Errorf("failed to attach calendar invite: %v", err)
+		}
+		headers = append(headers, "Content-Type: "+contentType)
+		emailContent = strings.Join(headers, "\r\n") + "\r\n\r\n" + content
+	}
 
 	addr := fmt.Sprintf("%s:%d", s.smtpHost, s.smtpPort)
 
@@ -479,6 +515,58 @@ func (s *EmailService) sendInterviewScheduledEmail(to []string, interview *model
 	return s.SendEmail(to, template.Subject, template.Message)
 }
 
+// sendInterviewInviteEmail sends the calendar invite of an interview: a
+// request when it is scheduled or rescheduled, a cancellation when it is
+// cancelled
+func (s *EmailService) sendInterviewInviteEmail(to []string, invite *interviewInvite, reason string) error {
+	if s.from == "" {
+		return fmt.Errorf("no from address is configured to organize calendar invites")
+	}
+	interview := invite.Interview
+	invite.Organizer = calendarAttendee{Name: "Safety365 System", Email: s.from}
+
+	subject := "Interview Scheduled"
+	heading := "Interview Scheduled"
+	colour, background := "#2e7d32", "#e8f5e9"
+	switch {
+	case invite.Method == CalendarMethodCancel:
+		subject, heading = "Interview Cancelled", "Interview Cancelled"
+		colour, background = "#c62828", "#ffebee"
+	case interview.Sequence > 0:
+		subject, heading = "Interview Rescheduled", "Interview Rescheduled"
+		colour, background = "#ef6c00", "#fff3e0"
+	}
+
+	var note string
+	if reason != "" {
+		note = fmt.Sprintf(`<p><strong>Reason:</strong> %s</p>`, template.HTMLEscapeString(reason))
+	}
+	message := template.HTML(fmt.Sprintf(`
+			<div style="background-color: %s; padding: 20px; border-radius: 8px; margin: 20px 0;">
+				<h3 style="color: %s; margin-bottom: 15px;">%s</h3>
+				<div style="background-color: white; padding: 15px; border-radius: 6px;">
+					<p><strong>Subject:</strong> %s</p>
+					<p><strong>Date:</strong> %s</p>
+					<p><strong>Time:</strong> %s - %s</p>
+					<p><strong>Location:</strong> %s</p>
+					%s
+				</div>
+				<p style="color: #424245; margin-top: 15px;">The attached invite updates your calendar.</p>
+			</div>`,
+		background, colour, heading,
+		template.HTMLEscapeString(invite.Summary),
+		interview.ScheduledFor.Format("Monday, January 2, 2006"),
+		interview.ScheduledFor.Format("3:04 PM"),
+		interview.EndsAt().Format("3:04 PM"),
+		template.HTMLEscapeString(interview.Location),
+		note))
+
+	return s.sendEmail(to, subject, message, &calendarAttachment{
+		Method:  invite.Method,
+		Content: invite.ICS(time.Now()),
+	})
+}
+
 // NotifyUrgentIncident sends urgent notifications to all managers for severe incidents
 func (s *EmailService) sendUrgentIncidentEmail(to []string, incident *schema.CreateIncidentRequest) error {
 	template := &EmailTemplate{
@@ -782,3 +870,74 @@ func (s *EmailService) NotifyIncidentIsClosed(to []string, incident *models.Inci
 
 	return s.SendEmail(to, template.Subject, template.Message)
 }
+
+// calendarEmailBody writes an email carrying a calendar invite: the HTML
+// message and the invite as alternatives, so mail clients offer to add it
+// to the calendar, and the invite again as an invite.ics attachment
+func calendarEmailBody(htmlContent string, invite *calendarAttachment) (string, string, error) {
+	var body bytes.Buffer
+	mixed := multipart.NewWriter(&body)
+
+	var alternatives bytes.Buffer
+	alternative := multipart.NewWriter(&alternatives)
+	htmlPart, err := alternative.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"text/html; charset=UTF-8"},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := htmlPart.Write([]byte(htmlContent)); err != nil {
+		return "", "", err
+	}
+	calendarPart, err := alternative.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {fmt.Sprintf("text/calendar; charset=UTF-8; method=%s", invite.Method)},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := calendarPart.Write(base64Lines(invite.Content)); err != nil {
+		return "", "", err
+	}
+	if err := alternative.Close(); err != nil {
+		return "", "", err
+	}
+
+	alternativePart, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := alternativePart.Write(alternatives.Bytes()); err != nil {
+		return "", "", err
+	}
+	attachment, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {fmt.Sprintf(`application/ics; name="invite.ics"; method=%s`, invite.Method)},
+		"Content-Disposition":       {`attachment; filename="invite.ics"`},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := attachment.Write(base64Lines(invite.Content)); err != nil {
+		return "", "", err
+	}
+	if err := mixed.Close(); err != nil {
+		return "", "", err
+	}
+
+	return "multipart/mixed; boundary=" + mixed.Boundary(), body.String(), nil
+}
+
+// base64Lines encodes content as base64 in lines of 76 characters
+func base64Lines(content []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(content)
+	var b bytes.Buffer
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes()
+}
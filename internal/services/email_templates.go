@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"html/template"
 	"strings"
+	"time"
 
 	"github.com/hopkali04/health-sys/internal/models"
 )
@@ -40,3 +41,74 @@ func (s *EmailService) sendVPCNotificationEmail(to []string, vpc *models.VPC) er
 
 	return s.SendEmail(to, template.Subject, template.Message)
 }
+
+// SendAccountLockedEmail tells a user their account was locked after repeated failed sign-ins
+func (s *EmailService) SendAccountLockedEmail(to []string, lockedUntil time.Time) error {
+	template := &EmailTemplate{
+		Subject: "🔒 Your Account Has Been Locked",
+		Title:   "Account Locked",
+		Message: template.HTML(fmt.Sprintf(`
+			<div style="background-color: #fff3e0; padding: 20px; border-radius: 8px; margin: 20px 0;">
+				<h3 style="color: #e65100; margin-bottom: 15px;">Too Many Failed Sign-in Attempts</h3>
+				<p>Your account has been locked because of repeated failed sign-in attempts.</p>
+				<p><strong>Locked Until:</strong> %s</p>
+				<p style="color: #424245; margin-top: 15px;">If this was not you, reset your password once the lock expires and contact your system administrator.</p>
+			</div>`,
+			lockedUntil.Format("January 2, 2006 3:04 PM MST"))),
+		ActionLink: "/auth/reset-password",
+		ActionText: "Reset Password",
+	}
+
+	return s.SendEmail(to, template.Subject, template.Message)
+}
+
+// SendAccountLockedAdminEmail alerts administrators that a user account was locked
+func (s *EmailService) SendAccountLockedAdminEmail(to []string, accountEmail, ipAddress string, lockedUntil time.Time, lockoutCount int) error {
+	template := &EmailTemplate{
+		Subject: "🔒 Security Alert: Account Locked",
+		Title:   "Account Locked",
+		Message: template.HTML(fmt.Sprintf(`
+			<div style="background-color: #ffebee; padding: 20px; border-radius: 8px; margin: 20px 0;">
+				<h3 style="color: #c62828; margin-bottom: 15px;">Account Locked After Failed Sign-ins</h3>
+				<div style="background-color: white; padding: 15px; border-radius: 6px;">
+					<p><strong>Account:</strong> %s</p>
+					<p><strong>Last Attempt From:</strong> %s</p>
+					<p><strong>Locked Until:</strong> %s</p>
+					<p><strong>Consecutive Locks:</strong> %d</p>
+				</div>
+				<p style="color: #424245; margin-top: 15px;">Review the activity and unlock the account from user management if appropriate.</p>
+			</div>`,
+			template.HTMLEscapeString(accountEmail),
+			template.HTMLEscapeString(ipAddress),
+			lockedUntil.Format("January 2, 2006 3:04 PM MST"),
+			lockoutCount)),
+		ActionLink: "/admin",
+		ActionText: "Manage Users",
+	}
+
+	return s.SendEmail(to, template.Subject, template.Message)
+}
+
+// SendSuspiciousLoginActivityEmail alerts administrators that one IP address is failing sign-ins against many accounts
+func (s *EmailService) SendSuspiciousLoginActivityEmail(to []string, ipAddress string, failures int64, window time.Duration) error {
+	template := &EmailTemplate{
+		Subject: "🚨 Security Alert: Suspicious Sign-in Activity",
+		Title:   "Suspicious Sign-in Activity",
+		Message: template.HTML(fmt.Sprintf(`
+			<div style="background-color: #ffebee; padding: 20px; border-radius: 8px; margin: 20px 0;">
+				<h3 style="color: #c62828; margin-bottom: 15px;">Repeated Failed Sign-ins From One Address</h3>
+				<div style="background-color: white; padding: 15px; border-radius: 6px;">
+					<p><strong>IP Address:</strong> %s</p>
+					<p><strong>Failed Attempts:</strong> %d in the last %s</p>
+				</div>
+				<p style="color: #424245; margin-top: 15px;">Sign-ins from this address are being refused until the activity stops.</p>
+			</div>`,
+			template.HTMLEscapeString(ipAddress),
+			failures,
+			window)),
+		ActionLink: "/admin",
+		ActionText: "Open Admin Dashboard",
+	}
+
+	return s.SendEmail(to, template.Subject, template.Message)
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/services/upload"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvestigationNotFound = errors.New("investigation not found")
+	ErrEvidenceNotFound      = errors.New("evidence not found")
+)
+
+// EvidenceService records the evidence collected during an investigation,
+// with the uploaded file for photos, videos and documents
+type EvidenceService struct {
+	scopeResolver
+	db      *gorm.DB
+	storage storage.Storage
+	uploads *upload.Service
+}
+
+func NewEvidenceService(db *gorm.DB, store storage.Storage, uploads *upload.Service) *EvidenceService {
+	return &EvidenceService{scopeResolver: scopeResolver{db: db}, db: db, storage: store, uploads: uploads}
+}
+
+// ListEvidence retrieves the evidence of an investigation in the scope
+func (s *EvidenceService) ListEvidence(ctx context.Context, scope IncidentScope, investigationID uuid.UUID) ([]models.InvestigationEvidence, error) {
+	if err := s.checkInvestigation(ctx, scope, investigationID); err != nil {
+		return nil, err
+	}
+	var evidence []models.InvestigationEvidence
+	err := s.db.WithContext(ctx).Preload("Collector").
+		Where("investigation_id = ?", investigationID).
+		Order("collected_at").
+		Find(&evidence).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list evidence: %w", err)
+	}
+	return evidence, nil
+}
+
+// AddEvidence records a piece of evidence. file is optional: physical items
+// and testimony may have none.
+func (s *EvidenceService) AddEvidence(ctx context.Context, scope IncidentScope, dto schema.CreateEvidenceDTO, file *multipart.FileHeader) (*models.InvestigationEvidence, error) {
+	if _, err := editableInvestigation(ctx, s.db, scope, dto.InvestigationID); err != nil {
+		return nil, err
+	}
+
+	evidence := &models.InvestigationEvidence{
+		InvestigationID: dto.InvestigationID,
+		EvidenceType:    dto.EvidenceType,
+		Description:     dto.Description,
+		CollectedAt:     time.Now(),
+		CollectedBy:     dto.CollectedBy,
+		StorageLocation: dto.StorageLocation,
+	}
+
+	err := withUploads(ctx, s.db, s.uploads, func(tx *gorm.DB, stage *upload.Stage) error {
+		if file != nil {
+			stored, err := stage.SaveAttachment(ctx, s.evidenceDir(dto.InvestigationID), file)
+			if err != nil {
+				return err
+			}
+			evidence.FileName = stored.Name
+			evidence.FileType = stored.ContentType
+			evidence.FileSize = int(stored.Size)
+			evidence.StoragePath = stored.Key
+			evidence.ImageMetadata = imageMetadata(stored)
+		}
+		if err := tx.Create(evidence).Error; err != nil {
+			return fmt.Errorf("failed to create evidence: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.findEvidence(ctx, scope, dto.InvestigationID, evidence.ID)
+}
+
+// ReplaceEvidenceFile uploads the file of a piece of evidence, replacing the
+// one it had. The old file is deleted once the record points at the new one.
+func (s *EvidenceService) ReplaceEvidenceFile(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID, file *multipart.FileHeader) (*models.InvestigationEvidence, error) {
+	if _, err := editableInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return nil, err
+	}
+	evidence, err := s.findEvidence(ctx, scope, investigationID, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := *evidence
+
+	err = withUploads(ctx, s.db, s.uploads, func(tx *gorm.DB, stage *upload.Stage) error {
+		stored, err := stage.SaveAttachment(ctx, s.evidenceDir(investigationID), file)
+		if err != nil {
+			return err
+		}
+		updates := fileColumns(stored)
+		updates["file_url"] = ""
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(evidence).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update evidence: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleteStoredFiles(ctx, s.storage, previous.StoragePath, previous.ThumbnailPath, previous.PreviewPath)
+	return s.findEvidence(ctx, scope, investigationID, id)
+}
+
+// DeleteEvidence deletes a piece of evidence and its file
+func (s *EvidenceService) DeleteEvidence(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID) error {
+	if _, err := editableInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return err
+	}
+	evidence, err := s.findEvidence(ctx, scope, investigationID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Delete(&models.InvestigationEvidence{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete evidence: %w", err)
+	}
+	deleteStoredFiles(ctx, s.storage, evidence.StoragePath, evidence.ThumbnailPath, evidence.PreviewPath)
+	return nil
+}
+
+// evidenceDir gives each upload its own folder, so files with the same name
+// do not overwrite each other
+func (s *EvidenceService) evidenceDir(investigationID uuid.UUID) string {
+	return storage.Key("investigations", investigationID.String(), uuid.NewString())
+}
+
+// checkInvestigation reports investigations outside the scope as not found
+func (s *EvidenceService) checkInvestigation(ctx context.Context, scope IncidentScope, investigationID uuid.UUID) error {
+	_, err := scopedInvestigation(ctx, s.db, scope, investigationID)
+	return err
+}
+
+func (s *EvidenceService) findEvidence(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID) (*models.InvestigationEvidence, error) {
+	if err := s.checkInvestigation(ctx, scope, investigationID); err != nil {
+		return nil, err
+	}
+	var evidence models.InvestigationEvidence
+	err := s.db.WithContext(ctx).Preload("Collector").
+		First(&evidence, "id = ? AND investigation_id = ?", id, investigationID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrEvidenceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find evidence: %w", err)
+	}
+	return &evidence, nil
+}
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/services/upload"
+	"gorm.io/gorm"
+)
+
+// FileKind names the kind of record a stored file belongs to
+type FileKind string
+
+const (
+	FileIncidentAttachment FileKind = "incident-attachment"
+	FileVPCAttachment      FileKind = "vpc-attachment"
+	FileActionEvidence     FileKind = "action-evidence"
+	FileHazardAttachment   FileKind = "hazard-attachment"
+	FileEvidence           FileKind = "investigation-evidence"
+)
+
+// ImageVariant names a resized copy of a photo attachment
+type ImageVariant string
+
+const (
+	ImageThumbnail ImageVariant = "thumbnail"
+	ImagePreview   ImageVariant = "preview"
+)
+
+var (
+	ErrFileNotFound       = errors.New("file not found")
+	ErrInvalidFileLink    = errors.New("invalid or expired download link")
+	ErrSignedURLsDisabled = errors.New("signed download links are not configured")
+)
+
+// StoredFile is a downloadable file and the record it belongs to
+type StoredFile struct {
+	Kind        FileKind
+	ID          uuid.UUID
+	ParentID    string
+	Name        string
+	ContentType string
+	Key         string
+	// Variants holds the keys of the resized copies of a photo
+	Variants map[ImageVariant]string
+}
+
+// Variant returns a resized copy of a photo. Files without one are reported
+// as not found.
+func (f *StoredFile) Variant(variant ImageVariant) (*StoredFile, error) {
+	key := f.Variants[variant]
+	if key == "" {
+		return nil, ErrFileNotFound
+	}
+	copied := *f
+	copied.Name = strings.TrimSuffix(f.Name, path.Ext(f.Name)) + "-" + string(variant) + ".jpg"
+	copied.ContentType = "image/jpeg"
+	copied.Key = key
+	copied.Variants = nil
+	return &copied, nil
+}
+
+// imageMetadata records what the upload checks found out about a photo
+func imageMetadata(file *upload.File) models.ImageMetadata {
+	if file.Image == nil {
+		return models.ImageMetadata{}
+	}
+	return models.ImageMetadata{
+		ThumbnailPath: file.Image.ThumbnailKey,
+		PreviewPath:   file.Image.PreviewKey,
+		ImageWidth:    file.Image.Width,
+		ImageHeight:   file.Image.Height,
+		CapturedAt:    file.Image.CapturedAt,
+		Latitude:      file.Image.Latitude,
+		Longitude:     file.Image.Longitude,
+	}
+}
+
+// FileService serves stored files to the users allowed to see the record
+// they belong to, and signs short-lived download links for emails and PDFs
+type FileService struct {
+	scopeResolver
+	db           *gorm.DB
+	storage      storage.Storage
+	signingKey   []byte
+	signedURLTTL time.Duration
+	publicURL    string
+}
+
+func NewFileService(db *gorm.DB, store storage.Storage, cfg config.Storage) *FileService {
+	return &FileService{
+		scopeResolver: scopeResolver{db: db},
+		db:            db,
+		storage:       store,
+		signingKey:    []byte(cfg.SigningKey),
+		signedURLTTL:  cfg.SignedURLTTL,
+		publicURL:     strings.TrimSuffix(cfg.PublicURL, "/"),
+	}
+}
+
+// FindFile looks up a file of the record parentID. Incident attachments and
+// corrective action evidence outside the scope are reported as not found.
+func (s *FileService) FindFile(ctx context.Context, scope IncidentScope, kind FileKind, parentID string, id uuid.UUID) (*StoredFile, error) {
+	file, err := s.findFile(s.db.WithContext(ctx), kind, id, scope)
+	if err != nil {
+		return nil, err
+	}
+	if file.ParentID != parentID {
+		return nil, ErrFileNotFound
+	}
+	return file, nil
+}
+
+// FindSignedFile looks up the file a signed download link points to
+func (s *FileService) FindSignedFile(ctx context.Context, kind FileKind, id uuid.UUID, expires, signature string) (*StoredFile, error) {
+	if len(s.signingKey) == 0 {
+		return nil, ErrSignedURLsDisabled
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, ErrInvalidFileLink
+	}
+	given, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, s.sign(kind, id, expiresAt)) {
+		return nil, ErrInvalidFileLink
+	}
+
+	// The link was issued to someone allowed to see the file
+	return s.findFile(s.db.WithContext(ctx), kind, id, IncidentScope{All: true})
+}
+
+// Open reads the file, or the part of it selected by byteRange
+func (s *FileService) Open(ctx context.Context, file *StoredFile, byteRange *storage.ByteRange) (*storage.Object, error) {
+	object, err := s.storage.Get(ctx, file.Key, byteRange)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.ContentType != "" {
+		object.ContentType = file.ContentType
+	}
+	return object, nil
+}
+
+// SignedURL returns a download link for the file that works without
+// signing in until it expires
+func (s *FileService) SignedURL(file *StoredFile) (string, time.Time, error) {
+	if len(s.signingKey) == 0 {
+		return "", time.Time{}, ErrSignedURLsDisabled
+	}
+	expiresAt := time.Now().Add(s.signedURLTTL).Truncate(time.Second)
+	signature := hex.EncodeToString(s.sign(file.Kind, file.ID, expiresAt.Unix()))
+	url := fmt.Sprintf("%s/api/v1/files/%s/%s?expires=%d&signature=%s",
+		s.publicURL, file.Kind, file.ID, expiresAt.Unix(), signature)
+	return url, expiresAt, nil
+}
+
+func (s *FileService) sign(kind FileKind, id uuid.UUID, expiresAt int64) []byte {
+	mac := hmac.New(sha256.New, s.signingKey)
+	fmt.Fprintf(mac, "%s/%s/%d", kind, id, expiresAt)
+	return mac.Sum(nil)
+}
+
+// findFile loads a file record. scope restricts the incidents and
+// investigations whose files may be returned.
+func (s *FileService) findFile(db *gorm.DB, kind FileKind, id uuid.UUID, scope IncidentScope) (*StoredFile, error) {
+	switch kind {
+	case FileIncidentAttachment:
+		var attachments []models.IncidentAttachment
+		err := db.Joins("JOIN incidents ON incidents.id = incident_attachments.incident_id").
+			Scopes(scope.Incidents).
+			Where("incident_attachments.id = ?", id).
+			Limit(1).
+			Find(&attachments).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to find attachment: %w", err)
+		}
+		if len(attachments) == 0 {
+			return nil, ErrFileNotFound
+		}
+		attachment := attachments[0]
+		return &StoredFile{
+			Kind:        kind,
+			ID:          attachment.ID,
+			ParentID:    attachment.IncidentID.String(),
+			Name:        attachment.FileName,
+			ContentType: attachment.FileType,
+			Key:         attachment.StoragePath,
+			Variants:    imageVariants(attachment.ImageMetadata),
+		}, nil
+
+	case FileActionEvidence:
+		var evidence []models.ActionEvidence
+		err := db.Joins("JOIN corrective_actions ON corrective_actions.id = action_evidences.corrective_action_id").
+			Joins("JOIN incidents ON incidents.id = corrective_actions.incident_id").
+			Scopes(scope.Incidents).
+			Where("action_evidences.id = ?", id).
+			Limit(1).
+			Find(&evidence).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to find evidence: %w", err)
+		}
+		if len(evidence) == 0 {
+			return nil, ErrFileNotFound
+		}
+		return &StoredFile{
+			Kind:     kind,
+			ID:       evidence[0].ID,
+			ParentID: evidence[0].CorrectiveActionID.String(),
+			Name:     evidence[0].FileName,
+			Key:      evidence[0].FileURL,
+		}, nil
+
+	case FileVPCAttachment:
+		var attachments []models.VPCAttachment
+		err := db.Joins("JOIN vpcs ON vpcs.id = vpc_attachments.vpc_id").
+			Where("vpc_attachments.id = ?", id).
+			Limit(1).
+			Find(&attachments).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to find attachment: %w", err)
+		}
+		if len(attachments) == 0 {
+			return nil, ErrFileNotFound
+		}
+		attachment := attachments[0]
+		return &StoredFile{
+			Kind:        kind,
+			ID:          attachment.ID,
+			ParentID:    attachment.VPCID,
+			Name:        attachment.FileName,
+			ContentType: attachment.FileType,
+			Key:         attachment.StoragePath,
+			Variants:    imageVariants(attachment.ImageMetadata),
+		}, nil
+
+	case FileHazardAttachment:
+		var attachments []models.HazardAttachment
+		err := db.Joins("JOIN hazards ON hazards.id = hazard_attachments.hazard_id").
+			Where("hazard_attachments.id = ?", id).
+			Limit(1).
+			Find(&attachments).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to find attachment: %w", err)
+		}
+		if len(attachments) == 0 {
+			return nil, ErrFileNotFound
+		}
+		attachment := attachments[0]
+		return &StoredFile{
+			Kind:        kind,
+			ID:          attachment.ID,
+			ParentID:    attachment.HazardID.String(),
+			Name:        attachment.FileName,
+			ContentType: attachment.FileType,
+			Key:         attachment.StoragePath,
+			Variants:    imageVariants(attachment.ImageMetadata),
+		}, nil
+
+	case FileEvidence:
+		var evidence []models.InvestigationEvidence
+		err := db.Joins("JOIN investigations ON investigations.id = investigation_evidences.investigation_id").
+			Scopes(scope.Investigations).
+			Where("investigation_evidences.id = ? AND investigation_evidences.storage_path <> ''", id).
+			Limit(1).
+			Find(&evidence).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to find evidence: %w", err)
+		}
+		if len(evidence) == 0 {
+			return nil, ErrFileNotFound
+		}
+		return &StoredFile{
+			Kind:        kind,
+			ID:          evidence[0].ID,
+			ParentID:    evidence[0].InvestigationID.String(),
+			Name:        evidence[0].FileName,
+			ContentType: evidence[0].FileType,
+			Key:         evidence[0].StoragePath,
+			Variants:    imageVariants(evidence[0].ImageMetadata),
+		}, nil
+
+	default:
+		return nil, ErrFileNotFound
+	}
+}
+
+func imageVariants(metadata models.ImageMetadata) map[ImageVariant]string {
+	return map[ImageVariant]string{
+		ImageThumbnail: metadata.ThumbnailPath,
+		ImagePreview:   metadata.PreviewPath,
+	}
+}
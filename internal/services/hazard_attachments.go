@@ -0,0 +1,136 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/services/upload"
+	"gorm.io/gorm"
+)
+
+var ErrHazardNotFound = errors.New("hazard not found")
+
+// ListAttachments retrieves all attachments of a hazard
+func (s *HazardService) ListAttachments(ctx context.Context, hazardID uuid.UUID) ([]models.HazardAttachment, error) {
+	if err := s.checkHazard(ctx, hazardID); err != nil {
+		return nil, err
+	}
+	var attachments []models.HazardAttachment
+	err := s.db.WithContext(ctx).Preload("Uploader").
+		Where("hazard_id = ?", hazardID).
+		Order("created_at").
+		Find(&attachments).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hazard attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// AddAttachments uploads files to a hazard. Each upload gets its own
+// folder, so files with the same name do not overwrite each other.
+func (s *HazardService) AddAttachments(ctx context.Context, hazardID uuid.UUID, files []*multipart.FileHeader, uploadedBy uuid.UUID) ([]models.HazardAttachment, error) {
+	if err := s.checkHazard(ctx, hazardID); err != nil {
+		return nil, err
+	}
+
+	attachments := make([]models.HazardAttachment, 0, len(files))
+	err := withUploads(ctx, s.db, s.uploads, func(tx *gorm.DB, stage *upload.Stage) error {
+		for _, file := range files {
+			stored, err := stage.SaveAttachment(ctx, storage.Key("hazards", hazardID.String(), uuid.NewString()), file)
+			if err != nil {
+				return err
+			}
+			attachments = append(attachments, models.HazardAttachment{
+				HazardID:      hazardID,
+				FileName:      stored.Name,
+				FileType:      stored.ContentType,
+				FileSize:      int(stored.Size),
+				StoragePath:   stored.Key,
+				UploadedBy:    uploadedBy,
+				ImageMetadata: imageMetadata(stored),
+			})
+		}
+		if err := tx.Create(&attachments).Error; err != nil {
+			return fmt.Errorf("failed to create attachment records: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// ReplaceAttachment swaps the file of a hazard attachment for a new upload.
+// The old file is deleted once the record points at the new one.
+func (s *HazardService) ReplaceAttachment(ctx context.Context, hazardID, id uuid.UUID, file *multipart.FileHeader, uploadedBy uuid.UUID) (*models.HazardAttachment, error) {
+	attachment, err := s.findAttachment(ctx, hazardID, id)
+	if err != nil {
+		return nil, err
+	}
+	previous := *attachment
+
+	err = withUploads(ctx, s.db, s.uploads, func(tx *gorm.DB, stage *upload.Stage) error {
+		stored, err := stage.SaveAttachment(ctx, storage.Key("hazards", hazardID.String(), uuid.NewString()), file)
+		if err != nil {
+			return err
+		}
+		updates := fileColumns(stored)
+		updates["uploaded_by"] = uploadedBy
+		if err := tx.Model(attachment).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update attachment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleteStoredFiles(ctx, s.storage, previous.StoragePath, previous.ThumbnailPath, previous.PreviewPath)
+	return s.findAttachment(ctx, hazardID, id)
+}
+
+// DeleteAttachment deletes a hazard attachment and its stored files
+func (s *HazardService) DeleteAttachment(ctx context.Context, hazardID, id uuid.UUID) error {
+	attachment, err := s.findAttachment(ctx, hazardID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Delete(attachment).Error; err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	deleteStoredFiles(ctx, s.storage, attachment.StoragePath, attachment.ThumbnailPath, attachment.PreviewPath)
+	return nil
+}
+
+func (s *HazardService) checkHazard(ctx context.Context, hazardID uuid.UUID) error {
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Hazard{}).Where("id = ?", hazardID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to find hazard: %w", err)
+	}
+	if count == 0 {
+		return ErrHazardNotFound
+	}
+	return nil
+}
+
+func (s *HazardService) findAttachment(ctx context.Context, hazardID, id uuid.UUID) (*models.HazardAttachment, error) {
+	if err := s.checkHazard(ctx, hazardID); err != nil {
+		return nil, err
+	}
+	var attachment models.HazardAttachment
+	err := s.db.WithContext(ctx).Preload("Uploader").
+		First(&attachment, "id = ? AND hazard_id = ?", id, hazardID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find attachment: %w", err)
+	}
+	return &attachment, nil
+}
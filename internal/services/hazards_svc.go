@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -8,21 +9,26 @@ import (
 	"github.com/google/uuid"
 	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/services/upload"
 	"gorm.io/gorm"
 )
 
 // HazardService provides methods for interacting with hazard data.
 type HazardService struct {
-	db *gorm.DB
+	scopeResolver
+	db      *gorm.DB
+	storage storage.Storage
+	uploads *upload.Service
 }
 
 // NewHazardService creates a new instance of HazardService.
-func NewHazardService(db *gorm.DB) *HazardService {
-	return &HazardService{db: db}
+func NewHazardService(db *gorm.DB, store storage.Storage, uploads *upload.Service) *HazardService {
+	return &HazardService{scopeResolver: scopeResolver{db: db}, db: db, storage: store, uploads: uploads}
 }
 
 // CreateHazard creates a new hazard record in the database.
-func (s *HazardService) CreateHazard(req schema.CreateHazardRequest, userID uuid.UUID) (*models.Hazard, error) {
+func (s *HazardService) CreateHazard(ctx context.Context, req schema.CreateHazardRequest, userID uuid.UUID) (*models.Hazard, error) {
 	hazard := &models.Hazard{
 		Type:              req.Type,
 		RiskLevel:         req.RiskLevel,
@@ -40,7 +46,7 @@ func (s *HazardService) CreateHazard(req schema.CreateHazardRequest, userID uuid
 		hazard.AssignedTo = &req.AssignedTo
 	}
 
-	if err := s.db.Create(hazard).Error; err != nil {
+	if err := s.db.WithContext(ctx).Create(hazard).Error; err != nil {
 		return nil, fmt.Errorf("failed to create hazard: %w", err)
 	}
 
@@ -92,9 +98,9 @@ func (s *HazardService) ListHazards(page, pageSize int, filters map[string]inter
 }
 
 // UpdateHazard updates an existing hazard's information.
-func (s *HazardService) UpdateHazard(id uuid.UUID, updates schema.UpdateHazardRequest) (*models.Hazard, error) {
+func (s *HazardService) UpdateHazard(ctx context.Context, id uuid.UUID, updates schema.UpdateHazardRequest) (*models.Hazard, error) {
 	var hazard models.Hazard
-	if err := s.db.Preload("Reporter").Preload("Assignee").First(&hazard, "id = ?", id).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Reporter").Preload("Assignee").First(&hazard, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("hazard not found")
 		}
@@ -137,31 +143,39 @@ func (s *HazardService) UpdateHazard(id uuid.UUID, updates schema.UpdateHazardRe
 
 	hazard.UpdatedAt = time.Now()
 
-	if err := s.db.Save(&hazard).Error; err != nil {
+	if err := s.db.WithContext(ctx).Save(&hazard).Error; err != nil {
 		return nil, fmt.Errorf("failed to update hazard: %w", err)
 	}
 
 	return &hazard, nil
 }
 
-// DeleteHazard soft deletes a hazard from the database.
-func (s *HazardService) DeleteHazard(id uuid.UUID) error {
-	if err := s.db.Delete(&models.Hazard{}, id).Error; err != nil {
+// DeleteHazard soft deletes a hazard from the database, along with the
+// files attached to it.
+func (s *HazardService) DeleteHazard(ctx context.Context, id uuid.UUID) error {
+	var attachments []models.HazardAttachment
+	if err := s.db.WithContext(ctx).Where("hazard_id = ?", id).Find(&attachments).Error; err != nil {
+		return fmt.Errorf("failed to find hazard attachments: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Delete(&models.Hazard{}, "id = ?", id).Error; err != nil {
 		return fmt.Errorf("failed to delete hazard: %w", err)
 	}
+	for _, attachment := range attachments {
+		deleteStoredFiles(ctx, s.storage, attachment.StoragePath, attachment.ThumbnailPath, attachment.PreviewPath)
+	}
 	return nil
 }
 
 // AssignHazardToUser assigns a hazard to a specific user.
-func (s *HazardService) AssignHazardToUser(id uuid.UUID, userID uuid.UUID) (*models.Hazard, error) {
+func (s *HazardService) AssignHazardToUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Hazard, error) {
 	var hazard models.Hazard
-	if err := s.db.First(&hazard, "id = ?", id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&hazard, "id = ?", id).Error; err != nil {
 		return nil, fmt.Errorf("failed to find hazard: %w", err)
 	}
 
 	hazard.AssignedTo = &userID
 
-	if err := s.db.Save(&hazard).Error; err != nil {
+	if err := s.db.WithContext(ctx).Save(&hazard).Error; err != nil {
 		return nil, fmt.Errorf("failed to assign hazard: %w", err)
 	}
 
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/models"
+	"gorm.io/gorm"
+)
+
+// teamQuery selects every employee below @manager in the reporting line
+const teamQuery = `WITH RECURSIVE team AS (
+	SELECT id FROM employees WHERE reporting_manager_id = @manager
+	UNION
+	SELECT e.id FROM employees e JOIN team ON e.reporting_manager_id = team.id
+) SELECT id FROM team`
+
+// IncidentScope describes which incidents a user may see. Employees see the
+// incidents they reported or are assigned to; roles with read:incidents:team
+// also see their department and reporting line; roles with read:incidents:all
+// see everything.
+type IncidentScope struct {
+	All        bool
+	Team       bool
+	EmployeeID uuid.UUID
+	Department string
+	// ReadMedical allows medical details such as the injury type
+	ReadMedical bool
+}
+
+// ResolveIncidentScope works out the scope of the signed-in user
+func ResolveIncidentScope(ctx context.Context, db *gorm.DB, userID uuid.UUID, role string) (IncidentScope, error) {
+	scope := IncidentScope{
+		All:         middleware.HasPermission(role, middleware.PermissionReadAllIncidents),
+		Team:        middleware.HasPermission(role, middleware.PermissionReadTeamIncidents),
+		ReadMedical: middleware.HasPermission(role, middleware.PermissionReadMedical),
+	}
+	if scope.All {
+		return scope, nil
+	}
+
+	var employee models.Employee
+	err := db.WithContext(ctx).Select("id", "department").Where("user_id = ?", userID).First(&employee).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Without an employee record there is nothing the user reported or was assigned
+		return scope, nil
+	}
+	if err != nil {
+		return scope, fmt.Errorf("failed to resolve incident scope: %w", err)
+	}
+
+	scope.EmployeeID = employee.ID
+	scope.Department = employee.Department
+	return scope, nil
+}
+
+// scopeResolver is embedded in services whose handlers work out who is
+// signed in and what they may see
+type scopeResolver struct {
+	db *gorm.DB
+}
+
+// ResolveScope returns the incidents, and so the investigations, the signed-in user may see
+func (r scopeResolver) ResolveScope(ctx context.Context, userID uuid.UUID, role string) (IncidentScope, error) {
+	return ResolveIncidentScope(ctx, r.db, userID, role)
+}
+
+// GetEmployeeByUserID finds the employee record of the signed-in user
+func (r scopeResolver) GetEmployeeByUserID(userID uuid.UUID) (*models.Employee, error) {
+	return employeeByUserID(r.db, userID)
+}
+
+// Incidents restricts a query on the incidents table to the scope. Use it
+// with db.Scopes.
+func (sc IncidentScope) Incidents(db *gorm.DB) *gorm.DB {
+	if sc.All {
+		return db
+	}
+
+	condition := "incidents.reported_by = @employee OR incidents.assigned_to = @employee"
+	if sc.Team {
+		condition += " OR incidents.reported_by IN (" + teamQuery + ")" +
+			" OR incidents.assigned_to IN (" + teamQuery + ")"
+		if sc.Department != "" {
+			condition += " OR incidents.reported_by IN (SELECT id FROM employees WHERE department = @department)"
+		}
+	}
+
+	return db.Where("("+condition+")", map[string]interface{}{
+		"employee":   sc.EmployeeID,
+		"manager":    sc.EmployeeID,
+		"department": sc.Department,
+	})
+}
+
+// memberQuery selects the investigations @employee is currently a team member of
+const memberQuery = `SELECT investigation_id FROM investigation_members WHERE employee_id = @employee AND left_at IS NULL`
+
+// Investigations restricts a query on the investigations table to the
+// investigations of visible incidents and those the user leads or is a
+// member of
+func (sc IncidentScope) Investigations(db *gorm.DB) *gorm.DB {
+	if sc.All {
+		return db
+	}
+	return db.Where("(investigations.lead_investigator_id = @employee OR investigations.id IN ("+memberQuery+") OR investigations.incident_id IN (@incidents))",
+		map[string]interface{}{
+			"employee":  sc.EmployeeID,
+			"incidents": sc.incidentIDs(db),
+		})
+}
+
+// scopedInvestigation finds an investigation in the scope, reporting those
+// outside it as not found
+func scopedInvestigation(ctx context.Context, db *gorm.DB, scope IncidentScope, investigationID uuid.UUID) (*models.Investigation, error) {
+	var investigation models.Investigation
+	err := db.WithContext(ctx).Scopes(scope.Investigations).
+		Where("investigations.id = ?", investigationID).
+		First(&investigation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvestigationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find investigation: %w", err)
+	}
+	return &investigation, nil
+}
+
+// editableInvestigation finds an investigation in the scope whose findings
+// may still change. Investigations under review or signed off are locked.
+func editableInvestigation(ctx context.Context, db *gorm.DB, scope IncidentScope, investigationID uuid.UUID) (*models.Investigation, error) {
+	investigation, err := scopedInvestigation(ctx, db, scope, investigationID)
+	if err != nil {
+		return nil, err
+	}
+	if investigation.Locked() {
+		return nil, ErrInvestigationLocked
+	}
+	return investigation, nil
+}
+
+// incidentIDs is a subquery of the visible incident IDs, for raw SQL and
+// for tables that reference incidents
+func (sc IncidentScope) incidentIDs(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).
+		Model(&models.Incident{}).
+		Select("incidents.id").
+		Scopes(sc.Incidents)
+}
+
+// Redact clears the medical details of incidents the scope may not read
+func (sc IncidentScope) Redact(incidents ...*models.Incident) {
+	if sc.ReadMedical {
+		return
+	}
+	for _, incident := range incidents {
+		if incident != nil {
+			incident.InjuryType = ""
+		}
+	}
+}
+
+// RedactAll clears the medical details of a slice of incidents in place
+func (sc IncidentScope) RedactAll(incidents []models.Incident) {
+	for i := range incidents {
+		sc.Redact(&incidents[i])
+	}
+}
@@ -1,26 +1,29 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"mime/multipart"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/services/upload"
 	"gorm.io/gorm"
 )
 
 type IncidentService struct {
-	db *gorm.DB
+	scopeResolver
+	db       *gorm.DB
+	uploads  *upload.Service
+	workflow *IncidentWorkflow
 }
 
-func NewIncidentService(db *gorm.DB) *IncidentService {
-	return &IncidentService{db: db}
+func NewIncidentService(db *gorm.DB, uploads *upload.Service, workflow *IncidentWorkflow) *IncidentService {
+	return &IncidentService{scopeResolver: scopeResolver{db: db}, db: db, uploads: uploads, workflow: workflow}
 }
 
 func (r *IncidentService) GetEmployeeByUserID(userID uuid.UUID) (*models.Employee, error) {
@@ -35,12 +38,14 @@ func (r *IncidentService) GetEmployeeByUserID(userID uuid.UUID) (*models.Employe
 	// Return the retrieved employee
 	return &employee, nil
 }
-func (s *IncidentService) GetClosedIncidentsByEmployeeID(employeeID uuid.UUID, page, pageSize int) ([]models.Incident, int64, error) {
+
+func (s *IncidentService) GetClosedIncidentsByEmployeeID(scope IncidentScope, employeeID uuid.UUID, page, pageSize int) ([]models.Incident, int64, error) {
 	var incidents []models.Incident
 	var total int64
 
 	query := s.db.Model(&models.Incident{}).
-		Where("status = ? AND (reported_by = ? OR assigned_to = ?)", "closed", employeeID, employeeID)
+		Where("status = ? AND (reported_by = ? OR assigned_to = ?)", "closed", employeeID, employeeID).
+		Scopes(scope.Incidents)
 
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
@@ -58,35 +63,46 @@ func (s *IncidentService) GetClosedIncidentsByEmployeeID(employeeID uuid.UUID, p
 		return nil, 0, fmt.Errorf("failed to get closed incidents for employee: %w", err)
 	}
 
+	scope.RedactAll(incidents)
 	return incidents, total, nil
 }
-func (s *IncidentService) GetByEmployeeID(employeeID uuid.UUID) ([]models.Incident, error) {
+func (s *IncidentService) GetByEmployeeID(scope IncidentScope, employeeID uuid.UUID) ([]models.Incident, error) {
 	var incidents []models.Incident
 	err := s.db.Preload("Reporter").Preload("Assignee").
 		Where("reported_by = ? AND status != ?", employeeID, "closed").
+		Scopes(scope.Incidents).
 		Find(&incidents).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to get incidents by employee ID: %w", err)
 	}
+	scope.RedactAll(incidents)
 	return incidents, nil
 }
 
 // CreateIncident creates a new incident record
-func (s *IncidentService) CreateIncident(req schema.CreateIncidentRequest, userID uuid.UUID) (*models.Incident, error) {
-	// refNumber := generateReferenceNumber()
+func (s *IncidentService) CreateIncident(ctx context.Context, req schema.CreateIncidentRequest, userID uuid.UUID) (*models.Incident, error) {
+	incident := newIncident(req, userID)
+	if err := s.db.WithContext(ctx).Create(incident).Error; err != nil {
+		return nil, fmt.Errorf("failed to create incident: %w", err)
+	}
+
+	return incident, nil
+}
 
+// newIncident builds the record of a newly reported incident
+func newIncident(req schema.CreateIncidentRequest, userID uuid.UUID) *models.Incident {
 	// if req.Type == "injury" && req.InjuryType == "" {
 	// 	return nil, fmt.Errorf("injury type is required for injury incidents")
 	// }
-	incident := &models.Incident{
-		// ReferenceNumber: refNumber,
+	return &models.Incident{
+		// ReferenceNumber is taken from the sequence when the incident is created
 		UserIncidentID: req.UserIncidentID,
 		FullLocation:   req.FullLocation,
 		Type:           req.Type,
 		InjuryType:     req.InjuryType,
 		SeverityLevel:  req.SeverityLevel,
 		LateReason:     req.LateReason,
-		Status:         "new",
+		Status:         models.IncidentStatusNew,
 		Title:          req.Title,
 		Description:    req.Description,
 		Location:       req.Location,
@@ -99,105 +115,79 @@ func (s *IncidentService) CreateIncident(req schema.CreateIncidentRequest, userI
 		EnvironmentalConditions: req.EnvironmentalConditions,
 		EquipmentInvolved:       req.EquipmentInvolved,
 	}
-
-	if err := s.db.Create(incident).Error; err != nil {
-		return nil, fmt.Errorf("failed to create incident: %w", err)
-	}
-
-	return incident, nil
 }
 
-// CreateIncidentWithAttachment creates an incident with an image attachment
+// CreateIncidentWithAttachment creates an incident with its attachments in
+// one transaction. The files are staged and only kept if the incident is.
 func (s *IncidentService) CreateIncidentWithAttachment(
+	ctx context.Context,
 	req schema.CreateIncidentRequest,
 	files []*multipart.FileHeader,
 	uploadedBy uuid.UUID,
 ) (*models.Incident, error) {
-	// Start a transaction
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
-	}
-
-	// Create the incident first
-	incident, err := s.CreateIncident(req, uploadedBy)
-	if err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	// Loop through each file and handle the upload
-	for _, file := range files {
-		// Handle file upload
-		filename := filepath.Base(file.Filename)
-		storagePath := fmt.Sprintf("uploads/incidents/%s/%s", incident.ID, filename)
-
-		// Create directory if it doesn't exist
-		if err := os.MkdirAll(filepath.Dir(storagePath), 0755); err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to create directory: %w", err)
-		}
-
-		// Open and save the file
-		src, err := file.Open()
-		if err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to open file: %w", err)
+	incident := newIncident(req, uploadedBy)
+	incident.ID = uuid.New()
+
+	err := withUploads(ctx, s.db, s.uploads, func(tx *gorm.DB, stage *upload.Stage) error {
+		// Stage the files first: creating the incident locks the reference
+		// number counter until the transaction ends
+		attachments := make([]models.IncidentAttachment, 0, len(files))
+		for _, file := range files {
+			// Check and stage the file; each upload gets its own folder
+			saved, err := stage.SaveAttachment(ctx, storage.Key("incidents", incident.ID.String(), uuid.NewString()), file)
+			if err != nil {
+				return err
+			}
+
+			attachments = append(attachments, models.IncidentAttachment{
+				IncidentID:  incident.ID,
+				FileName:    saved.Name,
+				FileType:    saved.ContentType,
+				FileSize:    int(saved.Size),
+				StoragePath: saved.Key,
+				UploadedBy:  uploadedBy,
+
+				ImageMetadata: imageMetadata(saved),
+			})
 		}
-		defer src.Close()
 
-		dst, err := os.Create(storagePath)
-		if err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to create file: %w", err)
+		if err := tx.Create(incident).Error; err != nil {
+			return fmt.Errorf("failed to create incident: %w", err)
 		}
-		defer dst.Close()
-
-		if _, err = io.Copy(dst, src); err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to save file: %w", err)
-		}
-
-		// Create attachment record
-		attachment := &models.IncidentAttachment{
-			IncidentID:  incident.ID,
-			FileName:    filename,
-			FileType:    file.Header.Get("Content-Type"),
-			FileSize:    int(file.Size),
-			StoragePath: storagePath,
-			UploadedBy:  uploadedBy,
+		if len(attachments) > 0 {
+			if err := tx.Create(&attachments).Error; err != nil {
+				return fmt.Errorf("failed to create attachment record: %w", err)
+			}
 		}
-
-		if err := tx.Create(attachment).Error; err != nil {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to create attachment record: %w", err)
-		}
-	}
-
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return incident, nil
 }
 
-// GetIncident retrieves an incident by ID
-func (s *IncidentService) GetIncident(id uuid.UUID) (*models.Incident, error) {
+// GetIncident retrieves an incident by ID. Incidents outside the scope are
+// reported as not found.
+func (s *IncidentService) GetIncident(scope IncidentScope, id uuid.UUID) (*models.Incident, error) {
 	var incident models.Incident
-	err := s.db.Preload("Reporter").Preload("Assignee").First(&incident, "id = ?", id).Error
+	err := s.db.Preload("Reporter").Preload("Assignee").
+		Scopes(scope.Incidents).
+		First(&incident, "incidents.id = ?", id).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to get incident: %w", err)
 	}
+	scope.Redact(&incident)
 	return &incident, nil
 }
 
 // ListIncidents retrieves a paginated list of incidents
-func (s *IncidentService) ListIncidents(page, pageSize int, filters map[string]interface{}) ([]models.Incident, int64, error) {
+func (s *IncidentService) ListIncidents(scope IncidentScope, page, pageSize int, filters map[string]interface{}) ([]models.Incident, int64, error) {
 	var incidents []models.Incident
 	var total int64
 
-	query := s.db.Model(&models.Incident{}).Where("status != ?", "closed")
+	query := s.db.Model(&models.Incident{}).Where("status != ?", "closed").Scopes(scope.Incidents)
 
 	// Apply filters
 	for key, value := range filters {
@@ -220,13 +210,14 @@ func (s *IncidentService) ListIncidents(page, pageSize int, filters map[string]i
 		return nil, 0, fmt.Errorf("failed to list incidents: %w", err)
 	}
 
+	scope.RedactAll(incidents)
 	return incidents, total, nil
 }
-func (s *IncidentService) ListClosedIncidents(page, pageSize int, filters map[string]interface{}) ([]models.Incident, int64, error) {
+func (s *IncidentService) ListClosedIncidents(scope IncidentScope, page, pageSize int, filters map[string]interface{}) ([]models.Incident, int64, error) {
 	var incidents []models.Incident
 	var total int64
 
-	query := s.db.Model(&models.Incident{}).Where("status = ?", "closed")
+	query := s.db.Model(&models.Incident{}).Where("status = ?", "closed").Scopes(scope.Incidents)
 
 	// Apply additional filters
 	for key, value := range filters {
@@ -249,14 +240,16 @@ func (s *IncidentService) ListClosedIncidents(page, pageSize int, filters map[st
 		return nil, 0, fmt.Errorf("failed to list closed incidents: %w", err)
 	}
 
+	scope.RedactAll(incidents)
 	return incidents, total, nil
 }
 
-// UpdateIncident updates an existing incident
-func (s *IncidentService) UpdateIncident(id uuid.UUID, updates schema.UpdateIncidentRequest) (*models.Incident, error) {
+// UpdateIncident updates an existing incident. A status change goes through
+// the workflow, made by the employee changedBy.
+func (s *IncidentService) UpdateIncident(ctx context.Context, id uuid.UUID, updates schema.UpdateIncidentRequest, changedBy *uuid.UUID) (*models.Incident, error) {
     // Retrieve existing incident
     var incident models.Incident
-    if err := s.db.Preload("Reporter").Preload("Assignee").First(&incident, "id = ?", id).Error; err != nil {
+    if err := s.db.WithContext(ctx).Preload("Reporter").Preload("Assignee").First(&incident, "id = ?", id).Error; err != nil {
         if errors.Is(err, gorm.ErrRecordNotFound) {
             return nil, fmt.Errorf("incident not found")
         }
@@ -297,14 +290,6 @@ func (s *IncidentService) UpdateIncident(id uuid.UUID, updates schema.UpdateInci
         incident.FullLocation = *updates.FullLocation
     }
 
-    if updates.Status != nil {
-        incident.Status = *updates.Status
-        if *updates.Status == "closed" {
-            now := time.Now()
-            incident.ClosedAt = &now
-        }
-    }
-
     if updates.ReporterFullName != nil {
         incident.UserReported = *updates.ReporterFullName
     }
@@ -339,16 +324,34 @@ func (s *IncidentService) UpdateIncident(id uuid.UUID, updates schema.UpdateInci
 
     incident.UpdatedAt = time.Now()
 
-    if err := s.db.Save(&incident).Error; err != nil {
-        return nil, fmt.Errorf("failed to update incident: %w", err)
+    var change *IncidentStatusChange
+    err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+        // The status is only changed by the workflow
+        if err := tx.Omit("status", "closed_at").Save(&incident).Error; err != nil {
+            return fmt.Errorf("failed to update incident: %w", err)
+        }
+        if updates.Status == nil {
+            return nil
+        }
+        var err error
+        change, err = s.workflow.Transition(tx, id, *updates.Status, changedBy, "")
+        return err
+    })
+    if err != nil {
+        return nil, err
     }
 
+    if change != nil {
+        incident.Status = change.To
+        incident.ClosedAt = change.Incident.ClosedAt
+        s.workflow.Notify(change)
+    }
     return &incident, nil
 }
 
 // DeleteIncident soft deletes an incident
-func (s *IncidentService) DeleteIncident(id uuid.UUID) error {
-	return s.db.Delete(&models.Incident{}, id).Error
+func (s *IncidentService) DeleteIncident(ctx context.Context, id uuid.UUID) error {
+	return s.db.WithContext(ctx).Delete(&models.Incident{}, "id = ?", id).Error
 }
 
 // GetIncidentSummary generates a summary of incidents
@@ -428,39 +431,25 @@ func (s *IncidentService) GetIncidentSummary(startDate, endDate time.Time) (*mod
 	return &summary, nil
 }
 
-// CloseIncident closes an incident by setting its status to "closed" and updating the ClosedAt field
-func (s *IncidentService) CloseIncident(id uuid.UUID) (*models.Incident, error) {
-	var incident models.Incident
-	err := s.db.First(&incident, "id = ?", id).Error
-	if err != nil {
-		return nil, fmt.Errorf("failed to find incident: %w", err)
-	}
-
-	incident.Status = "closed"
-	now := time.Now()
-	incident.ClosedAt = &now
-
-	err = s.db.Save(&incident).Error
-	if err != nil {
-		return nil, fmt.Errorf("failed to close incident: %w", err)
-	}
-
-	return &incident, nil
+// CloseIncident closes an incident through the workflow, which requires a
+// completed investigation and verified corrective actions
+func (s *IncidentService) CloseIncident(ctx context.Context, id uuid.UUID, changedBy *uuid.UUID, note string) (*models.Incident, error) {
+	return s.changeStatus(ctx, id, models.IncidentStatusClosed, changedBy, note)
 }
 
 
 
 // AssignIncidentToUser assigns an incident to a user by updating the AssignedTo field
-func (s *IncidentService) AssignIncidentToUser(id uuid.UUID, userID uuid.UUID) (*models.Incident, error) {
+func (s *IncidentService) AssignIncidentToUser(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.Incident, error) {
 	var incident models.Incident
-	err := s.db.First(&incident, "id = ?", id).Error
+	err := s.db.WithContext(ctx).First(&incident, "id = ?", id).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to find incident: %w", err)
 	}
 
 	incident.AssignedTo = &userID
 
-	err = s.db.Save(&incident).Error
+	err = s.db.WithContext(ctx).Save(&incident).Error
 	if err != nil {
 		return nil, fmt.Errorf("failed to assign incident: %w", err)
 	}
@@ -469,13 +458,14 @@ func (s *IncidentService) AssignIncidentToUser(id uuid.UUID, userID uuid.UUID) (
 }
 
 func (s *IncidentService) FilterListIncidents(
+	scope IncidentScope,
 	page, pageSize int,
 	filters map[string]interface{},
 ) ([]models.Incident, int64, error) {
 	var incidents []models.Incident
 	var total int64
 
-	query := s.db.Model(&models.Incident{})
+	query := s.db.Model(&models.Incident{}).Scopes(scope.Incidents)
 
 	// Apply filters
 	for key, value := range filters {
@@ -505,33 +495,51 @@ func (s *IncidentService) FilterListIncidents(
 		return nil, 0, fmt.Errorf("failed to list incidents: %w", err)
 	}
 
+	scope.RedactAll(incidents)
 	return incidents, total, nil
 }
 
-func (s *IncidentService) UpdateIncidentStatus(id uuid.UUID, status string) (*models.Incident, error) {
-	var incident models.Incident
-	err := s.db.First(&incident, "id = ?", id).Error
+// UpdateIncidentStatus moves an incident to status if the workflow allows
+// it. The note is kept in the status history.
+func (s *IncidentService) UpdateIncidentStatus(ctx context.Context, id uuid.UUID, status string, changedBy *uuid.UUID, note string) (*models.Incident, error) {
+	return s.changeStatus(ctx, id, status, changedBy, note)
+}
+
+func (s *IncidentService) changeStatus(ctx context.Context, id uuid.UUID, status string, changedBy *uuid.UUID, note string) (*models.Incident, error) {
+	var change *IncidentStatusChange
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		change, err = s.workflow.Transition(tx, id, status, changedBy, note)
+		return err
+	})
 	if err != nil {
+		return nil, err
+	}
+	s.workflow.Notify(change)
+
+	var incident models.Incident
+	if err := s.db.WithContext(ctx).First(&incident, "id = ?", id).Error; err != nil {
 		return nil, fmt.Errorf("failed to find incident: %w", err)
 	}
+	return &incident, nil
+}
 
-	incident.Status = status
-	if status == "closed" {
-		now := time.Now()
-		incident.ClosedAt = &now
+// GetStatusHistory lists the status changes of an incident, oldest first
+func (s *IncidentService) GetStatusHistory(ctx context.Context, scope IncidentScope, incidentID uuid.UUID) ([]models.IncidentStatusHistory, error) {
+	if _, err := s.GetIncident(scope, incidentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIncidentNotFound
+		}
+		return nil, err
 	}
 
-	err = s.db.Save(&incident).Error
+	var history []models.IncidentStatusHistory
+	err := s.db.WithContext(ctx).Preload("Changer").
+		Where("incident_id = ?", incidentID).
+		Order("created_at").
+		Find(&history).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to update incident status: %w", err)
+		return nil, fmt.Errorf("failed to get status history: %w", err)
 	}
-
-	return &incident, nil
-}
-
-// Helper function to generate a unique reference number
-func generateReferenceNumber() string {
-	timestamp := time.Now().Format("20060102")
-	random := uuid.New().String()[:8]
-	return fmt.Sprintf("INC-%s-%s", timestamp, random)
+	return history, nil
 }
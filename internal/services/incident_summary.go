@@ -21,6 +21,8 @@ type IncidentSummary struct {
 	Interviews        []models.InvestigationInterview `json:"interviews"`
 	Evidence          []models.InvestigationEvidence  `json:"evidence"`
 	ActionEvidence    []models.ActionEvidence         `json:"action_evidence"`
+	StatusHistory     []models.IncidentStatusHistory  `json:"status_history"`
+	RootCauseAnalysis *RootCauseAnalysis              `json:"root_cause_analysis"`
 	Timeline          []TimelineEvent                 `json:"timeline"`
 	Statistics        SummaryStatistics               `json:"statistics"`
 }
@@ -44,7 +46,7 @@ type SummaryStatistics struct {
 }
 
 // Add this method to the IncidentService
-func (s *IncidentService) GenerateIncidentSummary(incidentID uuid.UUID) (*IncidentSummary, error) {
+func (s *IncidentService) GenerateIncidentSummary(scope IncidentScope, incidentID uuid.UUID) (*IncidentSummary, error) {
 	var summary IncidentSummary
 
 	// Start a transaction
@@ -63,7 +65,7 @@ func (s *IncidentService) GenerateIncidentSummary(incidentID uuid.UUID) (*Incide
 	}()
 
 	// Get incident details
-	incident, err := s.GetIncident(incidentID)
+	incident, err := s.GetIncident(scope, incidentID)
 	if err != nil {
 		log.Error("Failed to get incident: %v", err)
 		tx.Rollback()
@@ -78,7 +80,7 @@ func (s *IncidentService) GenerateIncidentSummary(incidentID uuid.UUID) (*Incide
 
 	// Get investigation details
 	var investigation models.Investigation
-	if err := tx.Where("incident_id = ?", incidentID).First(&investigation).Error; err != nil {
+	if err := tx.Preload("LeadInvestigator").Where("incident_id = ?", incidentID).First(&investigation).Error; err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
 			log.Error("Failed to get investigation: %v", err)
 			tx.Rollback()
@@ -98,10 +100,13 @@ func (s *IncidentService) GenerateIncidentSummary(incidentID uuid.UUID) (*Incide
 	}
 	summary.CorrectiveActions = actions
 
-	// Get interviews
+	// Get interviews with their transcripts
 	var interviews []models.InvestigationInterview
 	if summary.Investigation != nil {
-		if err := tx.Where("investigation_id = ?", summary.Investigation.ID).Find(&interviews).Error; err != nil {
+		if err := tx.Preload("Interviewee").Preload("Answers", orderByPosition).
+			Where("investigation_id = ?", summary.Investigation.ID).
+			Order("scheduled_for").
+			Find(&interviews).Error; err != nil {
 			log.Error("Failed to get interviews: %v", err)
 			tx.Rollback()
 			return nil, fmt.Errorf("failed to get interviews: %w", err)
@@ -120,6 +125,17 @@ func (s *IncidentService) GenerateIncidentSummary(incidentID uuid.UUID) (*Incide
 		summary.Evidence = evidence
 	}
 
+	// Get root cause analysis
+	if summary.Investigation != nil {
+		analysis, err := loadRootCauseAnalysis(tx, summary.Investigation.ID)
+		if err != nil {
+			log.Error("Failed to get root cause analysis: %v", err)
+			tx.Rollback()
+			return nil, err
+		}
+		summary.RootCauseAnalysis = analysis
+	}
+
 	// Get action evidence
 	var allActionEvidence []models.ActionEvidence
 	for _, action := range actions {
@@ -133,8 +149,17 @@ func (s *IncidentService) GenerateIncidentSummary(incidentID uuid.UUID) (*Incide
 	}
 	summary.ActionEvidence = allActionEvidence
 
+	// Get status history
+	var history []models.IncidentStatusHistory
+	if err := tx.Preload("Changer").Where("incident_id = ?", incidentID).Order("created_at").Find(&history).Error; err != nil {
+		log.Error("Failed to get status history: %v", err)
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+	summary.StatusHistory = history
+
 	// Generate timeline
-	summary.Timeline = s.generateTimeline(incident, summary.Investigation, actions, interviews)
+	summary.Timeline = s.generateTimeline(incident, summary.Investigation, actions, interviews, history)
 	if len(summary.Timeline) == 0 {
 		log.Warn("No timeline events generated for incident %v", incidentID)
 	}
@@ -186,6 +211,7 @@ func (s *IncidentService) generateTimeline(
 	investigation *models.Investigation,
 	actions []models.CorrectiveAction,
 	interviews []models.InvestigationInterview,
+	history []models.IncidentStatusHistory,
 ) []TimelineEvent {
 	var timeline []TimelineEvent
 
@@ -273,6 +299,29 @@ func (s *IncidentService) generateTimeline(
 		}
 	}
 
+	// Add status changes
+	for _, change := range history {
+		changerName := "System"
+		var changerID uuid.UUID
+		if change.Changer != nil {
+			changerName = change.Changer.FirstName + " " + change.Changer.LastName
+			changerID = change.Changer.ID
+		}
+
+		description := fmt.Sprintf("Status changed from %s to %s", change.FromStatus, change.ToStatus)
+		if change.Note != "" {
+			description += ": " + change.Note
+		}
+
+		timeline = append(timeline, TimelineEvent{
+			Date:        change.CreatedAt,
+			EventType:   "status_changed",
+			Description: description,
+			UserID:      changerID,
+			UserName:    changerName,
+		})
+	}
+
 	// Sort timeline by date
 	sort.Slice(timeline, func(i, j int) bool {
 		return timeline[i].Date.Before(timeline[j].Date)
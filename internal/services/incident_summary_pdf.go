@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+)
+
+// summaryPDF writes an incident summary to a PDF in the style of the
+// reports: dark red section bars over Helvetica text
+type summaryPDF struct {
+	pdf *fpdf.Fpdf
+	// tr maps UTF-8 text to the code page of the core fonts
+	tr func(string) string
+}
+
+// IncidentSummaryPDF renders an incident summary, with the root cause
+// analysis drawn as 5-Whys chains and a fishbone diagram in outline
+func IncidentSummaryPDF(summary *IncidentSummary) (*bytes.Buffer, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	doc := &summaryPDF{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+	incident := summary.Incident
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s - generated %s - page %d", incident.ReferenceNumber, time.Now().Format("2006-01-02 15:04"), pdf.PageNo()), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.CellFormat(0, 10, doc.tr("Incident Summary"), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.CellFormat(0, 7, doc.tr(incident.ReferenceNumber+" - "+incident.Title), "", 1, "C", false, 0, "")
+	pdf.Ln(6)
+
+	doc.section("Incident Details")
+	doc.field("Type", humanize(incident.Type))
+	doc.field("Severity", humanize(incident.SeverityLevel))
+	doc.field("Status", humanize(incident.Status))
+	doc.field("Occurred", incident.OccurredAt.Format("2006-01-02 15:04"))
+	doc.field("Location", incident.Location)
+	doc.field("Reported by", employeeName(incident.Reporter))
+	if incident.ClosedAt != nil {
+		doc.field("Closed", incident.ClosedAt.Format("2006-01-02 15:04"))
+	}
+	doc.paragraph(0, "B", "Description")
+	doc.paragraph(0, "", incident.Description)
+	if incident.ImmediateActionsTaken != "" {
+		doc.paragraph(0, "B", "Immediate actions taken")
+		doc.paragraph(0, "", incident.ImmediateActionsTaken)
+	}
+	pdf.Ln(4)
+
+	if investigation := summary.Investigation; investigation != nil {
+		doc.section("Investigation")
+		doc.field("Lead investigator", employeeName(investigation.LeadInvestigator))
+		doc.field("Status", humanize(investigation.Status))
+		doc.field("Started", investigation.StartedAt.Format("2006-01-02"))
+		if investigation.CompletedAt != nil {
+			doc.field("Completed", investigation.CompletedAt.Format("2006-01-02"))
+		}
+		for _, text := range [][2]string{
+			{"Root cause", investigation.RootCause},
+			{"Findings", investigation.Findings},
+			{"Recommendations", investigation.Recommendations},
+		} {
+			if text[1] != "" {
+				doc.paragraph(0, "B", text[0])
+				doc.paragraph(0, "", text[1])
+			}
+		}
+		pdf.Ln(4)
+	}
+
+	if analysis := summary.RootCauseAnalysis; analysis != nil && (len(analysis.FiveWhys) > 0 || len(analysis.Causes) > 0) {
+		doc.section("Root Cause Analysis")
+		doc.rootCauseAnalysis(analysis)
+		pdf.Ln(4)
+	}
+
+	if len(summary.Interviews) > 0 {
+		doc.section("Interviews")
+		for i := range summary.Interviews {
+			doc.interview(&summary.Interviews[i])
+		}
+		pdf.Ln(4)
+	}
+
+	if len(summary.CorrectiveActions) > 0 {
+		doc.section("Corrective Actions")
+		for _, action := range summary.CorrectiveActions {
+			doc.paragraph(0, "B", fmt.Sprintf("%s (%s priority, %s)", action.Description, action.Priority, humanize(action.Status)))
+			doc.paragraph(4, "", fmt.Sprintf("Due %s", action.DueDate.Format("2006-01-02")))
+		}
+		pdf.Ln(4)
+	}
+
+	if len(summary.Timeline) > 0 {
+		doc.section("Timeline")
+		for _, event := range summary.Timeline {
+			doc.paragraph(0, "", fmt.Sprintf("%s  %s (%s)", event.Date.Format("2006-01-02 15:04"), event.Description, event.UserName))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate incident summary PDF: %w", err)
+	}
+	return &buf, nil
+}
+
+// rootCauseAnalysis draws each 5-Whys chain as a staircase of answers and
+// the fishbone diagram as its categories with their causes and sub-causes
+func (d *summaryPDF) rootCauseAnalysis(analysis *RootCauseAnalysis) {
+	for i, chain := range analysis.FiveWhys {
+		d.paragraph(0, "B", fmt.Sprintf("5-Whys %d: %s", i+1, chain.Problem))
+		for j := range chain.Whys {
+			d.cause(float64(4+4*j), fmt.Sprintf("Why %d: ", j+1), &chain.Whys[j])
+		}
+		d.pdf.Ln(2)
+	}
+
+	if len(analysis.Causes) == 0 {
+		return
+	}
+	d.paragraph(0, "B", "Fishbone diagram")
+	for _, category := range models.CauseCategories {
+		var causes []*models.RootCause
+		for i := range analysis.Causes {
+			if analysis.Causes[i].Category == category {
+				causes = append(causes, &analysis.Causes[i])
+			}
+		}
+		if len(causes) == 0 {
+			continue
+		}
+		d.paragraph(4, "BI", humanize(category))
+		for _, cause := range causes {
+			d.cause(8, "- ", cause)
+			for i := range cause.SubCauses {
+				d.cause(16, "- ", &cause.SubCauses[i])
+			}
+		}
+	}
+}
+
+// cause writes a cause with its root cause marker and links
+func (d *summaryPDF) cause(indent float64, prefix string, cause *models.RootCause) {
+	text := prefix + cause.Description
+	if cause.IsRootCause {
+		text += " [root cause]"
+	}
+	d.paragraph(indent, "", text)
+
+	var links []string
+	for _, evidence := range cause.Evidence {
+		links = append(links, "evidence: "+clip(evidence.Description, 60))
+	}
+	for _, action := range cause.CorrectiveActions {
+		links = append(links, "action: "+clip(action.Description, 60))
+	}
+	if len(links) > 0 {
+		d.pdf.SetTextColor(96, 96, 96)
+		d.paragraph(indent+4, "I", strings.Join(links, "; "))
+		d.pdf.SetTextColor(0, 0, 0)
+	}
+}
+
+// interview writes an interview with its full transcript and whether the
+// interviewee acknowledged it
+func (d *summaryPDF) interview(interview *models.InvestigationInterview) {
+	d.paragraph(0, "B", fmt.Sprintf("%s, %s (%s)", employeeName(interview.Interviewee),
+		interview.ScheduledFor.Format("2006-01-02 15:04"), humanize(interview.Status)))
+	switch {
+	case interview.StatementIntact():
+		d.paragraph(4, "I", "Statement acknowledged by the interviewee on "+interview.AcknowledgedAt.Format("2006-01-02 15:04"))
+	case interview.AcknowledgedAt != nil:
+		d.paragraph(4, "I", "Transcript changed after the interviewee acknowledged it")
+	}
+	for i, answer := range interview.Answers {
+		d.paragraph(4, "B", fmt.Sprintf("Q%d. %s", i+1, answer.Question))
+		d.paragraph(8, "", answer.Answer)
+	}
+	if interview.Notes != "" {
+		d.paragraph(4, "I", "Notes: "+interview.Notes)
+	}
+	d.pdf.Ln(2)
+}
+
+func (d *summaryPDF) section(title string) {
+	d.pdf.SetFillColor(204, 0, 0)     // Dark Red (#CC0000)
+	d.pdf.SetTextColor(255, 255, 255) // White
+	d.pdf.SetFont("Helvetica", "B", 12)
+	d.pdf.CellFormat(0, 8, " "+d.tr(title), "", 1, "L", true, 0, "")
+	d.pdf.SetTextColor(0, 0, 0)
+	d.pdf.Ln(3)
+}
+
+func (d *summaryPDF) field(label, value string) {
+	d.pdf.SetFont("Helvetica", "B", 10)
+	d.pdf.CellFormat(40, 6, d.tr(label+":"), "", 0, "L", false, 0, "")
+	d.pdf.SetFont("Helvetica", "", 10)
+	d.pdf.MultiCell(0, 6, d.tr(value), "", "L", false)
+}
+
+func (d *summaryPDF) paragraph(indent float64, style, text string) {
+	left, _, _, _ := d.pdf.GetMargins()
+	d.pdf.SetFont("Helvetica", style, 10)
+	d.pdf.SetX(left + indent)
+	d.pdf.MultiCell(0, 5, d.tr(text), "", "L", false)
+}
+
+func employeeName(employee models.Employee) string {
+	if employee.ID == uuid.Nil {
+		return "Unknown"
+	}
+	return employee.FirstName + " " + employee.LastName
+}
+
+// humanize turns a stored value such as action_required into "Action required"
+func humanize(value string) string {
+	value = strings.ReplaceAll(value, "_", " ")
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
+
+func clip(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnknownIncidentStatus = errors.New("unknown incident status")
+	// ErrTransitionNotAllowed means no transition of the workflow leads from
+	// the incident's status to the requested one
+	ErrTransitionNotAllowed = errors.New("status change not allowed")
+	// ErrTransitionBlocked means a guard of the transition failed; the
+	// error says which
+	ErrTransitionBlocked = errors.New("status change blocked")
+)
+
+// IncidentStatusChange is a status change made by the incident workflow
+type IncidentStatusChange struct {
+	Incident   *models.Incident
+	Transition string
+	From       string
+	To         string
+	// ChangedBy is the employee who made the change, or nil for the system
+	ChangedBy *uuid.UUID
+	Note      string
+}
+
+// IncidentGuard decides whether a status change may happen. It returns the
+// reason the change is blocked, or nil.
+type IncidentGuard func(tx *gorm.DB, change *IncidentStatusChange) error
+
+// IncidentTransitionHook is called once a status change has committed
+type IncidentTransitionHook func(change IncidentStatusChange)
+
+// incidentGuards are the guards workflow transitions may name
+var incidentGuards = map[string]IncidentGuard{
+	"investigation_completed": requireCompletedInvestigation,
+	"actions_completed":       requireActionStatus("completed", "verified"),
+	"actions_verified":        requireActionStatus("verified"),
+	"note_required":           requireNote,
+}
+
+// IncidentWorkflow is the state machine of incident statuses. Every status
+// change goes through it, is checked against the configured transitions
+// and their guards, and is recorded in the status history.
+type IncidentWorkflow struct {
+	transitions []config.WorkflowTransition
+	hooks       []IncidentTransitionHook
+}
+
+func NewIncidentWorkflow(cfg config.IncidentWorkflow) (*IncidentWorkflow, error) {
+	names := map[string]bool{}
+	pairs := map[string]string{}
+	for _, transition := range cfg.Transitions {
+		if transition.Name == "" {
+			return nil, fmt.Errorf("incident workflow transition to %q has no name", transition.To)
+		}
+		if names[transition.Name] {
+			return nil, fmt.Errorf("incident workflow transition %q is defined twice", transition.Name)
+		}
+		names[transition.Name] = true
+
+		if !isIncidentStatus(transition.To) {
+			return nil, fmt.Errorf("incident workflow transition %q: %w %q", transition.Name, ErrUnknownIncidentStatus, transition.To)
+		}
+		if len(transition.From) == 0 {
+			return nil, fmt.Errorf("incident workflow transition %q has no from status", transition.Name)
+		}
+		for _, from := range transition.From {
+			if !isIncidentStatus(from) {
+				return nil, fmt.Errorf("incident workflow transition %q: %w %q", transition.Name, ErrUnknownIncidentStatus, from)
+			}
+			// Changes are requested by target status, so each pair of
+			// statuses may only have one transition
+			pair := from + " -> " + transition.To
+			if other, ok := pairs[pair]; ok {
+				return nil, fmt.Errorf("incident workflow transitions %q and %q both lead %s", other, transition.Name, pair)
+			}
+			pairs[pair] = transition.Name
+		}
+		for _, guard := range transition.Guards {
+			if _, ok := incidentGuards[guard]; !ok {
+				return nil, fmt.Errorf("incident workflow transition %q has unknown guard %q", transition.Name, guard)
+			}
+		}
+	}
+
+	return &IncidentWorkflow{transitions: cfg.Transitions}, nil
+}
+
+// OnTransition registers a hook run after every status change. Hooks run
+// in the background, in the order they were registered.
+func (w *IncidentWorkflow) OnTransition(hook IncidentTransitionHook) {
+	w.hooks = append(w.hooks, hook)
+}
+
+// Transition moves an incident to status in tx, holding its row lock until
+// tx ends. It returns ErrTransitionNotAllowed when the workflow has no such
+// transition and ErrTransitionBlocked when a guard fails. The change is nil
+// when the incident already had the status.
+func (w *IncidentWorkflow) Transition(tx *gorm.DB, incidentID uuid.UUID, status string, changedBy *uuid.UUID, note string) (*IncidentStatusChange, error) {
+	return w.transition(tx, incidentID, status, changedBy, note, true)
+}
+
+// Advance is Transition for changes the system makes as a side effect,
+// such as requiring action once a corrective action is created. When the
+// workflow does not allow the change it leaves the status alone.
+func (w *IncidentWorkflow) Advance(tx *gorm.DB, incidentID uuid.UUID, status string, changedBy *uuid.UUID) (*IncidentStatusChange, error) {
+	return w.transition(tx, incidentID, status, changedBy, "", false)
+}
+
+func (w *IncidentWorkflow) transition(tx *gorm.DB, incidentID uuid.UUID, status string, changedBy *uuid.UUID, note string, strict bool) (*IncidentStatusChange, error) {
+	if !isIncidentStatus(status) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownIncidentStatus, status)
+	}
+
+	var incident models.Incident
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&incident, "id = ?", incidentID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIncidentNotFound
+		}
+		return nil, fmt.Errorf("failed to find incident: %w", err)
+	}
+	if incident.Status == status {
+		return nil, nil
+	}
+
+	transition := w.find(incident.Status, status)
+	if transition == nil {
+		if !strict {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: an incident cannot go from %s to %s", ErrTransitionNotAllowed, incident.Status, status)
+	}
+
+	change := &IncidentStatusChange{
+		Incident:   &incident,
+		Transition: transition.Name,
+		From:       incident.Status,
+		To:         status,
+		ChangedBy:  changedBy,
+		Note:       strings.TrimSpace(note),
+	}
+	for _, name := range transition.Guards {
+		if err := incidentGuards[name](tx, change); err != nil {
+			if !strict {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("%w: %v", ErrTransitionBlocked, err)
+		}
+	}
+
+	now := time.Now()
+	incident.Status = status
+	incident.UpdatedAt = now
+	if status == models.IncidentStatusClosed {
+		incident.ClosedAt = &now
+	} else {
+		incident.ClosedAt = nil
+	}
+	err = tx.Model(&incident).Select("status", "closed_at", "updated_at").Updates(&incident).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to update incident status: %w", err)
+	}
+
+	history := models.IncidentStatusHistory{
+		IncidentID: incident.ID,
+		Transition: change.Transition,
+		FromStatus: change.From,
+		ToStatus:   change.To,
+		ChangedBy:  changedBy,
+		Note:       change.Note,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to record status change: %w", err)
+	}
+
+	return change, nil
+}
+
+// Notify runs the hooks of committed status changes in the background.
+// Nil changes are skipped, so the results of Transition and Advance can be
+// passed as they are.
+func (w *IncidentWorkflow) Notify(changes ...*IncidentStatusChange) {
+	var committed []IncidentStatusChange
+	for _, change := range changes {
+		if change != nil {
+			committed = append(committed, *change)
+		}
+	}
+	if len(committed) == 0 || len(w.hooks) == 0 {
+		return
+	}
+
+	runInBackground("Incident status hook", func() {
+		for _, change := range committed {
+			for _, hook := range w.hooks {
+				hook(change)
+			}
+		}
+	})
+}
+
+// find returns the transition leading from one status to another, or nil
+func (w *IncidentWorkflow) find(from, to string) *config.WorkflowTransition {
+	for i, transition := range w.transitions {
+		if transition.To != to {
+			continue
+		}
+		for _, status := range transition.From {
+			if status == from {
+				return &w.transitions[i]
+			}
+		}
+	}
+	return nil
+}
+
+func isIncidentStatus(status string) bool {
+	for _, known := range models.IncidentStatuses {
+		if status == known {
+			return true
+		}
+	}
+	return false
+}
+
+func requireCompletedInvestigation(tx *gorm.DB, change *IncidentStatusChange) error {
+	var investigation models.Investigation
+	err := tx.Select("status").Where("incident_id = ?", change.Incident.ID).First(&investigation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("the incident has no investigation")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check the investigation: %w", err)
+	}
+	if investigation.Status != "completed" {
+		return fmt.Errorf("the investigation is %s, not completed", strings.ReplaceAll(investigation.Status, "_", " "))
+	}
+	return nil
+}
+
+// requireActionStatus passes when every corrective action of the incident
+// has one of the statuses. An action only counts as verified when a
+// verifier is recorded on it.
+func requireActionStatus(statuses ...string) IncidentGuard {
+	return func(tx *gorm.DB, change *IncidentStatusChange) error {
+		var open int64
+		err := tx.Model(&models.CorrectiveAction{}).
+			Where("incident_id = ?", change.Incident.ID).
+			Where("(status NOT IN ? OR (status = 'verified' AND verified_by IS NULL))", statuses).
+			Count(&open).Error
+		if err != nil {
+			return fmt.Errorf("failed to check corrective actions: %w", err)
+		}
+		if open > 0 {
+			return fmt.Errorf("%d corrective actions are not %s", open, strings.Join(statuses, " or "))
+		}
+		return nil
+	}
+}
+
+func requireNote(tx *gorm.DB, change *IncidentStatusChange) error {
+	if change.Note == "" {
+		return errors.New("a note explaining the change is required")
+	}
+	return nil
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	database "github.com/hopkali04/health-sys/internal/db"
+	"github.com/hopkali04/health-sys/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// defaultWorkflow is the workflow of a config file that leaves the
+// transitions out
+func defaultWorkflow(t *testing.T) *IncidentWorkflow {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("{}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	workflow, err := NewIncidentWorkflow(cfg.IncidentWorkflow)
+	if err != nil {
+		t.Fatalf("the default workflow is invalid: %v", err)
+	}
+	return workflow
+}
+
+func TestNewIncidentWorkflowRejectsInvalidTransitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		transitions []config.WorkflowTransition
+	}{
+		{"no name", []config.WorkflowTransition{
+			{From: []string{"new"}, To: "investigating"},
+		}},
+		{"name defined twice", []config.WorkflowTransition{
+			{Name: "start", From: []string{"new"}, To: "investigating"},
+			{Name: "start", From: []string{"investigating"}, To: "resolved"},
+		}},
+		{"unknown to status", []config.WorkflowTransition{
+			{Name: "archive", From: []string{"closed"}, To: "archived"},
+		}},
+		{"no from status", []config.WorkflowTransition{
+			{Name: "start", To: "investigating"},
+		}},
+		{"unknown from status", []config.WorkflowTransition{
+			{Name: "start", From: []string{"draft"}, To: "investigating"},
+		}},
+		{"two transitions between the same statuses", []config.WorkflowTransition{
+			{Name: "resolve", From: []string{"investigating"}, To: "resolved"},
+			{Name: "resolve_quickly", From: []string{"new", "investigating"}, To: "resolved"},
+		}},
+		{"unknown guard", []config.WorkflowTransition{
+			{Name: "close", From: []string{"resolved"}, To: "closed", Guards: []string{"manager_approved"}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewIncidentWorkflow(config.IncidentWorkflow{Transitions: tt.transitions}); err == nil {
+				t.Fatal("NewIncidentWorkflow accepted the workflow")
+			}
+		})
+	}
+}
+
+func TestDefaultWorkflowTransitions(t *testing.T) {
+	workflow := defaultWorkflow(t)
+
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"new", "investigating", "start_investigation"},
+		{"new", "action_required", "require_action"},
+		{"investigating", "resolved", "resolve"},
+		{"action_required", "resolved", "resolve"},
+		{"resolved", "action_required", "require_action"},
+		{"resolved", "closed", "close"},
+		{"resolved", "investigating", "reopen"},
+		{"closed", "investigating", "reopen"},
+		{"new", "resolved", ""},
+		{"new", "closed", ""},
+		{"investigating", "closed", ""},
+		{"closed", "new", ""},
+		{"closed", "resolved", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			got := ""
+			if transition := workflow.find(tt.from, tt.to); transition != nil {
+				got = transition.Name
+			}
+			if got != tt.want {
+				t.Fatalf("got transition %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// testDB connects to the scratch Postgres database in TEST_DATABASE_DSN and
+// migrates it. The test is skipped when it is not set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatalf("failed to migrate the test database: %v", err)
+	}
+	if err := database.SeedRoles(db); err != nil {
+		t.Fatalf("failed to seed roles: %v", err)
+	}
+	return db
+}
+
+// workflowTest moves incidents through the default workflow in a
+// transaction that is rolled back once the test ends
+type workflowTest struct {
+	tx       *gorm.DB
+	workflow *IncidentWorkflow
+	employee uuid.UUID
+}
+
+func newWorkflowTest(t *testing.T) *workflowTest {
+	t.Helper()
+	tx := testDB(t).Begin()
+	t.Cleanup(func() { tx.Rollback() })
+
+	user := models.User{Email: fmt.Sprintf("workflow-%s@example.com", uuid.NewString())}
+	if err := tx.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	employee := models.Employee{
+		UserID:         user.ID,
+		EmployeeNumber: "WF-" + uuid.NewString()[:8],
+		FirstName:      "Wanda",
+		LastName:       "Flow",
+		Department:     "Operations",
+		Position:       "Supervisor",
+		Role:           "employee",
+		StartDate:      time.Now(),
+	}
+	if err := tx.Create(&employee).Error; err != nil {
+		t.Fatalf("failed to create employee: %v", err)
+	}
+	return &workflowTest{tx: tx, workflow: defaultWorkflow(t), employee: employee.ID}
+}
+
+// incident creates an incident with status
+func (w *workflowTest) incident(t *testing.T, status string) *models.Incident {
+	t.Helper()
+	incident := &models.Incident{
+		ReferenceNumber: "WF-" + uuid.NewString(),
+		Type:            "near_miss",
+		SeverityLevel:   "low",
+		Status:          status,
+		Title:           "Pallet fell from racking",
+		Description:     "A pallet slid off the top shelf into the aisle.",
+		Location:        "Warehouse",
+		OccurredAt:      time.Now(),
+		ReportedBy:      w.employee,
+	}
+	if err := w.tx.Create(incident).Error; err != nil {
+		t.Fatalf("failed to create incident: %v", err)
+	}
+	return incident
+}
+
+// investigation opens an investigation of the incident with status
+func (w *workflowTest) investigation(t *testing.T, incident *models.Incident, status string) {
+	t.Helper()
+	investigation := &models.Investigation{
+		IncidentID:         incident.ID,
+		LeadInvestigatorID: w.employee,
+		StartedAt:          time.Now(),
+		Status:             status,
+	}
+	if err := w.tx.Create(investigation).Error; err != nil {
+		t.Fatalf("failed to create investigation: %v", err)
+	}
+}
+
+// action adds a corrective action with status to the incident, verified by
+// verifiedBy when it is not nil
+func (w *workflowTest) action(t *testing.T, incident *models.Incident, status string, verifiedBy *uuid.UUID) {
+	t.Helper()
+	action := &models.CorrectiveAction{
+		IncidentID:  incident.ID,
+		Description: "Fit pallet stops to the top shelf",
+		ActionType:  "engineering",
+		Priority:    "medium",
+		Status:      status,
+		AssignedTo:  w.employee,
+		AssignedBy:  w.employee,
+		DueDate:     time.Now().Add(7 * 24 * time.Hour),
+		VerifiedBy:  verifiedBy,
+	}
+	if err := w.tx.Create(action).Error; err != nil {
+		t.Fatalf("failed to create corrective action: %v", err)
+	}
+}
+
+// status reads the incident's current status
+func (w *workflowTest) status(t *testing.T, incident *models.Incident) string {
+	t.Helper()
+	var stored models.Incident
+	if err := w.tx.Select("status").First(&stored, "id = ?", incident.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return stored.Status
+}
+
+func TestTransitionRefusesChangesOutsideTheWorkflow(t *testing.T) {
+	w := newWorkflowTest(t)
+	incident := w.incident(t, models.IncidentStatusNew)
+
+	_, err := w.workflow.Transition(w.tx, incident.ID, models.IncidentStatusClosed, &w.employee, "")
+	if !errors.Is(err, ErrTransitionNotAllowed) {
+		t.Fatalf("Transition returned %v, want %v", err, ErrTransitionNotAllowed)
+	}
+	if _, err := w.workflow.Transition(w.tx, incident.ID, "archived", &w.employee, ""); !errors.Is(err, ErrUnknownIncidentStatus) {
+		t.Fatalf("Transition returned %v, want %v", err, ErrUnknownIncidentStatus)
+	}
+
+	change, err := w.workflow.Advance(w.tx, incident.ID, models.IncidentStatusClosed, nil)
+	if err != nil || change != nil {
+		t.Fatalf("Advance returned %v, %v; want the status left alone", change, err)
+	}
+	if status := w.status(t, incident); status != models.IncidentStatusNew {
+		t.Fatalf("incident is %s, want new", status)
+	}
+}
+
+func TestCloseGuards(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, w *workflowTest, incident *models.Incident)
+		allowed bool
+	}{
+		{"no investigation", func(t *testing.T, w *workflowTest, incident *models.Incident) {
+			w.action(t, incident, "verified", &w.employee)
+		}, false},
+		{"investigation in progress", func(t *testing.T, w *workflowTest, incident *models.Incident) {
+			w.investigation(t, incident, "in_progress")
+			w.action(t, incident, "verified", &w.employee)
+		}, false},
+		{"investigation pending review", func(t *testing.T, w *workflowTest, incident *models.Incident) {
+			w.investigation(t, incident, "pending_review")
+		}, false},
+		{"open action", func(t *testing.T, w *workflowTest, incident *models.Incident) {
+			w.investigation(t, incident, "completed")
+			w.action(t, incident, "verified", &w.employee)
+			w.action(t, incident, "in_progress", nil)
+		}, false},
+		{"completed action not verified", func(t *testing.T, w *workflowTest, incident *models.Incident) {
+			w.investigation(t, incident, "completed")
+			w.action(t, incident, "completed", nil)
+		}, false},
+		{"verified action without a verifier", func(t *testing.T, w *workflowTest, incident *models.Incident) {
+			w.investigation(t, incident, "completed")
+			w.action(t, incident, "verified", nil)
+		}, false},
+		{"investigation completed and actions verified", func(t *testing.T, w *workflowTest, incident *models.Incident) {
+			w.investigation(t, incident, "completed")
+			w.action(t, incident, "verified", &w.employee)
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newWorkflowTest(t)
+			incident := w.incident(t, models.IncidentStatusResolved)
+			tt.prepare(t, w, incident)
+
+			if !tt.allowed {
+				change, err := w.workflow.Advance(w.tx, incident.ID, models.IncidentStatusClosed, nil)
+				if err != nil || change != nil {
+					t.Fatalf("Advance returned %v, %v; want the status left alone", change, err)
+				}
+				_, err = w.workflow.Transition(w.tx, incident.ID, models.IncidentStatusClosed, &w.employee, "")
+				if !errors.Is(err, ErrTransitionBlocked) {
+					t.Fatalf("Transition returned %v, want %v", err, ErrTransitionBlocked)
+				}
+				if status := w.status(t, incident); status != models.IncidentStatusResolved {
+					t.Fatalf("incident is %s, want resolved", status)
+				}
+				return
+			}
+
+			change, err := w.workflow.Transition(w.tx, incident.ID, models.IncidentStatusClosed, &w.employee, "")
+			if err != nil {
+				t.Fatalf("Transition: %v", err)
+			}
+			if change.Transition != "close" || change.From != models.IncidentStatusResolved || change.To != models.IncidentStatusClosed {
+				t.Fatalf("change is %+v", change)
+			}
+			var stored models.Incident
+			if err := w.tx.First(&stored, "id = ?", incident.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.Status != models.IncidentStatusClosed || stored.ClosedAt == nil {
+				t.Fatalf("incident is %s, closed at %v", stored.Status, stored.ClosedAt)
+			}
+			var history []models.IncidentStatusHistory
+			if err := w.tx.Where("incident_id = ?", incident.ID).Find(&history).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != 1 || history[0].Transition != "close" || *history[0].ChangedBy != w.employee {
+				t.Fatalf("status history is %+v", history)
+			}
+		})
+	}
+}
+
+func TestReopenRequiresNote(t *testing.T) {
+	w := newWorkflowTest(t)
+	incident := w.incident(t, models.IncidentStatusClosed)
+
+	for _, note := range []string{"", "   "} {
+		_, err := w.workflow.Transition(w.tx, incident.ID, models.IncidentStatusInvestigating, &w.employee, note)
+		if !errors.Is(err, ErrTransitionBlocked) {
+			t.Fatalf("note %q: Transition returned %v, want %v", note, err, ErrTransitionBlocked)
+		}
+	}
+
+	change, err := w.workflow.Transition(w.tx, incident.ID, models.IncidentStatusInvestigating, &w.employee, " The pallet stops came loose again. ")
+	if err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if change.Transition != "reopen" || change.Note != "The pallet stops came loose again." {
+		t.Fatalf("change is %+v", change)
+	}
+	var stored models.Incident
+	if err := w.tx.First(&stored, "id = ?", incident.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.IncidentStatusInvestigating || stored.ClosedAt != nil {
+		t.Fatalf("incident is %s, closed at %v", stored.Status, stored.ClosedAt)
+	}
+}
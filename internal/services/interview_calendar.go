@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hopkali04/health-sys/internal/models"
+)
+
+// Methods of the calendar invites sent for interviews (RFC 5546)
+const (
+	CalendarMethodRequest = "REQUEST"
+	CalendarMethodCancel  = "CANCEL"
+)
+
+const calendarTimeFormat = "20060102T150405Z"
+
+// calendarAttendee is a person on a calendar invite
+type calendarAttendee struct {
+	Name  string
+	Email string
+}
+
+// interviewInvite is the calendar invite of an interview. It is sent again
+// with the same UID and a higher sequence whenever the interview is
+// rescheduled or cancelled, so calendars update the event they hold.
+type interviewInvite struct {
+	Method    string
+	Interview *models.InvestigationInterview
+	Summary   string
+	// Description is the plain text shown with the event
+	Description string
+	Organizer   calendarAttendee
+	Attendees   []calendarAttendee
+}
+
+// ICS writes the invite as an RFC 5545 calendar
+func (inv *interviewInvite) ICS(stamp time.Time) []byte {
+	status := "CONFIRMED"
+	if inv.Method == CalendarMethodCancel {
+		status = "CANCELLED"
+	}
+
+	var b strings.Builder
+	line := func(name, value string) {
+		writeCalendarLine(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("PRODID", "-//Safety365//Health System//EN")
+	line("VERSION", "2.0")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", inv.Method)
+	line("BEGIN", "VEVENT")
+	line("UID", inv.Interview.CalendarUID())
+	line("SEQUENCE", fmt.Sprint(inv.Interview.Sequence))
+	line("DTSTAMP", stamp.UTC().Format(calendarTimeFormat))
+	line("DTSTART", inv.Interview.ScheduledFor.UTC().Format(calendarTimeFormat))
+	line("DTEND", inv.Interview.EndsAt().UTC().Format(calendarTimeFormat))
+	line("SUMMARY", calendarText(inv.Summary))
+	if inv.Description != "" {
+		line("DESCRIPTION", calendarText(inv.Description))
+	}
+	if inv.Interview.Location != "" {
+		line("LOCATION", calendarText(inv.Interview.Location))
+	}
+	writeCalendarLine(&b, "ORGANIZER"+calendarName(inv.Organizer.Name)+":mailto:"+inv.Organizer.Email)
+	for _, attendee := range inv.Attendees {
+		writeCalendarLine(&b, "ATTENDEE"+calendarName(attendee.Name)+
+			";ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:"+attendee.Email)
+	}
+	line("STATUS", status)
+	line("TRANSP", "OPAQUE")
+	line("END", "VEVENT")
+	line("END", "VCALENDAR")
+	return []byte(b.String())
+}
+
+// writeCalendarLine ends a content line with CRLF, folding it so that no
+// line is longer than 75 octets. Lines are only folded between characters.
+func writeCalendarLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The space starting a continuation line counts towards its length
+		limit = 74
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// calendarText escapes a TEXT value
+func calendarText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(value)
+}
+
+// calendarName is the CN parameter for a name, quoted because names may
+// hold characters that separate parameters
+func calendarName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '"' || r < ' ' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" {
+		return ""
+	}
+	return `;CN="` + name + `"`
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInterviewConflict = errors.New("the interview overlaps another interview")
+	// ErrInvalidSchedule means the interview cannot be booked or changed as
+	// asked; the error says why
+	ErrInvalidSchedule = errors.New("invalid interview schedule")
+)
+
+// InterviewConflict is a booked interview that overlaps the time asked for.
+// Employee is the interviewee or investigator booked in both, and Role is
+// what they are in the booked interview.
+type InterviewConflict struct {
+	Interview models.InvestigationInterview
+	Employee  models.Employee
+	Role      string
+}
+
+// InterviewConflictError lists the interviews a schedule overlaps. It
+// matches ErrInterviewConflict with errors.Is.
+type InterviewConflictError struct {
+	Conflicts []InterviewConflict
+}
+
+func (e *InterviewConflictError) Error() string {
+	names := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		names[i] = fmt.Sprintf("%s %s (%s, %s)", conflict.Employee.FirstName, conflict.Employee.LastName,
+			conflict.Role, conflict.Interview.ScheduledFor.Format("2006-01-02 15:04"))
+	}
+	return fmt.Sprintf("%s: %s", ErrInterviewConflict, strings.Join(names, ", "))
+}
+
+func (e *InterviewConflictError) Is(target error) bool {
+	return target == ErrInterviewConflict
+}
+
+// booking is the time and place asked for an interview
+type booking struct {
+	InvestigationID uuid.UUID
+	IntervieweeID   uuid.UUID
+	ScheduledFor    time.Time
+	DurationMinutes int
+}
+
+func (b booking) endsAt() time.Time {
+	return b.ScheduledFor.Add(time.Duration(b.DurationMinutes) * time.Minute)
+}
+
+// RescheduleInterview moves a booked interview to another time, and place
+// when one is given. The previous schedule is kept in the history and the
+// calendar invite is updated.
+func (s *InterviewService) RescheduleInterview(ctx context.Context, scope IncidentScope, interviewID uuid.UUID, dto schema.RescheduleInterviewDTO, changedBy uuid.UUID) (*models.InvestigationInterview, error) {
+	if _, err := s.scopedInterview(ctx, scope, interviewID); err != nil {
+		return nil, err
+	}
+	if !dto.ScheduledFor.After(time.Now()) {
+		return nil, fmt.Errorf("%w: the interview must be scheduled in the future", ErrInvalidSchedule)
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		interview, err := lockInterview(tx, interviewID)
+		if err != nil {
+			return err
+		}
+		if !interview.IsBooked() {
+			return fmt.Errorf("%w: a %s interview cannot be rescheduled", ErrInvalidSchedule, interview.Status)
+		}
+
+		duration := interview.DurationMinutes
+		if dto.DurationMinutes > 0 {
+			duration = dto.DurationMinutes
+		}
+		location := interview.Location
+		if dto.Location != "" {
+			location = dto.Location
+		}
+		if dto.ScheduledFor.Equal(interview.ScheduledFor) && duration == interview.DurationMinutes && location == interview.Location {
+			return fmt.Errorf("%w: the interview is already scheduled then and there", ErrInvalidSchedule)
+		}
+
+		err = s.checkConflicts(tx, interview.ID, booking{
+			InvestigationID: interview.InvestigationID,
+			IntervieweeID:   interview.IntervieweeID,
+			ScheduledFor:    dto.ScheduledFor,
+			DurationMinutes: duration,
+		})
+		if err != nil {
+			return err
+		}
+		change := &models.InterviewScheduleChange{
+			InterviewID:          interview.ID,
+			Action:               models.ScheduleActionRescheduled,
+			PreviousScheduledFor: &interview.ScheduledFor,
+			PreviousLocation:     interview.Location,
+			ScheduledFor:         dto.ScheduledFor,
+			DurationMinutes:      duration,
+			Location:             location,
+			Reason:               dto.Reason,
+			ChangedBy:            changedBy,
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return fmt.Errorf("failed to record schedule change: %w", err)
+		}
+		return updateBookedInterview(tx, interview, "reschedule", map[string]interface{}{
+			"scheduled_for":    dto.ScheduledFor,
+			"duration_minutes": duration,
+			"location":         location,
+			"status":           "rescheduled",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	interview, err := s.scopedInterview(ctx, scope, interviewID)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyScheduleChange(interview, dto.Reason)
+	return interview, nil
+}
+
+// CancelInterview cancels a booked interview, keeping it and its history,
+// and withdraws the calendar invite
+func (s *InterviewService) CancelInterview(ctx context.Context, scope IncidentScope, interviewID uuid.UUID, reason string, changedBy uuid.UUID) (*models.InvestigationInterview, error) {
+	if _, err := s.scopedInterview(ctx, scope, interviewID); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		interview, err := lockInterview(tx, interviewID)
+		if err != nil {
+			return err
+		}
+		if !interview.IsBooked() {
+			return fmt.Errorf("%w: a %s interview cannot be cancelled", ErrInvalidSchedule, interview.Status)
+		}
+
+		change := &models.InterviewScheduleChange{
+			InterviewID:          interview.ID,
+			Action:               models.ScheduleActionCancelled,
+			PreviousScheduledFor: &interview.ScheduledFor,
+			PreviousLocation:     interview.Location,
+			ScheduledFor:         interview.ScheduledFor,
+			DurationMinutes:      interview.DurationMinutes,
+			Location:             interview.Location,
+			Reason:               reason,
+			ChangedBy:            changedBy,
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return fmt.Errorf("failed to record schedule change: %w", err)
+		}
+		return updateBookedInterview(tx, interview, "cancel", map[string]interface{}{
+			"status": "cancelled",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	interview, err := s.scopedInterview(ctx, scope, interviewID)
+	if err != nil {
+		return nil, err
+	}
+	s.notifyScheduleChange(interview, reason)
+	return interview, nil
+}
+
+// updateBookedInterview changes the schedule of an interview that is still
+// booked and bumps the sequence of its calendar invite
+func updateBookedInterview(tx *gorm.DB, interview *models.InvestigationInterview, action string, updates map[string]interface{}) error {
+	updates["sequence"] = gorm.Expr("sequence + 1")
+	updates["updated_at"] = time.Now()
+	result := tx.Model(&models.InvestigationInterview{}).
+		Where("id = ? AND status IN ?", interview.ID, models.InterviewBookedStatuses).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to %s interview: %w", action, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: the interview is no longer booked", ErrInvalidSchedule)
+	}
+	return nil
+}
+
+// checkConflicts returns an InterviewConflictError when the booking
+// overlaps another booked interview of its interviewee or of the lead
+// investigator, whether they are interviewed or investigating in it. The
+// rows of both are locked so that bookings of the same people are made one
+// at a time.
+func (s *InterviewService) checkConflicts(tx *gorm.DB, interviewID uuid.UUID, b booking) error {
+	var investigation models.Investigation
+	if err := tx.Select("id", "lead_investigator_id").First(&investigation, "id = ?", b.InvestigationID).Error; err != nil {
+		return fmt.Errorf("failed to find investigation: %w", err)
+	}
+	people := []uuid.UUID{b.IntervieweeID}
+	if investigation.LeadInvestigatorID != b.IntervieweeID {
+		people = append(people, investigation.LeadInvestigatorID)
+	}
+
+	var employees []models.Employee
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", people).Order("id").Find(&employees).Error
+	if err != nil {
+		return fmt.Errorf("failed to find employees: %w", err)
+	}
+	if len(employees) != len(people) {
+		return fmt.Errorf("%w: the interviewee is not an employee", ErrInvalidSchedule)
+	}
+
+	var booked []models.InvestigationInterview
+	err = tx.Preload("Investigation").
+		Joins("JOIN investigations ON investigations.id = investigation_interviews.investigation_id").
+		Where("investigation_interviews.id <> ?", interviewID).
+		Where("investigation_interviews.status IN ?", models.InterviewBookedStatuses).
+		Where("investigation_interviews.scheduled_for < ?", b.endsAt()).
+		Where("investigation_interviews.scheduled_for + investigation_interviews.duration_minutes * interval '1 minute' > ?", b.ScheduledFor).
+		Where("(investigation_interviews.interviewee_id IN ? OR investigations.lead_investigator_id IN ?)", people, people).
+		Order("investigation_interviews.scheduled_for").
+		Find(&booked).Error
+	if err != nil {
+		return fmt.Errorf("failed to check interview conflicts: %w", err)
+	}
+	if len(booked) == 0 {
+		return nil
+	}
+
+	conflictErr := &InterviewConflictError{}
+	for _, interview := range booked {
+		for _, employee := range employees {
+			role := ""
+			switch employee.ID {
+			case interview.IntervieweeID:
+				role = "interviewee"
+			case interview.Investigation.LeadInvestigatorID:
+				role = "investigator"
+			default:
+				continue
+			}
+			conflictErr.Conflicts = append(conflictErr.Conflicts, InterviewConflict{
+				Interview: interview,
+				Employee:  employee,
+				Role:      role,
+			})
+		}
+	}
+	sort.SliceStable(conflictErr.Conflicts, func(i, j int) bool {
+		return conflictErr.Conflicts[i].Interview.ScheduledFor.Before(conflictErr.Conflicts[j].Interview.ScheduledFor)
+	})
+	return conflictErr
+}
+
+// notifyScheduleChange tells the interviewee about a rescheduled or
+// cancelled interview and updates the calendar invite of the interviewee
+// and the lead investigator
+func (s *InterviewService) notifyScheduleChange(interview *models.InvestigationInterview, reason string) {
+	s.notificationService.inBackground(func() {
+		if err := s.notificationService.NotifyInterviewStatusChanged(interview, interview.Status); err != nil {
+			utils.LogError("Failed to send interview status notification", map[string]interface{}{
+				"interviewID": interview.ID,
+				"error":       err.Error(),
+			})
+		}
+		if err := s.notificationService.SendInterviewInvite(interview, reason); err != nil {
+			utils.LogError("Failed to send interview calendar invite", map[string]interface{}{
+				"interviewID": interview.ID,
+				"error":       err.Error(),
+			})
+		}
+	})
+}
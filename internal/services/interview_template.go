@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInterviewTemplateNotFound = errors.New("interview template not found")
+)
+
+// InterviewTemplateService manages the question templates interviews are
+// prepared from
+type InterviewTemplateService struct {
+	scopeResolver
+	db *gorm.DB
+}
+
+func NewInterviewTemplateService(db *gorm.DB) *InterviewTemplateService {
+	return &InterviewTemplateService{scopeResolver: scopeResolver{db: db}, db: db}
+}
+
+// ListTemplates lists the templates, of one incident type when it is given.
+// Inactive templates are left out unless asked for.
+func (s *InterviewTemplateService) ListTemplates(ctx context.Context, incidentType string, includeInactive bool) ([]models.InterviewTemplate, error) {
+	query := s.db.WithContext(ctx).Preload("Questions", orderByPosition).Order("incident_type").Order("name")
+	if incidentType != "" {
+		query = query.Where("incident_type = ?", incidentType)
+	}
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var templates []models.InterviewTemplate
+	if err := query.Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to list interview templates: %w", err)
+	}
+	return templates, nil
+}
+
+func (s *InterviewTemplateService) GetTemplate(ctx context.Context, id uuid.UUID) (*models.InterviewTemplate, error) {
+	return findInterviewTemplate(s.db.WithContext(ctx), id)
+}
+
+// CreateTemplate adds a template with its questions
+func (s *InterviewTemplateService) CreateTemplate(ctx context.Context, dto schema.InterviewTemplateDTO, createdBy uuid.UUID) (*models.InterviewTemplate, error) {
+	template := &models.InterviewTemplate{
+		Name:         dto.Name,
+		IncidentType: dto.IncidentType,
+		Description:  dto.Description,
+		IsActive:     dto.IsActive == nil || *dto.IsActive,
+		CreatedBy:    createdBy,
+		Questions:    templateQuestions(dto.Questions),
+	}
+	if err := s.db.WithContext(ctx).Create(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create interview template: %w", err)
+	}
+	return template, nil
+}
+
+// ReplaceTemplate updates a template and replaces its questions. Interviews
+// the template was applied to keep the questions they were asked.
+func (s *InterviewTemplateService) ReplaceTemplate(ctx context.Context, id uuid.UUID, dto schema.InterviewTemplateDTO) (*models.InterviewTemplate, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		template, err := findInterviewTemplate(tx, id)
+		if err != nil {
+			return err
+		}
+
+		template.Name = dto.Name
+		template.IncidentType = dto.IncidentType
+		template.Description = dto.Description
+		if dto.IsActive != nil {
+			template.IsActive = *dto.IsActive
+		}
+		err = tx.Model(template).
+			Select("name", "incident_type", "description", "is_active", "updated_at").
+			Updates(template).Error
+		if err != nil {
+			return fmt.Errorf("failed to update interview template: %w", err)
+		}
+
+		if err := tx.Where("template_id = ?", id).Delete(&models.InterviewQuestion{}).Error; err != nil {
+			return fmt.Errorf("failed to replace interview questions: %w", err)
+		}
+		questions := templateQuestions(dto.Questions)
+		for i := range questions {
+			questions[i].TemplateID = id
+		}
+		if err := tx.Create(&questions).Error; err != nil {
+			return fmt.Errorf("failed to replace interview questions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return findInterviewTemplate(s.db.WithContext(ctx), id)
+}
+
+// DeleteTemplate deletes a template. Interviews keep the questions they
+// were asked from it.
+func (s *InterviewTemplateService) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	result := s.db.WithContext(ctx).Delete(&models.InterviewTemplate{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete interview template: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrInterviewTemplateNotFound
+	}
+	return nil
+}
+
+func findInterviewTemplate(db *gorm.DB, id uuid.UUID) (*models.InterviewTemplate, error) {
+	var template models.InterviewTemplate
+	err := db.Preload("Questions", orderByPosition).First(&template, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInterviewTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find interview template: %w", err)
+	}
+	return &template, nil
+}
+
+func templateQuestions(dtos []schema.InterviewQuestionDTO) []models.InterviewQuestion {
+	questions := make([]models.InterviewQuestion, len(dtos))
+	for i, dto := range dtos {
+		questions[i] = models.InterviewQuestion{
+			Position: i,
+			Text:     dto.Text,
+			Guidance: dto.Guidance,
+			Required: dto.Required,
+		}
+	}
+	return questions
+}
+
+// orderByPosition orders preloaded questions and answers as they are asked
+func orderByPosition(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotInterviewee = errors.New("only the interviewee can acknowledge the statement")
+	// ErrStatementAcknowledged means the interviewee acknowledged the
+	// transcript, which can no longer change
+	ErrStatementAcknowledged = errors.New("the statement has been acknowledged and cannot be changed")
+	// ErrInvalidTranscript means the questions or answers do not fit the
+	// interview; the error says why
+	ErrInvalidTranscript = errors.New("invalid interview transcript")
+)
+
+// ApplyTemplate sets the questions of an interview from a template for the
+// type of the incident under investigation. It replaces questions that
+// have not been answered yet.
+func (s *InterviewService) ApplyTemplate(ctx context.Context, scope IncidentScope, interviewID, templateID uuid.UUID) (*models.InvestigationInterview, error) {
+	interview, err := s.scopedInterview(ctx, scope, interviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.db.WithContext(ctx)
+	template, err := findInterviewTemplate(db, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsActive {
+		return nil, fmt.Errorf("%w: the template is not active", ErrInvalidTranscript)
+	}
+	var incidentType string
+	err = db.Model(&models.Incident{}).
+		Joins("JOIN investigations ON investigations.incident_id = incidents.id").
+		Where("investigations.id = ?", interview.InvestigationID).
+		Pluck("incidents.type", &incidentType).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find incident type: %w", err)
+	}
+	if template.IncidentType != incidentType {
+		return nil, fmt.Errorf("%w: the template is for %s incidents", ErrInvalidTranscript, strings.ReplaceAll(template.IncidentType, "_", " "))
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		interview, err := lockInterview(tx, interviewID)
+		if err != nil {
+			return err
+		}
+		if interview.AcknowledgedAt != nil {
+			return ErrStatementAcknowledged
+		}
+		for _, answer := range interview.Answers {
+			if answer.Answer != "" {
+				return fmt.Errorf("%w: answers have been recorded already", ErrInvalidTranscript)
+			}
+		}
+
+		if err := tx.Where("interview_id = ?", interviewID).Delete(&models.InterviewAnswer{}).Error; err != nil {
+			return fmt.Errorf("failed to replace interview questions: %w", err)
+		}
+		answers := make([]models.InterviewAnswer, len(template.Questions))
+		for i, question := range template.Questions {
+			questionID := question.ID
+			answers[i] = models.InterviewAnswer{
+				InterviewID: interviewID,
+				QuestionID:  &questionID,
+				Position:    i,
+				Question:    question.Text,
+				Required:    question.Required,
+			}
+		}
+		if len(answers) > 0 {
+			if err := tx.Create(&answers).Error; err != nil {
+				return fmt.Errorf("failed to replace interview questions: %w", err)
+			}
+		}
+		err = tx.Model(&models.InvestigationInterview{}).Where("id = ?", interviewID).
+			Update("template_id", templateID).Error
+		if err != nil {
+			return fmt.Errorf("failed to apply interview template: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.scopedInterview(ctx, scope, interviewID)
+}
+
+// RecordAnswers records answers to the questions of an interview and adds
+// follow-up questions. The testimony of a completed interview is brought up
+// to date.
+func (s *InterviewService) RecordAnswers(ctx context.Context, scope IncidentScope, interviewID uuid.UUID, dto schema.RecordInterviewAnswersDTO, recordedBy uuid.UUID) (*models.InvestigationInterview, error) {
+	if _, err := s.scopedInterview(ctx, scope, interviewID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		interview, err := lockInterview(tx, interviewID)
+		if err != nil {
+			return err
+		}
+		if interview.AcknowledgedAt != nil {
+			return ErrStatementAcknowledged
+		}
+
+		byID := make(map[uuid.UUID]*models.InterviewAnswer, len(interview.Answers))
+		next := 0
+		for i := range interview.Answers {
+			byID[interview.Answers[i].ID] = &interview.Answers[i]
+			if interview.Answers[i].Position >= next {
+				next = interview.Answers[i].Position + 1
+			}
+		}
+
+		var followUps []models.InterviewAnswer
+		for _, given := range dto.Answers {
+			if given.ID == nil {
+				if strings.TrimSpace(given.Question) == "" {
+					return fmt.Errorf("%w: a follow-up question needs its text", ErrInvalidTranscript)
+				}
+				answer := models.InterviewAnswer{
+					InterviewID: interviewID,
+					Position:    next,
+					Question:    given.Question,
+					Answer:      given.Answer,
+					RecordedBy:  &recordedBy,
+					RecordedAt:  &now,
+				}
+				if err := tx.Create(&answer).Error; err != nil {
+					return fmt.Errorf("failed to record answer: %w", err)
+				}
+				followUps = append(followUps, answer)
+				next++
+				continue
+			}
+
+			answer, ok := byID[*given.ID]
+			if !ok {
+				return fmt.Errorf("%w: answer %s is not part of the interview", ErrInvalidTranscript, *given.ID)
+			}
+			answer.Answer = given.Answer
+			answer.RecordedBy = &recordedBy
+			answer.RecordedAt = &now
+			err := tx.Model(answer).
+				Select("answer", "recorded_by", "recorded_at", "updated_at").
+				Updates(answer).Error
+			if err != nil {
+				return fmt.Errorf("failed to record answer: %w", err)
+			}
+		}
+
+		if interview.Status == "completed" {
+			interview.Answers = append(interview.Answers, followUps...)
+			return recordTestimony(tx, interview)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.scopedInterview(ctx, scope, interviewID)
+}
+
+// Acknowledge records the interviewee's acknowledgement of their statement
+// once the interview is completed and every required question answered.
+// The transcript cannot change afterwards.
+func (s *InterviewService) Acknowledge(ctx context.Context, scope IncidentScope, interviewID uuid.UUID, employee *models.Employee) (*models.InvestigationInterview, error) {
+	interview, err := s.findInterview(ctx, scope, interviewID, true)
+	if err != nil {
+		return nil, err
+	}
+	if interview.IntervieweeID != employee.ID {
+		return nil, ErrNotInterviewee
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		interview, err := lockInterview(tx, interviewID)
+		if err != nil {
+			return err
+		}
+		if interview.AcknowledgedAt != nil {
+			return ErrStatementAcknowledged
+		}
+		if interview.Status != "completed" {
+			return fmt.Errorf("%w: the interview is not completed", ErrInvalidTranscript)
+		}
+		if len(interview.Answers) == 0 {
+			return fmt.Errorf("%w: the interview has no recorded answers", ErrInvalidTranscript)
+		}
+		for i, answer := range interview.Answers {
+			if answer.Required && strings.TrimSpace(answer.Answer) == "" {
+				return fmt.Errorf("%w: question %d is required and has no answer", ErrInvalidTranscript, i+1)
+			}
+		}
+
+		now := time.Now()
+		interview.AcknowledgedAt = &now
+		interview.StatementHash = interview.TranscriptHash()
+		err = tx.Model(&models.InvestigationInterview{}).Where("id = ?", interviewID).
+			Updates(map[string]interface{}{
+				"acknowledged_at": interview.AcknowledgedAt,
+				"statement_hash":  interview.StatementHash,
+				"updated_at":      now,
+			}).Error
+		if err != nil {
+			return fmt.Errorf("failed to acknowledge statement: %w", err)
+		}
+		return recordTestimony(tx, interview)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.findInterview(ctx, scope, interviewID, true)
+}
+
+// recordTestimony records a completed interview as testimony evidence of
+// its investigation, collected by the lead investigator, or brings that
+// evidence up to date
+func recordTestimony(tx *gorm.DB, interview *models.InvestigationInterview) error {
+	if interview.Interviewee.ID == uuid.Nil {
+		if err := tx.First(&interview.Interviewee, "id = ?", interview.IntervieweeID).Error; err != nil {
+			return fmt.Errorf("failed to find interviewee: %w", err)
+		}
+	}
+	description := testimonyDescription(interview)
+
+	if interview.EvidenceID != nil {
+		result := tx.Model(&models.InvestigationEvidence{}).Where("id = ?", *interview.EvidenceID).
+			Updates(map[string]interface{}{"description": description, "updated_at": time.Now()})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update testimony: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+		// The evidence was deleted, so it is recorded again
+	}
+
+	var investigation models.Investigation
+	if err := tx.Select("id", "lead_investigator_id").First(&investigation, "id = ?", interview.InvestigationID).Error; err != nil {
+		return fmt.Errorf("failed to find investigation: %w", err)
+	}
+	collectedAt := interview.CompletedAt
+	if collectedAt.IsZero() {
+		collectedAt = time.Now()
+	}
+	evidence := &models.InvestigationEvidence{
+		InvestigationID: interview.InvestigationID,
+		EvidenceType:    "testimony",
+		Description:     description,
+		CollectedAt:     collectedAt,
+		CollectedBy:     investigation.LeadInvestigatorID,
+		StorageLocation: interview.Location,
+	}
+	if err := tx.Create(evidence).Error; err != nil {
+		return fmt.Errorf("failed to record testimony: %w", err)
+	}
+	interview.EvidenceID = &evidence.ID
+	err := tx.Model(&models.InvestigationInterview{}).Where("id = ?", interview.ID).
+		Update("evidence_id", evidence.ID).Error
+	if err != nil {
+		return fmt.Errorf("failed to link testimony: %w", err)
+	}
+	return nil
+}
+
+// testimonyDescription is the statement of an interview as it is kept in
+// the evidence: who was interviewed and when, and the transcript
+func testimonyDescription(interview *models.InvestigationInterview) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Statement of %s %s, interviewed on %s",
+		interview.Interviewee.FirstName, interview.Interviewee.LastName,
+		interview.ScheduledFor.Format("2006-01-02 15:04"))
+	if interview.Location != "" {
+		fmt.Fprintf(&b, " at %s", interview.Location)
+	}
+	b.WriteString(".")
+	if interview.AcknowledgedAt != nil {
+		fmt.Fprintf(&b, " Acknowledged by the interviewee on %s.", interview.AcknowledgedAt.Format("2006-01-02 15:04"))
+	}
+	if transcript := interview.Transcript(); transcript != "" {
+		b.WriteString("\n\n" + transcript)
+	}
+	if interview.Notes != "" {
+		b.WriteString("\n\nNotes: " + interview.Notes)
+	}
+	return b.String()
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMemberNotFound = errors.New("investigation member not found")
+	// ErrAlreadyMember means the employee is on the team already, as a
+	// member or as the lead investigator
+	ErrAlreadyMember = errors.New("employee is already on the investigation team")
+	// ErrInvalidMember means the member cannot be added as asked; the
+	// error says why
+	ErrInvalidMember = errors.New("invalid investigation member")
+)
+
+// InvestigationTeam is the lead investigator of an investigation and its
+// members, current and former
+type InvestigationTeam struct {
+	Investigation *models.Investigation
+	Members       []models.InvestigationMember
+}
+
+// InvestigationMemberService manages the teams of investigations
+type InvestigationMemberService struct {
+	scopeResolver
+	db            *gorm.DB
+	notifications *NotificationService
+}
+
+func NewInvestigationMemberService(db *gorm.DB, notifications *NotificationService) *InvestigationMemberService {
+	return &InvestigationMemberService{scopeResolver: scopeResolver{db: db}, db: db, notifications: notifications}
+}
+
+// GetTeam retrieves the team of an investigation, members in the order
+// they joined
+func (s *InvestigationMemberService) GetTeam(ctx context.Context, scope IncidentScope, investigationID uuid.UUID) (*InvestigationTeam, error) {
+	if _, err := scopedInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return nil, err
+	}
+
+	var investigation models.Investigation
+	if err := s.db.WithContext(ctx).Preload("LeadInvestigator").First(&investigation, "id = ?", investigationID).Error; err != nil {
+		return nil, fmt.Errorf("failed to find investigation: %w", err)
+	}
+	var members []models.InvestigationMember
+	err := s.db.WithContext(ctx).Preload("Employee").
+		Where("investigation_id = ?", investigationID).
+		Order("joined_at").
+		Find(&members).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list investigation members: %w", err)
+	}
+	return &InvestigationTeam{Investigation: &investigation, Members: members}, nil
+}
+
+// AddMember puts an employee on an investigation team and tells them
+func (s *InvestigationMemberService) AddMember(ctx context.Context, scope IncidentScope, investigationID uuid.UUID, dto schema.InvestigationMemberDTO, addedBy uuid.UUID) (*models.InvestigationMember, error) {
+	investigation, err := editableInvestigation(ctx, s.db, scope, investigationID)
+	if err != nil {
+		return nil, err
+	}
+	if investigation.LeadInvestigatorID == dto.EmployeeID {
+		return nil, ErrAlreadyMember
+	}
+
+	var employee models.Employee
+	err = s.db.WithContext(ctx).First(&employee, "id = ?", dto.EmployeeID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: employee not found", ErrInvalidMember)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find employee: %w", err)
+	}
+	if !employee.IsActive {
+		return nil, fmt.Errorf("%w: the employee is not active", ErrInvalidMember)
+	}
+
+	member := &models.InvestigationMember{
+		InvestigationID: investigationID,
+		EmployeeID:      dto.EmployeeID,
+		Role:            dto.Role,
+		JoinedAt:        time.Now(),
+		AddedBy:         addedBy,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The unique index on current members backs this check up
+		var current int64
+		err := tx.Model(&models.InvestigationMember{}).
+			Where("investigation_id = ? AND employee_id = ? AND left_at IS NULL", investigationID, dto.EmployeeID).
+			Count(&current).Error
+		if err != nil {
+			return fmt.Errorf("failed to check investigation members: %w", err)
+		}
+		if current > 0 {
+			return ErrAlreadyMember
+		}
+		if err := tx.Create(member).Error; err != nil {
+			return fmt.Errorf("failed to add investigation member: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	member.Employee = employee
+	if err := s.db.WithContext(ctx).Preload("Incident").First(investigation, "id = ?", investigationID).Error; err == nil {
+		s.notifications.inBackground(func() { s.notifications.NotifyMemberAdded(investigation, member) })
+	}
+	return member, nil
+}
+
+// UpdateMemberRole changes the role of a current member
+func (s *InvestigationMemberService) UpdateMemberRole(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID, role string) (*models.InvestigationMember, error) {
+	member, err := s.currentMember(ctx, scope, investigationID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	member.Role = role
+	if err := s.db.WithContext(ctx).Model(member).Update("role", role).Error; err != nil {
+		return nil, fmt.Errorf("failed to update investigation member: %w", err)
+	}
+	return member, nil
+}
+
+// RemoveMember takes a member off the team, keeping the record of their
+// time on it
+func (s *InvestigationMemberService) RemoveMember(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID) error {
+	member, err := s.currentMember(ctx, scope, investigationID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Model(member).Update("left_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to remove investigation member: %w", err)
+	}
+	return nil
+}
+
+// currentMember finds a current member of a team that may still change
+func (s *InvestigationMemberService) currentMember(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID) (*models.InvestigationMember, error) {
+	if _, err := editableInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return nil, err
+	}
+
+	var member models.InvestigationMember
+	err := s.db.WithContext(ctx).Preload("Employee").
+		Where("id = ? AND investigation_id = ? AND left_at IS NULL", id, investigationID).
+		First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find investigation member: %w", err)
+	}
+	return &member, nil
+}
+
+// myInvestigations finds the investigations in the scope an employee leads
+// or is a current member of, with the role they hold on each
+func myInvestigations(db *gorm.DB, scope IncidentScope, employeeID uuid.UUID) ([]models.MyInvestigation, error) {
+	var investigations []models.Investigation
+	err := db.Preload("Incident").
+		Scopes(scope.Investigations).
+		Where("(investigations.lead_investigator_id = @employee OR investigations.id IN ("+memberQuery+"))",
+			map[string]interface{}{"employee": employeeID}).
+		Order("investigations.started_at DESC").
+		Find(&investigations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list investigations: %w", err)
+	}
+
+	var members []models.InvestigationMember
+	err = db.Where("employee_id = ? AND left_at IS NULL", employeeID).Find(&members).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list investigation memberships: %w", err)
+	}
+	roles := make(map[uuid.UUID]string, len(members))
+	for _, member := range members {
+		roles[member.InvestigationID] = member.Role
+	}
+
+	result := make([]models.MyInvestigation, len(investigations))
+	for i, investigation := range investigations {
+		role := roles[investigation.ID]
+		if investigation.LeadInvestigatorID == employeeID {
+			role = models.MemberRoleLead
+		}
+		result[i] = models.MyInvestigation{
+			InvestigationID: investigation.ID,
+			IncidentID:      investigation.IncidentID,
+			ReferenceNumber: investigation.Incident.ReferenceNumber,
+			IncidentTitle:   investigation.Incident.Title,
+			Status:          investigation.Status,
+			StartedAt:       investigation.StartedAt,
+			Role:            role,
+		}
+	}
+	return result, nil
+}
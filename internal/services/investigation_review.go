@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNotLeadInvestigator means only the lead investigator may submit
+	ErrNotLeadInvestigator = errors.New("only the lead investigator can submit the investigation for review")
+	// ErrNotReviewer means the employee may not sign off the step awaiting review
+	ErrNotReviewer = errors.New("not a reviewer of this step")
+	// ErrReviewNotAllowed means the investigation is not in a status the
+	// review action applies to; the error says which
+	ErrReviewNotAllowed = errors.New("review not allowed")
+	ErrCommentsRequired = errors.New("comments are required to reject an investigation")
+	// ErrInvestigationLocked means the investigation is under review or
+	// signed off, and cannot be edited
+	ErrInvestigationLocked = errors.New("investigation is under review or completed")
+)
+
+// SignedReview is a review record with the result of checking its signature
+type SignedReview struct {
+	models.InvestigationReview
+	// Valid is false when the record, or one before it, was changed after
+	// it was signed
+	Valid bool
+}
+
+// ReviewState is where an investigation stands in the review chain
+type ReviewState struct {
+	Investigation *models.Investigation
+	Steps         []config.ReviewStep
+	// CurrentStep is the step awaiting sign-off while the investigation is
+	// pending review, or nil
+	CurrentStep *config.ReviewStep
+	Reviews     []SignedReview
+}
+
+// InvestigationReviewService runs the review chain investigations go
+// through before they are completed: the lead investigator submits, and
+// the reviewer of each step approves or rejects in turn
+type InvestigationReviewService struct {
+	scopeResolver
+	db            *gorm.DB
+	steps         []config.ReviewStep
+	key           []byte
+	notifications *NotificationService
+}
+
+func NewInvestigationReviewService(db *gorm.DB, cfg config.InvestigationReview, notifications *NotificationService) (*InvestigationReviewService, error) {
+	if len(cfg.Steps) == 0 {
+		return nil, errors.New("investigation review has no steps")
+	}
+	if cfg.SigningKey == "" {
+		return nil, errors.New("investigation_review.signing_key must be set to sign review records")
+	}
+	names := map[string]bool{}
+	for _, step := range cfg.Steps {
+		if step.Name == "" || step.Role == "" {
+			return nil, fmt.Errorf("investigation review step %q needs a name and a role", step.Name)
+		}
+		if names[step.Name] {
+			return nil, fmt.Errorf("investigation review step %q is defined twice", step.Name)
+		}
+		names[step.Name] = true
+	}
+
+	return &InvestigationReviewService{
+		scopeResolver: scopeResolver{db: db},
+		db:            db,
+		steps:         cfg.Steps,
+		key:           []byte(cfg.SigningKey),
+		notifications: notifications,
+	}, nil
+}
+
+// GetState returns the review chain of an investigation and every
+// decision recorded on it, with their signatures checked
+func (s *InvestigationReviewService) GetState(ctx context.Context, scope IncidentScope, investigationID uuid.UUID) (*ReviewState, error) {
+	investigation, err := scopedInvestigation(ctx, s.db, scope, investigationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []models.InvestigationReview
+	err = s.db.WithContext(ctx).Preload("Reviewer").
+		Where("investigation_id = ?", investigationID).
+		Order("signed_at").Order("round").Order("step").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	state := &ReviewState{
+		Investigation: investigation,
+		Steps:         s.steps,
+		Reviews:       make([]SignedReview, len(records)),
+	}
+	if investigation.Status == models.InvestigationStatusPendingReview && investigation.ReviewStep < len(s.steps) {
+		state.CurrentStep = &s.steps[investigation.ReviewStep]
+	}
+
+	// Each signature covers the one before it, so a change shows in every
+	// later record too
+	previous, valid := "", true
+	for i, record := range records {
+		valid = valid && hmac.Equal([]byte(record.Signature), []byte(s.sign(previous, &record)))
+		state.Reviews[i] = SignedReview{InvestigationReview: record, Valid: valid}
+		previous = record.Signature
+	}
+	return state, nil
+}
+
+// Submit sends an investigation for review, starting a new round at the
+// first step of the chain
+func (s *InvestigationReviewService) Submit(ctx context.Context, scope IncidentScope, investigationID uuid.UUID, submitter *models.Employee) (*models.Investigation, error) {
+	if _, err := scopedInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return nil, err
+	}
+
+	var investigation models.Investigation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockInvestigation(tx, investigationID, &investigation); err != nil {
+			return err
+		}
+		if investigation.LeadInvestigatorID != submitter.ID {
+			return ErrNotLeadInvestigator
+		}
+		switch investigation.Status {
+		case models.InvestigationStatusInProgress, models.InvestigationStatusReopened:
+		default:
+			return fmt.Errorf("%w: the investigation is %s", ErrReviewNotAllowed, strings.ReplaceAll(investigation.Status, "_", " "))
+		}
+
+		now := time.Now()
+		investigation.Status = models.InvestigationStatusPendingReview
+		investigation.ReviewRound++
+		investigation.ReviewStep = 0
+		investigation.SubmittedAt = &now
+		investigation.UpdatedAt = now
+		err := tx.Model(&investigation).
+			Select("status", "review_round", "review_step", "submitted_at", "updated_at").
+			Updates(&investigation).Error
+		if err != nil {
+			return fmt.Errorf("failed to submit investigation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// requestReview loads the incident the notifications name
+	s.requestReview(ctx, &investigation)
+	submitted := investigation
+	s.notifications.inBackground(func() { s.notifications.NotifyInvestigationSubmitted(&submitted) })
+	return &investigation, nil
+}
+
+// Approve signs off the step awaiting review. Approving the last step
+// completes the investigation.
+func (s *InvestigationReviewService) Approve(ctx context.Context, scope IncidentScope, investigationID uuid.UUID, reviewer *models.Employee, comments string) (*models.Investigation, error) {
+	investigation, review, err := s.decide(ctx, scope, investigationID, reviewer, models.ReviewDecisionApproved, comments)
+	if err != nil {
+		return nil, err
+	}
+
+	if investigation.Status == models.InvestigationStatusCompleted {
+		s.notifications.inBackground(func() { s.notifications.NotifyReviewDecision(investigation, review) })
+	} else {
+		s.requestReview(ctx, investigation)
+	}
+	return investigation, nil
+}
+
+// Reject records a reviewer's rejection and reopens the investigation for
+// its lead investigator. Comments saying why are required.
+func (s *InvestigationReviewService) Reject(ctx context.Context, scope IncidentScope, investigationID uuid.UUID, reviewer *models.Employee, comments string) (*models.Investigation, error) {
+	if strings.TrimSpace(comments) == "" {
+		return nil, ErrCommentsRequired
+	}
+	investigation, review, err := s.decide(ctx, scope, investigationID, reviewer, models.ReviewDecisionRejected, comments)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifications.inBackground(func() { s.notifications.NotifyReviewDecision(investigation, review) })
+	return investigation, nil
+}
+
+// decide records a signed decision on the step awaiting review and moves
+// the investigation on
+func (s *InvestigationReviewService) decide(ctx context.Context, scope IncidentScope, investigationID uuid.UUID, reviewer *models.Employee, decision, comments string) (*models.Investigation, *models.InvestigationReview, error) {
+	if _, err := scopedInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return nil, nil, err
+	}
+
+	var investigation models.Investigation
+	var review models.InvestigationReview
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The lock also orders the records of the signature chain
+		if err := lockInvestigation(tx, investigationID, &investigation); err != nil {
+			return err
+		}
+		if investigation.Status != models.InvestigationStatusPendingReview || investigation.ReviewStep >= len(s.steps) {
+			return fmt.Errorf("%w: the investigation is not pending review", ErrReviewNotAllowed)
+		}
+		step := s.steps[investigation.ReviewStep]
+		ok, err := s.isReviewer(tx, &investigation, step, reviewer)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: the %s review needs a %s", ErrNotReviewer, strings.ReplaceAll(step.Name, "_", " "), strings.ReplaceAll(step.Role, "_", " "))
+		}
+
+		var previous models.InvestigationReview
+		err = tx.Select("signature").Where("investigation_id = ?", investigationID).
+			Order("signed_at DESC").Order("round DESC").Order("step DESC").
+			Limit(1).Find(&previous).Error
+		if err != nil {
+			return fmt.Errorf("failed to read the last review: %w", err)
+		}
+
+		review = models.InvestigationReview{
+			InvestigationID: investigationID,
+			Round:           investigation.ReviewRound,
+			Step:            investigation.ReviewStep,
+			StepName:        step.Name,
+			ReviewerID:      reviewer.ID,
+			Decision:        decision,
+			Comments:        strings.TrimSpace(comments),
+			// Postgres keeps microseconds, so the time is signed as stored
+			SignedAt: time.Now().UTC().Truncate(time.Microsecond),
+		}
+		review.Signature = s.sign(previous.Signature, &review)
+		if err := tx.Create(&review).Error; err != nil {
+			return fmt.Errorf("failed to record review: %w", err)
+		}
+
+		now := time.Now()
+		investigation.UpdatedAt = now
+		if decision == models.ReviewDecisionRejected {
+			investigation.Status = models.InvestigationStatusReopened
+			investigation.ReviewStep = 0
+		} else if investigation.ReviewStep++; investigation.ReviewStep == len(s.steps) {
+			investigation.Status = models.InvestigationStatusCompleted
+			investigation.CompletedAt = &now
+		}
+		err = tx.Model(&investigation).
+			Select("status", "review_step", "completed_at", "updated_at").
+			Updates(&investigation).Error
+		if err != nil {
+			return fmt.Errorf("failed to update investigation: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Preload("Incident").First(&investigation, "id = ?", investigationID).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to reload investigation: %w", err)
+	}
+	return &investigation, &review, nil
+}
+
+// isReviewer reports whether an employee may sign off a step. The lead
+// investigator never reviews their own investigation.
+func (s *InvestigationReviewService) isReviewer(db *gorm.DB, investigation *models.Investigation, step config.ReviewStep, employee *models.Employee) (bool, error) {
+	if employee.ID == investigation.LeadInvestigatorID || !employee.IsActive || employee.Role != step.Role {
+		return false, nil
+	}
+	if !step.SameDepartment {
+		return true, nil
+	}
+	department, err := reporterDepartment(db, investigation.IncidentID)
+	if err != nil {
+		return false, err
+	}
+	return employee.Department == department, nil
+}
+
+// requestReview notifies the reviewers of the step now awaiting sign-off
+func (s *InvestigationReviewService) requestReview(ctx context.Context, investigation *models.Investigation) {
+	if investigation.ReviewStep >= len(s.steps) {
+		return
+	}
+	step := s.steps[investigation.ReviewStep]
+
+	db := s.db.WithContext(ctx)
+	if err := db.Preload("Incident").First(investigation, "id = ?", investigation.ID).Error; err != nil {
+		utils.LogError("Failed to load investigation for review notification", map[string]interface{}{
+			"investigationID": investigation.ID,
+			"error":           err.Error(),
+		})
+		return
+	}
+	query := db.Where("role = ? AND is_active = ? AND id <> ?", step.Role, true, investigation.LeadInvestigatorID)
+	if step.SameDepartment {
+		department, err := reporterDepartment(db, investigation.IncidentID)
+		if err != nil {
+			utils.LogError("Failed to find reviewers", map[string]interface{}{
+				"investigationID": investigation.ID,
+				"error":           err.Error(),
+			})
+			return
+		}
+		query = query.Where("department = ?", department)
+	}
+	var reviewers []models.Employee
+	if err := query.Find(&reviewers).Error; err != nil {
+		utils.LogError("Failed to find reviewers", map[string]interface{}{
+			"investigationID": investigation.ID,
+			"error":           err.Error(),
+		})
+		return
+	}
+	if len(reviewers) == 0 {
+		utils.LogWarn("No reviewer can sign off the investigation", map[string]interface{}{
+			"investigationID": investigation.ID,
+			"step":            step.Name,
+		})
+		return
+	}
+
+	notified := *investigation
+	s.notifications.inBackground(func() { s.notifications.NotifyReviewRequested(&notified, step.Name, reviewers) })
+}
+
+// sign returns the signature of a review record, chained to the signature
+// of the record before it
+func (s *InvestigationReviewService) sign(previous string, review *models.InvestigationReview) string {
+	content := strings.Join([]string{
+		previous,
+		review.InvestigationID.String(),
+		fmt.Sprint(review.Round),
+		fmt.Sprint(review.Step),
+		review.StepName,
+		review.ReviewerID.String(),
+		review.Decision,
+		review.Comments,
+		review.SignedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(content))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func lockInvestigation(tx *gorm.DB, id uuid.UUID, investigation *models.Investigation) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(investigation, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvestigationNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find investigation: %w", err)
+	}
+	return nil
+}
+
+// reporterDepartment is the department of the employee who reported an incident
+func reporterDepartment(db *gorm.DB, incidentID uuid.UUID) (string, error) {
+	var department string
+	err := db.Model(&models.Employee{}).
+		Select("employees.department").
+		Joins("JOIN incidents ON incidents.reported_by = employees.id").
+		Where("incidents.id = ?", incidentID).
+		Scan(&department).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to find the reporter's department: %w", err)
+	}
+	return department, nil
+}
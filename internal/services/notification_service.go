@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"html/template"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,6 +26,12 @@ const (
 	InterviewStatusChanged NotificationType = "interview_status_changed"
 	VpcCreated             NotificationType = "vpc_created"
 	ExtensionRequested     NotificationType = "extension_requested" // New notification type
+	IncidentStatusChanged  NotificationType = "incident_status_changed"
+	ReviewRequested        NotificationType = "investigation_review_requested"
+	InvestigationApproved  NotificationType = "investigation_approved"
+	InvestigationRejected  NotificationType = "investigation_rejected"
+	InvestigationSubmitted NotificationType = "investigation_submitted"
+	MemberAdded            NotificationType = "investigation_member_added"
 )
 
 type NotificationService struct {
@@ -281,6 +288,131 @@ func (s *NotificationService) NotifyActionAssignment(action *models.CorrectiveAc
 	)
 }
 
+// NotifyIncidentStatusChanged tells the reporter and the assignee of an
+// incident that its status changed, unless they changed it themselves. It
+// is registered as an incident workflow hook.
+func (s *NotificationService) NotifyIncidentStatusChanged(change IncidentStatusChange) {
+	incident := change.Incident
+	employeeIDs := []uuid.UUID{incident.ReportedBy}
+	if incident.AssignedTo != nil && *incident.AssignedTo != incident.ReportedBy {
+		employeeIDs = append(employeeIDs, *incident.AssignedTo)
+	}
+
+	message := fmt.Sprintf("Incident %s (%s) moved from %s to %s",
+		incident.ReferenceNumber, incident.Title, change.From, change.To)
+	if change.Note != "" {
+		message += ": " + change.Note
+	}
+
+	for _, employeeID := range employeeIDs {
+		if change.ChangedBy != nil && *change.ChangedBy == employeeID {
+			continue
+		}
+		employee, err := s.GetEmployeeByID(employeeID)
+		if err != nil {
+			log.Printf("Failed to notify employee %s of incident status change: %v", employeeID, err)
+			continue
+		}
+		notification := &models.Notification{
+			UserID:        employee.UserID,
+			Type:          string(IncidentStatusChanged),
+			Title:         "Incident Status Changed",
+			Message:       message,
+			ReferenceID:   incident.ID,
+			ReferenceType: "incident",
+		}
+		if err := s.db.Create(notification).Error; err != nil {
+			log.Printf("Failed to create notification record: %v", err)
+		}
+	}
+}
+
+// NotifyReviewRequested asks the reviewers of a step of the review chain
+// to sign off an investigation
+func (s *NotificationService) NotifyReviewRequested(investigation *models.Investigation, step string, reviewers []models.Employee) {
+	message := fmt.Sprintf("The investigation of incident %s (%s) awaits your review as %s",
+		investigation.Incident.ReferenceNumber, investigation.Incident.Title, strings.ReplaceAll(step, "_", " "))
+	for _, reviewer := range reviewers {
+		notification := &models.Notification{
+			UserID:        reviewer.UserID,
+			Type:          string(ReviewRequested),
+			Title:         "Investigation Review Requested",
+			Message:       message,
+			ReferenceID:   investigation.ID,
+			ReferenceType: "investigation",
+		}
+		if err := s.db.Create(notification).Error; err != nil {
+			log.Printf("Failed to create notification record: %v", err)
+		}
+	}
+}
+
+// NotifyReviewDecision tells the investigation team that a reviewer
+// rejected the investigation, or that the last reviewer approved it
+func (s *NotificationService) NotifyReviewDecision(investigation *models.Investigation, review *models.InvestigationReview) {
+	if review.Decision == models.ReviewDecisionRejected {
+		s.notifyTeam(investigation, InvestigationRejected, "Investigation Rejected",
+			fmt.Sprintf("The investigation of incident %s was rejected at the %s review and has been reopened: %s",
+				investigation.Incident.ReferenceNumber, strings.ReplaceAll(review.StepName, "_", " "), review.Comments))
+		return
+	}
+	s.notifyTeam(investigation, InvestigationApproved, "Investigation Approved",
+		fmt.Sprintf("The investigation of incident %s has been approved by every reviewer and is completed",
+			investigation.Incident.ReferenceNumber))
+}
+
+// NotifyInvestigationSubmitted tells the investigation team that the lead
+// investigator submitted the investigation for review
+func (s *NotificationService) NotifyInvestigationSubmitted(investigation *models.Investigation) {
+	s.notifyTeam(investigation, InvestigationSubmitted, "Investigation Submitted for Review",
+		fmt.Sprintf("The investigation of incident %s has been submitted for review", investigation.Incident.ReferenceNumber))
+}
+
+// NotifyMemberAdded tells an employee they were put on an investigation team
+func (s *NotificationService) NotifyMemberAdded(investigation *models.Investigation, member *models.InvestigationMember) {
+	notification := &models.Notification{
+		UserID: member.Employee.UserID,
+		Type:   string(MemberAdded),
+		Title:  "Added to Investigation Team",
+		Message: fmt.Sprintf("You have been added to the investigation of incident %s (%s) as %s",
+			investigation.Incident.ReferenceNumber, investigation.Incident.Title, strings.ReplaceAll(member.Role, "_", " ")),
+		ReferenceID:   investigation.ID,
+		ReferenceType: "investigation",
+	}
+	if err := s.db.Create(notification).Error; err != nil {
+		log.Printf("Failed to create notification record: %v", err)
+	}
+}
+
+// notifyTeam sends a notification to the lead investigator and the current
+// members of an investigation
+func (s *NotificationService) notifyTeam(investigation *models.Investigation, notificationType NotificationType, title, message string) {
+	var userIDs []uuid.UUID
+	err := s.db.Model(&models.Employee{}).
+		Where("id = ? OR id IN (?)", investigation.LeadInvestigatorID,
+			s.db.Model(&models.InvestigationMember{}).Select("employee_id").
+				Where("investigation_id = ? AND left_at IS NULL", investigation.ID)).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		log.Printf("Failed to find investigation team: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		notification := &models.Notification{
+			UserID:        userID,
+			Type:          string(notificationType),
+			Title:         title,
+			Message:       message,
+			ReferenceID:   investigation.ID,
+			ReferenceType: "investigation",
+		}
+		if err := s.db.Create(notification).Error; err != nil {
+			log.Printf("Failed to create notification record: %v", err)
+		}
+	}
+}
+
 func (s *NotificationService) NotifyActionDueSoon(action *models.CorrectiveAction) error {
 	notification := &models.Notification{
 		UserID:  action.AssignedTo,
@@ -293,8 +425,12 @@ func (s *NotificationService) NotifyActionDueSoon(action *models.CorrectiveActio
 }
 
 func (s *NotificationService) NotifyInterviewScheduled(interview *models.InvestigationInterview) error {
+	interviewee, err := s.GetEmployeeByID(interview.IntervieweeID)
+	if err != nil {
+		return err
+	}
 	notification := &models.Notification{
-		UserID: interview.IntervieweeID,
+		UserID: interviewee.UserID,
 		Type:   string(InterviewScheduled),
 		Title:  "Investigation Interview Scheduled",
 		Message: fmt.Sprintf("You have been scheduled for an interview on %s at %s",
@@ -312,17 +448,22 @@ func (s *NotificationService) NotifyInterviewStatusChanged(interview *models.Inv
 		message = fmt.Sprintf("Your interview has been rescheduled to %s at %s",
 			interview.ScheduledFor.Format("2006-01-02"),
 			interview.ScheduledFor.Format("15:04"))
-	case "canceled":
-		message = "Your interview has been canceled."
+	case "cancelled":
+		message = "Your interview has been cancelled."
 	case "completed":
 		message = "Your interview has been completed."
 	default:
 		message = "There has been a change in your interview status."
 	}
 
+	interviewee, err := s.GetEmployeeByID(interview.IntervieweeID)
+	if err != nil {
+		return err
+	}
+
 	// Create the notification
 	notification := &models.Notification{
-		UserID:  interview.IntervieweeID,
+		UserID:  interviewee.UserID,
 		Type:    string(InterviewStatusChanged),
 		Title:   "Investigation Interview Status Changed",
 		Message: message,
@@ -332,6 +473,56 @@ func (s *NotificationService) NotifyInterviewStatusChanged(interview *models.Inv
 	return s.db.Create(notification).Error
 }
 
+// SendInterviewInvite emails the calendar invite of an interview to the
+// interviewee and the lead investigator, or the cancellation of the invite
+// once the interview is cancelled
+func (s *NotificationService) SendInterviewInvite(interview *models.InvestigationInterview, reason string) error {
+	var investigation models.Investigation
+	err := s.db.Preload("Incident").Preload("LeadInvestigator.User").
+		First(&investigation, "id = ?", interview.InvestigationID).Error
+	if err != nil {
+		return fmt.Errorf("failed to find investigation: %w", err)
+	}
+	var interviewee models.Employee
+	if err := s.db.Preload("User").First(&interviewee, "id = ?", interview.IntervieweeID).Error; err != nil {
+		return fmt.Errorf("failed to find interviewee: %w", err)
+	}
+
+	method := CalendarMethodRequest
+	if interview.Status == "cancelled" {
+		method = CalendarMethodCancel
+	}
+	intervieweeName := interviewee.FirstName + " " + interviewee.LastName
+	invite := &interviewInvite{
+		Method:    method,
+		Interview: interview,
+		Summary:   fmt.Sprintf("Investigation interview: %s (%s)", intervieweeName, investigation.Incident.ReferenceNumber),
+		Description: fmt.Sprintf("Interview of %s for the investigation of incident %s: %s",
+			intervieweeName, investigation.Incident.ReferenceNumber, investigation.Incident.Title),
+	}
+
+	attendees := []models.Employee{interviewee}
+	if investigation.LeadInvestigatorID != interviewee.ID {
+		attendees = append(attendees, investigation.LeadInvestigator)
+	}
+	var to []string
+	for _, employee := range attendees {
+		if employee.User.Email == "" {
+			continue
+		}
+		invite.Attendees = append(invite.Attendees, calendarAttendee{
+			Name:  employee.FirstName + " " + employee.LastName,
+			Email: employee.User.Email,
+		})
+		to = append(to, employee.User.Email)
+	}
+	if len(to) == 0 {
+		log.Printf("No email address found for the attendees of interview %s", interview.ID)
+		return nil
+	}
+	return s.emailService.sendInterviewInviteEmail(to, invite, reason)
+}
+
 func (s *NotificationService) NotifyInvestigationLeader(interview *models.Investigation) error {
 	var user models.Employee
 	if err := s.db.First(&user, "id = ?", interview.LeadInvestigatorID).Preload("User").Error; err != nil {
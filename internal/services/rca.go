@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrFiveWhysNotFound  = errors.New("5-whys chain not found")
+	ErrRootCauseNotFound = errors.New("root cause not found")
+	// ErrInvalidRootCause means a cause does not fit the analysis, such as
+	// a fishbone cause without a category; the error says why
+	ErrInvalidRootCause = errors.New("invalid root cause")
+)
+
+// RootCauseAnalysis is the structured root cause analysis of an
+// investigation: its 5-Whys chains, and the fishbone causes with their
+// sub-causes
+type RootCauseAnalysis struct {
+	FiveWhys []models.FiveWhys  `json:"five_whys"`
+	Causes   []models.RootCause `json:"causes"`
+}
+
+// RCAService records the root cause analysis of investigations
+type RCAService struct {
+	scopeResolver
+	db *gorm.DB
+}
+
+func NewRCAService(db *gorm.DB) *RCAService {
+	return &RCAService{scopeResolver: scopeResolver{db: db}, db: db}
+}
+
+// GetAnalysis retrieves the root cause analysis of an investigation in the scope
+func (s *RCAService) GetAnalysis(ctx context.Context, scope IncidentScope, investigationID uuid.UUID) (*RootCauseAnalysis, error) {
+	if _, err := scopedInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return nil, err
+	}
+	return loadRootCauseAnalysis(s.db.WithContext(ctx), investigationID)
+}
+
+// CreateFiveWhys adds a 5-Whys chain to an investigation
+func (s *RCAService) CreateFiveWhys(ctx context.Context, scope IncidentScope, investigationID uuid.UUID, dto schema.FiveWhysDTO, createdBy uuid.UUID) (*models.FiveWhys, error) {
+	investigation, err := editableInvestigation(ctx, s.db, scope, investigationID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := &models.FiveWhys{
+		InvestigationID: investigationID,
+		Problem:         dto.Problem,
+		CreatedBy:       createdBy,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.FiveWhys{}).Where("investigation_id = ?", investigationID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count 5-whys chains: %w", err)
+		}
+		chain.Position = int(count)
+		if err := tx.Create(chain).Error; err != nil {
+			return fmt.Errorf("failed to create 5-whys chain: %w", err)
+		}
+		return s.createWhys(tx, investigation, chain, dto.Whys, createdBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.findFiveWhys(ctx, investigationID, chain.ID)
+}
+
+// ReplaceFiveWhys rewrites the problem and the answers of a 5-Whys chain.
+// The answers are replaced as a whole, since each depends on the one before.
+func (s *RCAService) ReplaceFiveWhys(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID, dto schema.FiveWhysDTO, changedBy uuid.UUID) (*models.FiveWhys, error) {
+	investigation, err := editableInvestigation(ctx, s.db, scope, investigationID)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := s.findFiveWhys(ctx, investigationID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(chain).Update("problem", dto.Problem).Error; err != nil {
+			return fmt.Errorf("failed to update 5-whys chain: %w", err)
+		}
+		// Links to evidence and actions go with the answers
+		if err := tx.Where("five_whys_id = ?", chain.ID).Delete(&models.RootCause{}).Error; err != nil {
+			return fmt.Errorf("failed to replace 5-whys answers: %w", err)
+		}
+		return s.createWhys(tx, investigation, chain, dto.Whys, changedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.findFiveWhys(ctx, investigationID, chain.ID)
+}
+
+// DeleteFiveWhys deletes a 5-Whys chain and its answers
+func (s *RCAService) DeleteFiveWhys(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID) error {
+	if _, err := editableInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return err
+	}
+	result := s.db.WithContext(ctx).Where("id = ? AND investigation_id = ?", id, investigationID).Delete(&models.FiveWhys{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete 5-whys chain: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrFiveWhysNotFound
+	}
+	return nil
+}
+
+// CreateCause adds a cause to the fishbone diagram of an investigation. A
+// cause with a parent is a sub-cause and takes the parent's category.
+func (s *RCAService) CreateCause(ctx context.Context, scope IncidentScope, investigationID uuid.UUID, dto schema.RootCauseDTO, createdBy uuid.UUID) (*models.RootCause, error) {
+	investigation, err := editableInvestigation(ctx, s.db, scope, investigationID)
+	if err != nil {
+		return nil, err
+	}
+
+	cause := &models.RootCause{
+		InvestigationID: investigationID,
+		ParentID:        dto.ParentID,
+		Category:        dto.Category,
+		Description:     dto.Description,
+		IsRootCause:     dto.IsRootCause,
+		CreatedBy:       createdBy,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if dto.ParentID != nil {
+			parent, err := s.findCause(tx, investigationID, *dto.ParentID)
+			if errors.Is(err, ErrRootCauseNotFound) {
+				return fmt.Errorf("%w: the parent cause does not exist", ErrInvalidRootCause)
+			}
+			if err != nil {
+				return err
+			}
+			// Sub-causes go one level deep, as on the diagram
+			if parent.ParentID != nil {
+				return fmt.Errorf("%w: a sub-cause cannot have sub-causes", ErrInvalidRootCause)
+			}
+			if dto.Category != "" && dto.Category != parent.Category {
+				return fmt.Errorf("%w: a sub-cause is in the category of its parent, %s", ErrInvalidRootCause, parent.Category)
+			}
+			cause.Category = parent.Category
+		} else if cause.Category == "" {
+			return fmt.Errorf("%w: a fishbone cause needs a category", ErrInvalidRootCause)
+		}
+
+		var count int64
+		siblings := tx.Model(&models.RootCause{}).Where("investigation_id = ? AND five_whys_id IS NULL", investigationID)
+		if dto.ParentID != nil {
+			siblings = siblings.Where("parent_id = ?", *dto.ParentID)
+		} else {
+			siblings = siblings.Where("parent_id IS NULL AND category = ?", cause.Category)
+		}
+		if err := siblings.Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to count causes: %w", err)
+		}
+		cause.Position = int(count)
+
+		if err := tx.Create(cause).Error; err != nil {
+			return fmt.Errorf("failed to create cause: %w", err)
+		}
+		return linkCause(tx, investigation, cause, dto.EvidenceIDs, dto.CorrectiveActionIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.findCause(s.db.WithContext(ctx), investigationID, cause.ID)
+}
+
+// UpdateCause changes a fishbone cause and replaces its links. Moving a
+// cause to another category moves its sub-causes with it.
+func (s *RCAService) UpdateCause(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID, dto schema.UpdateRootCauseDTO) (*models.RootCause, error) {
+	investigation, err := editableInvestigation(ctx, s.db, scope, investigationID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		cause, err := s.findCause(tx, investigationID, id)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"description":   dto.Description,
+			"is_root_cause": dto.IsRootCause,
+		}
+		if dto.Category != "" && dto.Category != cause.Category {
+			if cause.ParentID != nil {
+				return fmt.Errorf("%w: a sub-cause is in the category of its parent, %s", ErrInvalidRootCause, cause.Category)
+			}
+			updates["category"] = dto.Category
+			err := tx.Model(&models.RootCause{}).Where("parent_id = ?", cause.ID).Update("category", dto.Category).Error
+			if err != nil {
+				return fmt.Errorf("failed to move sub-causes: %w", err)
+			}
+		}
+		if err := tx.Model(cause).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to update cause: %w", err)
+		}
+		return linkCause(tx, investigation, cause, dto.EvidenceIDs, dto.CorrectiveActionIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.findCause(s.db.WithContext(ctx), investigationID, id)
+}
+
+// DeleteCause deletes a fishbone cause and its sub-causes
+func (s *RCAService) DeleteCause(ctx context.Context, scope IncidentScope, investigationID, id uuid.UUID) error {
+	if _, err := editableInvestigation(ctx, s.db, scope, investigationID); err != nil {
+		return err
+	}
+	result := s.db.WithContext(ctx).
+		Where("id = ? AND investigation_id = ? AND five_whys_id IS NULL", id, investigationID).
+		Delete(&models.RootCause{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete cause: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRootCauseNotFound
+	}
+	return nil
+}
+
+// createWhys records the answers of a 5-Whys chain in order
+func (s *RCAService) createWhys(tx *gorm.DB, investigation *models.Investigation, chain *models.FiveWhys, whys []schema.RootCauseDTO, createdBy uuid.UUID) error {
+	for i, why := range whys {
+		cause := &models.RootCause{
+			InvestigationID: investigation.ID,
+			FiveWhysID:      &chain.ID,
+			Category:        why.Category,
+			Description:     why.Description,
+			Position:        i,
+			IsRootCause:     why.IsRootCause,
+			CreatedBy:       createdBy,
+		}
+		if err := tx.Create(cause).Error; err != nil {
+			return fmt.Errorf("failed to create 5-whys answer: %w", err)
+		}
+		if err := linkCause(tx, investigation, cause, why.EvidenceIDs, why.CorrectiveActionIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *RCAService) findFiveWhys(ctx context.Context, investigationID, id uuid.UUID) (*models.FiveWhys, error) {
+	var chain models.FiveWhys
+	err := s.db.WithContext(ctx).
+		Preload("Whys", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Whys.Evidence").
+		Preload("Whys.CorrectiveActions").
+		First(&chain, "id = ? AND investigation_id = ?", id, investigationID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFiveWhysNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find 5-whys chain: %w", err)
+	}
+	return &chain, nil
+}
+
+// findCause finds a fishbone cause; answers of 5-Whys chains are edited
+// through their chain
+func (s *RCAService) findCause(db *gorm.DB, investigationID, id uuid.UUID) (*models.RootCause, error) {
+	var cause models.RootCause
+	err := db.
+		Preload("SubCauses", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("SubCauses.Evidence").
+		Preload("SubCauses.CorrectiveActions").
+		Preload("Evidence").
+		Preload("CorrectiveActions").
+		First(&cause, "id = ? AND investigation_id = ? AND five_whys_id IS NULL", id, investigationID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRootCauseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find cause: %w", err)
+	}
+	return &cause, nil
+}
+
+// loadRootCauseAnalysis reads the analysis of an investigation, with the
+// evidence and corrective actions each cause is linked to
+func loadRootCauseAnalysis(db *gorm.DB, investigationID uuid.UUID) (*RootCauseAnalysis, error) {
+	byPosition := func(db *gorm.DB) *gorm.DB { return db.Order("position") }
+
+	analysis := &RootCauseAnalysis{}
+	err := db.Preload("Whys", byPosition).
+		Preload("Whys.Evidence").
+		Preload("Whys.CorrectiveActions").
+		Where("investigation_id = ?", investigationID).
+		Order("position").
+		Find(&analysis.FiveWhys).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load 5-whys chains: %w", err)
+	}
+
+	err = db.Preload("SubCauses", byPosition).
+		Preload("SubCauses.Evidence").
+		Preload("SubCauses.CorrectiveActions").
+		Preload("Evidence").
+		Preload("CorrectiveActions").
+		Where("investigation_id = ? AND five_whys_id IS NULL AND parent_id IS NULL", investigationID).
+		Order("category").Order("position").
+		Find(&analysis.Causes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fishbone causes: %w", err)
+	}
+	return analysis, nil
+}
+
+// linkCause replaces the evidence and corrective actions a cause is linked
+// to. Evidence must belong to the investigation and actions to its incident.
+func linkCause(tx *gorm.DB, investigation *models.Investigation, cause *models.RootCause, evidenceIDs, actionIDs []uuid.UUID) error {
+	evidence := []models.InvestigationEvidence{}
+	if ids := uniqueIDs(evidenceIDs); len(ids) > 0 {
+		err := tx.Where("id IN ? AND investigation_id = ?", ids, investigation.ID).Find(&evidence).Error
+		if err != nil {
+			return fmt.Errorf("failed to find evidence: %w", err)
+		}
+		if len(evidence) != len(ids) {
+			return fmt.Errorf("%w: linked evidence must belong to the investigation", ErrInvalidRootCause)
+		}
+	}
+	if err := tx.Model(cause).Association("Evidence").Replace(evidence); err != nil {
+		return fmt.Errorf("failed to link evidence: %w", err)
+	}
+
+	actions := []models.CorrectiveAction{}
+	if ids := uniqueIDs(actionIDs); len(ids) > 0 {
+		err := tx.Where("id IN ? AND incident_id = ?", ids, investigation.IncidentID).Find(&actions).Error
+		if err != nil {
+			return fmt.Errorf("failed to find corrective actions: %w", err)
+		}
+		if len(actions) != len(ids) {
+			return fmt.Errorf("%w: linked corrective actions must belong to the incident", ErrInvalidRootCause)
+		}
+	}
+	if err := tx.Model(cause).Association("CorrectiveActions").Replace(actions); err != nil {
+		return fmt.Errorf("failed to link corrective actions: %w", err)
+	}
+	return nil
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
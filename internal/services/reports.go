@@ -33,6 +33,7 @@ const (
 	DepartmentSummary  ReportType = "department_summary"
 	RiskAssessment     ReportType = "risk_assessment"
 	TrainingCompliance ReportType = "training_compliance"
+	RootCauses         ReportType = "root_causes"
 )
 
 type ReportRequest struct {
@@ -81,6 +82,8 @@ func (s *ReportService) GenerateReport(req ReportRequest) (interface{}, error) {
 		return s.generateLocationAnalysisReport(req)
 	case ComplianceReport:
 		return s.generateComplianceReport(req)
+	case RootCauses:
+		return s.generateRootCauseReport(req)
 	default:
 		return nil, errors.New("unsupported report type")
 	}
@@ -332,6 +335,8 @@ func (s *ReportService) ExportToExcel(data interface{}, reportType ReportType) (
 		return s.exportLocationAnalysis(f, data.(*LocationAnalysisData))
 	case ComplianceReport:
 		return s.exportComplianceReport(f, data.(*ComplianceData))
+	case RootCauses:
+		return s.exportRootCauses(f, data.(*RootCauseData))
 	default:
 		return nil, errors.New("unsupported export type")
 	}
@@ -626,6 +631,8 @@ func (s *ReportService) ExportToPDF(data interface{}, reportType ReportType) (*b
 		return s.exportLocationAnalysisPDF(pdf, data.(*LocationAnalysisData))
 	case ComplianceReport:
 		return s.exportComplianceReportPDF(pdf, data.(*ComplianceData))
+	case RootCauses:
+		return s.exportRootCausesPDF(pdf, data.(*RootCauseData))
 	default:
 		return nil, errors.New("unsupported export type")
 	}
@@ -2,23 +2,273 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"regexp"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
 	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleExists        = errors.New("role already exists")
+	ErrInvalidRoleName   = errors.New("role names may only contain lowercase letters, digits and underscores")
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrSystemRole        = errors.New("built-in roles cannot be deleted")
+	ErrRoleInUse         = errors.New("role is still assigned to employees")
+	ErrAdminLockout      = errors.New("the admin role must keep the permission to manage roles")
+	ErrEmployeeNotFound  = errors.New("employee not found")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
 type RoleService struct {
-	db *gorm.DB
+	db    *gorm.DB
+	store *middleware.PermissionStore
+}
+
+// NewRoleService creates the role service together with the permission cache
+// it keeps current. cacheTTL bounds how long the cache trusts itself when
+// another server instance changes roles.
+func NewRoleService(db *gorm.DB, cacheTTL time.Duration) *RoleService {
+	s := &RoleService{db: db}
+	s.store = middleware.NewPermissionStore(s.RolePermissions, cacheTTL)
+	return s
+}
+
+// PermissionStore returns the cache of role permissions backed by this service
+func (s *RoleService) PermissionStore() *middleware.PermissionStore {
+	return s.store
+}
+
+// RolePermissions loads the permissions of every role. It is the source of
+// the cached middleware.PermissionStore.
+func (s *RoleService) RolePermissions(ctx context.Context) (map[string][]string, error) {
+	var roles []models.Role
+	if err := s.db.WithContext(ctx).Preload("Permissions").Find(&roles).Error; err != nil {
+		return nil, fmt.Errorf("failed to load roles: %w", err)
+	}
+
+	permissions := make(map[string][]string, len(roles))
+	for _, role := range roles {
+		names := make([]string, 0, len(role.Permissions))
+		for _, permission := range role.Permissions {
+			names = append(names, permission.Name)
+		}
+		permissions[role.Name] = names
+	}
+	return permissions, nil
+}
+
+// ListRoles returns every role with its permissions
+func (s *RoleService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	err := s.db.WithContext(ctx).
+		Preload("Permissions", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		Order("name").
+		Find(&roles).Error
+	return roles, err
+}
+
+// GetRole returns a role by name
+func (s *RoleService) GetRole(ctx context.Context, name string) (*models.Role, error) {
+	return s.findRole(s.db.WithContext(ctx), name)
+}
+
+// ListPermissions returns the permissions that can be granted to roles
+func (s *RoleService) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := s.db.WithContext(ctx).Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+// CreateRole adds a custom role
+func (s *RoleService) CreateRole(ctx context.Context, request schema.CreateRoleRequest) (*models.Role, error) {
+	if !roleNamePattern.MatchString(request.Name) {
+		return nil, ErrInvalidRoleName
+	}
+
+	var role models.Role
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Role{}).Where("name = ?", request.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrRoleExists
+		}
+
+		permissions, err := s.findPermissions(tx, request.Permissions)
+		if err != nil {
+			return err
+		}
+
+		role = models.Role{
+			Name:        request.Name,
+			Description: request.Description,
+			Permissions: permissions,
+		}
+		return tx.Create(&role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	return &role, nil
 }
 
-func NewRoleService(db *gorm.DB) *RoleService {
-	return &RoleService{db: db}
+// UpdateRole replaces the description and permissions of a role
+func (s *RoleService) UpdateRole(ctx context.Context, name string, request schema.UpdateRoleRequest) (*models.Role, error) {
+	var role *models.Role
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		role, err = s.findRole(tx, name)
+		if err != nil {
+			return err
+		}
+
+		permissions, err := s.findPermissions(tx, request.Permissions)
+		if err != nil {
+			return err
+		}
+		if role.Name == middleware.RoleAdmin && !containsPermission(permissions, middleware.PermissionManageRoles) {
+			return ErrAdminLockout
+		}
+
+		if err := tx.Model(role).Update("description", request.Description).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(role).Association("Permissions").Replace(permissions); err != nil {
+			return err
+		}
+		role.Permissions = permissions
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate()
+	return role, nil
+}
+
+// DeleteRole removes a custom role that no employee holds
+func (s *RoleService) DeleteRole(ctx context.Context, name string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := s.findRole(tx, name)
+		if err != nil {
+			return err
+		}
+		if role.IsSystem {
+			return ErrSystemRole
+		}
+
+		var holders int64
+		if err := tx.Model(&models.Employee{}).Where("role = ?", role.Name).Count(&holders).Error; err != nil {
+			return err
+		}
+		if holders > 0 {
+			return ErrRoleInUse
+		}
+
+		if err := tx.Model(role).Association("Permissions").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidate()
+	return nil
 }
 
 // AssignRole assigns a role to an employee
 func (s *RoleService) AssignRole(ctx context.Context, employeeID uuid.UUID, role string) error {
 	log.Printf("Assigning role %s to employee with ID: %s", role, employeeID)
-	return s.db.WithContext(ctx).Model(&models.Employee{}).Where("id = ?", employeeID).Update("role", role).Error
+
+	db := s.db.WithContext(ctx)
+	if _, err := s.findRole(db, role); err != nil {
+		return err
+	}
+
+	result := db.Model(&models.Employee{}).Where("id = ?", employeeID).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEmployeeNotFound
+	}
+	return nil
+}
+
+func (s *RoleService) findRole(db *gorm.DB, name string) (*models.Role, error) {
+	var role models.Role
+	err := db.Preload("Permissions", func(db *gorm.DB) *gorm.DB { return db.Order("name") }).
+		Where("name = ?", name).
+		First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (s *RoleService) findPermissions(db *gorm.DB, names []string) ([]models.Permission, error) {
+	if len(names) == 0 {
+		return []models.Permission{}, nil
+	}
+
+	var permissions []models.Permission
+	if err := db.Where("name IN ?", names).Order("name").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if !containsPermission(permissions, name) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, name)
+		}
+	}
+	return permissions, nil
+}
+
+func (s *RoleService) invalidate() {
+	s.store.Invalidate()
+}
+
+func containsPermission(permissions []models.Permission, name string) bool {
+	for _, permission := range permissions {
+		if permission.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailsWithPermission returns the email addresses of the active employees
+// whose role grants permission, for alerts meant for whoever may act on them
+func EmailsWithPermission(db *gorm.DB, permission string) ([]string, error) {
+	var emails []string
+	err := db.Table("users").
+		Joins("JOIN employees ON employees.user_id = users.id").
+		Joins("JOIN roles ON roles.name = employees.role").
+		Joins("JOIN role_permissions ON role_permissions.role_id = roles.id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("permissions.name = ? AND employees.is_active = ? AND users.email <> ''", permission, true).
+		Distinct().
+		Pluck("users.email", &emails).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query employees with permission %s: %w", permission, err)
+	}
+	return emails, nil
 }
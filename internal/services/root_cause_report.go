@@ -0,0 +1,189 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/xuri/excelize/v2"
+)
+
+// RootCauseCategoryCount counts the causes of one fishbone category found
+// by the investigations of incidents in the report period
+type RootCauseCategoryCount struct {
+	Category   string `json:"category" gorm:"column:category"`
+	Causes     int    `json:"causes" gorm:"column:causes"`
+	RootCauses int    `json:"rootCauses" gorm:"column:root_causes"`
+	Incidents  int    `json:"incidents" gorm:"column:incidents"`
+}
+
+// RecurringRootCause is a root cause found in an incident of the period
+type RecurringRootCause struct {
+	ReferenceNumber string `json:"referenceNumber" gorm:"column:reference_number"`
+	Category        string `json:"category" gorm:"column:category"`
+	Description     string `json:"description" gorm:"column:description"`
+}
+
+type RootCauseData struct {
+	Period     string                   `json:"period"`
+	Categories []RootCauseCategoryCount `json:"categories"`
+	RootCauses []RecurringRootCause     `json:"rootCauses"`
+}
+
+// rootCauseFilter joins causes to their incidents and applies the period,
+// department and location of the request
+const rootCauseFilter = `
+        FROM root_causes rc
+        JOIN investigations inv ON inv.id = rc.investigation_id
+        JOIN incidents i ON i.id = inv.incident_id
+        WHERE rc.category <> '' AND i.occurred_at BETWEEN ? AND ?`
+
+func (s *ReportService) rootCauseArgs(req ReportRequest) (string, []interface{}) {
+	query := rootCauseFilter
+	args := []interface{}{req.StartDate, req.EndDate}
+	if req.Department != "" {
+		query += ` AND i.reported_by IN (SELECT id FROM employees WHERE department = ?)`
+		args = append(args, req.Department)
+	}
+	if req.Location != "" {
+		query += ` AND i.location = ?`
+		args = append(args, req.Location)
+	}
+	return query, args
+}
+
+// generateRootCauseReport counts the causes found by investigations in
+// each fishbone category, across the incidents of the period. Answers of
+// 5-Whys chains count when they were given a category.
+func (s *ReportService) generateRootCauseReport(req ReportRequest) (*RootCauseData, error) {
+	data := &RootCauseData{
+		Period: fmt.Sprintf("%s to %s", req.StartDate.Format("2006-01-02"), req.EndDate.Format("2006-01-02")),
+	}
+	filter, args := s.rootCauseArgs(req)
+
+	var counts []RootCauseCategoryCount
+	err := s.db.Raw(`
+        SELECT
+            rc.category,
+            COUNT(*) AS causes,
+            COUNT(*) FILTER (WHERE rc.is_root_cause) AS root_causes,
+            COUNT(DISTINCT i.id) AS incidents`+filter+`
+        GROUP BY rc.category
+    `, args...).Scan(&counts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count root causes: %w", err)
+	}
+
+	// Every category is listed, in diagram order, so reports line up
+	byCategory := make(map[string]RootCauseCategoryCount, len(counts))
+	for _, count := range counts {
+		byCategory[count.Category] = count
+	}
+	for _, category := range models.CauseCategories {
+		count := byCategory[category]
+		count.Category = category
+		data.Categories = append(data.Categories, count)
+	}
+
+	err = s.db.Raw(`
+        SELECT i.reference_number, rc.category, rc.description`+filter+` AND rc.is_root_cause
+        ORDER BY i.occurred_at DESC, rc.category
+        LIMIT 100
+    `, args...).Scan(&data.RootCauses).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list root causes: %w", err)
+	}
+
+	return data, nil
+}
+
+func (s *ReportService) exportRootCauses(f *excelize.File, data *RootCauseData) (*excelize.File, error) {
+	f.SetSheetName("Sheet1", "Cause Categories")
+	headers := []string{"Category", "Causes", "Root Causes", "Incidents"}
+	for i, header := range headers {
+		cell := fmt.Sprintf("%s1", string(rune('A'+i)))
+		f.SetCellValue("Cause Categories", cell, header)
+	}
+	for i, count := range data.Categories {
+		row := i + 2
+		f.SetCellValue("Cause Categories", fmt.Sprintf("A%d", row), humanize(count.Category))
+		f.SetCellValue("Cause Categories", fmt.Sprintf("B%d", row), count.Causes)
+		f.SetCellValue("Cause Categories", fmt.Sprintf("C%d", row), count.RootCauses)
+		f.SetCellValue("Cause Categories", fmt.Sprintf("D%d", row), count.Incidents)
+	}
+
+	f.NewSheet("Root Causes")
+	causeHeaders := []string{"Incident", "Category", "Root Cause"}
+	for i, header := range causeHeaders {
+		cell := fmt.Sprintf("%s1", string(rune('A'+i)))
+		f.SetCellValue("Root Causes", cell, header)
+	}
+	for i, cause := range data.RootCauses {
+		row := i + 2
+		f.SetCellValue("Root Causes", fmt.Sprintf("A%d", row), cause.ReferenceNumber)
+		f.SetCellValue("Root Causes", fmt.Sprintf("B%d", row), humanize(cause.Category))
+		f.SetCellValue("Root Causes", fmt.Sprintf("C%d", row), cause.Description)
+	}
+	f.SetColWidth("Root Causes", "A", "A", 22)
+	f.SetColWidth("Root Causes", "C", "C", 80)
+
+	return f, nil
+}
+
+func (s *ReportService) exportRootCausesPDF(pdf *fpdf.Fpdf, data *RootCauseData) (*bytes.Buffer, error) {
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.SetTextColor(0, 0, 0)
+	pdf.CellFormat(0, 10, "Root Cause Report", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(0, 7, data.Period, "", 1, "C", false, 0, "")
+	pdf.Ln(8)
+
+	s.addSectionTitlePDF(pdf, "Causes by Category")
+	headers := []string{"Category", "Causes", "Root Causes", "Incidents"}
+	colWidths := []float64{70, 33, 34, 33}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(204, 0, 0)
+	pdf.SetTextColor(255, 255, 255)
+	for i, header := range headers {
+		pdf.CellFormat(colWidths[i], 8, header, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.SetTextColor(0, 0, 0)
+	for i, count := range data.Categories {
+		if i%2 == 1 {
+			pdf.SetFillColor(255, 238, 238) // Light Pink
+		} else {
+			pdf.SetFillColor(255, 255, 255)
+		}
+		pdf.CellFormat(colWidths[0], 8, humanize(count.Category), "1", 0, "L", true, 0, "")
+		pdf.CellFormat(colWidths[1], 8, fmt.Sprintf("%d", count.Causes), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(colWidths[2], 8, fmt.Sprintf("%d", count.RootCauses), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(colWidths[3], 8, fmt.Sprintf("%d", count.Incidents), "1", 1, "C", true, 0, "")
+	}
+	pdf.SetFillColor(255, 255, 255)
+	pdf.Ln(8)
+
+	if len(data.RootCauses) > 0 {
+		s.addSectionTitlePDF(pdf, "Root Causes Found")
+		for _, cause := range data.RootCauses {
+			pdf.SetFont("Helvetica", "B", 10)
+			pdf.CellFormat(0, 6, tr(fmt.Sprintf("%s - %s", cause.ReferenceNumber, humanize(cause.Category))), "", 1, "L", false, 0, "")
+			pdf.SetFont("Helvetica", "", 10)
+			pdf.MultiCell(0, 5, tr(cause.Description), "", "L", false)
+			pdf.Ln(2)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to generate PDF for Root Causes: %w", err)
+	}
+	return &buf, nil
+}
@@ -0,0 +1,354 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Groups are roles. The group ID and display name are the role name, and the
+// members are the users whose employee record holds the role. An employee
+// has exactly one role, so joining a group leaves the previous one, and
+// leaving a group falls back to the default role.
+
+// ListGroups returns the groups matching a filter on displayName or id
+func (s *Service) ListGroups(ctx context.Context, filter string, page Page, withMembers bool) ([]schema.SCIMGroup, int64, error) {
+	attribute, value, err := parseFilter(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.Role{})
+	switch attribute {
+	case "":
+	case "displayname", "id":
+		query = query.Where("name = ?", value)
+	default:
+		return nil, 0, fmt.Errorf("%w: cannot filter groups by %s", ErrInvalidFilter, attribute)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page = page.normalize()
+	resources := []schema.SCIMGroup{}
+	if page.Count == 0 {
+		return resources, total, nil
+	}
+
+	var roles []models.Role
+	if err := query.Order("name").
+		Offset(page.StartIndex - 1).
+		Limit(page.Count).
+		Find(&roles).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for _, role := range roles {
+		group, err := s.toGroupResource(s.db.WithContext(ctx), role, withMembers)
+		if err != nil {
+			return nil, 0, err
+		}
+		resources = append(resources, group)
+	}
+	return resources, total, nil
+}
+
+// GetGroup returns a group by ID
+func (s *Service) GetGroup(ctx context.Context, id string, withMembers bool) (*schema.SCIMGroup, error) {
+	db := s.db.WithContext(ctx)
+	role, err := s.findRole(db, id)
+	if err != nil {
+		return nil, err
+	}
+	group, err := s.toGroupResource(db, *role, withMembers)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// CreateGroup adds a custom role without permissions. An administrator
+// grants its permissions afterwards.
+func (s *Service) CreateGroup(ctx context.Context, resource schema.SCIMGroup) (*schema.SCIMGroup, error) {
+	_, err := s.roles.CreateRole(ctx, schema.CreateRoleRequest{
+		Name:        resource.DisplayName,
+		Description: "Provisioned by the HR directory",
+	})
+	switch {
+	case errors.Is(err, services.ErrRoleExists):
+		return nil, fmt.Errorf("%w: group %s", ErrUniqueness, resource.DisplayName)
+	case errors.Is(err, services.ErrInvalidRoleName):
+		return nil, fmt.Errorf("%w: %s", ErrInvalidValue, err.Error())
+	case err != nil:
+		return nil, err
+	}
+
+	if len(resource.Members) > 0 {
+		if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return s.addMembers(tx, resource.DisplayName, memberIDs(resource.Members))
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	utils.LogInfo("Created group over SCIM", map[string]interface{}{
+		"role": resource.DisplayName,
+	})
+	return s.GetGroup(ctx, resource.DisplayName, true)
+}
+
+// ReplaceGroup sets the members of a group to exactly those listed
+func (s *Service) ReplaceGroup(ctx context.Context, id string, resource schema.SCIMGroup) (*schema.SCIMGroup, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := s.findRole(tx, id)
+		if err != nil {
+			return err
+		}
+		if resource.DisplayName != "" && resource.DisplayName != role.Name {
+			return fmt.Errorf("%w: groups cannot be renamed", ErrMutability)
+		}
+		if err := s.removeAllMembers(tx, role.Name); err != nil {
+			return err
+		}
+		return s.addMembers(tx, role.Name, memberIDs(resource.Members))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetGroup(ctx, id, true)
+}
+
+// PatchGroup adds, removes or replaces group members
+func (s *Service) PatchGroup(ctx context.Context, id string, request schema.SCIMPatchRequest) error {
+	if len(request.Operations) == 0 {
+		return fmt.Errorf("%w: no operations", ErrInvalidSyntax)
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := s.findRole(tx, id)
+		if err != nil {
+			return err
+		}
+
+		for _, operation := range request.Operations {
+			op := strings.ToLower(operation.Op)
+			path := normalizePath(operation.Path)
+
+			// Without a path the value is an object of attributes
+			value := operation.Value
+			if path == "" {
+				object, ok := operation.Value.(map[string]interface{})
+				if !ok {
+					return fmt.Errorf("%w: %s without a path needs an object value", ErrInvalidSyntax, op)
+				}
+				if name, ok := object["displayName"].(string); ok && name != role.Name {
+					return fmt.Errorf("%w: groups cannot be renamed", ErrMutability)
+				}
+				members, ok := object["members"]
+				if !ok {
+					continue
+				}
+				path, value = "members", members
+			}
+
+			switch path {
+			case "displayname":
+				if name, _ := value.(string); name != role.Name {
+					return fmt.Errorf("%w: groups cannot be renamed", ErrMutability)
+				}
+			case "members", "members.value":
+				if err := s.patchMembers(tx, role.Name, op, operation.Path, value); err != nil {
+					return err
+				}
+			case "externalid":
+				// Roles have no external ID; the directory matches them by name
+			default:
+				return fmt.Errorf("%w: unknown attribute %s", ErrInvalidSyntax, operation.Path)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteGroup removes a custom role that no employee holds
+func (s *Service) DeleteGroup(ctx context.Context, id string) error {
+	err := s.roles.DeleteRole(ctx, id)
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		return ErrNotFound
+	case errors.Is(err, services.ErrSystemRole):
+		return fmt.Errorf("%w: built-in roles cannot be deleted", ErrMutability)
+	case errors.Is(err, services.ErrRoleInUse):
+		return fmt.Errorf("%w: the group still has members", ErrMutability)
+	}
+	return err
+}
+
+func (s *Service) patchMembers(tx *gorm.DB, role, op, rawPath string, value interface{}) error {
+	ids := patchMemberIDs(value)
+	switch op {
+	case "add":
+		return s.addMembers(tx, role, ids)
+	case "replace":
+		if err := s.removeAllMembers(tx, role); err != nil {
+			return err
+		}
+		return s.addMembers(tx, role, ids)
+	case "remove":
+		// members[value eq "id"] names the member in the path
+		if id, ok := valueFilter(rawPath); ok {
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			return s.removeAllMembers(tx, role)
+		}
+		return s.removeMembers(tx, role, ids)
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidSyntax, op)
+	}
+}
+
+func (s *Service) addMembers(tx *gorm.DB, role string, ids []string) error {
+	userIDs, err := parseMemberIDs(ids)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&models.Employee{}).Where("user_id IN ?", userIDs).Count(&count).Error; err != nil {
+		return err
+	}
+	if count != int64(len(userIDs)) {
+		return fmt.Errorf("%w: some members are not provisioned users", ErrInvalidValue)
+	}
+
+	return tx.Model(&models.Employee{}).
+		Where("user_id IN ? AND role <> ?", userIDs, role).
+		Update("role", role).Error
+}
+
+func (s *Service) removeMembers(tx *gorm.DB, role string, ids []string) error {
+	userIDs, err := parseMemberIDs(ids)
+	if err != nil {
+		return err
+	}
+	if len(userIDs) == 0 || role == s.cfg.DefaultRole {
+		return nil
+	}
+	return tx.Model(&models.Employee{}).
+		Where("user_id IN ? AND role = ?", userIDs, role).
+		Update("role", s.cfg.DefaultRole).Error
+}
+
+func (s *Service) removeAllMembers(tx *gorm.DB, role string) error {
+	if role == s.cfg.DefaultRole {
+		return nil
+	}
+	return tx.Model(&models.Employee{}).
+		Where("role = ?", role).
+		Update("role", s.cfg.DefaultRole).Error
+}
+
+func (s *Service) findRole(db *gorm.DB, name string) (*models.Role, error) {
+	var roles []models.Role
+	if err := db.Where("name = ?", name).Limit(1).Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return nil, ErrNotFound
+	}
+	return &roles[0], nil
+}
+
+func (s *Service) toGroupResource(db *gorm.DB, role models.Role, withMembers bool) (schema.SCIMGroup, error) {
+	created, modified := role.CreatedAt, role.UpdatedAt
+	group := schema.SCIMGroup{
+		Schemas:     []string{schema.SCIMGroupSchema},
+		ID:          role.Name,
+		DisplayName: role.Name,
+		Meta: &schema.SCIMMeta{
+			ResourceType: "Group",
+			Created:      &created,
+			LastModified: &modified,
+			Location:     s.location("Groups", role.Name),
+		},
+	}
+	if !withMembers {
+		return group, nil
+	}
+
+	var employees []models.Employee
+	if err := db.Joins("JOIN users ON users.id = employees.user_id AND users.deleted_at IS NULL").
+		Where("employees.role = ?", role.Name).
+		Order("employees.last_name, employees.first_name").
+		Find(&employees).Error; err != nil {
+		return group, err
+	}
+
+	group.Members = make([]schema.SCIMMultiValue, 0, len(employees))
+	for i := range employees {
+		id := employees[i].UserID.String()
+		group.Members = append(group.Members, schema.SCIMMultiValue{
+			Value:   id,
+			Display: displayName(&employees[i]),
+			Ref:     s.location("Users", id),
+		})
+	}
+	return group, nil
+}
+
+func memberIDs(members []schema.SCIMMultiValue) []string {
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.Value)
+	}
+	return ids
+}
+
+// patchMemberIDs reads the member list of a PATCH value
+func patchMemberIDs(value interface{}) []string {
+	var ids []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, entry := range v {
+			ids = append(ids, patchMemberIDs(entry)...)
+		}
+	case map[string]interface{}:
+		if id, ok := v["value"].(string); ok {
+			ids = append(ids, id)
+		}
+	case string:
+		ids = append(ids, v)
+	}
+	return ids
+}
+
+func parseMemberIDs(ids []string) ([]uuid.UUID, error) {
+	userIDs := make([]uuid.UUID, 0, len(ids))
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		userID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: member %s is not a user ID", ErrInvalidValue, id)
+		}
+		if !seen[userID] {
+			seen[userID] = true
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,178 @@
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hopkali04/health-sys/internal/schema"
+)
+
+// changesFromPatch turns the operations of a user PATCH request into changes.
+// Attributes this service does not store are ignored so that directories
+// sending their full attribute mapping keep working.
+func changesFromPatch(request schema.SCIMPatchRequest) (userChanges, error) {
+	var changes userChanges
+	if len(request.Operations) == 0 {
+		return changes, fmt.Errorf("%w: no operations", ErrInvalidSyntax)
+	}
+
+	for _, operation := range request.Operations {
+		op := strings.ToLower(operation.Op)
+		switch op {
+		case "add", "replace":
+			if operation.Path == "" {
+				// Without a path the value is an object of attributes
+				values, ok := operation.Value.(map[string]interface{})
+				if !ok {
+					return changes, fmt.Errorf("%w: %s without a path needs an object value", ErrInvalidSyntax, op)
+				}
+				for path, value := range values {
+					if err := setUserAttribute(&changes, normalizePath(path), value); err != nil {
+						return changes, err
+					}
+				}
+				continue
+			}
+			if err := setUserAttribute(&changes, normalizePath(operation.Path), operation.Value); err != nil {
+				return changes, err
+			}
+		case "remove":
+			if operation.Path == "" {
+				return changes, fmt.Errorf("%w: remove needs a path", ErrInvalidSyntax)
+			}
+			if err := setUserAttribute(&changes, normalizePath(operation.Path), nil); err != nil {
+				return changes, err
+			}
+		default:
+			return changes, fmt.Errorf("%w: unknown operation %q", ErrInvalidSyntax, operation.Op)
+		}
+	}
+	return changes, nil
+}
+
+// setUserAttribute records one attribute of a PATCH request. A nil value
+// removes the attribute.
+func setUserAttribute(changes *userChanges, path string, value interface{}) error {
+	switch path {
+	case "username":
+		changes.UserName = textValue(value)
+	case "externalid":
+		changes.ExternalID = textValue(value)
+	case "active":
+		active, err := boolValue(value)
+		if err != nil {
+			return err
+		}
+		changes.Active = &active
+	case "title":
+		changes.Title = textValue(value)
+	case "name":
+		if name, ok := value.(map[string]interface{}); ok {
+			for key, part := range name {
+				if err := setUserAttribute(changes, "name."+strings.ToLower(key), part); err != nil {
+					return err
+				}
+			}
+		}
+	case "name.givenname":
+		changes.GivenName = textValue(value)
+	case "name.familyname":
+		changes.FamilyName = textValue(value)
+	case "emails", "emails.value":
+		if email := multiValue(value, "value"); email != nil && *email != "" {
+			changes.Email = email
+		}
+	case "phonenumbers", "phonenumbers.value":
+		changes.Phone = multiValue(value, "value")
+	case "addresses", "addresses.locality":
+		changes.Office = multiValue(value, "locality")
+	case "addresses.formatted":
+		if changes.Office == nil {
+			changes.Office = multiValue(value, "formatted")
+		}
+	case "enterprise.department":
+		changes.Department = textValue(value)
+	case "enterprise.employeenumber":
+		changes.EmployeeNumber = textValue(value)
+	case "enterprise.manager", "enterprise.manager.value":
+		changes.ManagerID = multiValue(value, "value")
+	case "enterprise":
+		if extension, ok := value.(map[string]interface{}); ok {
+			for key, part := range extension {
+				if err := setUserAttribute(changes, "enterprise."+strings.ToLower(key), part); err != nil {
+					return err
+				}
+			}
+		}
+	case "groups":
+		return fmt.Errorf("%w: groups are managed through the Groups endpoint", ErrMutability)
+	case "id", "meta":
+		return fmt.Errorf("%w: %s is read-only", ErrMutability, path)
+	}
+	return nil
+}
+
+// textValue reads a string attribute; nil clears it
+func textValue(value interface{}) *string {
+	text := ""
+	switch v := value.(type) {
+	case nil:
+	case string:
+		text = v
+	default:
+		text = fmt.Sprint(v)
+	}
+	return &text
+}
+
+// boolValue reads a boolean that some directories send as a string
+func boolValue(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("%w: %q is not a boolean", ErrInvalidValue, v)
+		}
+		return parsed, nil
+	default:
+		return false, fmt.Errorf("%w: %v is not a boolean", ErrInvalidValue, v)
+	}
+}
+
+// multiValue reads a multi-valued attribute, which may arrive as a plain
+// value, a single object or a list of objects. The primary entry wins.
+func multiValue(value interface{}, key string) *string {
+	switch v := value.(type) {
+	case nil:
+		return textValue(nil)
+	case string:
+		return &v
+	case map[string]interface{}:
+		return textValue(v[key])
+	case []interface{}:
+		var first *string
+		for _, entry := range v {
+			object, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if primary, _ := object["primary"].(bool); primary {
+				return textValue(object[key])
+			}
+			if first == nil {
+				first = textValue(object[key])
+			}
+		}
+		if first != nil {
+			return first
+		}
+		return textValue(nil)
+	default:
+		return textValue(v)
+	}
+}
@@ -0,0 +1,163 @@
+// Package scim implements the SCIM 2.0 provisioning protocol (RFC 7644) on
+// top of users, employees and roles, so the HR directory can create, update
+// and deactivate accounts.
+package scim
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/services/user"
+	"gorm.io/gorm"
+)
+
+// The errors map onto the SCIM error types of RFC 7644 section 3.12
+var (
+	ErrNotFound      = errors.New("resource not found")
+	ErrUniqueness    = errors.New("resource already exists")
+	ErrInvalidFilter = errors.New("unsupported filter")
+	ErrInvalidValue  = errors.New("invalid value")
+	ErrInvalidSyntax = errors.New("invalid request")
+	ErrMutability    = errors.New("attribute cannot be changed")
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 200
+)
+
+// filterPattern matches the only filter form directories use for lookups:
+// attribute eq "value"
+var filterPattern = regexp.MustCompile(`(?i)^\s*([a-z][\w.:\[\]" ]*?)\s+eq\s+"((?:[^"\\]|\\.)*)"\s*$`)
+
+// Service provisions users and groups for the HR directory
+type Service struct {
+	db       *gorm.DB
+	roles    *services.RoleService
+	sessions *user.SessionService
+	cfg      config.SCIM
+}
+
+func NewService(db *gorm.DB, roles *services.RoleService, sessions *user.SessionService, cfg config.SCIM) *Service {
+	return &Service{
+		db:       db,
+		roles:    roles,
+		sessions: sessions,
+		cfg:      cfg,
+	}
+}
+
+// VerifyToken reports whether a bearer token is one of the configured SCIM tokens
+func (s *Service) VerifyToken(token string) bool {
+	valid := false
+	for _, configured := range s.cfg.Tokens {
+		if configured != "" && subtle.ConstantTimeCompare([]byte(configured), []byte(token)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
+
+// Page selects a window of query results. StartIndex is 1-based; a Count
+// of zero asks only for the total.
+type Page struct {
+	StartIndex int
+	Count      int
+}
+
+// DefaultPage is the page returned when the directory does not ask for one
+func DefaultPage() Page {
+	return Page{StartIndex: 1, Count: defaultPageSize}
+}
+
+func (p Page) normalize() Page {
+	if p.StartIndex < 1 {
+		p.StartIndex = 1
+	}
+	if p.Count < 0 {
+		p.Count = 0
+	}
+	if p.Count > maxPageSize {
+		p.Count = maxPageSize
+	}
+	return p
+}
+
+// parseFilter splits an `attribute eq "value"` filter. An empty filter
+// returns an empty attribute.
+func parseFilter(filter string) (string, string, error) {
+	if strings.TrimSpace(filter) == "" {
+		return "", "", nil
+	}
+	match := filterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return "", "", fmt.Errorf("%w: only `attribute eq \"value\"` is supported", ErrInvalidFilter)
+	}
+	value := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(match[2])
+	return normalizePath(match[1]), value, nil
+}
+
+// normalizePath lowercases an attribute path, drops schema URN prefixes and
+// value filters, so `emails[type eq "work"].value` becomes `emails.value`
+// and the enterprise department becomes `enterprise.department`
+func normalizePath(path string) string {
+	path = strings.ToLower(strings.TrimSpace(path))
+	path = strings.TrimPrefix(path, strings.ToLower(schema.SCIMUserSchema)+":")
+	path = strings.TrimPrefix(path, strings.ToLower(schema.SCIMGroupSchema)+":")
+	if strings.HasPrefix(path, strings.ToLower(schema.SCIMEnterpriseUserSchema)) {
+		path = "enterprise." + strings.TrimPrefix(strings.TrimPrefix(path, strings.ToLower(schema.SCIMEnterpriseUserSchema)), ":")
+	}
+	for {
+		start := strings.Index(path, "[")
+		end := strings.Index(path, "]")
+		if start < 0 || end < start {
+			break
+		}
+		path = path[:start] + path[end+1:]
+	}
+	return strings.TrimSuffix(path, ".")
+}
+
+// valueFilter returns the value of a `members[value eq "id"]` style path
+func valueFilter(path string) (string, bool) {
+	start := strings.Index(path, "[")
+	end := strings.LastIndex(path, "]")
+	if start < 0 || end < start {
+		return "", false
+	}
+	attribute, value, err := parseFilter(path[start+1 : end])
+	if err != nil || attribute != "value" {
+		return "", false
+	}
+	return value, true
+}
+
+func parseID(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrNotFound
+	}
+	return parsed, nil
+}
+
+func (s *Service) location(resource, id string) string {
+	if s.cfg.BaseURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(s.cfg.BaseURL, "/") + "/" + resource + "/" + id
+}
+
+func displayName(employee *models.Employee) string {
+	if employee == nil {
+		return ""
+	}
+	return strings.TrimSpace(employee.FirstName + " " + employee.LastName)
+}
@@ -0,0 +1,590 @@
+package scim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// account is a user together with its employee record, the pair a SCIM
+// user resource maps onto
+type account struct {
+	User     models.User
+	Employee *models.Employee
+	// ManagerUserID is the user ID of the employee's reporting manager
+	ManagerUserID *uuid.UUID
+}
+
+// userChanges are the attributes a request sets. Nil fields are left alone;
+// empty strings clear optional attributes.
+type userChanges struct {
+	UserName       *string
+	Email          *string
+	ExternalID     *string
+	GivenName      *string
+	FamilyName     *string
+	Title          *string
+	Department     *string
+	EmployeeNumber *string
+	Phone          *string
+	Office         *string
+	ManagerID      *string
+	Active         *bool
+}
+
+// ListUsers returns the users matching a filter on userName, externalId,
+// emails or id
+func (s *Service) ListUsers(ctx context.Context, filter string, page Page) ([]schema.SCIMUser, int64, error) {
+	attribute, value, err := parseFilter(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.User{}).Where("deleted_at IS NULL")
+	switch attribute {
+	case "":
+	case "username", "emails", "emails.value":
+		query = query.Where("LOWER(email) = ?", strings.ToLower(value))
+	case "externalid":
+		query = query.Where("external_id = ?", value)
+	case "id":
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return []schema.SCIMUser{}, 0, nil
+		}
+		query = query.Where("id = ?", id)
+	default:
+		return nil, 0, fmt.Errorf("%w: cannot filter users by %s", ErrInvalidFilter, attribute)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	page = page.normalize()
+	resources := []schema.SCIMUser{}
+	if page.Count == 0 {
+		return resources, total, nil
+	}
+
+	var users []models.User
+	if err := query.Order("created_at, id").
+		Offset(page.StartIndex - 1).
+		Limit(page.Count).
+		Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	accounts, err := s.loadAccounts(s.db.WithContext(ctx), users)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range accounts {
+		resources = append(resources, s.toUserResource(&accounts[i]))
+	}
+	return resources, total, nil
+}
+
+// GetUser returns a user by ID
+func (s *Service) GetUser(ctx context.Context, id string) (*schema.SCIMUser, error) {
+	acc, err := s.findAccount(s.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	resource := s.toUserResource(acc)
+	return &resource, nil
+}
+
+// CreateUser provisions a user and its employee record
+func (s *Service) CreateUser(ctx context.Context, resource schema.SCIMUser) (*schema.SCIMUser, error) {
+	changes := changesFromResource(resource)
+	if changes.UserName == nil || *changes.UserName == "" {
+		return nil, fmt.Errorf("%w: userName is required", ErrInvalidValue)
+	}
+
+	acc := &account{
+		User: models.User{
+			ID:         uuid.New(),
+			IsActive:   true,
+			IsVerified: true,
+		},
+		Employee: &models.Employee{
+			ID:             uuid.New(),
+			EmployeeNumber: fmt.Sprintf("EMP%s", uuid.New().String()[:8]),
+			Department:     s.cfg.DefaultDepartment,
+			Position:       s.cfg.DefaultPosition,
+			Role:           s.cfg.DefaultRole,
+			StartDate:      time.Now(),
+			IsActive:       true,
+		},
+	}
+	acc.Employee.UserID = acc.User.ID
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.applyChanges(tx, acc, changes); err != nil {
+			return err
+		}
+		if acc.Employee.FirstName == "" {
+			acc.Employee.FirstName = strings.Split(acc.User.Email, "@")[0]
+		}
+		if acc.Employee.LastName == "" {
+			acc.Employee.LastName = "-"
+		}
+
+		if err := tx.Create(&acc.User).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if err := tx.Omit(clause.Associations).Create(acc.Employee).Error; err != nil {
+			return fmt.Errorf("failed to create employee: %w", err)
+		}
+
+		// Create skips false booleans in favour of the column default
+		if !acc.User.IsActive {
+			if err := tx.Model(&acc.User).Update("is_active", false).Error; err != nil {
+				return err
+			}
+			return tx.Model(acc.Employee).Update("is_active", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LogInfo("Provisioned user over SCIM", map[string]interface{}{
+		"userID": acc.User.ID,
+	})
+	return s.GetUser(ctx, acc.User.ID.String())
+}
+
+// ReplaceUser sets every attribute of a user to the resource sent by the directory
+func (s *Service) ReplaceUser(ctx context.Context, id string, resource schema.SCIMUser) (*schema.SCIMUser, error) {
+	changes := changesFromResource(resource)
+	if changes.UserName == nil || *changes.UserName == "" {
+		return nil, fmt.Errorf("%w: userName is required", ErrInvalidValue)
+	}
+	// Attributes left out of a replacement are cleared
+	for _, field := range []**string{&changes.ExternalID, &changes.Title, &changes.Phone, &changes.Office, &changes.ManagerID} {
+		if *field == nil {
+			empty := ""
+			*field = &empty
+		}
+	}
+	if changes.Active == nil {
+		active := true
+		changes.Active = &active
+	}
+
+	return s.updateUser(ctx, id, changes)
+}
+
+// PatchUser applies the operations of a PATCH request to a user
+func (s *Service) PatchUser(ctx context.Context, id string, request schema.SCIMPatchRequest) (*schema.SCIMUser, error) {
+	changes, err := changesFromPatch(request)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, id, changes)
+}
+
+// DeleteUser deactivates a user and hides it from the directory. The records
+// stay, since incidents and investigations refer to the employee.
+func (s *Service) DeleteUser(ctx context.Context, id string) error {
+	userID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		acc, err := s.findAccount(tx, id)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		acc.User.IsActive = false
+		acc.User.DeletedAt = &now
+		if err := tx.Save(&acc.User).Error; err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		if acc.Employee != nil {
+			acc.Employee.IsActive = false
+			acc.Employee.EndDate = now
+			if err := tx.Omit(clause.Associations).Save(acc.Employee).Error; err != nil {
+				return fmt.Errorf("failed to deactivate employee: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.revokeSessions(userID)
+	utils.LogInfo("Deleted user over SCIM", map[string]interface{}{
+		"userID": userID,
+	})
+	return nil
+}
+
+func (s *Service) updateUser(ctx context.Context, id string, changes userChanges) (*schema.SCIMUser, error) {
+	var deactivated bool
+	var userID uuid.UUID
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		acc, err := s.findAccount(tx.Clauses(clause.Locking{Strength: "UPDATE"}), id)
+		if err != nil {
+			return err
+		}
+		userID = acc.User.ID
+		wasActive := acc.User.IsActive
+
+		if err := s.applyChanges(tx, acc, changes); err != nil {
+			return err
+		}
+		deactivated = wasActive && !acc.User.IsActive
+
+		if err := tx.Save(&acc.User).Error; err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		if acc.Employee != nil {
+			if err := tx.Omit(clause.Associations).Save(acc.Employee).Error; err != nil {
+				return fmt.Errorf("failed to update employee: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deactivated {
+		s.revokeSessions(userID)
+		utils.LogInfo("Deactivated user over SCIM", map[string]interface{}{
+			"userID": userID,
+		})
+	}
+	return s.GetUser(ctx, id)
+}
+
+// applyChanges validates the changes and sets them on the account in memory
+func (s *Service) applyChanges(tx *gorm.DB, acc *account, changes userChanges) error {
+	email := changes.Email
+	if email == nil || *email == "" {
+		email = changes.UserName
+	}
+	if email != nil && *email != "" {
+		address := strings.ToLower(strings.TrimSpace(*email))
+		if !strings.Contains(address, "@") {
+			return fmt.Errorf("%w: userName must be an email address", ErrInvalidValue)
+		}
+		if err := s.ensureUnique(tx, &models.User{}, "LOWER(email) = ?", address, acc.User.ID, "userName"); err != nil {
+			return err
+		}
+		acc.User.Email = address
+	}
+
+	if changes.ExternalID != nil {
+		if *changes.ExternalID == "" {
+			acc.User.ExternalID = nil
+		} else {
+			if err := s.ensureUnique(tx, &models.User{}, "external_id = ?", *changes.ExternalID, acc.User.ID, "externalId"); err != nil {
+				return err
+			}
+			externalID := *changes.ExternalID
+			acc.User.ExternalID = &externalID
+		}
+	}
+
+	if changes.Active != nil {
+		acc.User.IsActive = *changes.Active
+	}
+
+	employee := acc.Employee
+	if employee == nil {
+		// Accounts created before SCIM may lack an employee record; the
+		// remaining attributes have nowhere to go
+		return nil
+	}
+	if changes.Active != nil {
+		employee.IsActive = *changes.Active
+	}
+
+	setRequired(&employee.FirstName, changes.GivenName, 100)
+	setRequired(&employee.LastName, changes.FamilyName, 100)
+	setRequired(&employee.Position, changes.Title, 100)
+	setRequired(&employee.Department, changes.Department, 100)
+	setOptional(&employee.ContactNumber, changes.Phone, 20)
+	setOptional(&employee.OfficeLocation, changes.Office, 100)
+
+	if changes.EmployeeNumber != nil && *changes.EmployeeNumber != "" && *changes.EmployeeNumber != employee.EmployeeNumber {
+		var count int64
+		if err := tx.Model(&models.Employee{}).
+			Where("employee_number = ? AND id <> ?", *changes.EmployeeNumber, employee.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: employeeNumber %s is in use", ErrUniqueness, *changes.EmployeeNumber)
+		}
+		employee.EmployeeNumber = *changes.EmployeeNumber
+	}
+
+	if changes.ManagerID != nil {
+		if err := s.applyManager(tx, acc, *changes.ManagerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyManager points the employee's reporting line at the employee of the
+// manager's user ID
+func (s *Service) applyManager(tx *gorm.DB, acc *account, managerID string) error {
+	if managerID == "" {
+		acc.Employee.ReportingManagerID = nil
+		acc.ManagerUserID = nil
+		return nil
+	}
+
+	managerUserID, err := uuid.Parse(managerID)
+	if err != nil || managerUserID == acc.User.ID {
+		return fmt.Errorf("%w: manager %s is not a valid user", ErrInvalidValue, managerID)
+	}
+
+	var manager models.Employee
+	err = tx.Select("id").Where("user_id = ?", managerUserID).First(&manager).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: manager %s does not exist", ErrInvalidValue, managerID)
+	}
+	if err != nil {
+		return err
+	}
+
+	acc.Employee.ReportingManagerID = &manager.ID
+	acc.ManagerUserID = &managerUserID
+	return nil
+}
+
+func (s *Service) ensureUnique(tx *gorm.DB, model interface{}, condition, value string, self uuid.UUID, attribute string) error {
+	var count int64
+	if err := tx.Model(model).Where(condition, value).Where("id <> ?", self).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s %s is in use", ErrUniqueness, attribute, value)
+	}
+	return nil
+}
+
+func (s *Service) findAccount(db *gorm.DB, id string) (*account, error) {
+	userID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+	if err := db.Where("id = ? AND deleted_at IS NULL", userID).Limit(1).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ErrNotFound
+	}
+
+	accounts, err := s.loadAccounts(db.Session(&gorm.Session{NewDB: true}), users)
+	if err != nil {
+		return nil, err
+	}
+	return &accounts[0], nil
+}
+
+// loadAccounts pairs users with their employee records and managers
+func (s *Service) loadAccounts(db *gorm.DB, users []models.User) ([]account, error) {
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+
+	var employees []models.Employee
+	if len(ids) > 0 {
+		if err := db.Preload("ReportingManager").Where("user_id IN ?", ids).Find(&employees).Error; err != nil {
+			return nil, err
+		}
+	}
+	byUser := make(map[uuid.UUID]*models.Employee, len(employees))
+	for i := range employees {
+		byUser[employees[i].UserID] = &employees[i]
+	}
+
+	accounts := make([]account, 0, len(users))
+	for _, u := range users {
+		acc := account{User: u, Employee: byUser[u.ID]}
+		if acc.Employee != nil && acc.Employee.ReportingManager != nil {
+			managerUserID := acc.Employee.ReportingManager.UserID
+			acc.ManagerUserID = &managerUserID
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, nil
+}
+
+func (s *Service) toUserResource(acc *account) schema.SCIMUser {
+	active := acc.User.IsActive
+	created := acc.User.CreatedAt
+	modified := acc.User.UpdatedAt
+
+	resource := schema.SCIMUser{
+		Schemas:  []string{schema.SCIMUserSchema, schema.SCIMEnterpriseUserSchema},
+		ID:       acc.User.ID.String(),
+		UserName: acc.User.Email,
+		Active:   &active,
+		Emails:   []schema.SCIMMultiValue{{Value: acc.User.Email, Type: "work", Primary: true}},
+		Meta: &schema.SCIMMeta{
+			ResourceType: "User",
+			Created:      &created,
+			LastModified: &modified,
+			Location:     s.location("Users", acc.User.ID.String()),
+		},
+	}
+	if acc.User.ExternalID != nil {
+		resource.ExternalID = *acc.User.ExternalID
+	}
+
+	employee := acc.Employee
+	if employee == nil {
+		return resource
+	}
+	if employee.UpdatedAt.After(modified) {
+		modified = employee.UpdatedAt
+		resource.Meta.LastModified = &modified
+	}
+
+	resource.Name = &schema.SCIMName{
+		Formatted:  displayName(employee),
+		GivenName:  employee.FirstName,
+		FamilyName: employee.LastName,
+	}
+	resource.DisplayName = displayName(employee)
+	resource.Title = employee.Position
+	if employee.ContactNumber != "" {
+		resource.PhoneNumbers = []schema.SCIMMultiValue{{Value: employee.ContactNumber, Type: "work", Primary: true}}
+	}
+	if employee.OfficeLocation != "" {
+		resource.Addresses = []schema.SCIMAddress{{Type: "work", Locality: employee.OfficeLocation, Primary: true}}
+	}
+	resource.Groups = []schema.SCIMMultiValue{{
+		Value:   employee.Role,
+		Display: employee.Role,
+		Ref:     s.location("Groups", employee.Role),
+	}}
+
+	resource.Enterprise = &schema.SCIMEnterpriseUser{
+		EmployeeNumber: employee.EmployeeNumber,
+		Department:     employee.Department,
+	}
+	if acc.ManagerUserID != nil {
+		resource.Enterprise.Manager = &schema.SCIMManager{
+			Value:       acc.ManagerUserID.String(),
+			DisplayName: displayName(employee.ReportingManager),
+			Ref:         s.location("Users", acc.ManagerUserID.String()),
+		}
+	}
+	return resource
+}
+
+// changesFromResource reads the attributes of a full user resource
+func changesFromResource(resource schema.SCIMUser) userChanges {
+	changes := userChanges{
+		UserName:   stringPtr(resource.UserName),
+		ExternalID: stringPtr(resource.ExternalID),
+		Active:     resource.Active,
+	}
+	if resource.Name != nil {
+		changes.GivenName = stringPtr(resource.Name.GivenName)
+		changes.FamilyName = stringPtr(resource.Name.FamilyName)
+	}
+	changes.Title = stringPtr(resource.Title)
+	if email := primaryValue(resource.Emails); email != "" {
+		changes.Email = &email
+	}
+	if phone := primaryValue(resource.PhoneNumbers); phone != "" {
+		changes.Phone = &phone
+	}
+	for _, address := range resource.Addresses {
+		if address.Primary || address.Type == "work" || len(resource.Addresses) == 1 {
+			office := address.Locality
+			if office == "" {
+				office = address.Formatted
+			}
+			changes.Office = &office
+			break
+		}
+	}
+	if resource.Enterprise != nil {
+		changes.Department = stringPtr(resource.Enterprise.Department)
+		changes.EmployeeNumber = stringPtr(resource.Enterprise.EmployeeNumber)
+		if resource.Enterprise.Manager != nil {
+			changes.ManagerID = &resource.Enterprise.Manager.Value
+		}
+	}
+	return changes
+}
+
+func (s *Service) revokeSessions(userID uuid.UUID) {
+	if err := s.sessions.RevokeUserSessions(userID); err != nil {
+		utils.LogError("Failed to revoke sessions of deprovisioned user", map[string]interface{}{
+			"userID": userID,
+			"error":  err.Error(),
+		})
+	}
+}
+
+// setRequired sets a not-null column, ignoring attempts to clear it
+func setRequired(field *string, value *string, size int) {
+	if value != nil && strings.TrimSpace(*value) != "" {
+		*field = truncate(strings.TrimSpace(*value), size)
+	}
+}
+
+func setOptional(field *string, value *string, size int) {
+	if value != nil {
+		*field = truncate(strings.TrimSpace(*value), size)
+	}
+}
+
+func primaryValue(values []schema.SCIMMultiValue) string {
+	for _, value := range values {
+		if value.Primary {
+			return value.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+func stringPtr(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func truncate(value string, size int) string {
+	runes := []rune(value)
+	if len(runes) > size {
+		return string(runes[:size])
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+// Package sequence hands out the reference numbers of incidents, hazards
+// and VPCs from counters in the database. Taking a number locks its counter
+// row until the transaction ends, so concurrent reports never share one.
+package sequence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/config"
+	"gorm.io/gorm"
+)
+
+// Generator formats reference numbers as prefix, site code, year and a
+// zero-padded count, e.g. INC-LLW-2026-00042
+type Generator struct {
+	site     string
+	prefixes map[string]string
+	yearly   bool
+	digits   int
+	now      func() time.Time
+}
+
+func NewGenerator(cfg config.ReferenceNumbers) (*Generator, error) {
+	var yearly bool
+	switch cfg.Reset {
+	case "yearly":
+		yearly = true
+	case "never":
+	default:
+		return nil, fmt.Errorf("unknown reference number reset %q: use yearly or never", cfg.Reset)
+	}
+	if strings.ContainsAny(cfg.Site, "- ") {
+		return nil, fmt.Errorf("site code %q must not contain dashes or spaces", cfg.Site)
+	}
+
+	return &Generator{
+		site:     strings.ToUpper(cfg.Site),
+		prefixes: cfg.Prefixes,
+		yearly:   yearly,
+		digits:   cfg.Digits,
+		now:      time.Now,
+	}, nil
+}
+
+// Next takes the next number for kind in tx. The counter is named after
+// the formatted prefix, so a new year or a changed site code starts a new
+// count.
+func (g *Generator) Next(tx *gorm.DB, kind string) (string, error) {
+	prefix, ok := g.prefixes[kind]
+	if !ok {
+		return "", fmt.Errorf("no reference number prefix for %q", kind)
+	}
+
+	parts := []string{prefix}
+	if g.site != "" {
+		parts = append(parts, g.site)
+	}
+	if g.yearly {
+		parts = append(parts, strconv.Itoa(g.now().Year()))
+	}
+	counter := strings.Join(parts, "-")
+
+	// The upsert locks the counter row, or waits for a concurrent
+	// transaction holding it, until tx commits or rolls back
+	var value int64
+	err := tx.Session(&gorm.Session{NewDB: true}).Raw(`
+		INSERT INTO reference_counters (name, value, updated_at)
+		VALUES (?, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT (name) DO UPDATE
+		SET value = reference_counters.value + 1, updated_at = CURRENT_TIMESTAMP
+		RETURNING value`, counter).Scan(&value).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to take the next %s number: %w", kind, err)
+	}
+
+	return fmt.Sprintf("%s-%0*d", counter, g.digits, value), nil
+}
@@ -0,0 +1,103 @@
+package sequence
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/config"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB connects to the scratch Postgres database in TEST_DATABASE_DSN,
+// e.g. "host=localhost user=postgres dbname=health_sys_test sslmode=disable".
+// The test is skipped when it is not set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS "reference_counters" (
+		"name" varchar(100),
+		"value" bigint NOT NULL DEFAULT 0,
+		"updated_at" timestamptz DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY ("name")
+	)`).Error
+	if err != nil {
+		t.Fatalf("failed to create reference_counters: %v", err)
+	}
+	return db
+}
+
+func TestNextIsUniqueAcrossConcurrentTransactions(t *testing.T) {
+	db := testDB(t)
+
+	// A site code of its own keeps the counter apart from other runs
+	site := fmt.Sprintf("T%d", time.Now().UnixNano())
+	generator, err := NewGenerator(config.ReferenceNumbers{
+		Site:     site,
+		Prefixes: map[string]string{"incident": "INC"},
+		Reset:    "never",
+		Digits:   5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := "INC-" + site
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM reference_counters WHERE name = ?", counter)
+	})
+
+	const workers, perWorker = 10, 20
+	var (
+		mu      sync.Mutex
+		numbers []string
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				var number string
+				err := db.Transaction(func(tx *gorm.DB) error {
+					var err error
+					number, err = generator.Next(tx, "incident")
+					return err
+				})
+				if err != nil {
+					t.Errorf("Next failed: %v", err)
+					return
+				}
+				mu.Lock()
+				numbers = append(numbers, number)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+
+	if len(numbers) != workers*perWorker {
+		t.Fatalf("got %d numbers, want %d", len(numbers), workers*perWorker)
+	}
+	sort.Strings(numbers)
+	for i, number := range numbers {
+		want := fmt.Sprintf("%s-%05d", counter, i+1)
+		if number != want {
+			t.Fatalf("number %d is %s, want %s: numbers are not unique and gapless", i+1, number, want)
+		}
+	}
+}
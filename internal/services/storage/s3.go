@@ -0,0 +1,456 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/config"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	// maxPresignExpiry is the longest validity S3 accepts for a signed URL
+	maxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// S3Storage keeps files in a bucket of an S3-compatible object store.
+// Requests are signed with AWS Signature Version 4.
+type S3Storage struct {
+	endpoint     *url.URL
+	region       string
+	bucket       string
+	accessKeyID  string
+	secretKey    string
+	prefix       string
+	usePathStyle bool
+	client       *http.Client
+	now          func() time.Time
+}
+
+func NewS3Storage(cfg config.S3Storage) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("s3 storage needs an endpoint and a bucket")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3 storage needs an access key")
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", cfg.Endpoint)
+	}
+
+	return &S3Storage{
+		endpoint:     endpoint,
+		region:       cfg.Region,
+		bucket:       cfg.Bucket,
+		accessKeyID:  cfg.AccessKeyID,
+		secretKey:    cfg.SecretAccessKey,
+		prefix:       strings.Trim(cfg.Prefix, "/"),
+		usePathStyle: cfg.UsePathStyle,
+		client:       &http.Client{},
+		now:          time.Now,
+	}, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if size < 0 {
+		return errors.New("s3 uploads need the file size")
+	}
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string, byteRange *ByteRange) (*Object, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != nil {
+		req.Header.Set("Range", byteRange.header())
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	object := &Object{
+		Body:        resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+		Length:      resp.ContentLength,
+		ModTime:     modTime,
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		// Content-Range: bytes <first>-<last>/<size>
+		var first, last int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%d", &first, &last, &object.Size); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("invalid Content-Range from s3: %w", err)
+		}
+		object.Offset, object.Length = first, last-first+1
+	}
+	return object, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, objectURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Move copies the object to its new key and deletes the original. S3 has
+// no rename.
+func (s *S3Storage) Move(ctx context.Context, from, to string) error {
+	if err := validateKey(from); err != nil {
+		return err
+	}
+	objectURL, err := s.objectURL(to)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Amz-Copy-Source", escapePath("/"+s.bucket+"/"+s.objectName(from)))
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// A copy can fail after the 200 status has been sent, so the body has
+	// to be checked as well
+	var result struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid copy response from s3: %w", err)
+	}
+	if result.XMLName.Local == "Error" {
+		return fmt.Errorf("failed to copy file: s3 returned %s: %s", result.Code, result.Message)
+	}
+
+	return s.Delete(ctx, from)
+}
+
+// List pages through the bucket with ListObjectsV2
+func (s *S3Storage) List(ctx context.Context, prefix string, fn func(Entry) error) error {
+	objectPrefix := s.objectName(prefix)
+
+	var token string
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", objectPrefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		bucketURL := s.bucketURL()
+		if bucketURL.Path == "" {
+			bucketURL.Path, bucketURL.RawPath = "/", "/"
+		}
+		bucketURL.RawQuery = canonicalQuery(query)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, bucketURL.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.do(req)
+		if err != nil {
+			return fmt.Errorf("failed to list files: %w", err)
+		}
+		var page struct {
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+			Contents              []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+			} `xml:"Contents"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("invalid list response from s3: %w", err)
+		}
+
+		for _, object := range page.Contents {
+			key := object.Key
+			if s.prefix != "" {
+				key = strings.TrimPrefix(key, s.prefix+"/")
+			}
+			if err := fn(Entry{Key: key, Size: object.Size, ModTime: object.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// SignedURL returns a presigned GET URL for the object
+func (s *S3Storage) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	objectURL, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	if expiry <= 0 || expiry > maxPresignExpiry {
+		return "", fmt.Errorf("signed URL expiry must be between 1s and %s", maxPresignExpiry)
+	}
+
+	now := s.now().UTC()
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", sigV4Algorithm)
+	query.Set("X-Amz-Credential", s.accessKeyID+"/"+s.credentialScope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		objectURL.EscapedPath(),
+		canonicalQuery(query),
+		"host:" + objectURL.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+	objectURL.RawQuery = canonicalQuery(query)
+	return objectURL.String(), nil
+}
+
+// objectURL addresses an object either as endpoint/bucket/key (path style)
+// or as bucket.endpoint/key
+func (s *S3Storage) objectURL(key string) (*url.URL, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	objectURL := s.bucketURL()
+	objectURL.Path += "/" + s.objectName(key)
+	objectURL.RawPath = escapePath(objectURL.Path)
+	return objectURL, nil
+}
+
+// bucketURL addresses the bucket either as endpoint/bucket (path style) or
+// as bucket.endpoint
+func (s *S3Storage) bucketURL() *url.URL {
+	bucketURL := *s.endpoint
+	bucketPath := strings.TrimSuffix(s.endpoint.Path, "/")
+	if s.usePathStyle {
+		bucketPath += "/" + s.bucket
+	} else {
+		bucketURL.Host = s.bucket + "." + s.endpoint.Host
+	}
+	bucketURL.Path = bucketPath
+	bucketURL.RawPath = escapePath(bucketPath)
+	bucketURL.RawQuery = ""
+	return &bucketURL
+}
+
+// objectName is the name of the object a key is stored as in the bucket
+func (s *S3Storage) objectName(key string) string {
+	if s.prefix != "" {
+		return s.prefix + "/" + key
+	}
+	return key
+}
+
+// do signs and sends a request. Responses other than 2xx are turned into
+// errors; a missing object is ErrNotFound.
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	s.sign(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusRequestedRangeNotSatisfiable:
+		return nil, ErrInvalidRange
+	}
+	var s3Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&s3Error)
+	if s3Error.Code != "" {
+		return nil, fmt.Errorf("s3 returned %d %s: %s", resp.StatusCode, s3Error.Code, s3Error.Message)
+	}
+	return nil, fmt.Errorf("s3 returned %d", resp.StatusCode)
+}
+
+// sign adds an Authorization header. The payload is left unsigned so that
+// uploads can be streamed without reading them twice.
+func (s *S3Storage) sign(req *http.Request) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": unsignedPayload,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.accessKeyID, s.credentialScope(now), signedHeaders, s.signature(now, canonicalRequest)))
+}
+
+func (s *S3Storage) credentialScope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *S3Storage) signature(now time.Time, canonicalRequest string) string {
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		now.Format(amzDateFormat),
+		s.credentialScope(now),
+		hex.EncodeToString(hashed[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery sorts and encodes query parameters as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func escapePath(p string) string {
+	return uriEncode(p, false)
+}
+
+// uriEncode percent-encodes everything but the RFC 3986 unreserved
+// characters. Slashes are kept in paths.
+func uriEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
@@ -0,0 +1,133 @@
+// Package storage keeps uploaded files behind a backend chosen in the
+// config: a directory on the local disk or an S3-compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/config"
+)
+
+var (
+	ErrNotFound     = errors.New("file not found")
+	ErrInvalidKey   = errors.New("invalid storage key")
+	ErrInvalidRange = errors.New("range not satisfiable")
+	ErrNotSupported = errors.New("not supported by this storage driver")
+)
+
+// Storage stores files under slash-separated keys such as
+// incidents/<incident id>/photo.jpg
+type Storage interface {
+	// Put stores size bytes read from body under key, replacing any file
+	// already stored there
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the file stored under key, or the part of it selected by
+	// byteRange when that is not nil. The caller closes the body.
+	Get(ctx context.Context, key string, byteRange *ByteRange) (*Object, error)
+	// Delete removes the file stored under key. Deleting a missing file
+	// is not an error.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL the file can be downloaded from until expiry
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// Move renames the file stored under from to to, replacing any file
+	// already stored there
+	Move(ctx context.Context, from, to string) error
+	// List calls fn for every file whose key starts with prefix. An empty
+	// prefix lists the whole store.
+	List(ctx context.Context, prefix string, fn func(Entry) error) error
+}
+
+// Entry describes a stored file found by List
+type Entry struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// ByteRange selects the bytes Start to End, inclusive, as an HTTP Range
+// header does. An End below zero reads to the end of the file and a Start
+// below zero reads the last -Start bytes.
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
+// Object is an open stored file
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	// Size is the size of the whole file. Body holds Length bytes of it
+	// starting at Offset.
+	Size    int64
+	Offset  int64
+	Length  int64
+	ModTime time.Time
+}
+
+// resolve turns the range into an offset and length within a file of the
+// given size
+func (r ByteRange) resolve(size int64) (int64, int64, error) {
+	start, end := r.Start, r.End
+	if start < 0 {
+		start = size + start
+		if start < 0 {
+			start = 0
+		}
+		end = size - 1
+	}
+	if end < 0 || end >= size {
+		end = size - 1
+	}
+	if start >= size || start > end {
+		return 0, 0, ErrInvalidRange
+	}
+	return start, end - start + 1, nil
+}
+
+// header formats the range as an HTTP Range header
+func (r ByteRange) header() string {
+	switch {
+	case r.Start < 0:
+		return fmt.Sprintf("bytes=%d", r.Start)
+	case r.End < 0:
+		return fmt.Sprintf("bytes=%d-", r.Start)
+	default:
+		return fmt.Sprintf("bytes=%d-%d", r.Start, r.End)
+	}
+}
+
+// New returns the backend selected in the config
+func New(cfg config.Storage) (Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return NewLocalStorage(cfg.Local)
+	case "s3":
+		return NewS3Storage(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// Key joins path elements into a storage key
+func Key(elem ...string) string {
+	return path.Join(elem...)
+}
+
+// validateKey rejects keys that could escape the storage root
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." || segment == "." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"gorm.io/gorm"
+)
+
+// storedFileColumns lists, per table, the columns holding storage keys
+var storedFileColumns = []struct {
+	model   interface{}
+	columns []string
+}{
+	{&models.IncidentAttachment{}, []string{"storage_path", "thumbnail_path", "preview_path"}},
+	{&models.VPCAttachment{}, []string{"storage_path", "thumbnail_path", "preview_path"}},
+	{&models.HazardAttachment{}, []string{"storage_path", "thumbnail_path", "preview_path"}},
+	{&models.InvestigationEvidence{}, []string{"storage_path", "thumbnail_path", "preview_path"}},
+	{&models.ActionEvidence{}, []string{"file_url"}},
+}
+
+// StorageGCOptions controls a storage garbage collection run
+type StorageGCOptions struct {
+	// MinAge spares files younger than this, which may belong to an upload
+	// whose transaction has not committed yet
+	MinAge time.Duration
+	// Delete deletes the orphaned files. Without it the run only reports
+	// what would be deleted.
+	Delete bool
+}
+
+// ErrStorageKeysMissing is returned instead of deleting anything when
+// records refer to keys that are not in storage. That usually means the
+// records use another key format than the store, e.g. keys still carrying
+// the local storage directory, and every file they refer to would be taken
+// for an orphan.
+var ErrStorageKeysMissing = errors.New("records refer to files missing from storage")
+
+// StorageGCResult reports a storage garbage collection run
+type StorageGCResult struct {
+	// Checked is the number of stored files looked at
+	Checked int
+	// Orphaned are the files no record refers to that were old enough to
+	// delete
+	Orphaned []storage.Entry
+	// Deleted is the number of orphaned files actually deleted, which is
+	// none unless Delete was set and no key is missing
+	Deleted int
+	// Missing are keys records refer to that are not in storage
+	Missing []string
+}
+
+// CollectStorageGarbage reconciles the stored files with the attachment and
+// evidence tables. Files no record refers to, including staged uploads left
+// by a crash, are reported once older than MinAge and deleted with Delete.
+// Quarantined files are kept for review.
+func CollectStorageGarbage(ctx context.Context, db *gorm.DB, store storage.Storage, opts StorageGCOptions) (*StorageGCResult, error) {
+	referenced, err := referencedStorageKeys(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StorageGCResult{}
+	found := make(map[string]bool, len(referenced))
+	cutoff := time.Now().Add(-opts.MinAge)
+	err = store.List(ctx, "", func(entry storage.Entry) error {
+		result.Checked++
+		if referenced[entry.Key] {
+			found[entry.Key] = true
+			return nil
+		}
+		if strings.HasPrefix(entry.Key, "quarantine/") || entry.ModTime.After(cutoff) {
+			return nil
+		}
+		result.Orphaned = append(result.Orphaned, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range referenced {
+		if !found[key] {
+			result.Missing = append(result.Missing, key)
+		}
+	}
+	sort.Strings(result.Missing)
+
+	if !opts.Delete {
+		return result, nil
+	}
+	if len(result.Missing) > 0 {
+		return result, fmt.Errorf("%w: %d keys, refusing to delete", ErrStorageKeysMissing, len(result.Missing))
+	}
+	for _, entry := range result.Orphaned {
+		if err := store.Delete(ctx, entry.Key); err != nil {
+			return result, fmt.Errorf("failed to delete %s: %w", entry.Key, err)
+		}
+		result.Deleted++
+	}
+	return result, nil
+}
+
+// referencedStorageKeys collects every storage key a record refers to
+func referencedStorageKeys(ctx context.Context, db *gorm.DB) (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	for _, table := range storedFileColumns {
+		for _, column := range table.columns {
+			var keys []string
+			err := db.WithContext(ctx).Model(table.model).
+				Where(column+" <> ''").
+				Distinct().
+				Pluck(column, &keys).Error
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", column, err)
+			}
+			for _, key := range keys {
+				referenced[key] = true
+			}
+		}
+	}
+	return referenced, nil
+}
@@ -0,0 +1,147 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hopkali04/health-sys/internal/config"
+)
+
+const (
+	// AudienceAccess marks tokens that grant access to the API
+	AudienceAccess = "api"
+	// AudienceMFA marks the intermediate token issued after a correct password
+	// when a second factor is still required. It only grants access to the
+	// MFA endpoints.
+	AudienceMFA = "mfa"
+
+	mfaTokenTTL = 5 * time.Minute
+)
+
+// Claims are the claims carried by an access token
+type Claims struct {
+	UserID    string `json:"userID"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// JWTManager signs and verifies access tokens. New tokens are always signed
+// with the active key, while every configured key is accepted on verification
+// so keys can be rotated without logging everyone out.
+type JWTManager struct {
+	keys        map[string][]byte
+	activeKeyID string
+	issuer      string
+	accessTTL   time.Duration
+}
+
+func NewJWTManager(cfg *config.Config) (*JWTManager, error) {
+	if len(cfg.Auth.Keys) == 0 {
+		return nil, errors.New("auth: no signing keys configured")
+	}
+
+	keys := make(map[string][]byte, len(cfg.Auth.Keys))
+	for _, key := range cfg.Auth.Keys {
+		if key.ID == "" || key.Secret == "" {
+			return nil, errors.New("auth: signing keys require both kid and secret")
+		}
+		if _, exists := keys[key.ID]; exists {
+			return nil, fmt.Errorf("auth: duplicate signing key id %q", key.ID)
+		}
+		keys[key.ID] = []byte(key.Secret)
+	}
+
+	if _, ok := keys[cfg.Auth.ActiveKeyID]; !ok {
+		return nil, fmt.Errorf("auth: active key id %q is not among the configured keys", cfg.Auth.ActiveKeyID)
+	}
+
+	return &JWTManager{
+		keys:        keys,
+		activeKeyID: cfg.Auth.ActiveKeyID,
+		issuer:      cfg.Auth.Issuer,
+		accessTTL:   cfg.Auth.AccessTokenTTL,
+	}, nil
+}
+
+// GenerateAccessToken issues a signed access token bound to a user session
+func (m *JWTManager) GenerateAccessToken(userID, role, sessionID string) (string, time.Time, error) {
+	return m.generate(Claims{UserID: userID, Role: role, SessionID: sessionID}, AudienceAccess, m.accessTTL)
+}
+
+// GenerateMFAToken issues the short-lived token that proves the password step
+// of a login succeeded and is exchanged for an access token once the second
+// factor has been verified
+func (m *JWTManager) GenerateMFAToken(userID string) (string, time.Time, error) {
+	return m.generate(Claims{UserID: userID}, AudienceMFA, mfaTokenTTL)
+}
+
+// ParseAccessToken verifies the signature, issuer and expiry of an access token
+func (m *JWTManager) ParseAccessToken(tokenString string) (*Claims, error) {
+	return m.parse(tokenString, AudienceAccess)
+}
+
+// ParseMFAToken verifies an intermediate MFA token
+func (m *JWTManager) ParseMFAToken(tokenString string) (*Claims, error) {
+	return m.parse(tokenString, AudienceMFA)
+}
+
+func (m *JWTManager) generate(claims Claims, audience string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		Issuer:    m.issuer,
+		Subject:   claims.UserID,
+		Audience:  jwt.ClaimStrings{audience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = m.activeKeyID
+
+	signed, err := token.SignedString(m.keys[m.activeKeyID])
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign %s token: %w", audience, err)
+	}
+
+	return signed, expiresAt, nil
+}
+
+func (m *JWTManager) parse(tokenString, audience string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, m.keyFunc,
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.UserID == "" {
+		return nil, errors.New("token is missing the user id claim")
+	}
+
+	return claims, nil
+}
+
+func (m *JWTManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, errors.New("token is missing the kid header")
+	}
+
+	key, ok := m.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
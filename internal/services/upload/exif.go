@@ -0,0 +1,423 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"strings"
+	"time"
+)
+
+// EXIF tags read from uploaded photos
+const (
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+// TIFF field types
+const (
+	typeASCII    = 2
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+)
+
+const exifDateFormat = "2006:01:02 15:04:05"
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	pngHeader  = []byte("\x89PNG\r\n\x1a\n")
+
+	errNoEXIF      = errors.New("no exif data")
+	errInvalidEXIF = errors.New("invalid exif data")
+)
+
+// exifData is the camera metadata kept about a photo
+type exifData struct {
+	// Orientation is the EXIF orientation, 1 to 8; 1 is upright
+	Orientation int
+	CapturedAt  *time.Time
+	Latitude    *float64
+	Longitude   *float64
+}
+
+// readEXIF reads the metadata of a JPEG or PNG photo. Photos without EXIF
+// data, or with data that cannot be read, are upright with no metadata.
+func readEXIF(content []byte) exifData {
+	var tiff []byte
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xD8}):
+		tiff = jpegEXIF(content)
+	case bytes.HasPrefix(content, pngHeader):
+		tiff = pngEXIF(content)
+	}
+
+	data := exifData{Orientation: 1}
+	if tiff == nil {
+		return data
+	}
+	parsed, err := parseEXIF(tiff)
+	if err != nil {
+		return data
+	}
+	return parsed
+}
+
+// jpegEXIF returns the TIFF structure of the first Exif APP1 segment
+func jpegEXIF(content []byte) []byte {
+	var found []byte
+	walkJPEG(content, func(marker byte, segment []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(segment[4:], exifHeader) {
+			found = segment[4+len(exifHeader):]
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// pngEXIF returns the content of the eXIf chunk
+func pngEXIF(content []byte) []byte {
+	var found []byte
+	walkPNG(content, func(chunkType string, chunk []byte) bool {
+		if chunkType == "eXIf" {
+			found = chunk[8 : len(chunk)-4]
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// walkJPEG calls fn with each marker segment before the image data, marker
+// and length included, until fn returns false. It returns the offset of the
+// start of scan segment, or -1 when the file is malformed.
+func walkJPEG(content []byte, fn func(marker byte, segment []byte) bool) int {
+	offset := 2
+	for offset+4 <= len(content) {
+		if content[offset] != 0xFF {
+			return -1
+		}
+		marker := content[offset+1]
+		if marker == 0xFF {
+			// Fill byte before a marker
+			offset++
+			continue
+		}
+		if marker == 0xDA {
+			return offset
+		}
+		length := int(binary.BigEndian.Uint16(content[offset+2:]))
+		if length < 2 || offset+2+length > len(content) {
+			return -1
+		}
+		if !fn(marker, content[offset:offset+2+length]) {
+			return offset
+		}
+		offset += 2 + length
+	}
+	return -1
+}
+
+// walkPNG calls fn with each chunk up to IEND, length and CRC included,
+// until fn returns false. It returns false when the file is malformed.
+func walkPNG(content []byte, fn func(chunkType string, chunk []byte) bool) bool {
+	offset := len(pngHeader)
+	for offset+12 <= len(content) {
+		length := int(binary.BigEndian.Uint32(content[offset:]))
+		if offset+12+length > len(content) {
+			return false
+		}
+		chunk := content[offset : offset+12+length]
+		chunkType := string(chunk[4:8])
+		if !fn(chunkType, chunk) || chunkType == "IEND" {
+			return true
+		}
+		offset += 12 + length
+	}
+	return false
+}
+
+// stripEXIF removes camera metadata from a JPEG or PNG photo without
+// re-encoding it: EXIF, XMP and IPTC segments from JPEGs, and eXIf and text
+// chunks from PNGs. The orientation is written back so that the photo still
+// displays upright. Other formats are returned as they are.
+func stripEXIF(content []byte, orientation int) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xFF, 0xD8}):
+		return stripJPEG(content, orientation)
+	case bytes.HasPrefix(content, pngHeader):
+		return stripPNG(content, orientation)
+	default:
+		return content, nil
+	}
+}
+
+func stripJPEG(content []byte, orientation int) ([]byte, error) {
+	stripped := make([]byte, 0, len(content))
+	stripped = append(stripped, 0xFF, 0xD8)
+
+	written := false
+	writeOrientation := func() {
+		if !written && orientation > 1 {
+			tiff := orientationEXIF(orientation)
+			segment := make([]byte, 4, 4+len(exifHeader)+len(tiff))
+			segment[0], segment[1] = 0xFF, 0xE1
+			binary.BigEndian.PutUint16(segment[2:], uint16(2+len(exifHeader)+len(tiff)))
+			segment = append(append(segment, exifHeader...), tiff...)
+			stripped = append(stripped, segment...)
+		}
+		written = true
+	}
+
+	scan := walkJPEG(content, func(marker byte, segment []byte) bool {
+		switch marker {
+		case 0xE1, 0xED:
+			// APP1 holds EXIF and XMP, APP13 holds IPTC
+			return true
+		case 0xE0:
+			// EXIF follows the JFIF header
+			stripped = append(stripped, segment...)
+			return true
+		}
+		writeOrientation()
+		stripped = append(stripped, segment...)
+		return true
+	})
+	if scan < 0 {
+		return nil, errInvalidEXIF
+	}
+	writeOrientation()
+	return append(stripped, content[scan:]...), nil
+}
+
+func stripPNG(content []byte, orientation int) ([]byte, error) {
+	stripped := make([]byte, 0, len(content))
+	stripped = append(stripped, pngHeader...)
+
+	valid := walkPNG(content, func(chunkType string, chunk []byte) bool {
+		switch chunkType {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+			return true
+		}
+		stripped = append(stripped, chunk...)
+		if chunkType == "IHDR" && orientation > 1 {
+			stripped = append(stripped, pngChunk("eXIf", orientationEXIF(orientation))...)
+		}
+		return true
+	})
+	if !valid {
+		return nil, errInvalidEXIF
+	}
+	return stripped, nil
+}
+
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], chunkType)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// orientationEXIF builds a TIFF structure holding only the orientation
+func orientationEXIF(orientation int) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8}
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, tagOrientation)
+	tiff = binary.BigEndian.AppendUint16(tiff, typeShort)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0)
+	return binary.BigEndian.AppendUint32(tiff, 0)
+}
+
+// tiffReader reads the image file directories of a TIFF structure
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// tiffEntry is a field of an image file directory
+type tiffEntry struct {
+	fieldType uint16
+	count     uint32
+	value     []byte
+}
+
+func parseEXIF(tiff []byte) (exifData, error) {
+	data := exifData{Orientation: 1}
+	if len(tiff) < 8 {
+		return data, errNoEXIF
+	}
+
+	r := tiffReader{data: tiff}
+	switch string(tiff[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return data, errInvalidEXIF
+	}
+	if r.order.Uint16(tiff[2:]) != 42 {
+		return data, errInvalidEXIF
+	}
+
+	ifd0, err := r.readIFD(r.order.Uint32(tiff[4:]))
+	if err != nil {
+		return data, err
+	}
+	if orientation, ok := r.uint(ifd0[tagOrientation]); ok && orientation >= 1 && orientation <= 8 {
+		data.Orientation = int(orientation)
+	}
+
+	capturedAt := r.ascii(ifd0[tagDateTime])
+	offset := ""
+	if exifOffset, ok := r.uint(ifd0[tagExifIFD]); ok {
+		if exifIFD, err := r.readIFD(exifOffset); err == nil {
+			if original := r.ascii(exifIFD[tagDateTimeOriginal]); original != "" {
+				capturedAt = original
+				offset = r.ascii(exifIFD[tagOffsetTimeOriginal])
+			}
+		}
+	}
+	data.CapturedAt = parseEXIFTime(capturedAt, offset)
+
+	if gpsOffset, ok := r.uint(ifd0[tagGPSIFD]); ok {
+		if gps, err := r.readIFD(gpsOffset); err == nil {
+			data.Latitude = r.coordinate(gps[tagGPSLatitude], r.ascii(gps[tagGPSLatitudeRef]), "S", 90)
+			data.Longitude = r.coordinate(gps[tagGPSLongitude], r.ascii(gps[tagGPSLongitudeRef]), "W", 180)
+			if data.Latitude == nil || data.Longitude == nil {
+				data.Latitude, data.Longitude = nil, nil
+			}
+		}
+	}
+	return data, nil
+}
+
+func (r tiffReader) readIFD(offset uint32) (map[uint16]tiffEntry, error) {
+	if uint64(offset)+2 > uint64(len(r.data)) {
+		return nil, errInvalidEXIF
+	}
+	count := int(r.order.Uint16(r.data[offset:]))
+	start := int(offset) + 2
+	if start+count*12 > len(r.data) {
+		return nil, errInvalidEXIF
+	}
+
+	entries := make(map[uint16]tiffEntry, count)
+	for i := 0; i < count; i++ {
+		field := r.data[start+i*12 : start+(i+1)*12]
+		entry := tiffEntry{
+			fieldType: r.order.Uint16(field[2:]),
+			count:     r.order.Uint32(field[4:]),
+		}
+
+		var size uint64
+		switch entry.fieldType {
+		case typeASCII:
+			size = 1
+		case typeShort:
+			size = 2
+		case typeLong:
+			size = 4
+		case typeRational:
+			size = 8
+		default:
+			continue
+		}
+		size *= uint64(entry.count)
+		if size <= 4 {
+			entry.value = field[8 : 8+size]
+		} else {
+			valueOffset := uint64(r.order.Uint32(field[8:]))
+			if valueOffset+size > uint64(len(r.data)) {
+				continue
+			}
+			entry.value = r.data[valueOffset : valueOffset+size]
+		}
+		entries[r.order.Uint16(field)] = entry
+	}
+	return entries, nil
+}
+
+func (r tiffReader) uint(entry tiffEntry) (uint32, bool) {
+	switch {
+	case entry.fieldType == typeShort && len(entry.value) >= 2:
+		return uint32(r.order.Uint16(entry.value)), true
+	case entry.fieldType == typeLong && len(entry.value) >= 4:
+		return r.order.Uint32(entry.value), true
+	default:
+		return 0, false
+	}
+}
+
+func (r tiffReader) ascii(entry tiffEntry) string {
+	if entry.fieldType != typeASCII {
+		return ""
+	}
+	value, _, _ := strings.Cut(string(entry.value), "\x00")
+	return strings.TrimSpace(value)
+}
+
+// coordinate converts degrees, minutes and seconds to signed decimal
+// degrees. negativeRef is the hemisphere that is negative.
+func (r tiffReader) coordinate(entry tiffEntry, ref, negativeRef string, limit float64) *float64 {
+	if entry.fieldType != typeRational || entry.count != 3 || ref == "" {
+		return nil
+	}
+
+	var parts [3]float64
+	for i := range parts {
+		numerator := r.order.Uint32(entry.value[i*8:])
+		denominator := r.order.Uint32(entry.value[i*8+4:])
+		if denominator == 0 {
+			return nil
+		}
+		parts[i] = float64(numerator) / float64(denominator)
+	}
+
+	degrees := parts[0] + parts[1]/60 + parts[2]/3600
+	if degrees > limit {
+		return nil
+	}
+	if strings.EqualFold(ref, negativeRef) {
+		degrees = -degrees
+	}
+	return &degrees
+}
+
+// parseEXIFTime reads an EXIF date. Cameras that do not record their UTC
+// offset are taken to be on UTC.
+func parseEXIFTime(value, offset string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	layout := exifDateFormat
+	if offset != "" {
+		layout += "-07:00"
+		value += offset
+	}
+	parsed, err := time.Parse(layout, value)
+	if err != nil {
+		if offset == "" {
+			return nil
+		}
+		return parseEXIFTime(strings.TrimSuffix(value, offset), "")
+	}
+	if parsed.Year() < 1900 {
+		return nil
+	}
+	parsed = parsed.UTC()
+	return &parsed
+}
@@ -0,0 +1,167 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register the GIF decoder
+	"image/jpeg"
+	_ "image/png" // register the PNG decoder
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// maxImagePixels stops small files that decode to huge images
+	maxImagePixels = 50_000_000
+	variantQuality = 82
+)
+
+// Image describes an uploaded photo and its resized copies
+type Image struct {
+	Width  int
+	Height int
+	// ThumbnailKey and PreviewKey are empty unless the upload asked for
+	// resized copies
+	ThumbnailKey string
+	PreviewKey   string
+	CapturedAt   *time.Time
+	Latitude     *float64
+	Longitude    *float64
+
+	orientation int
+}
+
+// processImage reads the photo's metadata and strips it when the policy
+// says so. It returns the content to store.
+func (s *Service) processImage(content []byte, name string) ([]byte, *Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s cannot be read as an image", ErrContentMismatch, name)
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, nil, fmt.Errorf("%w: %s is %dx%d pixels", ErrFileTooLarge, name, config.Width, config.Height)
+	}
+
+	metadata := readEXIF(content)
+	info := &Image{
+		Width:      config.Width,
+		Height:     config.Height,
+		CapturedAt: metadata.CapturedAt,
+		Latitude:   metadata.Latitude,
+		Longitude:  metadata.Longitude,
+
+		orientation: metadata.Orientation,
+	}
+	if metadata.Orientation >= 5 {
+		// Orientations 5 to 8 turn the photo on its side
+		info.Width, info.Height = info.Height, info.Width
+	}
+
+	if s.keepEXIF {
+		return content, info, nil
+	}
+	stripped, err := stripEXIF(content, metadata.Orientation)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s cannot be read as an image", ErrContentMismatch, name)
+	}
+	return stripped, info, nil
+}
+
+// saveVariants stores a thumbnail and a preview of the photo next to it, as
+// upright JPEGs without metadata
+func (s *Service) saveVariants(ctx context.Context, dir, name string, content []byte, info *Image) error {
+	decoded, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("%w: %s cannot be read as an image", ErrContentMismatch, name)
+	}
+
+	variants := []struct {
+		dir  string
+		size int
+		key  *string
+	}{
+		{"previews", s.previewSize, &info.PreviewKey},
+		{"thumbnails", s.thumbnailSize, &info.ThumbnailKey},
+	}
+	for _, variant := range variants {
+		resized := orient(resize(decoded, variant.size), info.orientation)
+
+		var encoded bytes.Buffer
+		if err := jpeg.Encode(&encoded, resized, &jpeg.Options{Quality: variantQuality}); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", variant.dir, err)
+		}
+		key := storage.Key(dir, variant.dir, variantName(name))
+		if err := s.storage.Put(ctx, key, &encoded, int64(encoded.Len()), "image/jpeg"); err != nil {
+			return fmt.Errorf("failed to save %s: %w", variant.dir, err)
+		}
+		*variant.key = key
+	}
+	return nil
+}
+
+// variantName names the JPEG copy of a photo, keeping the original
+// extension so that photo.png and photo.jpg do not share copies
+func variantName(name string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "_" + strings.TrimPrefix(strings.ToLower(ext), ".") + ".jpg"
+}
+
+// resize scales the image down so that its longest side is at most size
+// pixels, over a white background. Smaller images keep their size.
+func resize(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if longest := max(width, height); longest > size {
+		width = max(1, width*size/longest)
+		height = max(1, height*size/longest)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+	return dst
+}
+
+// orient turns an image upright according to its EXIF orientation
+func orient(src image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if orientation >= 5 {
+		width, height = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = width-1-x, y
+			case 3: // upside down
+				dx, dy = width-1-x, height-1-y
+			case 4: // upside down and mirrored
+				dx, dy = x, height-1-y
+			case 5: // mirrored and turned left
+				dx, dy = y, x
+			case 6: // turned left, so turn right
+				dx, dy = width-1-y, x
+			case 7: // mirrored and turned right
+				dx, dy = width-1-y, height-1-x
+			case 8: // turned right, so turn left
+				dx, dy = y, height-1-x
+			}
+			dst.Set(dx, dy, src.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return dst
+}
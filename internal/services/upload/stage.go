@@ -0,0 +1,110 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+// StagingDir holds files uploaded during a database transaction that has
+// not committed yet. Files left here by a crash are removed by the storage
+// garbage collector.
+const StagingDir = "staging"
+
+// Stage collects the uploads of one unit of work. Files are checked and
+// written under the staging folder, so nothing appears at its final key
+// until Commit moves it there. Rollback deletes everything the stage wrote.
+type Stage struct {
+	uploads *Service
+	dir     string
+	// moves maps each staged key to its final key, in upload order
+	moves     []stagedMove
+	committed []string
+}
+
+type stagedMove struct {
+	from string
+	to   string
+}
+
+// Stage starts a unit of work for uploads that must only be kept if a
+// database transaction commits
+func (s *Service) Stage() *Stage {
+	return &Stage{
+		uploads: s,
+		dir:     storage.Key(StagingDir, uuid.NewString()),
+	}
+}
+
+// Save checks an uploaded file like Service.Save and stages it. The keys of
+// the returned file are its final keys.
+func (st *Stage) Save(ctx context.Context, dir string, header *multipart.FileHeader) (*File, error) {
+	return st.save(ctx, dir, header, false)
+}
+
+// SaveAttachment stages a file like SaveAttachment, with the resized copies
+// of photos
+func (st *Stage) SaveAttachment(ctx context.Context, dir string, header *multipart.FileHeader) (*File, error) {
+	return st.save(ctx, dir, header, true)
+}
+
+func (st *Stage) save(ctx context.Context, dir string, header *multipart.FileHeader, variants bool) (*File, error) {
+	file, err := st.uploads.save(ctx, storage.Key(st.dir, dir), header, variants)
+	if err != nil {
+		return nil, err
+	}
+
+	file.Key = st.track(file.Key)
+	if file.Image != nil {
+		file.Image.ThumbnailKey = st.track(file.Image.ThumbnailKey)
+		file.Image.PreviewKey = st.track(file.Image.PreviewKey)
+	}
+	return file, nil
+}
+
+// track records a staged key and returns the final key it moves to
+func (st *Stage) track(staged string) string {
+	if staged == "" {
+		return ""
+	}
+	final := strings.TrimPrefix(staged, st.dir+"/")
+	st.moves = append(st.moves, stagedMove{from: staged, to: final})
+	return final
+}
+
+// Commit moves the staged files to their final keys. Call it as the last
+// step of the database transaction, so a failed move rolls the records back
+// too; Rollback then removes whatever was moved.
+func (st *Stage) Commit(ctx context.Context) error {
+	for _, move := range st.moves[len(st.committed):] {
+		if err := st.uploads.storage.Move(ctx, move.from, move.to); err != nil {
+			return fmt.Errorf("failed to store file: %w", err)
+		}
+		st.committed = append(st.committed, move.to)
+	}
+	return nil
+}
+
+// Rollback deletes every file the stage wrote, staged or already moved.
+// Failures are logged; the garbage collector removes what is left behind.
+func (st *Stage) Rollback(ctx context.Context) {
+	for i, move := range st.moves {
+		key := move.from
+		if i < len(st.committed) {
+			key = move.to
+		}
+		if err := st.uploads.storage.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			utils.LogError("Failed to delete staged file", map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			})
+		}
+	}
+	st.moves, st.committed = nil, nil
+}
@@ -0,0 +1,283 @@
+// Package upload checks files users upload before they are stored: the
+// content must match the extension, the file must be within the size limit
+// for its kind, and it must pass the virus scan when one is configured.
+// Photos have their camera metadata read, and stripped when the policy says
+// so, and attachments get resized copies for lists and previews.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+var (
+	ErrFileType        = errors.New("file type is not allowed")
+	ErrContentMismatch = errors.New("file content does not match its extension")
+	ErrFileTooLarge    = errors.New("file is too large")
+	ErrEmptyFile       = errors.New("file is empty")
+	ErrInfected        = errors.New("file failed the virus scan")
+)
+
+const maxFilenameLength = 120
+
+// fileType is an extension users may upload, the content types its content
+// may be sniffed as, and the kind that sets its size limit
+type fileType struct {
+	kind     string
+	contents []string
+}
+
+var fileTypes = map[string]fileType{
+	".jpg":  {"image", []string{"image/jpeg"}},
+	".jpeg": {"image", []string{"image/jpeg"}},
+	".png":  {"image", []string{"image/png"}},
+	".gif":  {"image", []string{"image/gif"}},
+	".pdf":  {"document", []string{"application/pdf"}},
+	".doc":  {"document", []string{"application/msword", "application/x-ole-storage"}},
+	".docx": {"document", []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"}},
+	".xls":  {"document", []string{"application/vnd.ms-excel", "application/x-ole-storage"}},
+	".xlsx": {"document", []string{"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"}},
+	".txt":  {"document", []string{"text/plain"}},
+	".mp4":  {"video", []string{"video/mp4"}},
+}
+
+// Scanner scans file content for malware. Scan returns an error wrapping
+// ErrInfected when the content is infected.
+type Scanner interface {
+	Scan(ctx context.Context, content io.Reader) error
+}
+
+// File is an uploaded file that passed the checks and was stored
+type File struct {
+	// Name is the sanitised file name
+	Name        string
+	ContentType string
+	Size        int64
+	Key         string
+	// Image is set for photos
+	Image *Image
+}
+
+// Service checks and stores uploaded files
+type Service struct {
+	storage       storage.Storage
+	maxSizes      map[string]int64
+	scanner       Scanner
+	keepEXIF      bool
+	thumbnailSize int
+	previewSize   int
+}
+
+func NewService(store storage.Storage, cfg config.Uploads) *Service {
+	maxSizes := make(map[string]int64, len(cfg.MaxSizeMB))
+	for kind, megabytes := range cfg.MaxSizeMB {
+		maxSizes[kind] = megabytes << 20
+	}
+
+	var scanner Scanner
+	if cfg.ClamAV.Address != "" {
+		scanner = NewClamdScanner(cfg.ClamAV.Address, cfg.ClamAV.Timeout)
+	}
+
+	return &Service{
+		storage:       store,
+		maxSizes:      maxSizes,
+		scanner:       scanner,
+		keepEXIF:      cfg.Images.EXIF == "keep",
+		thumbnailSize: cfg.Images.ThumbnailSize,
+		previewSize:   cfg.Images.PreviewSize,
+	}
+}
+
+// Save checks an uploaded file and stores it in dir. Infected files are
+// moved to quarantine instead and ErrInfected is returned.
+func (s *Service) Save(ctx context.Context, dir string, header *multipart.FileHeader) (*File, error) {
+	return s.save(ctx, dir, header, false)
+}
+
+// SaveAttachment saves a file like Save, and stores a thumbnail and a
+// preview of photos next to it
+func (s *Service) SaveAttachment(ctx context.Context, dir string, header *multipart.FileHeader) (*File, error) {
+	return s.save(ctx, dir, header, true)
+}
+
+func (s *Service) save(ctx context.Context, dir string, header *multipart.FileHeader, variants bool) (*File, error) {
+	name := SanitizeFilename(header.Filename)
+	ext := strings.ToLower(path.Ext(name))
+	allowed, ok := fileTypes[ext]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFileType, name)
+	}
+	if header.Size == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrEmptyFile, name)
+	}
+	if maxSize := s.maxSizes[allowed.kind]; maxSize > 0 && header.Size > maxSize {
+		return nil, fmt.Errorf("%w: %s is larger than %d MB", ErrFileTooLarge, name, maxSize>>20)
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	contentType, err := sniff(src, allowed)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, name)
+	}
+
+	if s.scanner != nil {
+		if err := s.scan(ctx, src, name, header.Size, contentType); err != nil {
+			return nil, err
+		}
+	}
+
+	file := &File{
+		Name:        name,
+		ContentType: contentType,
+		Size:        header.Size,
+		Key:         storage.Key(dir, name),
+	}
+	if allowed.kind != "image" {
+		if err := s.storage.Put(ctx, file.Key, src, file.Size, contentType); err != nil {
+			return nil, fmt.Errorf("failed to save file: %w", err)
+		}
+		return file, nil
+	}
+
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	content, file.Image, err = s.processImage(content, name)
+	if err != nil {
+		return nil, err
+	}
+	file.Size = int64(len(content))
+	if err := s.storage.Put(ctx, file.Key, bytes.NewReader(content), file.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+	if variants {
+		if err := s.saveVariants(ctx, dir, name, content, file.Image); err != nil {
+			s.Remove(ctx, file)
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+// Remove deletes a stored file and its resized copies. Failures are logged;
+// a file left behind is only wasted space.
+func (s *Service) Remove(ctx context.Context, file *File) {
+	keys := []string{file.Key}
+	if file.Image != nil {
+		keys = append(keys, file.Image.ThumbnailKey, file.Image.PreviewKey)
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.storage.Delete(ctx, key); err != nil {
+			utils.LogError("Failed to delete stored file", map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+// scan runs the virus scan and quarantines infected files. src is rewound
+// afterwards.
+func (s *Service) scan(ctx context.Context, src multipart.File, name string, size int64, contentType string) error {
+	scanErr := s.scanner.Scan(ctx, src)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if scanErr == nil {
+		return nil
+	}
+	if !errors.Is(scanErr, ErrInfected) {
+		return fmt.Errorf("virus scan failed: %w", scanErr)
+	}
+
+	key := storage.Key("quarantine", time.Now().UTC().Format("2006-01-02"), uuid.NewString(), name)
+	if err := s.storage.Put(ctx, key, src, size, contentType); err != nil {
+		utils.LogError("Failed to quarantine infected upload", map[string]interface{}{
+			"file":  name,
+			"error": err.Error(),
+		})
+	}
+	utils.LogWarn("Rejected infected upload", map[string]interface{}{
+		"file":       name,
+		"quarantine": key,
+		"result":     scanErr.Error(),
+	})
+	return fmt.Errorf("%w: %s", scanErr, name)
+}
+
+// sniff detects the content type from the file content and checks that it
+// is one the extension allows. src is rewound afterwards.
+func sniff(src multipart.File, allowed fileType) (string, error) {
+	detected, err := mimetype.DetectReader(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// A more specific type, such as CSV for a text file, still matches
+	for candidate := detected; candidate != nil; candidate = candidate.Parent() {
+		for _, contentType := range allowed.contents {
+			if candidate.Is(contentType) {
+				return allowed.contents[0], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w (detected %s)", ErrContentMismatch, detected.String())
+}
+
+// SanitizeFilename reduces an uploaded file name to a safe base name of
+// letters, digits, spaces, dots, dashes and underscores
+func SanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	var cleaned strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '.', r == '-', r == '_':
+			cleaned.WriteRune(r)
+		case r == ' ':
+			cleaned.WriteRune(' ')
+		case unicode.IsControl(r):
+		default:
+			cleaned.WriteRune('_')
+		}
+	}
+	name = strings.Trim(cleaned.String(), " .")
+
+	ext := strings.ToLower(path.Ext(name))
+	base := strings.TrimSpace(strings.TrimSuffix(name, path.Ext(name)))
+	if base == "" {
+		base = "file"
+	}
+	if runes := []rune(base); len(runes)+len(ext) > maxFilenameLength {
+		base = string(runes[:maxFilenameLength-len(ext)])
+	}
+	return base + ext
+}
@@ -0,0 +1,292 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/utils"
+)
+
+func TestMain(m *testing.M) {
+	// Rejected uploads are logged
+	utils.InitLogger("error", "text", "", "")
+	os.Exit(m.Run())
+}
+
+// eicar is the standard antivirus test file
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd answers INSTREAM scans like clamd: content holding the EICAR
+// test string is reported as infected and everything else as clean
+type fakeClamd struct {
+	listener net.Listener
+
+	mu      sync.Mutex
+	scanned [][]byte
+}
+
+func newFakeClamd(t *testing.T) *fakeClamd {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	clamd := &fakeClamd{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go clamd.serve(conn)
+		}
+	}()
+	return clamd
+}
+
+func (c *fakeClamd) serve(conn net.Conn) {
+	defer conn.Close()
+	command := make([]byte, len("zINSTREAM\x00"))
+	if _, err := io.ReadFull(conn, command); err != nil || string(command) != "zINSTREAM\x00" {
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+
+	var content bytes.Buffer
+	size := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return
+		}
+		n := binary.BigEndian.Uint32(size)
+		if n == 0 {
+			break
+		}
+		if _, err := io.CopyN(&content, conn, int64(n)); err != nil {
+			return
+		}
+	}
+
+	c.mu.Lock()
+	c.scanned = append(c.scanned, content.Bytes())
+	c.mu.Unlock()
+
+	if bytes.Contains(content.Bytes(), []byte(eicar)) {
+		conn.Write([]byte("stream: Eicar FOUND\x00"))
+		return
+	}
+	conn.Write([]byte("stream: OK\x00"))
+}
+
+func (c *fakeClamd) address() string {
+	return c.listener.Addr().String()
+}
+
+// scans returns the content of every scan so far
+func (c *fakeClamd) scans() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]byte(nil), c.scanned...)
+}
+
+// newTestService returns a service storing files in a temporary directory
+// and scanning them with clamd at address, when one is given
+func newTestService(t *testing.T, clamdAddress string) (*Service, storage.Storage) {
+	t.Helper()
+	store, err := storage.NewLocalStorage(config.LocalStorage{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService(store, config.Uploads{
+		MaxSizeMB: map[string]int64{"image": 1, "document": 1, "video": 2},
+		ClamAV:    config.ClamAV{Address: clamdAddress, Timeout: 5 * time.Second},
+		Images:    config.Images{EXIF: "strip", ThumbnailSize: 64, PreviewSize: 256},
+	}), store
+}
+
+// fileHeader returns an uploaded file as a multipart form carries it
+func fileHeader(t *testing.T, name string, content []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(32 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+// storedKeys lists every file in the store
+func storedKeys(t *testing.T, store storage.Storage) []string {
+	t.Helper()
+	var keys []string
+	err := store.List(context.Background(), "", func(entry storage.Entry) error {
+		keys = append(keys, entry.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestSaveStoresCleanFile(t *testing.T) {
+	clamd := newFakeClamd(t)
+	service, store := newTestService(t, clamd.address())
+	content := []byte("Witness statement: the forklift reversed without a banksman.\n")
+
+	file, err := service.Save(context.Background(), "incidents/1", fileHeader(t, "statement.txt", content))
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if file.Key != "incidents/1/statement.txt" || file.ContentType != "text/plain" || file.Size != int64(len(content)) {
+		t.Fatalf("saved %+v", file)
+	}
+	if scans := clamd.scans(); len(scans) != 1 || !bytes.Equal(scans[0], content) {
+		t.Fatalf("clamd scanned %q", scans)
+	}
+
+	object, err := store.Get(context.Background(), file.Key, nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	defer object.Body.Close()
+	stored, _ := io.ReadAll(object.Body)
+	if !bytes.Equal(stored, content) {
+		t.Fatalf("stored %q, want %q", stored, content)
+	}
+}
+
+func TestSaveQuarantinesInfectedFile(t *testing.T) {
+	clamd := newFakeClamd(t)
+	service, store := newTestService(t, clamd.address())
+
+	_, err := service.Save(context.Background(), "incidents/1", fileHeader(t, "invoice.txt", []byte(eicar)))
+	if !errors.Is(err, ErrInfected) {
+		t.Fatalf("Save returned %v, want %v", err, ErrInfected)
+	}
+	if !strings.Contains(err.Error(), "Eicar") {
+		t.Errorf("error %q does not name the signature", err)
+	}
+
+	keys := storedKeys(t, store)
+	if len(keys) != 1 || !strings.HasPrefix(keys[0], "quarantine/") || !strings.HasSuffix(keys[0], "/invoice.txt") {
+		t.Fatalf("stored %v, want the file in quarantine only", keys)
+	}
+	object, err := store.Get(context.Background(), keys[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer object.Body.Close()
+	if quarantined, _ := io.ReadAll(object.Body); string(quarantined) != eicar {
+		t.Fatalf("quarantined %q", quarantined)
+	}
+}
+
+func TestSaveFailsWhenScanFails(t *testing.T) {
+	// Nothing listens at the address once the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	service, store := newTestService(t, address)
+
+	_, err = service.Save(context.Background(), "incidents/1", fileHeader(t, "notes.txt", []byte("notes")))
+	if err == nil || errors.Is(err, ErrInfected) {
+		t.Fatalf("Save returned %v, want a scan failure", err)
+	}
+	if keys := storedKeys(t, store); len(keys) != 0 {
+		t.Fatalf("stored %v of a file that was not scanned", keys)
+	}
+}
+
+func TestSaveRejectsOversizedFile(t *testing.T) {
+	clamd := newFakeClamd(t)
+	service, store := newTestService(t, clamd.address())
+	content := bytes.Repeat([]byte("a"), 1<<20+1)
+
+	_, err := service.Save(context.Background(), "incidents/1", fileHeader(t, "log.txt", content))
+	if !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("Save returned %v, want %v", err, ErrFileTooLarge)
+	}
+	if len(clamd.scans()) != 0 {
+		t.Error("an oversized file was sent to clamd")
+	}
+	if keys := storedKeys(t, store); len(keys) != 0 {
+		t.Fatalf("stored %v", keys)
+	}
+}
+
+func TestSaveRejectsContentNotMatchingExtension(t *testing.T) {
+	clamd := newFakeClamd(t)
+	service, store := newTestService(t, clamd.address())
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    error
+	}{
+		{"photo.jpg", []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n"), ErrContentMismatch},
+		{"report.pdf", []byte("plain text pretending to be a PDF"), ErrContentMismatch},
+		{"report.txt", []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00\x00\x00\xff\xff\x00\x00"), ErrContentMismatch},
+		{"setup.exe", []byte("MZ\x90\x00"), ErrFileType},
+		{"empty.txt", nil, ErrEmptyFile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Save(context.Background(), "incidents/1", fileHeader(t, tt.name, tt.content))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Save returned %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if len(clamd.scans()) != 0 {
+		t.Error("a rejected file was sent to clamd")
+	}
+	if keys := storedKeys(t, store); len(keys) != 0 {
+		t.Fatalf("stored %v", keys)
+	}
+}
+
+func TestClamdScannerStreamsContentInChunks(t *testing.T) {
+	clamd := newFakeClamd(t)
+	scanner := NewClamdScanner(clamd.address(), 5*time.Second)
+	// Three and a bit chunks
+	content := bytes.Repeat([]byte("0123456789abcdef"), 3*clamdChunkSize/16+7)
+
+	if err := scanner.Scan(context.Background(), bytes.NewReader(content)); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if scans := clamd.scans(); len(scans) != 1 || !bytes.Equal(scans[0], content) {
+		t.Fatalf("clamd received %d scans, want the content once", len(scans))
+	}
+
+	err := scanner.Scan(context.Background(), strings.NewReader("prefix "+eicar))
+	if !errors.Is(err, ErrInfected) {
+		t.Fatalf("Scan returned %v, want %v", err, ErrInfected)
+	}
+}
@@ -0,0 +1,270 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/middleware"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/services"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"gorm.io/gorm"
+)
+
+var ErrTooManyAttempts = errors.New("too many failed sign-in attempts from this address")
+
+// AccountLockedError is returned while a lock is in force. It matches ErrAccountLocked with errors.Is.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	if e.Until.IsZero() {
+		return "account is locked"
+	}
+	return fmt.Sprintf("account is locked until %s", e.Until.Format(time.RFC3339))
+}
+
+func (e *AccountLockedError) Is(target error) bool {
+	return target == ErrAccountLocked
+}
+
+// LockoutService applies the lockout policy to failed sign-ins, both per
+// account and per IP address, and notifies users and admins about locks
+type LockoutService struct {
+	db           *gorm.DB
+	emailService *services.EmailService
+	policy       config.LockoutPolicy
+}
+
+func NewLockoutService(db *gorm.DB, es *services.EmailService, policy config.LockoutPolicy) *LockoutService {
+	return &LockoutService{
+		db:           db,
+		emailService: es,
+		policy:       policy,
+	}
+}
+
+// CheckIP refuses addresses that have failed too often within the policy window.
+// Refused attempts never reach an account, so one attacker cannot lock out many users.
+func (s *LockoutService) CheckIP(ipAddress string) error {
+	failures, err := s.recentIPFailures(s.db, ipAddress)
+	if err != nil {
+		return err
+	}
+	if failures >= int64(s.policy.IPThreshold) {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+// CheckAccount returns an AccountLockedError while the user's lock is in
+// force and lifts locks whose time has passed. Locks without an expiry,
+// such as those set before the lockout policy existed, need an admin.
+func (s *LockoutService) CheckAccount(tx *gorm.DB, user *models.User) error {
+	if !user.AccountLocked {
+		return nil
+	}
+
+	if user.AccountLockedUntil.IsZero() || time.Now().Before(user.AccountLockedUntil) {
+		return &AccountLockedError{Until: user.AccountLockedUntil}
+	}
+
+	user.AccountLocked = false
+	user.FailedLoginAttempts = 0
+	if err := tx.Model(user).Updates(map[string]interface{}{
+		"account_locked":        false,
+		"failed_login_attempts": 0,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to lift expired account lock: %w", err)
+	}
+
+	utils.LogInfo("Account lock expired", map[string]interface{}{
+		"userID": user.ID,
+	})
+	return nil
+}
+
+// RegisterFailure counts a wrong password or MFA code and locks the account
+// once the threshold is reached. Each repeated lock lasts longer.
+func (s *LockoutService) RegisterFailure(tx *gorm.DB, user *models.User, ipAddress, reason string) error {
+	if err := s.recordAttempt(tx, &user.ID, user.Email, ipAddress, false, reason); err != nil {
+		return err
+	}
+
+	user.FailedLoginAttempts++
+	updates := map[string]interface{}{"failed_login_attempts": user.FailedLoginAttempts}
+
+	locked := user.FailedLoginAttempts >= s.policy.Threshold
+	if locked {
+		user.LockoutCount++
+		user.AccountLocked = true
+		user.AccountLockedUntil = time.Now().Add(s.lockDuration(user.LockoutCount))
+		user.FailedLoginAttempts = 0
+
+		updates["failed_login_attempts"] = 0
+		updates["account_locked"] = true
+		updates["account_locked_until"] = user.AccountLockedUntil
+		updates["lockout_count"] = user.LockoutCount
+	}
+
+	if err := tx.Model(user).Updates(updates).Error; err != nil {
+		return fmt.Errorf("failed to record failed sign-in: %w", err)
+	}
+
+	if locked {
+		utils.LogWarn("Account locked after failed sign-ins", map[string]interface{}{
+			"userID":       user.ID,
+			"ip":           ipAddress,
+			"lockedUntil":  user.AccountLockedUntil,
+			"lockoutCount": user.LockoutCount,
+		})
+		go s.notifyAccountLocked(user.Email, ipAddress, user.AccountLockedUntil, user.LockoutCount)
+	}
+
+	return s.checkIPAlert(tx, ipAddress)
+}
+
+// RegisterUnknownAccount counts a sign-in attempt for an email or phone
+// number that does not exist against the caller's IP address
+func (s *LockoutService) RegisterUnknownAccount(identifier, ipAddress string) error {
+	if err := s.recordAttempt(s.db, nil, identifier, ipAddress, false, "unknown_account"); err != nil {
+		return err
+	}
+	return s.checkIPAlert(s.db, ipAddress)
+}
+
+// RegisterSuccess clears the failure counters after a complete sign-in
+func (s *LockoutService) RegisterSuccess(tx *gorm.DB, user *models.User, ipAddress string) error {
+	if err := s.recordAttempt(tx, &user.ID, user.Email, ipAddress, true, ""); err != nil {
+		return err
+	}
+
+	user.FailedLoginAttempts = 0
+	user.LockoutCount = 0
+	return tx.Model(user).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"lockout_count":         0,
+	}).Error
+}
+
+// Unlock lifts a lock immediately and resets the backoff
+func (s *LockoutService) Unlock(userID uuid.UUID) error {
+	result := s.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"account_locked":        false,
+		"account_locked_until":  time.Time{},
+		"failed_login_attempts": 0,
+		"lockout_count":         0,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unlock account: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// lockDuration grows exponentially with the number of consecutive locks
+func (s *LockoutService) lockDuration(lockoutCount int) time.Duration {
+	factor := math.Pow(s.policy.BackoffMultiplier, float64(lockoutCount-1))
+	duration := time.Duration(float64(s.policy.Duration) * factor)
+	if duration <= 0 || duration > s.policy.MaxDuration {
+		return s.policy.MaxDuration
+	}
+	return duration
+}
+
+func (s *LockoutService) recordAttempt(tx *gorm.DB, userID *uuid.UUID, identifier, ipAddress string, success bool, reason string) error {
+	attempt := models.LoginAttempt{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Identifier: identifier,
+		IPAddress:  ipAddress,
+		Success:    success,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	}
+	if err := tx.Create(&attempt).Error; err != nil {
+		return fmt.Errorf("failed to record sign-in attempt: %w", err)
+	}
+	return nil
+}
+
+func (s *LockoutService) recentIPFailures(tx *gorm.DB, ipAddress string) (int64, error) {
+	var failures int64
+	err := tx.Model(&models.LoginAttempt{}).
+		Where("ip_address = ? AND success = ? AND created_at > ?", ipAddress, false, time.Now().Add(-s.policy.IPWindow)).
+		Count(&failures).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count failed sign-ins: %w", err)
+	}
+	return failures, nil
+}
+
+// checkIPAlert notifies admins the moment an address crosses the IP threshold
+func (s *LockoutService) checkIPAlert(tx *gorm.DB, ipAddress string) error {
+	failures, err := s.recentIPFailures(tx, ipAddress)
+	if err != nil {
+		return err
+	}
+
+	if failures == int64(s.policy.IPThreshold) {
+		utils.LogWarn("Sign-in failures from one address crossed the threshold", map[string]interface{}{
+			"ip":       ipAddress,
+			"failures": failures,
+		})
+		go s.notifySuspiciousIP(ipAddress, failures)
+	}
+	return nil
+}
+
+func (s *LockoutService) notifyAccountLocked(email, ipAddress string, lockedUntil time.Time, lockoutCount int) {
+	if err := s.emailService.SendAccountLockedEmail([]string{email}, lockedUntil); err != nil {
+		utils.LogError("Failed to send account locked email", map[string]interface{}{
+			"email": email,
+			"error": err.Error(),
+		})
+	}
+
+	admins, err := s.adminEmails()
+	if err != nil || len(admins) == 0 {
+		return
+	}
+	if err := s.emailService.SendAccountLockedAdminEmail(admins, email, ipAddress, lockedUntil, lockoutCount); err != nil {
+		utils.LogError("Failed to send account locked admin alert", map[string]interface{}{
+			"email": email,
+			"error": err.Error(),
+		})
+	}
+}
+
+func (s *LockoutService) notifySuspiciousIP(ipAddress string, failures int64) {
+	admins, err := s.adminEmails()
+	if err != nil || len(admins) == 0 {
+		return
+	}
+	if err := s.emailService.SendSuspiciousLoginActivityEmail(admins, ipAddress, failures, s.policy.IPWindow); err != nil {
+		utils.LogError("Failed to send suspicious sign-in alert", map[string]interface{}{
+			"ip":    ipAddress,
+			"error": err.Error(),
+		})
+	}
+}
+
+// adminEmails returns the addresses of everyone who may manage users and
+// so unlock accounts
+func (s *LockoutService) adminEmails() ([]string, error) {
+	emails, err := services.EmailsWithPermission(s.db, middleware.PermissionManageUsers)
+	if err != nil {
+		utils.LogError("Failed to query admin emails", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+	return emails, nil
+}
@@ -0,0 +1,319 @@
+package user
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/token"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled")
+	ErrMFANotEnrolled    = errors.New("mfa enrollment has not been started")
+	ErrMFANotEnabled     = errors.New("mfa is not enabled")
+	ErrMFARequired       = errors.New("mfa is required for this role")
+	ErrInvalidMFACode    = errors.New("invalid mfa code")
+	ErrInvalidMFAToken   = errors.New("invalid or expired mfa token")
+	ErrAccountLocked     = errors.New("account is locked")
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew accepts codes from one time step either side of now to absorb clock drift
+	totpSkew = 1
+)
+
+// MFAService manages TOTP enrollment, verification and recovery codes
+type MFAService struct {
+	db            *gorm.DB
+	jwtManager    *token.JWTManager
+	lockout       *LockoutService
+	issuer        string
+	requiredRoles map[string]bool
+}
+
+func NewMFAService(db *gorm.DB, jm *token.JWTManager, lockout *LockoutService, issuer string, requiredRoles []string) *MFAService {
+	roles := make(map[string]bool, len(requiredRoles))
+	for _, role := range requiredRoles {
+		roles[role] = true
+	}
+	return &MFAService{
+		db:            db,
+		jwtManager:    jm,
+		lockout:       lockout,
+		issuer:        issuer,
+		requiredRoles: roles,
+	}
+}
+
+// RoleRequiresMFA reports whether the MFA policy forces users with this role to use a second factor
+func (s *MFAService) RoleRequiresMFA(role string) bool {
+	return s.requiredRoles[role]
+}
+
+// IssueChallenge creates the intermediate token returned after a correct password
+func (s *MFAService) IssueChallenge(userID uuid.UUID) (string, time.Time, error) {
+	return s.jwtManager.GenerateMFAToken(userID.String())
+}
+
+// ParseChallenge validates an intermediate token and returns the user it was issued to
+func (s *MFAService) ParseChallenge(mfaToken string) (uuid.UUID, error) {
+	claims, err := s.jwtManager.ParseMFAToken(mfaToken)
+	if err != nil {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+	return userID, nil
+}
+
+// BeginEnrollment generates a new TOTP secret for the user. The secret is
+// stored but MFA stays disabled until ConfirmEnrollment proves the user's
+// authenticator app produces matching codes.
+func (s *MFAService) BeginEnrollment(userID uuid.UUID) (*schema.MFAEnrollmentResponse, error) {
+	var user models.User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := token.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate mfa secret: %w", err)
+	}
+
+	if err := s.db.Model(&user).Updates(map[string]interface{}{
+		"mfa_secret":            secret,
+		"mfa_last_used_counter": 0,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to store mfa secret: %w", err)
+	}
+
+	return &schema.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: token.TOTPProvisioningURI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables MFA once the first code checks out and returns
+// the recovery codes. They are only ever shown to the user this once.
+func (s *MFAService) ConfirmEnrollment(userID uuid.UUID, code string) ([]string, error) {
+	var recoveryCodes []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if user.MFAEnabled {
+			return ErrMFAAlreadyEnabled
+		}
+		if user.MFASecret == "" {
+			return ErrMFANotEnrolled
+		}
+
+		counter, ok := token.ValidateTOTPCode(user.MFASecret, code, time.Now(), totpSkew)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"mfa_enabled":           true,
+			"mfa_last_used_counter": counter,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to enable mfa: %w", err)
+		}
+
+		recoveryCodes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// VerifyCode checks a TOTP code or, failing that, a recovery code for a user
+// with MFA enabled. Wrong codes count towards the account lockout.
+func (s *MFAService) VerifyCode(userID uuid.UUID, code, recoveryCode, ipAddress string) error {
+	var verifyErr error
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if !user.MFAEnabled {
+			return ErrMFANotEnabled
+		}
+		if err := s.lockout.CheckAccount(tx, user); err != nil {
+			return err
+		}
+
+		if code != "" {
+			counter, ok := token.ValidateTOTPCode(user.MFASecret, code, time.Now(), totpSkew)
+			// A code may only be used once, so reject steps at or before the last accepted one
+			if ok && counter > user.MFALastUsedCounter {
+				if err := tx.Model(user).Update("mfa_last_used_counter", counter).Error; err != nil {
+					return err
+				}
+				return s.lockout.RegisterSuccess(tx, user, ipAddress)
+			}
+		} else if recoveryCode != "" {
+			used, err := useRecoveryCode(tx, userID, recoveryCode)
+			if err != nil {
+				return err
+			}
+			if used {
+				return s.lockout.RegisterSuccess(tx, user, ipAddress)
+			}
+		}
+
+		verifyErr = ErrInvalidMFACode
+		return s.lockout.RegisterFailure(tx, user, ipAddress, "mfa")
+	})
+	if err != nil {
+		return err
+	}
+
+	return verifyErr
+}
+
+// Disable turns MFA off after checking a current code. Users whose role
+// requires MFA cannot switch it off themselves.
+func (s *MFAService) Disable(userID uuid.UUID, role, code, ipAddress string) error {
+	if s.RoleRequiresMFA(role) {
+		return ErrMFARequired
+	}
+
+	if err := s.VerifyCode(userID, code, "", ipAddress); err != nil {
+		return err
+	}
+
+	return s.Reset(userID)
+}
+
+// RegenerateRecoveryCodes invalidates the old recovery codes after checking a current code
+func (s *MFAService) RegenerateRecoveryCodes(userID uuid.UUID, code, ipAddress string) ([]string, error) {
+	if err := s.VerifyCode(userID, code, "", ipAddress); err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		recoveryCodes, err = replaceRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// Reset removes a user's MFA secret and recovery codes, e.g. when an admin
+// helps someone who lost their phone. The user has to enroll again.
+func (s *MFAService) Reset(userID uuid.UUID) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"mfa_enabled":           false,
+			"mfa_secret":            "",
+			"mfa_last_used_counter": 0,
+		})
+		if result.Error != nil {
+			return fmt.Errorf("failed to reset mfa: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("user not found")
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+		return nil
+	})
+}
+
+func lockUser(tx *gorm.DB, userID uuid.UUID) (*models.User, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+	return &user, nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.MFARecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes = append(codes, code)
+		rows = append(rows, models.MFARecoveryCode{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(code),
+		})
+	}
+
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func useRecoveryCode(tx *gorm.DB, userID uuid.UUID, code string) (bool, error) {
+	result := tx.Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// generateRecoveryCode returns a code formatted as XXXXX-XXXXX for easy transcription
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,393 @@
+package user
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hopkali04/health-sys/internal/config"
+)
+
+const (
+	oidcHTTPTimeout = 10 * time.Second
+	// jwksRefreshInterval stops a stream of tokens with unknown key IDs from
+	// refetching the provider's keys on every request
+	jwksRefreshInterval = time.Minute
+	// maxOIDCResponseSize caps what is read from the provider's endpoints
+	maxOIDCResponseSize = 1 << 20
+)
+
+// IdentityClaims are the verified claims of an ID token
+type IdentityClaims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Name          string
+	// Claims holds every claim, for provider-specific ones such as department
+	Claims jwt.MapClaims
+}
+
+// StringClaim returns a string claim, or "" when it is missing
+func (c *IdentityClaims) StringClaim(name string) string {
+	if name == "" {
+		return ""
+	}
+	value, _ := c.Claims[name].(string)
+	return strings.TrimSpace(value)
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// oidcClient talks to one OpenID Connect provider. The discovery document and
+// signing keys are fetched on first use and cached.
+type oidcClient struct {
+	cfg        config.OIDCProvider
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+func newOIDCClient(cfg config.OIDCProvider) *oidcClient {
+	return &oidcClient{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: oidcHTTPTimeout},
+	}
+}
+
+// authorizationURL builds the URL that sends the user to the provider
+func (c *oidcClient) authorizationURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	discovery, err := c.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"scope":                 {strings.Join(c.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// exchange redeems an authorization code and returns the raw ID token
+func (c *oidcClient) exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	discovery, err := c.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"client_id":     {c.cfg.ClientID},
+		"client_secret": {c.cfg.ClientSecret},
+		"code_verifier": {codeVerifier},
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	var response struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := c.doJSON(request, &response)
+	if err != nil {
+		return "", fmt.Errorf("token request to %s failed: %w", c.cfg.Name, err)
+	}
+	if status != http.StatusOK || response.Error != "" {
+		return "", fmt.Errorf("%s rejected the authorization code: %s %s", c.cfg.Name, response.Error, response.ErrorDescription)
+	}
+	if response.IDToken == "" {
+		return "", fmt.Errorf("%s returned no ID token", c.cfg.Name)
+	}
+	return response.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of an ID token
+func (c *oidcClient) verifyIDToken(ctx context.Context, rawToken, nonce string) (*IdentityClaims, error) {
+	discovery, err := c.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(rawToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return c.signingKey(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(c.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token from %s: %w", c.cfg.Name, err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, errors.New("ID token nonce does not match the sign-in request")
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, errors.New("ID token has no subject")
+	}
+
+	identity := &IdentityClaims{Subject: subject, Claims: claims}
+	identity.Email = strings.ToLower(identity.StringClaim("email"))
+	identity.GivenName = identity.StringClaim("given_name")
+	identity.FamilyName = identity.StringClaim("family_name")
+	identity.Name = identity.StringClaim("name")
+
+	// Some providers send email_verified as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+
+	// Entra only sends the email claim when it is configured; the user
+	// principal name is the directory's address for the user
+	if identity.Email == "" && c.cfg.TrustEmail {
+		if username := identity.StringClaim("preferred_username"); strings.Contains(username, "@") {
+			identity.Email = strings.ToLower(username)
+		}
+	}
+	if c.cfg.TrustEmail && identity.Email != "" {
+		identity.EmailVerified = true
+	}
+
+	return identity, nil
+}
+
+func (c *oidcClient) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.discovery != nil {
+		return c.discovery, nil
+	}
+
+	endpoint := strings.TrimSuffix(c.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovery oidcDiscovery
+	status, err := c.doJSON(request, &discovery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %s discovery document: %w", c.cfg.Name, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to read the %s discovery document: status %d", c.cfg.Name, status)
+	}
+	if discovery.Issuer != strings.TrimSuffix(c.cfg.Issuer, "/") && discovery.Issuer != c.cfg.Issuer {
+		return nil, fmt.Errorf("%s discovery document names issuer %q, expected %q", c.cfg.Name, discovery.Issuer, c.cfg.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("%s discovery document is missing endpoints", c.cfg.Name)
+	}
+
+	c.discovery = &discovery
+	return c.discovery, nil
+}
+
+// signingKey returns the provider key with the given ID, refetching the key
+// set when the provider has rotated to a key not seen before
+func (c *oidcClient) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	discovery, err := c.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(c.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := c.fetchKeys(ctx, discovery.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	c.keys = keys
+	c.keysFetchedAt = time.Now()
+
+	if key, ok := c.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by ID. A token without a key ID is accepted only
+// while the provider publishes a single key.
+func (c *oidcClient) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" {
+		if len(c.keys) == 1 {
+			for _, key := range c.keys {
+				return key, true
+			}
+		}
+		return nil, false
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+func (c *oidcClient) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, jwksURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var keySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	status, err := c.doJSON(request, &keySet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %s signing keys: %w", c.cfg.Name, err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to read the %s signing keys: status %d", c.cfg.Name, status)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(keySet.Keys))
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// Skip key types we cannot use rather than failing every sign-in
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (c *oidcClient) doJSON(request *http.Request, target interface{}) (int, error) {
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, maxOIDCResponseSize))
+	if err != nil {
+		return response.StatusCode, err
+	}
+	if err := json.Unmarshal(body, target); err != nil && response.StatusCode == http.StatusOK {
+		return response.StatusCode, fmt.Errorf("invalid JSON response: %w", err)
+	}
+	return response.StatusCode, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key parameter: %w", err)
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
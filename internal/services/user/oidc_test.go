@@ -0,0 +1,333 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hopkali04/health-sys/internal/config"
+)
+
+const (
+	testClientID = "health-sys-test"
+	testKeyID    = "test-key"
+)
+
+// testIssuer is an OpenID Connect provider serving a discovery document, its
+// signing keys and a token endpoint that returns the ID token it is given
+type testIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu           sync.Mutex
+	idToken      string
+	tokenRequest url.Values
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	issuer := &testIssuer{key: newTestKey(t)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]string{
+			"issuer":                 issuer.URL(),
+			"authorization_endpoint": issuer.URL() + "/authorize",
+			"token_endpoint":         issuer.URL() + "/token",
+			"jwks_uri":               issuer.URL() + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		public := issuer.key.PublicKey
+		writeTestJSON(w, map[string]interface{}{
+			"keys": []jsonWebKey{{
+				Kty: "RSA",
+				Kid: testKeyID,
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		issuer.mu.Lock()
+		defer issuer.mu.Unlock()
+		issuer.tokenRequest = r.PostForm
+		if r.PostForm.Get("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			writeTestJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		writeTestJSON(w, map[string]string{"id_token": issuer.idToken})
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) URL() string {
+	return i.server.URL
+}
+
+// provider is the configuration of a provider signing in through the issuer
+func (i *testIssuer) provider() config.OIDCProvider {
+	return config.OIDCProvider{
+		Name:         "google",
+		Issuer:       i.URL(),
+		ClientID:     testClientID,
+		ClientSecret: "secret",
+		RedirectURL:  "https://health.example.com/sso/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}
+}
+
+// claims are the claims of a valid ID token for the sign-in with nonce
+func (i *testIssuer) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            i.URL(),
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          "Jane.Doe@Example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}
+}
+
+// sign signs claims with key under the issuer's key ID
+func (i *testIssuer) sign(t *testing.T, claims jwt.MapClaims, key *rsa.PrivateKey) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("failed to sign ID token: %v", err)
+	}
+	return signed
+}
+
+// willIssue makes the token endpoint return idToken for the next code
+func (i *testIssuer) willIssue(idToken string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.idToken = idToken
+}
+
+func newTestKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+func writeTestJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func TestVerifyIDTokenAcceptsValidToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	client := newOIDCClient(issuer.provider())
+
+	identity, err := client.verifyIDToken(context.Background(), issuer.sign(t, issuer.claims("nonce-1"), issuer.key), "nonce-1")
+	if err != nil {
+		t.Fatalf("verifyIDToken: %v", err)
+	}
+	if identity.Subject != "subject-1" || identity.Email != "jane.doe@example.com" || !identity.EmailVerified {
+		t.Fatalf("got subject %q, email %q, verified %v", identity.Subject, identity.Email, identity.EmailVerified)
+	}
+	if identity.GivenName != "Jane" || identity.FamilyName != "Doe" {
+		t.Fatalf("got name %q %q", identity.GivenName, identity.FamilyName)
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	issuer := newTestIssuer(t)
+	otherKey := newTestKey(t)
+
+	tests := []struct {
+		name  string
+		token func() string
+	}{
+		{"bad signature", func() string {
+			return issuer.sign(t, issuer.claims("nonce-1"), otherKey)
+		}},
+		{"wrong issuer", func() string {
+			claims := issuer.claims("nonce-1")
+			claims["iss"] = "https://attacker.example.com"
+			return issuer.sign(t, claims, issuer.key)
+		}},
+		{"wrong audience", func() string {
+			claims := issuer.claims("nonce-1")
+			claims["aud"] = "another-client"
+			return issuer.sign(t, claims, issuer.key)
+		}},
+		{"nonce mismatch", func() string {
+			return issuer.sign(t, issuer.claims("nonce-of-another-sign-in"), issuer.key)
+		}},
+		{"no nonce", func() string {
+			claims := issuer.claims("nonce-1")
+			delete(claims, "nonce")
+			return issuer.sign(t, claims, issuer.key)
+		}},
+		{"expired", func() string {
+			claims := issuer.claims("nonce-1")
+			claims["iat"] = time.Now().Add(-time.Hour).Unix()
+			claims["exp"] = time.Now().Add(-10 * time.Minute).Unix()
+			return issuer.sign(t, claims, issuer.key)
+		}},
+		{"no subject", func() string {
+			claims := issuer.claims("nonce-1")
+			delete(claims, "sub")
+			return issuer.sign(t, claims, issuer.key)
+		}},
+		{"unsigned", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, issuer.claims("nonce-1"))
+			signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return signed
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newOIDCClient(issuer.provider())
+			if _, err := client.verifyIDToken(context.Background(), tt.token(), "nonce-1"); err == nil {
+				t.Fatal("verifyIDToken accepted the token")
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenEmailVerification(t *testing.T) {
+	issuer := newTestIssuer(t)
+
+	tests := []struct {
+		name       string
+		verified   interface{}
+		trustEmail bool
+		want       bool
+	}{
+		{"verified", true, false, true},
+		{"verified as a string", "true", false, true},
+		{"not verified", false, false, false},
+		{"claim missing", nil, false, false},
+		{"trusted provider", false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := issuer.provider()
+			provider.TrustEmail = tt.trustEmail
+			client := newOIDCClient(provider)
+
+			claims := issuer.claims("nonce-1")
+			claims["email_verified"] = tt.verified
+			if tt.verified == nil {
+				delete(claims, "email_verified")
+			}
+			identity, err := client.verifyIDToken(context.Background(), issuer.sign(t, claims, issuer.key), "nonce-1")
+			if err != nil {
+				t.Fatalf("verifyIDToken: %v", err)
+			}
+			if identity.EmailVerified != tt.want {
+				t.Fatalf("EmailVerified is %v, want %v", identity.EmailVerified, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	issuer := newTestIssuer(t)
+	// The tenant serves the issuer's discovery document, which names the
+	// issuer and not the tenant
+	tenant := httptest.NewServer(http.StripPrefix("/tenant", issuer.server.Config.Handler))
+	defer tenant.Close()
+	provider := issuer.provider()
+	provider.Issuer = tenant.URL + "/tenant"
+
+	client := newOIDCClient(provider)
+	if _, err := client.authorizationURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Fatal("authorizationURL accepted a discovery document naming another issuer")
+	}
+}
+
+func TestAuthorizationURLUsesPKCE(t *testing.T) {
+	issuer := newTestIssuer(t)
+	client := newOIDCClient(issuer.provider())
+
+	verifier := strings.Repeat("v", 64)
+	raw, err := client.authorizationURL(context.Background(), "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("authorizationURL: %v", err)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	challenge := sha256.Sum256([]byte(verifier))
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(challenge[:]),
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := query.Get(name); got != value {
+			t.Errorf("%s is %q, want %q", name, got, value)
+		}
+	}
+	if query.Get("code_verifier") != "" {
+		t.Error("the code verifier was sent to the browser")
+	}
+}
+
+func TestExchangeRedeemsCode(t *testing.T) {
+	issuer := newTestIssuer(t)
+	client := newOIDCClient(issuer.provider())
+	issuer.willIssue("id-token")
+
+	idToken, err := client.exchange(context.Background(), "good-code", "verifier-1")
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	if idToken != "id-token" {
+		t.Fatalf("got ID token %q", idToken)
+	}
+	issuer.mu.Lock()
+	form := issuer.tokenRequest
+	issuer.mu.Unlock()
+	if form.Get("code_verifier") != "verifier-1" || form.Get("grant_type") != "authorization_code" {
+		t.Fatalf("token request was %v", form)
+	}
+
+	if _, err := client.exchange(context.Background(), "bad-code", "verifier-1"); err == nil {
+		t.Fatal("exchange accepted a rejected code")
+	}
+}
@@ -0,0 +1,232 @@
+package user
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/token"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionExpired      = errors.New("session expired")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+// lastSeenResolution limits how often a session's last-seen time is written
+const lastSeenResolution = time.Minute
+
+// SessionService issues access tokens and manages the refresh tokens stored in UserSession
+type SessionService struct {
+	db           *gorm.DB
+	jwtManager   *token.JWTManager
+	tokenService *token.TokenService
+	refreshTTL   time.Duration
+}
+
+func NewSessionService(db *gorm.DB, jm *token.JWTManager, ts *token.TokenService, refreshTTL time.Duration) *SessionService {
+	return &SessionService{
+		db:           db,
+		jwtManager:   jm,
+		tokenService: ts,
+		refreshTTL:   refreshTTL,
+	}
+}
+
+// CreateSession starts a new session for the user and returns its first token pair
+func (s *SessionService) CreateSession(userID uuid.UUID, role, userAgent, ipAddress string) (*schema.AuthTokens, error) {
+	refreshToken, err := s.tokenService.GenerateToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	session := models.UserSession{
+		ID:           uuid.New(),
+		UserID:       userID,
+		RefreshToken: hashRefreshToken(refreshToken),
+		DeviceInfo:   models.JSONB{"user_agent": userAgent},
+		IPAddress:    ipAddress,
+		ExpiresAt:    time.Now().Add(s.refreshTTL),
+		LastSeenAt:   time.Now(),
+	}
+
+	if err := s.db.Create(&session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issueTokens(&session, refreshToken, role)
+}
+
+// RefreshSession exchanges a refresh token for a new token pair. The refresh
+// token is rotated, so the token presented here cannot be used again.
+func (s *SessionService) RefreshSession(refreshToken, ipAddress string) (*schema.AuthTokens, error) {
+	var (
+		session models.UserSession
+		tokens  *schema.AuthTokens
+	)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("refresh_token = ?", hashRefreshToken(refreshToken)).
+			First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return fmt.Errorf("failed to fetch session: %w", err)
+		}
+
+		if !session.IsActive() {
+			return ErrSessionExpired
+		}
+
+		var user models.User
+		if err := tx.Where("id = ?", session.UserID).First(&user).Error; err != nil {
+			return fmt.Errorf("failed to fetch session user: %w", err)
+		}
+		if !user.IsActive || user.AccountLocked {
+			return ErrSessionExpired
+		}
+
+		var employee models.Employee
+		if err := tx.Select("role").Where("user_id = ?", session.UserID).First(&employee).Error; err != nil {
+			return fmt.Errorf("employee not found for user ID: %s", session.UserID)
+		}
+
+		newRefreshToken, err := s.tokenService.GenerateToken()
+		if err != nil {
+			return fmt.Errorf("failed to generate refresh token: %w", err)
+		}
+
+		session.RefreshToken = hashRefreshToken(newRefreshToken)
+		session.IPAddress = ipAddress
+		session.ExpiresAt = time.Now().Add(s.refreshTTL)
+		if err := tx.Model(&session).Updates(map[string]interface{}{
+			"refresh_token": session.RefreshToken,
+			"ip_address":    session.IPAddress,
+			"expires_at":    session.ExpiresAt,
+			"last_seen_at":  time.Now(),
+			"updated_at":    time.Now(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+
+		tokens, err = s.issueTokens(&session, newRefreshToken, employee.Role)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// RevokeSession revokes the session that owns the given refresh token
+func (s *SessionService) RevokeSession(refreshToken string) error {
+	result := s.db.Model(&models.UserSession{}).
+		Where("refresh_token = ? AND revoked_at IS NULL", hashRefreshToken(refreshToken)).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke session: %w", result.Error)
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes every active session belonging to the user
+func (s *SessionService) RevokeUserSessions(userID uuid.UUID) error {
+	return revokeUserSessions(s.db, userID)
+}
+
+// RevokeUserSession revokes a single session, provided it belongs to the user
+func (s *SessionService) RevokeUserSession(userID, sessionID uuid.UUID) error {
+	result := s.db.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// ListActiveSessions returns the user's sessions that are neither revoked nor expired
+func (s *SessionService) ListActiveSessions(userID uuid.UUID) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := s.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// ValidateSession checks that the session behind an access token is still
+// active and records the request as the session's last activity
+func (s *SessionService) ValidateSession(sessionID string) error {
+	id, err := uuid.Parse(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+
+	var session models.UserSession
+	if err := s.db.Select("id", "revoked_at", "expires_at", "last_seen_at").
+		Where("id = ?", id).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("failed to fetch session: %w", err)
+	}
+
+	if !session.IsActive() {
+		return ErrSessionExpired
+	}
+
+	if time.Since(session.LastSeenAt) > lastSeenResolution {
+		s.db.Model(&models.UserSession{}).Where("id = ?", id).UpdateColumn("last_seen_at", time.Now())
+	}
+
+	return nil
+}
+
+func (s *SessionService) issueTokens(session *models.UserSession, refreshToken, role string) (*schema.AuthTokens, error) {
+	accessToken, accessExpiresAt, err := s.jwtManager.GenerateAccessToken(session.UserID.String(), role, session.ID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.AuthTokens{
+		SessionID:             session.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// revokeUserSessions is shared with VerificationService so a password reset
+// can revoke sessions inside its own transaction
+func revokeUserSessions(db *gorm.DB, userID uuid.UUID) error {
+	if err := db.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+	return nil
+}
+
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,376 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUnknownSSOProvider  = errors.New("unknown sso provider")
+	ErrInvalidSSOState     = errors.New("invalid or expired sso state")
+	ErrSSOEmailNotVerified = errors.New("the identity provider has not verified the email address")
+	ErrSSODomainNotAllowed = errors.New("email domain is not allowed for single sign-on")
+	ErrSSONoAccount        = errors.New("no account matches this identity")
+	ErrSSOIdentityConflict = errors.New("the account is linked to a different identity at this provider")
+	ErrAccountInactive     = errors.New("account is inactive")
+)
+
+// ssoIdentityColumns are the user columns that hold each provider's subject
+var ssoIdentityColumns = map[string]string{
+	"google":    "google_id",
+	"microsoft": "microsoft_id",
+}
+
+// SSOLogin is a sign-in request waiting for the user to return from the provider
+type SSOLogin struct {
+	AuthorizationURL string
+	State            string
+	ExpiresAt        time.Time
+}
+
+// SSOService signs users in through OpenID Connect providers using the
+// authorization code flow with PKCE
+type SSOService struct {
+	db        *gorm.DB
+	lockout   *LockoutService
+	providers map[string]*oidcClient
+	stateTTL  time.Duration
+}
+
+func NewSSOService(db *gorm.DB, lockout *LockoutService, cfg config.SSO) (*SSOService, error) {
+	providers := make(map[string]*oidcClient, len(cfg.Providers))
+	for _, provider := range cfg.Providers {
+		if _, ok := ssoIdentityColumns[provider.Name]; !ok {
+			return nil, fmt.Errorf("sso: unsupported provider %q, expected google or microsoft", provider.Name)
+		}
+		if _, exists := providers[provider.Name]; exists {
+			return nil, fmt.Errorf("sso: provider %q is configured twice", provider.Name)
+		}
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			return nil, fmt.Errorf("sso: provider %q requires issuer, client_id and redirect_url", provider.Name)
+		}
+		providers[provider.Name] = newOIDCClient(provider)
+	}
+
+	return &SSOService{
+		db:        db,
+		lockout:   lockout,
+		providers: providers,
+		stateTTL:  cfg.StateTTL,
+	}, nil
+}
+
+// Providers lists the configured providers
+func (s *SSOService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Begin starts a sign-in at the provider. The returned state must come back
+// with the authorization code.
+func (s *SSOService) Begin(ctx context.Context, provider string) (*SSOLogin, error) {
+	client, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownSSOProvider
+	}
+
+	state, err := randomURLToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomURLToken(32)
+	if err != nil {
+		return nil, err
+	}
+	// RFC 7636 allows 43 to 128 characters; 64 random bytes encode to 86
+	codeVerifier, err := randomURLToken(64)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizationURL, err := client.authorizationURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	db := s.db.WithContext(ctx)
+	// Sign-ins that were never completed are of no further use
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.SSOLoginState{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to clear expired sso states: %w", err)
+	}
+
+	login := models.SSOLoginState{
+		State:        hashSSOState(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(s.stateTTL),
+	}
+	if err := db.Create(&login).Error; err != nil {
+		return nil, fmt.Errorf("failed to store sso state: %w", err)
+	}
+
+	return &SSOLogin{AuthorizationURL: authorizationURL, State: state, ExpiresAt: login.ExpiresAt}, nil
+}
+
+// Complete redeems the authorization code of a sign-in started with Begin
+// and returns the user it signs in. Users are found by their subject at the
+// provider, then linked by verified email, and finally provisioned when the
+// provider allows it.
+func (s *SSOService) Complete(ctx context.Context, provider, state, code, ipAddress string) (*models.User, error) {
+	client, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownSSOProvider
+	}
+
+	login, err := s.consumeState(ctx, provider, state)
+	if err != nil {
+		return nil, err
+	}
+
+	rawToken, err := client.exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := client.verifyIDToken(ctx, rawToken, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	var user *models.User
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = s.resolveUser(tx, client.cfg, identity)
+		if err != nil {
+			return err
+		}
+
+		if err := s.lockout.CheckAccount(tx, user); err != nil {
+			return err
+		}
+		if !user.IsActive {
+			return ErrAccountInactive
+		}
+
+		// With MFA enabled the counters are reset once the second factor is verified
+		if !user.MFAEnabled {
+			if err := s.lockout.RegisterSuccess(tx, user, ipAddress); err != nil {
+				return err
+			}
+		}
+		user.LastLoginAt = time.Now()
+		return tx.Model(user).UpdateColumn("last_login_at", user.LastLoginAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// consumeState deletes the stored sign-in request so a state is used only once
+func (s *SSOService) consumeState(ctx context.Context, provider, state string) (*models.SSOLoginState, error) {
+	if state == "" {
+		return nil, ErrInvalidSSOState
+	}
+
+	var logins []models.SSOLoginState
+	result := s.db.WithContext(ctx).
+		Clauses(clause.Returning{}).
+		Where("state = ? AND provider = ?", hashSSOState(state), provider).
+		Delete(&logins)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to read sso state: %w", result.Error)
+	}
+	if len(logins) == 0 || time.Now().After(logins[0].ExpiresAt) {
+		return nil, ErrInvalidSSOState
+	}
+	return &logins[0], nil
+}
+
+func (s *SSOService) resolveUser(tx *gorm.DB, provider config.OIDCProvider, identity *IdentityClaims) (*models.User, error) {
+	column := ssoIdentityColumns[provider.Name]
+
+	var linked []models.User
+	if err := tx.Where(column+" = ?", identity.Subject).Limit(1).Find(&linked).Error; err != nil {
+		return nil, err
+	}
+	if len(linked) > 0 {
+		return &linked[0], nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrSSOEmailNotVerified
+	}
+	if !emailDomainAllowed(identity.Email, provider.AllowedDomains) {
+		return nil, ErrSSODomainNotAllowed
+	}
+
+	var existing []models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("LOWER(email) = ?", identity.Email).
+		Limit(1).
+		Find(&existing).Error; err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		user := &existing[0]
+		if current := providerSubject(user, provider.Name); current != nil && *current != "" {
+			return nil, ErrSSOIdentityConflict
+		}
+		if err := tx.Model(user).Updates(map[string]interface{}{column: identity.Subject, "is_verified": true}).Error; err != nil {
+			return nil, fmt.Errorf("failed to link %s identity: %w", provider.Name, err)
+		}
+		setProviderSubject(user, provider.Name, identity.Subject)
+		user.IsVerified = true
+
+		utils.LogInfo("Linked SSO identity to existing account", map[string]interface{}{
+			"userID":   user.ID,
+			"provider": provider.Name,
+		})
+		return user, nil
+	}
+
+	if !provider.Provision.Enabled {
+		return nil, ErrSSONoAccount
+	}
+	return s.provision(tx, provider, identity)
+}
+
+// provision creates the user and employee records of a first-time SSO user
+// from the claims of their ID token
+func (s *SSOService) provision(tx *gorm.DB, provider config.OIDCProvider, identity *IdentityClaims) (*models.User, error) {
+	user := &models.User{
+		ID:         uuid.New(),
+		Email:      identity.Email,
+		IsActive:   true,
+		IsVerified: true,
+	}
+	setProviderSubject(user, provider.Name, identity.Subject)
+	if err := tx.Create(user).Error; err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	firstName, lastName := identity.GivenName, identity.FamilyName
+	if firstName == "" && lastName == "" {
+		firstName, lastName = splitName(identity.Name)
+	}
+	if firstName == "" {
+		firstName = strings.Split(identity.Email, "@")[0]
+	}
+	if lastName == "" {
+		lastName = "-"
+	}
+
+	department := identity.StringClaim(provider.Provision.DepartmentClaim)
+	if department == "" {
+		department = provider.Provision.DefaultDepartment
+	}
+	position := identity.StringClaim(provider.Provision.JobTitleClaim)
+	if position == "" {
+		position = provider.Provision.DefaultPosition
+	}
+
+	employee := models.Employee{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		EmployeeNumber: fmt.Sprintf("EMP%s", uuid.New().String()[:8]),
+		FirstName:      truncate(firstName, 100),
+		LastName:       truncate(lastName, 100),
+		Department:     truncate(department, 100),
+		Position:       truncate(position, 100),
+		Role:           provider.Provision.DefaultRole,
+		StartDate:      time.Now(),
+		IsActive:       true,
+	}
+	if err := tx.Create(&employee).Error; err != nil {
+		return nil, fmt.Errorf("failed to create employee: %w", err)
+	}
+
+	utils.LogInfo("Provisioned account from SSO sign-in", map[string]interface{}{
+		"userID":     user.ID,
+		"provider":   provider.Name,
+		"department": employee.Department,
+	})
+	return user, nil
+}
+
+func providerSubject(user *models.User, provider string) *string {
+	if provider == "google" {
+		return user.GoogleID
+	}
+	return user.MicrosoftID
+}
+
+func setProviderSubject(user *models.User, provider, subject string) {
+	if provider == "google" {
+		user.GoogleID = &subject
+	} else {
+		user.MicrosoftID = &subject
+	}
+}
+
+func emailDomainAllowed(email string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	domain := email[strings.LastIndex(email, "@")+1:]
+	for _, candidate := range allowed {
+		if strings.EqualFold(domain, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitName(name string) (string, string) {
+	parts := strings.Fields(name)
+	switch len(parts) {
+	case 0:
+		return "", ""
+	case 1:
+		return parts[0], ""
+	default:
+		return strings.Join(parts[:len(parts)-1], " "), parts[len(parts)-1]
+	}
+}
+
+func truncate(value string, size int) string {
+	runes := []rune(value)
+	if len(runes) > size {
+		return string(runes[:size])
+	}
+	return value
+}
+
+func randomURLToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSSOState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,203 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hopkali04/health-sys/internal/config"
+	database "github.com/hopkali04/health-sys/internal/db"
+	"github.com/hopkali04/health-sys/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB connects to the scratch Postgres database in TEST_DATABASE_DSN and
+// migrates it. The test is skipped when it is not set.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatalf("failed to migrate the test database: %v", err)
+	}
+	return db
+}
+
+// ssoTest signs users in through a test issuer
+type ssoTest struct {
+	db      *gorm.DB
+	issuer  *testIssuer
+	service *SSOService
+}
+
+func newSSOTest(t *testing.T) *ssoTest {
+	t.Helper()
+	db := testDB(t)
+	issuer := newTestIssuer(t)
+	lockout := NewLockoutService(db, nil, config.LockoutPolicy{
+		Threshold:         5,
+		Duration:          time.Minute,
+		BackoffMultiplier: 2,
+		MaxDuration:       time.Hour,
+		IPThreshold:       100,
+		IPWindow:          time.Minute,
+	})
+	service, err := NewSSOService(db, lockout, config.SSO{
+		StateTTL:  time.Minute,
+		Providers: []config.OIDCProvider{issuer.provider()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ssoTest{db: db, issuer: issuer, service: service}
+}
+
+// createUser creates an account that has never signed in with the provider
+func (s *ssoTest) createUser(t *testing.T) *models.User {
+	t.Helper()
+	user := &models.User{
+		Email:      fmt.Sprintf("sso-%d@example.com", time.Now().UnixNano()),
+		IsActive:   true,
+		IsVerified: true,
+	}
+	if err := s.db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	t.Cleanup(func() {
+		s.db.Where("user_id = ?", user.ID).Delete(&models.LoginAttempt{})
+		s.db.Delete(user)
+	})
+	return user
+}
+
+// begin starts a sign-in and returns its state and nonce
+func (s *ssoTest) begin(t *testing.T) (string, string) {
+	t.Helper()
+	login, err := s.service.Begin(context.Background(), "google")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	authorization, err := url.Parse(login.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return login.State, authorization.Query().Get("nonce")
+}
+
+// claimsFor are the claims of an ID token identifying user
+func (s *ssoTest) claimsFor(user *models.User, nonce string) jwt.MapClaims {
+	claims := s.issuer.claims(nonce)
+	claims["sub"] = "subject-" + user.ID.String()
+	claims["email"] = user.Email
+	return claims
+}
+
+// complete finishes a sign-in, the provider issuing an ID token with claims
+func (s *ssoTest) complete(t *testing.T, state string, claims jwt.MapClaims) (*models.User, error) {
+	t.Helper()
+	s.issuer.willIssue(s.issuer.sign(t, claims, s.issuer.key))
+	return s.service.Complete(context.Background(), "google", state, "good-code", "203.0.113.7")
+}
+
+// googleID reads the subject an account is linked to at Google
+func (s *ssoTest) googleID(t *testing.T, user *models.User) *string {
+	t.Helper()
+	var stored models.User
+	if err := s.db.First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return stored.GoogleID
+}
+
+func TestCompleteLinksVerifiedEmail(t *testing.T) {
+	s := newSSOTest(t)
+	user := s.createUser(t)
+
+	state, nonce := s.begin(t)
+	claims := s.claimsFor(user, nonce)
+	signedIn, err := s.complete(t, state, claims)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if signedIn.ID != user.ID {
+		t.Fatalf("signed in %s, want %s", signedIn.ID, user.ID)
+	}
+	if linked := s.googleID(t, user); linked == nil || *linked != claims["sub"] {
+		t.Fatalf("account is linked to %v, want %v", linked, claims["sub"])
+	}
+}
+
+func TestCompleteDoesNotLinkUnverifiedEmail(t *testing.T) {
+	s := newSSOTest(t)
+	user := s.createUser(t)
+
+	state, nonce := s.begin(t)
+	claims := s.claimsFor(user, nonce)
+	claims["email_verified"] = false
+	if _, err := s.complete(t, state, claims); !errors.Is(err, ErrSSOEmailNotVerified) {
+		t.Fatalf("Complete returned %v, want %v", err, ErrSSOEmailNotVerified)
+	}
+	if linked := s.googleID(t, user); linked != nil {
+		t.Fatalf("account was linked to %s", *linked)
+	}
+}
+
+func TestCompleteRejectsNonceOfAnotherSignIn(t *testing.T) {
+	s := newSSOTest(t)
+	user := s.createUser(t)
+
+	_, otherNonce := s.begin(t)
+	state, _ := s.begin(t)
+	if _, err := s.complete(t, state, s.claimsFor(user, otherNonce)); err == nil {
+		t.Fatal("Complete accepted an ID token issued for another sign-in")
+	}
+	if linked := s.googleID(t, user); linked != nil {
+		t.Fatalf("account was linked to %s", *linked)
+	}
+}
+
+func TestCompleteRejectsStateNotIssuedToTheSignIn(t *testing.T) {
+	s := newSSOTest(t)
+	user := s.createUser(t)
+
+	state, nonce := s.begin(t)
+	claims := s.claimsFor(user, nonce)
+
+	if _, err := s.complete(t, "state-of-another-browser", claims); !errors.Is(err, ErrInvalidSSOState) {
+		t.Fatalf("unknown state: Complete returned %v, want %v", err, ErrInvalidSSOState)
+	}
+	if _, err := s.complete(t, state, claims); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if _, err := s.complete(t, state, claims); !errors.Is(err, ErrInvalidSSOState) {
+		t.Fatalf("reused state: Complete returned %v, want %v", err, ErrInvalidSSOState)
+	}
+}
+
+func TestCompleteRejectsExpiredState(t *testing.T) {
+	s := newSSOTest(t)
+	user := s.createUser(t)
+
+	state, nonce := s.begin(t)
+	err := s.db.Model(&models.SSOLoginState{}).Where("state = ?", hashSSOState(state)).
+		Update("expires_at", time.Now().Add(-time.Second)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.complete(t, state, s.claimsFor(user, nonce)); !errors.Is(err, ErrInvalidSSOState) {
+		t.Fatalf("Complete returned %v, want %v", err, ErrInvalidSSOState)
+	}
+}
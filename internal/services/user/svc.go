@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
@@ -8,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/utils"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -15,12 +17,14 @@ import (
 type UserService struct {
 	db                  *gorm.DB
 	VerificationService *VerificationService
+	lockout             *LockoutService
 }
 
-func NewUserService(db *gorm.DB, vc *VerificationService) *UserService {
+func NewUserService(db *gorm.DB, vc *VerificationService, lockout *LockoutService) *UserService {
 	return &UserService{
 		db:                  db,
 		VerificationService: vc,
+		lockout:             lockout,
 	}
 }
 
@@ -53,7 +57,7 @@ func (svc *UserService) GetallUsers() ([]models.User, error) {
 	return Users, nil
 }
 
-func (svc *UserService) CreateUser(user *schema.UserRequest) error {
+func (svc *UserService) CreateUser(ctx context.Context, user *schema.UserRequest) error {
 
 	// Hash the password using bcrypt
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
@@ -76,7 +80,7 @@ func (svc *UserService) CreateUser(user *schema.UserRequest) error {
 	}
 
 	// Save the user to the database
-	if err := svc.db.Create(&UserDatabase).Error; err != nil {
+	if err := svc.db.WithContext(ctx).Create(&UserDatabase).Error; err != nil {
 		return errors.New("failed to create user in the database")
 	}
 
@@ -84,13 +88,18 @@ func (svc *UserService) CreateUser(user *schema.UserRequest) error {
 }
 
 // CreateUserWithEmployee creates a user and an employee record in a single transaction
-func (svc *UserService) CreateUserWithEmployee(request *schema.CreateUserWithEmployeeRequest) error {
+func (svc *UserService) CreateUserWithEmployee(ctx context.Context, request *schema.CreateUserWithEmployeeRequest) error {
 	// Start a database transaction
-	tx := svc.db.Begin()
+	tx := svc.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return errors.New("failed to start transaction")
 	}
 
+	if err := checkRole(tx, request.Role); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Hash the password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -180,36 +189,75 @@ func (svc *UserService) Login(credentials *schema.UserLoginRequest) (*models.Use
 		}
 	}
 
-	// Check if the account is locked
-	if user.AccountLocked {
-		return nil, errors.New("account is locked")
-	}
+	// Check the password with the user's row locked, so that concurrent
+	// attempts count their failures one after another instead of all
+	// writing the same count
+	var loginErr error
+	err := svc.db.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockUser(tx, user.ID)
+		if err != nil {
+			return err
+		}
+		user = *locked
 
-	// Check if the account is inactive
-	if !user.IsActive {
-		return nil, errors.New("account is inactive")
-	}
+		// Check if the account is locked, lifting the lock if it has expired
+		if err := svc.lockout.CheckAccount(tx, &user); err != nil {
+			return err
+		}
 
-	// Compare the provided password with the hashed password in the database
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(credentials.Password)); err != nil {
-		// Increment failed login attempts
-		user.FailedLoginAttempts++
-		if user.FailedLoginAttempts >= 5 { // Lock the account after 5 failed attempts
-			user.AccountLocked = true
+		// Check if the account is inactive
+		if !user.IsActive {
+			return errors.New("account is inactive")
 		}
-		svc.db.Save(&user) // Save the updated user record
-		return nil, errors.New("incorrect password")
-	}
 
-	// Reset failed login attempts on successful login
-	user.FailedLoginAttempts = 0
-	user.LastLoginAt = time.Now() // Update the last login timestamp
-	svc.db.Save(&user)            // Save the updated user record
+		// Compare the provided password with the hashed password in the database
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(credentials.Password)); err != nil {
+			// Count the failure, locking the account once the policy threshold is reached
+			loginErr = errors.New("incorrect password")
+			return svc.lockout.RegisterFailure(tx, &user, credentials.IPAddress, "password")
+		}
 
-	// map Response for abstraction
+		// Reset failed login attempts on successful login. With MFA enabled the
+		// counter is only reset once the second factor is verified, so wrong
+		// codes keep counting towards the lockout across password attempts.
+		if !user.MFAEnabled {
+			if err := svc.lockout.RegisterSuccess(tx, &user, credentials.IPAddress); err != nil {
+				return err
+			}
+		}
+		user.LastLoginAt = time.Now() // Update the last login timestamp
+		return tx.Model(&user).UpdateColumn("last_login_at", user.LastLoginAt).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if loginErr != nil {
+		return nil, loginErr
+	}
 
 	return &user, nil
 }
+
+// CheckLoginAllowed refuses sign-ins from addresses with too many recent failures
+func (svc *UserService) CheckLoginAllowed(ipAddress string) error {
+	return svc.lockout.CheckIP(ipAddress)
+}
+
+// RecordUnknownAccountLogin counts a sign-in attempt for an account that does not exist
+func (svc *UserService) RecordUnknownAccountLogin(identifier, ipAddress string) {
+	if err := svc.lockout.RegisterUnknownAccount(identifier, ipAddress); err != nil {
+		utils.LogError("Failed to record sign-in attempt", map[string]interface{}{
+			"ip":    ipAddress,
+			"error": err.Error(),
+		})
+	}
+}
+
+// UnlockUser lifts an account lock ahead of its expiry
+func (svc *UserService) UnlockUser(userID uuid.UUID) error {
+	return svc.lockout.Unlock(userID)
+}
+
 func (svc *UserService) GetUserByID(id uuid.UUID) (*models.User, error) {
 	var user models.User
 	if err := svc.db.First(&user, id).Error; err != nil {
@@ -237,8 +285,8 @@ func (svc *UserService) GetUserByPhone(phoneNumber string) (*models.User, error)
 	return &user, nil
 }
 
-func (svc *UserService) UpdateUserEmail(id string, email string) error {
-	err := svc.db.Where("id = ?", id).UpdateColumn("email", email).Error
+func (svc *UserService) UpdateUserEmail(ctx context.Context, id string, email string) error {
+	err := svc.db.WithContext(ctx).Where("id = ?", id).UpdateColumn("email", email).Error
 	if err != nil {
 		return err
 	}
@@ -246,7 +294,7 @@ func (svc *UserService) UpdateUserEmail(id string, email string) error {
 }
 
 // ChangePassword updates the user's password in the database
-func (svc *UserService) ChangePassword(userID uuid.UUID, newPassword string) error {
+func (svc *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, newPassword string) error {
 	// Hash the new password using bcrypt
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -254,7 +302,7 @@ func (svc *UserService) ChangePassword(userID uuid.UUID, newPassword string) err
 	}
 
 	// Update the user's password hash and set the password changed timestamp
-	result := svc.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+	result := svc.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
 		"password_hash":       string(hashedPassword),
 		"password_changed_at": time.Now(),
 	})
@@ -270,8 +318,8 @@ func (svc *UserService) ChangePassword(userID uuid.UUID, newPassword string) err
 	return nil
 }
 
-func (svc *UserService) DeleteUser(id uuid.UUID) error {
-	return svc.db.Delete(&models.User{}, id).Error
+func (svc *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	return svc.db.WithContext(ctx).Delete(&models.User{}, "id = ?", id).Error
 }
 
 // CheckEmailExists checks if a user with the given email already exists in the database, returns true if exists
@@ -286,8 +334,8 @@ func (svc *UserService) CheckEmailExists(email string) (bool, error) {
 	return count > 0, nil
 }
 
-func (svc *UserService) BulkCreateUsers(users []schema.UserRequest) error {
-	tx := svc.db.Begin()
+func (svc *UserService) BulkCreateUsers(ctx context.Context, users []schema.UserRequest) error {
+	tx := svc.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return errors.New("failed to start transaction")
 	}
@@ -314,9 +362,9 @@ func (svc *UserService) BulkCreateUsers(users []schema.UserRequest) error {
 
 	return tx.Commit().Error
 }
-func (svc *UserService) BulkCreateUsersWithEmployees(requests []schema.CreateUserWithEmployeeRequest) error {
+func (svc *UserService) BulkCreateUsersWithEmployees(ctx context.Context, requests []schema.CreateUserWithEmployeeRequest) error {
 	// Start a database transaction
-	tx := svc.db.Begin()
+	tx := svc.db.WithContext(ctx).Begin()
 	if tx.Error != nil {
 		return errors.New("failed to start transaction for bulk creation")
 	}
@@ -329,6 +377,11 @@ func (svc *UserService) BulkCreateUsersWithEmployees(requests []schema.CreateUse
 
 	// Process each request in the batch
 	for _, request := range requests {
+		if err := checkRole(tx, request.Role); err != nil {
+			tx.Rollback()
+			return err
+		}
+
 		// Hash the password
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 		if err != nil {
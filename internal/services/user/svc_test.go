@@ -0,0 +1,72 @@
+package user
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hopkali04/health-sys/internal/config"
+	"github.com/hopkali04/health-sys/internal/models"
+	"github.com/hopkali04/health-sys/internal/schema"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginCountsConcurrentFailures(t *testing.T) {
+	db := testDB(t)
+	// A threshold above the number of attempts keeps the account unlocked,
+	// so every attempt reaches the password check
+	lockout := NewLockoutService(db, nil, config.LockoutPolicy{
+		Threshold:         100,
+		Duration:          time.Minute,
+		BackoffMultiplier: 2,
+		MaxDuration:       time.Hour,
+		IPThreshold:       1000,
+		IPWindow:          time.Minute,
+	})
+	service := NewUserService(db, nil, lockout)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse battery"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{
+		Email:        fmt.Sprintf("login-%d@example.com", time.Now().UnixNano()),
+		PasswordHash: string(hash),
+		IsActive:     true,
+		IsVerified:   true,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Where("user_id = ?", user.ID).Delete(&models.LoginAttempt{})
+		db.Delete(user)
+	})
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Login(&schema.UserLoginRequest{
+				Email:     user.Email,
+				Password:  "wrong password",
+				IPAddress: "203.0.113.9",
+			})
+			if err == nil || err.Error() != "incorrect password" {
+				t.Errorf("Login returned %v, want incorrect password", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var stored models.User
+	if err := db.First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.FailedLoginAttempts != attempts {
+		t.Fatalf("%d failed attempts counted, want %d", stored.FailedLoginAttempts, attempts)
+	}
+}
@@ -1,27 +1,35 @@
 package user
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
+	"gorm.io/gorm"
 )
 
-// UpdateUserRole updates an employee's role by their UserID
-func (s *UserService) UpdateUserRole(userID uuid.UUID, role string) error {
-	// Validate role
-	validRoles := []string{"admin", "safety_officer", "manager", "employee"}
-	isValidRole := false
-	for _, validRole := range validRoles {
-		if role == validRole {
-			isValidRole = true
-			break
-		}
+// ErrInvalidRole means the role is not one of the roles in the database
+var ErrInvalidRole = errors.New("invalid role")
+
+// checkRole returns ErrInvalidRole unless role is defined in the database
+func checkRole(tx *gorm.DB, role string) error {
+	var roles int64
+	if err := tx.Model(&models.Role{}).Where("name = ?", role).Count(&roles).Error; err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if roles == 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidRole, role)
 	}
+	return nil
+}
 
-	if !isValidRole {
-		return fmt.Errorf("invalid role: %s", role)
+// UpdateUserRole updates an employee's role by their UserID
+func (s *UserService) UpdateUserRole(userID uuid.UUID, role string) error {
+	// Validate role against the roles defined in the database
+	if err := checkRole(s.db, role); err != nil {
+		return err
 	}
 
 	// Update the employee's role
@@ -126,5 +126,11 @@ func (s *VerificationService) CompletePasswordReset(token, newPassword string) e
 	user.IsVerified = true
 	user.PasswordChangedAt = time.Now()
 
-	return s.db.Save(&user).Error
+	// Sign the user out everywhere so a stolen session cannot outlive the reset
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return revokeUserSessions(tx, user.ID)
+	})
 }
@@ -1,17 +1,18 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"mime/multipart"
-	"os"
-	"path/filepath"
 
 	"github.com/google/uuid"
+	"github.com/hopkali04/health-sys/internal/middleware"
 	"github.com/hopkali04/health-sys/internal/models"
 	"github.com/hopkali04/health-sys/internal/schema"
+	"github.com/hopkali04/health-sys/internal/services/storage"
+	"github.com/hopkali04/health-sys/internal/services/upload"
 	"github.com/hopkali04/health-sys/internal/utils"
 	"gorm.io/gorm"
 )
@@ -20,13 +21,15 @@ import (
 type VPCService struct {
 	db          *gorm.DB
 	mailService *EmailService
+	uploads     *upload.Service
 }
 
 // NewVPCService creates a new VPC service instance
-func NewVPCService(db *gorm.DB, emailSvc *EmailService) *VPCService {
+func NewVPCService(db *gorm.DB, emailSvc *EmailService, uploads *upload.Service) *VPCService {
 	return &VPCService{
 		db:          db,
 		mailService: emailSvc,
+		uploads:     uploads,
 	}
 }
 func (r *VPCService) GetEmployeeByUserID(userID uuid.UUID) (*models.Employee, error) {
@@ -41,134 +44,77 @@ func (r *VPCService) GetEmployeeByUserID(userID uuid.UUID) (*models.Employee, er
 	// Return the retrieved employee
 	return &employee, nil
 }
-func (s *VPCService) getAdminAndSafetyOfficerEmails() ([]string, error) {
-	var users []models.User
-	err := s.db.Table("users").
-		Joins("JOIN employees ON employees.user_id = users.id").
-		Where("(employees.role = ? OR employees.role = ?) AND employees.is_active = ?", "admin", "safety_officer", true).
-		Select("users.email").
-		Find(&users).Error
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to query admins and safety officers: %v", err)
-	}
-
-	emails := make([]string, 0, len(users))
-	for _, user := range users {
-		if user.Email != "" {
-			emails = append(emails, user.Email)
-		}
-	}
-
-	return emails, nil
+// vpcAlertEmails returns the addresses of everyone who may manage VPCs
+func (s *VPCService) vpcAlertEmails() ([]string, error) {
+	return EmailsWithPermission(s.db, middleware.PermissionManageVPCs)
 }
 
 // Create creates a new VPC record
-func (s *VPCService) Create(vpc *models.VPC) error {
-	return s.db.Create(vpc).Error
+func (s *VPCService) Create(ctx context.Context, vpc *models.VPC) error {
+	return s.db.WithContext(ctx).Create(vpc).Error
 }
 
 func (s *VPCService) CreateVPCWithAttachments(
+	ctx context.Context,
 	reqData schema.VPCRequest_new,
 	files []*multipart.FileHeader,
 	creatorEmployeeID uuid.UUID,
 ) (*models.VPC, error) {
 
-	tx := s.db.Begin()
-	if tx.Error != nil {
-		utils.LogError("Failed to begin database transaction for VPC creation", map[string]interface{}{"error": tx.Error})
-		return nil, fmt.Errorf("failed to begin transaction: %w", tx.Error)
-	}
-
-	// Create the VPC record
-
+	// The VPC, its attachment records and the staged files are kept or
+	// discarded together
 	vpc := reqData.ToModel(creatorEmployeeID) // Pass employee ID for CreatedBy field
-	if err := tx.Create(&vpc).Error; err != nil {
-		tx.Rollback()
-		utils.LogError("Failed to create VPC record in DB", map[string]interface{}{"error": err, "vpcData": reqData})
-		return nil, fmt.Errorf("failed to create VPC record: %w", err)
+	if vpc.ID == "" {
+		vpc.ID = uuid.NewString()
 	}
-
-	// Handle attachments if any are provided
-	if len(files) > 0 {
-		// Define base path for VPC uploads
-		baseUploadPath := "uploads/vpcs" // Consider making this configurable
-
-		// Ensure the VPC-specific directory exists: uploads/vpcs/<vpc_id>/
-		vpcUploadDir := filepath.Join(baseUploadPath, vpc.ID)
-		if err := os.MkdirAll(vpcUploadDir, 0755); err != nil {
-			tx.Rollback()
-			utils.LogError("Failed to create VPC attachment directory", map[string]interface{}{"path": vpcUploadDir, "error": err})
-			return nil, fmt.Errorf("failed to create directory '%s': %w", vpcUploadDir, err)
-		}
-
+	err := withUploads(ctx, s.db, s.uploads, func(tx *gorm.DB, stage *upload.Stage) error {
+		// Stage the attachments first: creating the VPC locks the reference
+		// number counter until the transaction ends
+		attachments := make([]models.VPCAttachment, 0, len(files))
 		for _, fileHeader := range files {
-			// Sanitize filename to prevent path traversal and use only the base name
-			fileName := filepath.Base(fileHeader.Filename)
-			storagePath := filepath.Join(vpcUploadDir, fileName)
-
-			src, err := fileHeader.Open()
+			saved, err := stage.SaveAttachment(ctx, storage.Key("vpcs", vpc.ID, uuid.NewString()), fileHeader)
 			if err != nil {
-				tx.Rollback()
-				utils.LogError("Failed to open uploaded file", map[string]interface{}{"filename": fileHeader.Filename, "error": err})
-				return nil, fmt.Errorf("failed to open file '%s': %w", fileHeader.Filename, err)
+				utils.LogError("Failed to store uploaded file", map[string]interface{}{"filename": fileHeader.Filename, "error": err})
+				return err
 			}
 
-			dst, err := os.Create(storagePath)
-			if err != nil {
-				src.Close() // Important to close src if dst creation fails
-				tx.Rollback()
-				utils.LogError("Failed to create destination file on server", map[string]interface{}{"path": storagePath, "error": err})
-				return nil, fmt.Errorf("failed to create file at '%s': %w", storagePath, err)
-			}
-
-			if _, err = io.Copy(dst, src); err != nil {
-				src.Close()
-				dst.Close() // Close dst even on copy error
-				tx.Rollback()
-				utils.LogError("Failed to save (copy) uploaded file to disk", map[string]interface{}{"filename": fileName, "path": storagePath, "error": err})
-				return nil, fmt.Errorf("failed to save file '%s': %w", fileName, err)
-			}
-			src.Close()
-			dst.Close()
-
-			// Determine file type for storage; use Content-Type from header or infer from extension.
-			// fileHeader.Header.Get("Content-Type") is good, or use utils.GetFileContentType(fileName) as a fallback/override.
-			fileType := fileHeader.Header.Get("Content-Type")
-			if fileType == "" { // Fallback if Content-Type header is missing
-				fileType = utils.GetFileContentType(fileName)
-			}
-
-			// Create VPCAttachment record
-			attachment := models.VPCAttachment{
+			attachments = append(attachments, models.VPCAttachment{
 				VPCID:       vpc.ID,
-				FileName:    fileName,
-				FileType:    fileType,
-				FileSize:    int(fileHeader.Size),
-				StoragePath: storagePath, // Store relative or absolute path as per your strategy
+				FileName:    saved.Name,
+				FileType:    saved.ContentType,
+				FileSize:    int(saved.Size),
+				StoragePath: saved.Key,
 				UploadedBy:  creatorEmployeeID,
-			}
 
+				ImageMetadata: imageMetadata(saved),
+			})
+		}
+
+		// Create the VPC record
+		if err := tx.Create(&vpc).Error; err != nil {
+			utils.LogError("Failed to create VPC record in DB", map[string]interface{}{"error": err, "vpcData": reqData})
+			return fmt.Errorf("failed to create VPC record: %w", err)
+		}
+
+		// Create VPCAttachment records
+		for _, attachment := range attachments {
 			if err := tx.Create(&attachment).Error; err != nil {
-				tx.Rollback()
-				utils.LogError("Failed to create VPC attachment record in DB", map[string]interface{}{"attachment_filename": fileName, "error": err})
-				return nil, fmt.Errorf("failed to create attachment record for '%s': %w", fileName, err)
+				utils.LogError("Failed to create VPC attachment record in DB", map[string]interface{}{"attachment_filename": attachment.FileName, "error": err})
+				return fmt.Errorf("failed to create attachment record for '%s': %w", attachment.FileName, err)
 			}
-			// Optionally append to a slice if you need to manually add to vpc.Attachments later,
-			// but GORM Preload is preferred for fetching.
 		}
-	}
-
-	// Commit the transaction
-	if err := tx.Commit().Error; err != nil {
-		utils.LogError("Failed to commit transaction for VPC creation with attachments", map[string]interface{}{"error": err})
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		utils.LogError("Failed to create VPC with attachments", map[string]interface{}{"error": err})
+		return nil, err
 	}
 
 	// Reload the VPC with its associations for a complete response object
 	// This ensures Creator (Employee model) and Attachments (with their Uploader Employee model) are populated.
 	var reloadedVPC models.VPC
-	err := s.db.
+	err = s.db.WithContext(ctx).
 		Preload("Creator").              // Preloads the models.Employee linked by vpc.CreatedBy
 		Preload("Attachments").          // Preloads []models.VPCAttachment
 		Preload("Attachments.Uploader"). // For each attachment, preloads its models.Employee Uploader
@@ -184,8 +130,8 @@ func (s *VPCService) CreateVPCWithAttachments(
 }
 
 // CreateBulk creates multiple VPC records
-func (s *VPCService) CreateBulk(vpcs []models.VPC) error {
-	return s.db.Create(&vpcs).Error
+func (s *VPCService) CreateBulk(ctx context.Context, vpcs []models.VPC) error {
+	return s.db.WithContext(ctx).Create(&vpcs).Error
 }
 
 // Get retrieves a VPC by ID
@@ -273,10 +219,10 @@ func (s *VPCService) ListAllWithAttachments(page, pageSize int) ([]models.VPC, i
 }
 
 // Update updates an existing VPC
-func (s *VPCService) Update(vpc *models.VPC) error {
+func (s *VPCService) Update(ctx context.Context, vpc *models.VPC) error {
 	// Check if the VPC exists
 	var exists models.VPC
-	result := s.db.Where("id = ?", vpc.ID).First(&exists)
+	result := s.db.WithContext(ctx).Where("id = ?", vpc.ID).First(&exists)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return errors.New("vpc not found")
@@ -285,12 +231,12 @@ func (s *VPCService) Update(vpc *models.VPC) error {
 	}
 
 	// Update the VPC
-	return s.db.Save(vpc).Error
+	return s.db.WithContext(ctx).Save(vpc).Error
 }
 
 // Delete deletes a VPC by ID
-func (s *VPCService) Delete(id string) error {
-	result := s.db.Delete(&models.VPC{}, "id = ?", id)
+func (s *VPCService) Delete(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Delete(&models.VPC{}, "id = ?", id)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -340,10 +286,10 @@ func (s *VPCService) HandleCreateVPCMail(vpc *models.VPC) {
 	// a copy of the VPC to avoid any potential data races
 	vpcCopy := *vpc
 
-	// Get Admin emails
-	managerEmails, err := s.getAdminAndSafetyOfficerEmails()
+	// Get the emails of everyone who manages VPCs
+	managerEmails, err := s.vpcAlertEmails()
 	if err != nil {
-		log.Printf("Failed to retrieve Admin & safety Officer emails for Vpc notification: %v", err)
+		log.Printf("Failed to retrieve VPC manager emails for Vpc notification: %v", err)
 		return
 	}
 
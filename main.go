@@ -21,6 +21,8 @@ func main() {
 
 	rootCmd.AddCommand(cli.MigrateCmd())
 	rootCmd.AddCommand(cli.UserCmd())
+	rootCmd.AddCommand(cli.AuditCmd())
+	rootCmd.AddCommand(cli.StorageCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)